@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -15,11 +17,18 @@ import (
 
 var compareCmd = &cobra.Command{
 	Use:   "compare",
-	Short: "Compare two workflow runs or commits side-by-side",
-	Long: `Compare two workflow runs or commits side-by-side.
+	Short: "Compare workflow runs or commits side-by-side, or two sets of runs",
+	Long: `Compare workflow runs or commits side-by-side, or two sets of runs.
 
 Shows stacked Gantt charts, a comparison table with duration deltas and status changes,
-and highlights items that only appear in one of the two runs.`,
+and highlights items that only appear in one of the two runs.
+
+Up to 10 workflow runs or commits can be lined up at once, e.g. to bisect a CI slowdown. Each is compared against
+the baseline, the first one unless --baseline names another, in a matrix of durations and costs.
+
+A single pair of runs is noisy. --baseline-branch and --candidate-branch instead compare every successful run
+of each branch already in the data dir, with per-workflow and per-job median and p90 deltas, standard deviation
+and a Mann-Whitney U test that tells whether a difference is significant. Gather or sync runs of both branches first.`,
 	Example: `
 # Compare two workflow runs
 octometrics compare -o kalverra -r octometrics --workflow-runs 123,456
@@ -27,8 +36,14 @@ octometrics compare -o kalverra -r octometrics --workflow-runs 123,456
 # Compare two commits
 octometrics compare -o kalverra -r octometrics --commits abc123,def456
 
+# Line up four workflow runs against the second one
+octometrics compare -o kalverra -r octometrics --workflow-runs 123,456,789,1011 --baseline 456
+
 # Force re-fetch data from GitHub
 octometrics compare -o kalverra -r octometrics --workflow-runs 123,456 -u
+
+# Compare the last 20 runs on main against every run on a branch
+octometrics compare -o kalverra -r octometrics --baseline-branch main --candidate-branch feat/x
 `,
 	PreRunE: func(cmd *cobra.Command, _ []string) error {
 		if err := cfg.ValidateCompare(); err != nil {
@@ -37,25 +52,41 @@ octometrics compare -o kalverra -r octometrics --workflow-runs 123,456 -u
 
 		workflowRuns, _ := cmd.Flags().GetInt64Slice("workflow-runs")
 		commits, _ := cmd.Flags().GetStringSlice("commits")
+		baselineBranch, _ := cmd.Flags().GetString("baseline-branch")
+		candidateBranch, _ := cmd.Flags().GetString("candidate-branch")
 
 		hasWorkflowRuns := len(workflowRuns) > 0
 		hasCommits := len(commits) > 0
+		hasBranches := baselineBranch != "" || candidateBranch != ""
 
-		if hasWorkflowRuns && hasCommits {
-			return errors.New("only one of --workflow-runs or --commits can be provided")
+		modes := 0
+		for _, has := range []bool{hasWorkflowRuns, hasCommits, hasBranches} {
+			if has {
+				modes++
+			}
 		}
-		if !hasWorkflowRuns && !hasCommits {
-			return errors.New("one of --workflow-runs or --commits must be provided")
+		if modes > 1 {
+			return errors.New("only one of --workflow-runs, --commits, or --baseline-branch can be provided")
 		}
-		if hasWorkflowRuns && len(workflowRuns) != 2 {
-			return fmt.Errorf("--workflow-runs requires exactly 2 IDs, got %d", len(workflowRuns))
+		if modes == 0 {
+			return errors.New("one of --workflow-runs, --commits, or --baseline-branch must be provided")
 		}
-		if hasCommits && len(commits) != 2 {
-			return fmt.Errorf("--commits requires exactly 2 SHAs, got %d", len(commits))
+		if hasBranches {
+			if baselineBranch == "" || candidateBranch == "" {
+				return errors.New("--baseline-branch and --candidate-branch must be provided together")
+			}
+			// Run sets are compared from the data dir, nothing is fetched from GitHub
+			return nil
+		}
+		if hasWorkflowRuns && (len(workflowRuns) < 2 || len(workflowRuns) > maxCompared) {
+			return fmt.Errorf("--workflow-runs requires 2 to %d IDs, got %d", maxCompared, len(workflowRuns))
+		}
+		if hasCommits && (len(commits) < 2 || len(commits) > maxCompared) {
+			return fmt.Errorf("--commits requires 2 to %d SHAs, got %d", maxCompared, len(commits))
 		}
 
 		var err error
-		githubClient, err = gather.NewGitHubClient(logger, cfg.GitHubToken, nil)
+		githubClient, err = newGitHubClient(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to create GitHub client: %w", err)
 		}
@@ -65,32 +96,57 @@ octometrics compare -o kalverra -r octometrics --workflow-runs 123,456 -u
 		startTime := time.Now()
 		logger = logger.With().Str("owner", cfg.Owner).Str("repo", cfg.Repo).Logger()
 
+		if baselineBranch, _ := cmd.Flags().GetString("baseline-branch"); baselineBranch != "" {
+			return compareRunSets(cmd)
+		}
+
 		workflowRuns, _ := cmd.Flags().GetInt64Slice("workflow-runs")
 		commits, _ := cmd.Flags().GetStringSlice("commits")
+		baseline, _ := cmd.Flags().GetString("baseline")
+		format, toStdout, err := determineFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != "html" && format != "md" && format != "json" {
+			return fmt.Errorf("invalid format %q: comparisons can be 'html', 'md', or 'json'", format)
+		}
+
 		reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
 		defer reporter.Stop("")
 		obsOpts := buildObserveOptions(cfg, reporter)
 
-		var (
-			comparison *observe.Comparison
-			err        error
-		)
+		var comparison *observe.Comparison
 
 		ctx := cmd.Context()
-		if len(workflowRuns) == 2 {
-			comparison, err = observe.CompareWorkflowRuns(
+		if len(workflowRuns) > 0 {
+			var baselineID int64
+			if baseline != "" {
+				baselineID, err = strconv.ParseInt(baseline, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid --baseline %q: must be a workflow run ID: %w", baseline, err)
+				}
+			}
+			workflowRuns, err = baselineFirst(workflowRuns, baselineID, baseline != "")
+			if err != nil {
+				return err
+			}
+			comparison, err = observe.CompareManyWorkflowRuns(
 				ctx,
 				logger, githubClient,
 				cfg.Owner, cfg.Repo,
-				workflowRuns[0], workflowRuns[1],
+				workflowRuns[0], workflowRuns[1:],
 				obsOpts...,
 			)
 		} else {
-			comparison, err = observe.CompareCommits(
+			commits, err = baselineFirst(commits, baseline, baseline != "")
+			if err != nil {
+				return err
+			}
+			comparison, err = observe.CompareManyCommits(
 				ctx,
 				logger, githubClient,
 				cfg.Owner, cfg.Repo,
-				commits[0], commits[1],
+				commits[0], commits[1:],
 				obsOpts...,
 			)
 		}
@@ -98,12 +154,7 @@ octometrics compare -o kalverra -r octometrics --workflow-runs 123,456 -u
 			return err
 		}
 
-		format, toStdout, err := determineFormat(cmd)
-		if err != nil {
-			return err
-		}
-
-		if toStdout || format == "md" {
+		if toStdout {
 			outStr, err := comparison.RenderString(logger, format)
 			if err != nil {
 				return fmt.Errorf("failed to render comparison: %w", err)
@@ -134,21 +185,98 @@ octometrics compare -o kalverra -r octometrics --workflow-runs 123,456 -u
 		fmt.Printf("Comparison built (%s)\n", time.Since(startTime).String())
 		fmt.Printf("Markdown written to %s\n", mdFile)
 
-		return observe.ServeHTML(logger, pagePath)
+		return observe.ServeHTML(logger, dataStore, pagePath)
 	},
 }
 
 func init() {
-	compareCmd.Flags().String("format", "html", "Output format: html or md")
+	compareCmd.Flags().String("format", "html", "Output format: html, md, or json")
 	compareCmd.Flags().Bool("stdout", false, "Output raw result to stdout without starting web server")
 	compareCmd.Flags().StringP("owner", "o", "", "Repository owner")
 	compareCmd.Flags().StringP("repo", "r", "", "Repository name")
 	compareCmd.Flags().StringP("github-token", "t", "", "GitHub API token (env: GITHUB_TOKEN)")
 	compareCmd.Flags().BoolP("force-update", "u", false, "Force update of existing data")
-	compareCmd.Flags().Int64Slice("workflow-runs", nil, "Two workflow run IDs to compare (comma-separated)")
-	compareCmd.Flags().StringSlice("commits", nil, "Two commit SHAs to compare (comma-separated)")
+	compareCmd.Flags().Int64Slice("workflow-runs", nil, "2 to 10 workflow run IDs to compare (comma-separated)")
+	compareCmd.Flags().StringSlice("commits", nil, "2 to 10 commit SHAs to compare (comma-separated)")
+	compareCmd.Flags().String("baseline", "",
+		"Workflow run ID or commit SHA the others are compared against, defaults to the first one")
 	compareCmd.Flags().StringSlice("exclude-workflows", nil,
 		"Omit workflow display names from observations (comma-separated or repeat flag)")
+	compareCmd.Flags().String("baseline-branch", "", "Branch whose runs are the baseline set to compare against")
+	compareCmd.Flags().String("candidate-branch", "", "Branch whose runs are compared to the baseline set")
+	compareCmd.Flags().Int("baseline-runs", 20, "Most recent baseline runs of each workflow to compare, 0 for all")
+	compareCmd.Flags().Int("candidate-runs", 0, "Most recent candidate runs of each workflow to compare, 0 for all")
+	compareCmd.Flags().String("event", "", "Only compare runs triggered by this event, e.g. push or pull_request")
 
 	rootCmd.AddCommand(compareCmd)
 }
+
+// maxCompared is the most workflow runs or commits compare lines up at once.
+const maxCompared = 10
+
+// baselineFirst moves baseline to the front of items, keeping the order of the others.
+// Without a baseline the first item is the baseline.
+func baselineFirst[T comparable](items []T, baseline T, hasBaseline bool) ([]T, error) {
+	if !hasBaseline {
+		return items, nil
+	}
+	i := slices.Index(items, baseline)
+	if i < 0 {
+		return nil, fmt.Errorf("--baseline %v is not one of the compared workflow runs or commits", baseline)
+	}
+	ordered := append([]T{baseline}, items[:i]...)
+	return append(ordered, items[i+1:]...), nil
+}
+
+// compareRunSets compares the baseline and candidate branches' runs from the data dir.
+func compareRunSets(cmd *cobra.Command) error {
+	baselineBranch, _ := cmd.Flags().GetString("baseline-branch")
+	candidateBranch, _ := cmd.Flags().GetString("candidate-branch")
+	baselineRuns, _ := cmd.Flags().GetInt("baseline-runs")
+	candidateRuns, _ := cmd.Flags().GetInt("candidate-runs")
+	event, _ := cmd.Flags().GetString("event")
+	if event == "all" {
+		event = ""
+	}
+
+	format, toStdout, err := determineFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if format != "html" && format != "md" && format != "json" {
+		return fmt.Errorf("invalid format %q: run set comparisons can be 'html', 'md', or 'json'", format)
+	}
+
+	reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
+	defer reporter.Stop("")
+	comparison, err := observe.LoadRunSetComparison(
+		dataStore,
+		cfg.Owner, cfg.Repo,
+		observe.RunSet{Branch: baselineBranch, Event: event, Limit: baselineRuns},
+		observe.RunSet{Branch: candidateBranch, Event: event, Limit: candidateRuns},
+		buildObserveOptions(cfg, reporter)...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if toStdout {
+		outStr, err := comparison.RenderString(format)
+		if err != nil {
+			return fmt.Errorf("failed to render comparison: %w", err)
+		}
+		fmt.Print(outStr)
+		return nil
+	}
+
+	pagePath, err := comparison.Render(logger, "html")
+	if err != nil {
+		return fmt.Errorf("failed to render comparison: %w", err)
+	}
+	mdFile, err := comparison.Render(logger, "md")
+	if err != nil {
+		return fmt.Errorf("failed to render comparison markdown: %w", err)
+	}
+	fmt.Printf("Markdown written to %s\n", mdFile)
+	return observe.ServeHTML(logger, dataStore, pagePath)
+}
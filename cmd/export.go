@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kalverra/octometrics/export"
+	"github.com/kalverra/octometrics/gather"
+)
+
+var exportOTLPCmd = &cobra.Command{
+	Use:   "export-otlp [owner/repo]",
+	Short: "Export gathered workflow runs as OpenTelemetry traces",
+	Long: `Export gathered workflow runs as OpenTelemetry traces.
+
+Converts every workflow run in the data dir, or only those of owner/repo, into OTLP traces: one trace per run attempt
+with a span for the run, each job and each step. Queue time, runner, cost and conclusion are span attributes.
+
+Traces are sent to --otlp-endpoint over OTLP/HTTP, one request per run. Without an endpoint they are written as
+OTLP JSON lines to --output-file, or stdout, ready for the collector's otlpjsonfile receiver.
+Nothing is gathered from GitHub, gather or sync the runs first.`,
+	Example: `
+# Send every gathered run to a local collector
+octometrics export-otlp --otlp-endpoint http://localhost:4318
+
+# Send one repo's runs to a hosted backend
+octometrics export-otlp kalverra/octometrics --otlp-endpoint https://otlp.example.com --otlp-header "x-api-key=$KEY"
+
+# Write OTLP JSON to a file
+octometrics export-otlp kalverra/octometrics -f traces.jsonl
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var owner, repo string
+		if len(args) > 0 {
+			var err error
+			owner, repo, err = parseRepoArg(args[0])
+			if err != nil {
+				return err
+			}
+		}
+
+		runs, err := export.WorkflowRuns(dataStore, owner, repo)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("no gathered workflow runs found in '%s'", cfg.DataDir)
+		}
+
+		if err := exportOTLP(cmd, runs); err != nil {
+			return err
+		}
+		logger.Info().Int("workflow_runs", len(runs)).Msg("Exported OTLP traces")
+		return nil
+	},
+}
+
+func init() {
+	exportOTLPCmd.Flags().StringP("output-file", "f", "", "File path to write OTLP JSON to, instead of stdout")
+	addOTLPFlags(exportOTLPCmd)
+	rootCmd.AddCommand(exportOTLPCmd)
+}
+
+// addOTLPFlags adds the flags that configure where OTLP traces are sent.
+func addOTLPFlags(cmd *cobra.Command) {
+	cmd.Flags().String("otlp-endpoint", "", "OTLP/HTTP endpoint to send traces to, e.g. http://localhost:4318")
+	cmd.Flags().StringSlice("otlp-header", nil, "Header to send to the OTLP endpoint as key=value, can be repeated")
+}
+
+// exportOTLP sends runs to the --otlp-endpoint, one request per run.
+// Without an endpoint the runs are written as OTLP JSON lines to --output-file, or stdout.
+func exportOTLP(cmd *cobra.Command, runs []*gather.WorkflowRunData) (err error) {
+	endpoint, _ := cmd.Flags().GetString("otlp-endpoint")
+	headerPairs, _ := cmd.Flags().GetStringSlice("otlp-header")
+	outputFile, _ := cmd.Flags().GetString("output-file")
+
+	if endpoint != "" {
+		headers, err := export.ParseOTLPHeaders(headerPairs)
+		if err != nil {
+			return err
+		}
+		return sendOTLP(cmd.Context(), endpoint, headers, runs)
+	}
+
+	out, closeOut, err := createOutput(outputFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeOut(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	for _, run := range runs {
+		if err := export.WriteOTLP(out, export.OTLPTraces(run)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sendOTLP(ctx context.Context, endpoint string, headers map[string]string, runs []*gather.WorkflowRunData) error {
+	for _, run := range runs {
+		if err := export.SendOTLP(ctx, endpoint, export.OTLPTraces(run), export.WithOTLPHeaders(headers)); err != nil {
+			return fmt.Errorf("failed to export workflow run %d: %w", run.GetID(), err)
+		}
+		logger.Debug().Int64("workflow_run_id", run.GetID()).Str("endpoint", endpoint).Msg("Sent OTLP traces")
+	}
+	return nil
+}
+
+// exportTrace writes run as Chrome Trace Event JSON to outputFile, or stdout.
+func exportTrace(outputFile string, run *gather.WorkflowRunData) (err error) {
+	out, closeOut, err := createOutput(outputFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeOut(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return export.WriteTrace(out, export.ChromeTrace(run))
+}
+
+// createOutput creates outputFile to write an export to, or returns stdout when it's empty.
+func createOutput(outputFile string) (out io.Writer, closeOut func() error, err error) {
+	if outputFile == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	//nolint:gosec // user specified output file path
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create output file %q: %w", outputFile, err)
+	}
+	return file, func() error {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close output file %q: %w", outputFile, err)
+		}
+		return nil
+	}, nil
+}
+
+// writeOutput writes a rendered page to outputFile, or stdout.
+func writeOutput(outputFile, content string) (err error) {
+	out, closeOut, err := createOutput(outputFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeOut(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	if _, err := io.WriteString(out, content); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/observe"
+)
+
+var flakyCmd = &cobra.Command{
+	Use:   "flaky owner/repo",
+	Short: "Find jobs that fail and then pass on the same commit",
+	Long: `Find jobs that fail and then pass on the same commit.
+
+Compares every attempt of each job on a commit, both re-runs of a workflow run and separate runs of the same
+workflow on identical SHAs, from workflow runs already in the data dir. Jobs that both failed and passed on a commit
+are flaky, scored by the share of commits they flaked on, along with the steps that failed.
+Nothing is gathered from GitHub, gather or sync the runs first.
+
+HTML opens the flaky jobs page, md and json print the same data.`,
+	Example: `
+# Open the flaky jobs page
+octometrics flaky kalverra/octometrics
+
+# Print flaky jobs as JSON
+octometrics flaky kalverra/octometrics --format json
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owner, repo, err := parseRepoArg(args[0])
+		if err != nil {
+			return err
+		}
+
+		format, toStdout, err := determineFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != "html" && format != "md" && format != "json" {
+			return fmt.Errorf("invalid format %q: flaky jobs can be 'html', 'md', or 'json'", format)
+		}
+
+		reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
+		defer reporter.Stop("")
+		obsOpts := buildObserveOptions(cfg, reporter)
+
+		if toStdout {
+			flaky, err := observe.LoadFlakyJobs(dataStore, owner, repo, obsOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to find flaky jobs: %w", err)
+			}
+			outStr, err := flaky.RenderString(format)
+			if err != nil {
+				return err
+			}
+			return writeOutput(cfg.OutputFile, outStr)
+		}
+
+		pagePath := fmt.Sprintf("/%s/%s/flaky", owner, repo)
+		return observe.Interactive(cmd.Context(), logger, nil, pagePath, dataStore, obsOpts...)
+	},
+}
+
+func init() {
+	flakyCmd.Flags().String("format", "html", "Output format: html, md, or json")
+	flakyCmd.Flags().StringP("output-file", "f", "", "File path to write flaky jobs to, instead of stdout")
+	flakyCmd.Flags().StringSlice("exclude-workflows", nil,
+		"Omit workflow display names from the analysis (comma-separated or repeat flag)")
+	flakyCmd.Flags().Bool("no-open", false, "Do not open browser window on startup")
+	flakyCmd.Flags().Int("port", 8080, "Port for local web server")
+
+	rootCmd.AddCommand(flakyCmd)
+}
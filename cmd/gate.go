@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/observe"
+	"github.com/kalverra/octometrics/report"
+)
+
+var gateCmd = &cobra.Command{
+	Use:   "gate [url]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Fail when CI got slower or more expensive than a baseline",
+	Long: `Fail when CI got slower or more expensive than a baseline.
+
+Compares a workflow run or commit against the --vs baseline, like the root command does, and checks every workflow
+and job against duration and cost thresholds. Thresholds are the largest allowed increase, absolute or in percent.
+When both are set for a metric, both have to be exceeded, so short jobs don't fail the gate over a few seconds.
+
+Thresholds come from --thresholds, a YAML file with defaults and overrides per workflow and job, and the --max-*
+flags, which replace the file's defaults:
+
+  duration_percent: 20
+  cost: 0.50
+  workflows:
+    CI:
+      duration: 5m
+      jobs:
+        test:
+          duration_percent: 10
+
+The markdown verdict is printed and, inside GitHub Actions, appended to GITHUB_STEP_SUMMARY.
+Exits non-zero when any threshold is breached.
+
+Inside GitHub Actions the current workflow run is gated by default. When the gate runs in the workflow it checks,
+pass --wait=false so it doesn't wait on itself.`,
+	Example: `
+# Gate a workflow run against a baseline run
+octometrics gate -o kalverra -r octometrics -w 123 --vs 456 --max-duration-increase-percent 20
+
+# Gate a commit against the main branch's head with a thresholds file
+octometrics gate https://github.com/kalverra/octometrics/commit/def456 --vs abc123 --thresholds .github/gate.yaml
+
+# Inside GitHub Actions, gate the current run
+octometrics gate --vs 456 --thresholds .github/gate.yaml --wait=false
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			res, err := parseGitHubURL(args[0])
+			if err != nil {
+				return err
+			}
+			cfg.Owner, cfg.Repo = res.Owner, res.Repo
+			cfg.WorkflowRunID, cfg.CommitSHA = res.WorkflowRunID, res.CommitSHA
+		}
+		if err := gateActionsDefaults(); err != nil {
+			return err
+		}
+		if err := cfg.ValidateCompare(); err != nil {
+			return err
+		}
+		if (cfg.WorkflowRunID == 0) == (cfg.CommitSHA == "") {
+			return errors.New("one of a workflow run ID or a commit SHA must be gated")
+		}
+
+		vsTarget, _ := cmd.Flags().GetString("vs")
+		if vsTarget == "" {
+			return errors.New("--vs baseline is required")
+		}
+		vsRunID, vsSHA, err := parseVsTarget(vsTarget)
+		if err != nil {
+			return err
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if format != "md" && format != "json" {
+			return fmt.Errorf("invalid format %q: gate results can be 'md' or 'json'", format)
+		}
+
+		thresholds, err := gateThresholds(cmd)
+		if err != nil {
+			return err
+		}
+
+		githubClient, err = newGitHubClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+		reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
+		defer reporter.Stop("")
+		obsOpts := buildObserveOptions(cfg, reporter)
+
+		ctx := cmd.Context()
+		var comparison *observe.Comparison
+		switch {
+		case vsRunID != 0 && cfg.WorkflowRunID != 0:
+			comparison, err = observe.CompareWorkflowRuns(
+				ctx,
+				logger, githubClient,
+				cfg.Owner, cfg.Repo,
+				vsRunID, cfg.WorkflowRunID,
+				obsOpts...,
+			)
+		case vsSHA != "" && cfg.CommitSHA != "":
+			comparison, err = observe.CompareCommits(
+				ctx,
+				logger, githubClient,
+				cfg.Owner, cfg.Repo,
+				vsSHA, cfg.CommitSHA,
+				obsOpts...,
+			)
+		default:
+			return errors.New("cannot compare: baseline and target must both be workflow runs or both be commits")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to compare against %s: %w", vsTarget, err)
+		}
+
+		result, err := observe.Gate(ctx, logger, githubClient, comparison, thresholds, obsOpts...)
+		if err != nil {
+			return err
+		}
+		outStr, err := result.RenderString(format)
+		if err != nil {
+			return err
+		}
+		fmt.Print(outStr)
+
+		if skipSummary, _ := cmd.Flags().GetBool("skip-summary"); !skipSummary && format == "md" {
+			if summaryPath := os.Getenv("GITHUB_STEP_SUMMARY"); summaryPath != "" {
+				if err := report.WriteSummary(summaryPath, outStr); err != nil {
+					return fmt.Errorf("failed to write step summary: %w", err)
+				}
+				logger.Info().Msg("Wrote gate verdict to step summary")
+			}
+		}
+
+		if !result.Passed() {
+			return fmt.Errorf("CI performance gate failed: %d threshold(s) breached", result.Breaches)
+		}
+		return nil
+	},
+}
+
+func init() {
+	gateCmd.Flags().StringP("owner", "o", "", "Repository owner")
+	gateCmd.Flags().StringP("repo", "r", "", "Repository name")
+	gateCmd.Flags().StringP("commit-sha", "c", "", "Commit SHA to gate")
+	gateCmd.Flags().Int64P("workflow-run-id", "w", 0, "Workflow run ID to gate, defaults to GITHUB_RUN_ID in Actions")
+	gateCmd.Flags().StringP("github-token", "t", "", "GitHub API token (env: GITHUB_TOKEN)")
+	gateCmd.Flags().String("vs", "", "Baseline workflow run ID, commit SHA, or URL to compare against")
+	gateCmd.Flags().String("thresholds", "", "YAML file of duration and cost thresholds per workflow and job")
+	gateCmd.Flags().Duration("max-duration-increase", 0, "Largest allowed duration increase, e.g. 5m")
+	gateCmd.Flags().Float64("max-duration-increase-percent", 0, "Largest allowed duration increase in percent")
+	gateCmd.Flags().Float64("max-cost-increase", 0, "Largest allowed cost increase in USD")
+	gateCmd.Flags().Float64("max-cost-increase-percent", 0, "Largest allowed cost increase in percent")
+	gateCmd.Flags().String("format", "md", "Output format: md or json")
+	gateCmd.Flags().Bool("skip-summary", false, "Skip writing the verdict to GITHUB_STEP_SUMMARY")
+	gateCmd.Flags().StringSlice("exclude-workflows", nil, "Omit workflow display names from the gate")
+	gateCmd.Flags().BoolP("force-update", "u", false, "Force update of existing data")
+	gateCmd.Flags().Bool("wait", true, "Wait for in-progress workflow/commit runs to complete before gating")
+	gateCmd.Flags().Duration("wait-timeout", 30*time.Minute, "Maximum duration to wait for in-progress runs")
+
+	rootCmd.AddCommand(gateCmd)
+}
+
+// gateActionsDefaults fills in the repo and workflow run being gated from the GitHub Actions environment.
+func gateActionsDefaults() error {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil
+	}
+	if cfg.Owner == "" && cfg.Repo == "" {
+		if owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/"); ok {
+			cfg.Owner, cfg.Repo = owner, repo
+		}
+	}
+	if cfg.WorkflowRunID == 0 && cfg.CommitSHA == "" {
+		if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+			id, err := strconv.ParseInt(runID, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid GITHUB_RUN_ID %q: %w", runID, err)
+			}
+			cfg.WorkflowRunID = id
+		}
+	}
+	return nil
+}
+
+// gateThresholds loads the --thresholds file, with the --max-* flags replacing its defaults.
+// It fails when no limit is set anywhere, as the gate would then pass every comparison.
+func gateThresholds(cmd *cobra.Command) (*observe.GateThresholds, error) {
+	thresholds := &observe.GateThresholds{}
+	if path, _ := cmd.Flags().GetString("thresholds"); path != "" {
+		var err error
+		thresholds, err = observe.LoadGateThresholds(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cmd.Flags().Changed("max-duration-increase") {
+		thresholds.Duration, _ = cmd.Flags().GetDuration("max-duration-increase")
+	}
+	if cmd.Flags().Changed("max-duration-increase-percent") {
+		thresholds.DurationPercent, _ = cmd.Flags().GetFloat64("max-duration-increase-percent")
+	}
+	if cmd.Flags().Changed("max-cost-increase") {
+		thresholds.Cost, _ = cmd.Flags().GetFloat64("max-cost-increase")
+	}
+	if cmd.Flags().Changed("max-cost-increase-percent") {
+		thresholds.CostPercent, _ = cmd.Flags().GetFloat64("max-cost-increase-percent")
+	}
+	if !thresholds.HasLimits() {
+		return nil, errors.New("no gate limits set: pass --thresholds or one of the --max-* flags")
+	}
+	return thresholds, nil
+}
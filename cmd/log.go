@@ -9,7 +9,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/kalverra/octometrics/gather"
-	"github.com/kalverra/octometrics/internal/githuburl"
 )
 
 var logCmd = &cobra.Command{
@@ -37,7 +36,7 @@ var logCmd = &cobra.Command{
 
 		if target != "" {
 			if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
-				res, parseErr := githuburl.Parse(target)
+				res, parseErr := parseGitHubURL(target)
 				if parseErr != nil {
 					return parseErr
 				}
@@ -74,9 +73,9 @@ var logCmd = &cobra.Command{
 			)
 		}
 
-		if cfg.GitHubToken != "" && githubClient == nil {
+		if (cfg.GitHubToken != "" || cfg.Replay != "") && githubClient == nil {
 			var clientErr error
-			githubClient, clientErr = gather.NewGitHubClient(logger, cfg.GitHubToken, nil)
+			githubClient, clientErr = newGitHubClient(cfg)
 			if clientErr != nil {
 				return fmt.Errorf("failed to create GitHub client: %w", clientErr)
 			}
@@ -89,7 +88,7 @@ var logCmd = &cobra.Command{
 			owner,
 			repo,
 			jobID,
-			cfg.DataDir,
+			dataStore,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to get job logs: %w", err)
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [json-data-dir]",
+	Short: "Import a JSON data directory into the configured store",
+	Long: `Import a JSON data directory into the configured store.
+
+Copies every gathered workflow run, commit, and pull request, along with the manifest index,
+sync state, and workflow file versions, from a file-based data directory into the store at --data-dir.
+The store defaults to sqlite.
+Raw job logs and other artifacts are left where they are.`,
+	Example: `
+# Move the default data directory over to SQLite
+octometrics migrate --store sqlite
+
+# Import an old data directory into a new SQLite data directory
+octometrics migrate ./old-data --data-dir ./data --store sqlite
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		source := cfg.DataDir
+		if len(args) > 0 {
+			source = args[0]
+		}
+		kind := cfg.Store
+		if kind == gather.StoreAuto {
+			kind = gather.StoreSQLite
+		}
+		if kind == gather.StoreFile && filepath.Clean(source) == filepath.Clean(cfg.DataDir) {
+			return fmt.Errorf("'%s' is already a file store, nothing to migrate", source)
+		}
+
+		dst, err := gather.OpenStore(kind, cfg.DataDir)
+		if err != nil {
+			return fmt.Errorf("failed to open %s store: %w", kind, err)
+		}
+		defer func() { _ = dst.Close() }()
+
+		migrated, err := gather.MigrateStore(gather.NewFileStore(source), dst)
+		if err != nil {
+			return fmt.Errorf("failed to migrate '%s' after %d entries: %w", source, migrated, err)
+		}
+
+		logger.Info().
+			Str("source", source).
+			Str("store", kind).
+			Str("data_dir", cfg.DataDir).
+			Int("entries", migrated).
+			Msg("Migrated data directory")
+		fmt.Printf("Imported %d entries from %s into the %s store at %s\n", migrated, source, kind, cfg.DataDir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -11,14 +11,23 @@ import (
 )
 
 var (
-	skipCPU    bool
-	skipMemory bool
-	skipDisk   bool
-	skipIO     bool
-
-	duration   time.Duration
-	interval   time.Duration
-	outputFile string
+	skipCPU      bool
+	skipMemory   bool
+	skipDisk     bool
+	skipDiskIO   bool
+	skipIO       bool
+	skipCgroup   bool
+	skipPressure bool
+	skipLoad     bool
+
+	topProcesses int
+
+	duration    time.Duration
+	interval    time.Duration
+	outputFile  string
+	metricsAddr string
+
+	markOutputFile string
 )
 
 var monitorCmd = &cobra.Command{
@@ -26,13 +35,18 @@ var monitorCmd = &cobra.Command{
 	Short: "Monitor system resources",
 	Long: `Monitor system resources for later analysis.
 
-This command will monitor system resources like CPU, memory, disk, and I/O during a GHA job.
+This command will monitor system resources like CPU, memory, disk usage, disk I/O, and network I/O during a GHA job.
+Inside a container, it also records the container's cgroup usage against its CPU and memory limits.
+With --top-processes it also records which processes use the most CPU and memory.
 
 It will write the data to a file for later analysis. Primarily used in the octometrics-action to monitor system resources during a GHA job.`,
 	Example: `
 octometrics monitor # Monitor system resources until interrupted
 octometrics monitor --duration=1h # Monitor system resources for 1 hour
 octometrics monitor --interval=5s # Monitor system resources every 5 seconds
+octometrics monitor --top-processes=5 # Also record the 5 heaviest processes by CPU and by memory
+octometrics monitor --metrics-addr=:9090 # Also serve the latest samples for Prometheus at :9090/metrics
+octometrics monitor mark "Run tests" # Mark the start of a step in a running monitor's data
 `,
 	RunE: func(_ *cobra.Command, _ []string) error {
 		var (
@@ -59,9 +73,27 @@ octometrics monitor --interval=5s # Monitor system resources every 5 seconds
 		if skipDisk {
 			monitorOpts = append(monitorOpts, monitor.DisableDisk())
 		}
+		if skipDiskIO {
+			monitorOpts = append(monitorOpts, monitor.DisableDiskIO())
+		}
 		if skipIO {
 			monitorOpts = append(monitorOpts, monitor.DisableIO())
 		}
+		if skipCgroup {
+			monitorOpts = append(monitorOpts, monitor.DisableCgroup())
+		}
+		if skipPressure {
+			monitorOpts = append(monitorOpts, monitor.DisablePressure())
+		}
+		if skipLoad {
+			monitorOpts = append(monitorOpts, monitor.DisableLoad())
+		}
+		if metricsAddr != "" {
+			monitorOpts = append(monitorOpts, monitor.WithMetricsAddr(metricsAddr))
+		}
+		if topProcesses > 0 {
+			monitorOpts = append(monitorOpts, monitor.WithTopProcesses(topProcesses))
+		}
 
 		err := monitor.Start(ctx, monitorOpts...)
 		if err != nil {
@@ -71,15 +103,53 @@ octometrics monitor --interval=5s # Monitor system resources every 5 seconds
 	},
 }
 
+var monitorMarkCmd = &cobra.Command{
+	Use:   "mark <step name>",
+	Short: "Mark the start of a step in a running monitor's data",
+	Long: `Mark the start of a step in the data of a running 'octometrics monitor'.
+
+The step lasts until the next marker or until monitoring stops. Reports and job pages then show each step's
+peak CPU, memory and network usage. Without markers, the job's step timing from GitHub is used instead.`,
+	Example: `
+octometrics monitor mark "Run tests"
+octometrics monitor mark "Build" --output-file=custom.monitor.jsonl
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		if err := monitor.Mark(args[0], monitor.WithOutputFile(markOutputFile)); err != nil {
+			return fmt.Errorf("error marking step: %w", err)
+		}
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(monitorCmd)
+	monitorCmd.AddCommand(monitorMarkCmd)
 
 	monitorCmd.Flags().BoolVar(&skipCPU, "skip-cpu", false, "Skip CPU monitoring")
 	monitorCmd.Flags().BoolVar(&skipMemory, "skip-memory", false, "Skip memory monitoring")
 	monitorCmd.Flags().BoolVar(&skipDisk, "skip-disk", false, "Skip disk monitoring")
+	monitorCmd.Flags().BoolVar(&skipDiskIO, "skip-disk-io", false, "Skip disk I/O throughput and IOPS monitoring")
 	monitorCmd.Flags().BoolVar(&skipIO, "skip-io", false, "Skip IO monitoring")
+	monitorCmd.Flags().BoolVar(
+		&skipCgroup, "skip-cgroup", false, "Skip monitoring the container's cgroup CPU, memory, and throttling",
+	)
+	monitorCmd.Flags().BoolVar(
+		&skipPressure, "skip-pressure", false, "Skip Linux pressure stall (PSI) monitoring for CPU, memory, and IO",
+	)
+	monitorCmd.Flags().BoolVar(&skipLoad, "skip-load", false, "Skip load average monitoring")
+	monitorCmd.Flags().IntVar(
+		&topProcesses, "top-processes", 0, "Record the top N processes by CPU and by memory each interval, 0 disables",
+	)
 	monitorCmd.Flags().DurationVarP(&duration, "duration", "d", 0, "Duration to monitor, defaults to indefinite")
 	monitorCmd.Flags().DurationVarP(&interval, "interval", "i", 1*time.Second, "At what interval to observe metrics")
 	monitorCmd.Flags().
 		StringVarP(&outputFile, "output-file", "o", monitor.DataFile, "Output file for the monitor data")
+	monitorCmd.Flags().StringVar(
+		&metricsAddr, "metrics-addr", "", "Serve the latest samples for Prometheus at /metrics on this address",
+	)
+
+	monitorMarkCmd.Flags().
+		StringVarP(&markOutputFile, "output-file", "o", monitor.DataFile, "Output file of the running monitor")
 }
@@ -4,7 +4,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
 	"strconv"
@@ -28,10 +30,12 @@ const (
 )
 
 var (
-	cfg          *config.Config
-	logger       zerolog.Logger
-	cpuFile      *os.File
-	githubClient *gather.GitHubClient
+	cfg            *config.Config
+	logger         zerolog.Logger
+	cpuFile        *os.File
+	githubClient   *gather.GitHubClient
+	dataStore      gather.Store
+	pricingCatalog *gather.PricingCatalog
 )
 
 // These variables are set at build time and describe the version and build of the application
@@ -57,7 +61,18 @@ func versionInfo() string {
 func commandNeedsGitHubToken(cmd *cobra.Command) bool {
 	for c := cmd; c != nil; c = c.Parent() {
 		switch c.Name() {
-		case "monitor", "report", "help", "completion":
+		case "monitor", "report", "export-otlp", "trends", "flaky", "help", "completion":
+			return false
+		}
+	}
+	return true
+}
+
+// commandUsesDataStore reports whether cmd reads or writes gathered data through the configured store.
+func commandUsesDataStore(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "monitor", "report", "migrate", "help", "completion":
 			return false
 		}
 	}
@@ -70,11 +85,16 @@ func buildGatherOptions(cfg *config.Config, reporter gather.ProgressReporter) []
 	}
 	opts := []gather.Option{
 		gather.CustomDataFolder(cfg.DataDir),
+	}
+	if dataStore != nil {
+		opts = append(opts, gather.WithStore(dataStore))
+	}
+	opts = append(opts,
 		gather.WithWait(cfg.Wait),
 		gather.WithWaitTimeout(cfg.WaitTimeout),
 		gather.WithPollInterval(cfg.PollInterval),
 		gather.WithProgressReporter(reporter),
-	}
+	)
 	if cfg.ForceUpdate {
 		opts = append(opts, gather.ForceUpdate())
 	}
@@ -86,9 +106,51 @@ func buildGatherOptions(cfg *config.Config, reporter gather.ProgressReporter) []
 	if cfg.DownloadLogs {
 		opts = append(opts, gather.WithDownloadLogs(true))
 	}
+	if pricingCatalog != nil {
+		opts = append(opts, gather.WithPricingCatalog(pricingCatalog))
+	}
 	return opts
 }
 
+// newGitHubClient creates a GitHub client for the configured token and GitHub host,
+// recording or replaying its traffic when --record or --replay is set.
+func newGitHubClient(cfg *config.Config) (*gather.GitHubClient, error) {
+	var (
+		next  http.RoundTripper
+		token = cfg.GitHubToken
+		err   error
+	)
+	switch {
+	case cfg.Record != "":
+		next, err = gather.NewRecorder(logger, cfg.Record, nil)
+	case cfg.Replay != "":
+		next, err = gather.NewReplayer(logger, cfg.Replay)
+		if token == "" {
+			token = gather.MockGitHubToken
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL, graphQLURL := cfg.EnterpriseURLs()
+	return gather.NewGitHubClient(
+		logger,
+		token,
+		next,
+		gather.WithEnterpriseURLs(apiURL, graphQLURL),
+		gather.WithHTTPCache(filepath.Join(cfg.DataDir, gather.HTTPCacheDir)),
+	)
+}
+
+// parseGitHubURL parses a GitHub web URL, also accepting the configured GitHub Enterprise Server host.
+func parseGitHubURL(rawURL string) (*githuburl.Result, error) {
+	if cfg == nil {
+		return githuburl.Parse(rawURL)
+	}
+	return githuburl.Parse(rawURL, cfg.GitHubWebHost())
+}
+
 func buildObserveOptions(cfg *config.Config, reporter gather.ProgressReporter) []observe.Option {
 	if reporter == nil {
 		reporter = gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
@@ -140,7 +202,25 @@ inefficiencies in your CI/CD pipelines.`,
 			}
 		}
 
-		if cfg.GitHubToken == "" && commandNeedsGitHubToken(cmd) {
+		if err := cfg.ValidateCassette(); err != nil {
+			return err
+		}
+
+		if cfg.PricingCatalog != "" {
+			pricingCatalog, err = gather.LoadPricingCatalog(cfg.PricingCatalog)
+			if err != nil {
+				return err
+			}
+		}
+
+		if commandUsesDataStore(cmd) {
+			dataStore, err = gather.OpenStore(cfg.Store, cfg.DataDir)
+			if err != nil {
+				return fmt.Errorf("failed to open data store: %w", err)
+			}
+		}
+
+		if cfg.GitHubToken == "" && cfg.Replay == "" && commandNeedsGitHubToken(cmd) {
 			logger.Warn().Msg("GitHub token not provided, will likely hit rate limits quickly")
 			fmt.Fprintln(os.Stderr, "WARNING: GitHub token not provided, will likely hit rate limits quickly")
 		}
@@ -150,7 +230,7 @@ inefficiencies in your CI/CD pipelines.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rebuild, _ := cmd.Flags().GetBool("rebuild-manifest")
 		if rebuild {
-			if err := observe.RebuildManifest(cmd.Context(), logger, cfg.DataDir); err != nil {
+			if err := observe.RebuildManifest(cmd.Context(), logger, dataStore); err != nil {
 				return fmt.Errorf("failed to rebuild manifest: %w", err)
 			}
 			logger.Info().Msg("Manifest rebuilt successfully")
@@ -162,7 +242,7 @@ inefficiencies in your CI/CD pipelines.`,
 		}
 
 		if len(args) > 0 {
-			res, err := githuburl.Parse(args[0])
+			res, err := parseGitHubURL(args[0])
 			if err != nil {
 				return err
 			}
@@ -182,10 +262,9 @@ inefficiencies in your CI/CD pipelines.`,
 		hasTarget := cfg.WorkflowRunID != 0 || cfg.PullRequestNumber != 0 || cfg.CommitSHA != "" ||
 			(!cfg.From.IsZero() && !cfg.To.IsZero())
 
-		var githubClient *gather.GitHubClient
-		if cfg.GitHubToken != "" {
+		if cfg.GitHubToken != "" || cfg.Replay != "" {
 			var clientErr error
-			githubClient, clientErr = gather.NewGitHubClient(logger, cfg.GitHubToken, nil)
+			githubClient, clientErr = newGitHubClient(cfg)
 			if clientErr != nil {
 				return fmt.Errorf("failed to create GitHub client: %w", clientErr)
 			}
@@ -256,6 +335,28 @@ inefficiencies in your CI/CD pipelines.`,
 			}
 		}
 
+		if format == "otlp" || format == "trace" {
+			if cfg.WorkflowRunID == 0 {
+				return fmt.Errorf("--format %s needs a workflow run", format)
+			}
+			run, _, err := gather.WorkflowRun(
+				cmd.Context(),
+				logger,
+				githubClient,
+				cfg.Owner,
+				cfg.Repo,
+				cfg.WorkflowRunID,
+				buildGatherOptions(cfg, reporter)...,
+			)
+			if err != nil {
+				return err
+			}
+			if format == "trace" {
+				return exportTrace(cfg.OutputFile, run)
+			}
+			return exportOTLP(cmd, []*gather.WorkflowRunData{run})
+		}
+
 		vsTarget, _ := cmd.Flags().GetString("vs")
 		if vsTarget != "" {
 			vsRunID, vsSHA, parseErr := parseVsTarget(vsTarget)
@@ -334,7 +435,7 @@ inefficiencies in your CI/CD pipelines.`,
 					return fmt.Errorf("failed to render pages linked from comparison: %w", ensureErr)
 				}
 
-				return observe.Interactive(cmd.Context(), logger, githubClient, pagePath, cfg.DataDir, obsOpts...)
+				return observe.Interactive(cmd.Context(), logger, githubClient, pagePath, dataStore, obsOpts...)
 			}
 		}
 
@@ -380,11 +481,7 @@ inefficiencies in your CI/CD pipelines.`,
 
 			if obs != nil {
 				if cfg.CommitSHA != "" {
-					if warnings := observe.CheckWorkflowDrift(".", cfg.CommitSHA); len(warnings) > 0 {
-						for _, w := range warnings {
-							fmt.Fprintln(os.Stderr, w)
-						}
-					}
+					warnWorkflowDrift(cmd.Context(), cfg.CommitSHA, obsOpts)
 				}
 				outStr, err := obs.RenderString(logger, format)
 				if err != nil {
@@ -401,7 +498,7 @@ inefficiencies in your CI/CD pipelines.`,
 				return nil
 			}
 
-			return observe.All(cmd.Context(), logger, githubClient, []string{format}, cfg.DataDir, obsOpts...)
+			return observe.All(cmd.Context(), logger, githubClient, []string{format}, dataStore, obsOpts...)
 		}
 
 		if cfg.NoObserve {
@@ -419,9 +516,14 @@ inefficiencies in your CI/CD pipelines.`,
 			pagePath = fmt.Sprintf("/%s/%s", cfg.Owner, cfg.Repo)
 		}
 
-		return observe.Interactive(cmd.Context(), logger, githubClient, pagePath, cfg.DataDir, obsOpts...)
+		return observe.Interactive(cmd.Context(), logger, githubClient, pagePath, dataStore, obsOpts...)
 	},
 	PersistentPostRunE: func(_ *cobra.Command, _ []string) error {
+		if dataStore != nil {
+			if err := dataStore.Close(); err != nil {
+				return fmt.Errorf("failed to close data store: %w", err)
+			}
+		}
 		if cpuFile != nil {
 			pprof.StopCPUProfile()
 			if err := cpuFile.Close(); err != nil {
@@ -436,7 +538,21 @@ func init() {
 	rootCmd.PersistentFlags().String("log-level", config.DefaultLogLevel, "Level for detailed logging")
 	rootCmd.PersistentFlags().
 		String("data-dir", config.DefaultDataDir(), "Directory for cached GitHub data (env: DATA_DIR)")
+	rootCmd.PersistentFlags().
+		String("store", "", "Data store backend: file or sqlite, detected from data-dir if unset (env: STORE)")
 	rootCmd.PersistentFlags().String("cpu-profile", "", "Write CPU profile to file")
+	rootCmd.PersistentFlags().
+		String("github-server-url", "", "GitHub Enterprise Server web URL (env: GITHUB_SERVER_URL)")
+	rootCmd.PersistentFlags().
+		String("github-api-url", "", "GitHub Enterprise Server REST API URL, derived if unset (env: GITHUB_API_URL)")
+	rootCmd.PersistentFlags().
+		String("github-graphql-url", "", "GitHub Enterprise Server GraphQL URL, derived if unset (env: GITHUB_GRAPHQL_URL)")
+	rootCmd.PersistentFlags().
+		String("pricing-catalog", "", "YAML catalog of runner rates and discounts for costs (env: PRICING_CATALOG)")
+	rootCmd.PersistentFlags().
+		String("record", "", "Record all GitHub API traffic as fixtures into this directory (env: RECORD)")
+	rootCmd.PersistentFlags().
+		String("replay", "", "Serve GitHub API traffic offline from fixtures recorded with --record (env: REPLAY)")
 
 	rootCmd.Flags().StringP("owner", "o", "", "Repository owner")
 	rootCmd.Flags().StringP("repo", "r", "", "Repository name")
@@ -454,7 +570,7 @@ func init() {
 	rootCmd.Flags().Bool("exclude-costs", false, "Skip gathering cost data for workflow runs")
 	rootCmd.Flags().StringSlice("exclude-workflows", nil, "Omit workflow display names from observations")
 	rootCmd.Flags().StringSlice("include-workflows", nil, "Include only specific workflow display names")
-	rootCmd.Flags().String("format", "html", "Output format: html, md, or json")
+	rootCmd.Flags().String("format", "html", "Output format: html, md, json, otlp, or trace")
 	rootCmd.Flags().StringP("output-file", "f", "", "File path to write rendered output")
 	rootCmd.Flags().Bool("json", false, "Output structured JSON to stdout")
 	rootCmd.Flags().Bool("stdout", false, "Output raw result to stdout without starting web server")
@@ -467,15 +583,52 @@ func init() {
 	rootCmd.Flags().String("vs", "", "Baseline workflow run ID, commit SHA, or URL to compare against")
 	rootCmd.Flags().Bool("no-open", false, "Do not open browser window on startup")
 	rootCmd.Flags().Int("port", 8080, "Port for local web server")
+	addOTLPFlags(rootCmd)
 }
 
 // Execute runs the root command for octometrics.
 func Execute() {
-	if err := fang.Execute(context.Background(), rootCmd, fang.WithVersion(versionInfo())); err != nil {
+	err := fang.Execute(context.Background(), rootCmd, fang.WithVersion(versionInfo()))
+	logHTTPCacheStats()
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
+// warnWorkflowDrift warns on stderr about workflows that changed on the default branch since a commit's runs,
+// whose observation then no longer shows how CI runs today.
+func warnWorkflowDrift(ctx context.Context, commitSHA string, obsOpts []observe.Option) {
+	drifts, err := observe.CommitWorkflowDrift(ctx, logger, githubClient, cfg.Owner, cfg.Repo, commitSHA, obsOpts...)
+	if err != nil {
+		logger.Warn().Err(err).Str("commit_sha", commitSHA).Msg("Failed to check workflows for changes")
+		return
+	}
+	for _, drift := range drifts {
+		fmt.Fprintf(
+			os.Stderr,
+			"⚠ %s changed on %s since this commit (+%d -%d lines).\n",
+			drift.RightPath,
+			drift.RightRef,
+			drift.Added,
+			drift.Removed,
+		)
+	}
+}
+
+// logHTTPCacheStats reports how the HTTP cache served the command's GitHub requests, if it built a client.
+// It runs after the command finishes, whether or not it failed, so partial runs still show what they cost.
+func logHTTPCacheStats() {
+	if githubClient == nil {
+		return
+	}
+	cacheStats := githubClient.HTTPCacheStats()
+	logger.Info().
+		Int64("http_cache_hits", cacheStats.Hits).
+		Int64("http_cache_misses", cacheStats.Misses).
+		Int64("http_cache_stored", cacheStats.Stored).
+		Msg("GitHub HTTP cache stats")
+}
+
 func determineFormat(cmd *cobra.Command) (format string, toStdout bool, err error) {
 	fmtFlag, _ := cmd.Flags().GetString("format")
 	stdoutFlag, _ := cmd.Flags().GetBool("stdout")
@@ -486,8 +639,10 @@ func determineFormat(cmd *cobra.Command) (format string, toStdout bool, err erro
 		fmtFlag = "json"
 	}
 
-	if fmtFlag != "html" && fmtFlag != "md" && fmtFlag != "markdown" && fmtFlag != "json" {
-		return "", false, fmt.Errorf("invalid format %q: must be 'html', 'md', or 'json'", fmtFlag)
+	switch fmtFlag {
+	case "html", "md", "markdown", "json", "otlp", "trace":
+	default:
+		return "", false, fmt.Errorf("invalid format %q: must be 'html', 'md', 'json', 'otlp', or 'trace'", fmtFlag)
 	}
 	if fmtFlag == "markdown" {
 		fmtFlag = "md"
@@ -514,7 +669,7 @@ func parseVsTarget(target string) (runID int64, sha string, err error) {
 	}
 
 	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
-		res, parseErr := githuburl.Parse(target)
+		res, parseErr := parseGitHubURL(target)
 		if parseErr != nil {
 			return 0, "", fmt.Errorf("invalid --vs URL %q: %w", target, parseErr)
 		}
@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/observe"
 )
 
 func TestRootCmdFlags(t *testing.T) {
@@ -29,6 +35,8 @@ func TestRootCmdFlags(t *testing.T) {
 		"output-file",
 		"stdout",
 		"rebuild-manifest",
+		"otlp-endpoint",
+		"otlp-header",
 	}
 
 	for _, flagName := range flags {
@@ -49,6 +57,136 @@ func TestLogCmdFlags(t *testing.T) {
 	assert.NotNil(t, logCmd.Flags().Lookup("gaps"), "logCmd should have flag --gaps")
 }
 
+func TestMonitorCmdFlags(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, monitorCmd.Flags().Lookup("metrics-addr"), "monitorCmd should have flag --metrics-addr")
+	assert.NotNil(t, monitorCmd.Flags().Lookup("top-processes"), "monitorCmd should have flag --top-processes")
+}
+
+func TestExportOTLPCmdFlags(t *testing.T) {
+	t.Parallel()
+
+	for _, flagName := range []string{"otlp-endpoint", "otlp-header", "output-file"} {
+		assert.NotNil(t, exportOTLPCmd.Flags().Lookup(flagName), "exportOTLPCmd should have flag --%s", flagName)
+	}
+	require.Error(t, exportOTLPCmd.Args(exportOTLPCmd, []string{"a/b", "c/d"}), "only one repo should be accepted")
+}
+
+func TestTrendsCmd(t *testing.T) {
+	t.Parallel()
+
+	for _, flagName := range []string{"branch", "event", "interval", "from", "to", "format", "output-file"} {
+		assert.NotNil(t, trendsCmd.Flags().Lookup(flagName), "trendsCmd should have flag --%s", flagName)
+	}
+	require.Error(t, trendsCmd.Args(trendsCmd, []string{}), "a repo should be required")
+	assert.False(t, commandNeedsGitHubToken(trendsCmd), "trends only reads local data")
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t,
+		"/kalverra/octometrics/trends?branch=main&from=2025-01-01&interval=day",
+		trendsPagePath("kalverra", "octometrics", observe.TrendFilter{Branch: "main", Interval: "day", From: from}),
+	)
+}
+
+func TestFlakyCmd(t *testing.T) {
+	t.Parallel()
+
+	for _, flagName := range []string{"format", "output-file", "exclude-workflows"} {
+		assert.NotNil(t, flakyCmd.Flags().Lookup(flagName), "flakyCmd should have flag --%s", flagName)
+	}
+	require.Error(t, flakyCmd.Args(flakyCmd, []string{}), "a repo should be required")
+	assert.False(t, commandNeedsGitHubToken(flakyCmd), "flaky only reads local data")
+}
+
+func TestOrgCmd(t *testing.T) {
+	t.Parallel()
+
+	for _, flagName := range []string{"from", "to", "event", "rate-limit-reserve", "no-gather", "format"} {
+		assert.NotNil(t, orgCmd.Flags().Lookup(flagName), "orgCmd should have flag --%s", flagName)
+	}
+	require.Error(t, orgCmd.Args(orgCmd, []string{}), "an org should be required")
+
+	to := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	from, gotTo := orgRange(time.Time{}, to)
+	assert.Equal(t, to, gotTo)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), from, "org reports should default to 30 days")
+	assert.Equal(t,
+		"/orgs/kalverra?event=push&from=2025-06-01&to=2025-07-01",
+		orgPagePath("kalverra", from, to, "push"),
+	)
+	assert.Equal(t, "/orgs/kalverra?from=2025-06-01&to=2025-07-01", orgPagePath("kalverra", from, to, "all"))
+}
+
+func TestCompareCmdRunSetFlags(t *testing.T) {
+	t.Parallel()
+
+	flags := []string{"baseline-branch", "candidate-branch", "baseline-runs", "candidate-runs", "event"}
+	for _, flagName := range flags {
+		assert.NotNil(t, compareCmd.Flags().Lookup(flagName), "compareCmd should have flag --%s", flagName)
+	}
+}
+
+func TestBaselineFirst(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, compareCmd.Flags().Lookup("baseline"), "compareCmd should have flag --baseline")
+
+	ids, err := baselineFirst([]int64{1, 2, 3, 4}, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4}, ids, "the first ID should be the baseline by default")
+
+	ids, err = baselineFirst([]int64{1, 2, 3, 4}, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 1, 2, 4}, ids)
+
+	_, err = baselineFirst([]string{"abc", "def"}, "123", true)
+	require.Error(t, err, "the baseline should be one of the compared commits")
+}
+
+func TestGateCmd(t *testing.T) {
+	t.Parallel()
+
+	flags := []string{"vs", "thresholds", "max-duration-increase", "max-cost-increase-percent", "skip-summary"}
+	for _, flagName := range flags {
+		assert.NotNil(t, gateCmd.Flags().Lookup(flagName), "gateCmd should have flag --%s", flagName)
+	}
+	assert.True(t, commandNeedsGitHubToken(gateCmd))
+
+	path := filepath.Join(t.TempDir(), "gate.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("duration_percent: 20\ncost: 0.5\n"), 0o600))
+	thresholdsCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("thresholds", "", "")
+		cmd.Flags().Duration("max-duration-increase", 0, "")
+		cmd.Flags().Float64("max-duration-increase-percent", 0, "")
+		cmd.Flags().Float64("max-cost-increase", 0, "")
+		cmd.Flags().Float64("max-cost-increase-percent", 0, "")
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	thresholds, err := gateThresholds(thresholdsCmd("--thresholds", path, "--max-duration-increase-percent", "10"))
+	require.NoError(t, err)
+	assert.Equal(t, observe.GateLimits{DurationPercent: 10, Cost: 0.5}, thresholds.GateLimits,
+		"flags should replace the file's defaults")
+
+	thresholds, err = gateThresholds(thresholdsCmd("--max-cost-increase", "1.5"))
+	require.NoError(t, err)
+	assert.Equal(t, observe.GateLimits{Cost: 1.5}, thresholds.GateLimits, "flags alone should be enough")
+
+	_, err = gateThresholds(thresholdsCmd())
+	require.ErrorContains(t, err, "no gate limits set", "a gate without limits would pass everything")
+}
+
+func TestMonitorMarkCmd(t *testing.T) {
+	t.Parallel()
+
+	assert.NotNil(t, monitorMarkCmd.Flags().Lookup("output-file"), "monitorMarkCmd should have flag --output-file")
+	require.Error(t, monitorMarkCmd.Args(monitorMarkCmd, []string{}), "a step name should be required")
+	require.NoError(t, monitorMarkCmd.Args(monitorMarkCmd, []string{"Run tests"}))
+}
+
 func TestRootCmdURLArgs(t *testing.T) {
 	t.Parallel()
 
@@ -85,3 +223,25 @@ func TestVsTargetParsing(t *testing.T) {
 	assert.Equal(t, int64(0), runID)
 	assert.Equal(t, "64bb0b9579d398aa3afcc332f0e8dc729679ddf8", sha)
 }
+
+func TestParseRepoArg(t *testing.T) {
+	t.Parallel()
+
+	owner, repo, err := parseRepoArg("kalverra/octometrics")
+	require.NoError(t, err)
+	assert.Equal(t, "kalverra", owner)
+	assert.Equal(t, "octometrics", repo)
+
+	owner, repo, err = parseRepoArg("https://github.com/kalverra/octometrics.git")
+	require.NoError(t, err)
+	assert.Equal(t, "kalverra", owner)
+	assert.Equal(t, "octometrics", repo)
+
+	invalid := []string{"octometrics", "/octometrics", "kalverra/", "https://github.com/kalverra/octometrics/pull/1"}
+	for _, arg := range invalid {
+		_, _, err = parseRepoArg(arg)
+		assert.Error(t, err, "%q should be rejected", arg)
+	}
+
+	assert.NotNil(t, syncCmd.Flags().Lookup("from"), "syncCmd should have flag --from")
+}
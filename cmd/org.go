@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/observe"
+)
+
+// defaultOrgDays is how many days an org report covers without --from.
+const defaultOrgDays = 30
+
+var orgCmd = &cobra.Command{
+	Use:   "org <org>",
+	Short: "Roll up Actions cost and duration across an organization's repositories",
+	Long: `Roll up Actions cost and duration across an organization's repositories.
+
+Lists every repository of the org, leaving out archived and disabled ones, and gathers the workflow runs created
+between --from and --to. All repositories share one pool of workers, and gathering stops once the GitHub rate limit
+drops below --rate-limit-reserve, so the rest of your token's budget is left for other tools. Runs already in the data
+dir aren't fetched again, so an interrupted org gather picks up where it left off.
+
+The report rolls up completed runs by repository, workflow and runner type, and lists the most expensive jobs.
+Without --from and --to it covers the last 30 days.
+
+HTML opens the org report page, md and json print the same data.`,
+	Example: `
+# Report the last 30 days of an org
+octometrics org kalverra
+
+# Report a month of pushes as markdown
+octometrics org kalverra --from 2025-06-01 --to 2025-07-01 --event push --format md
+
+# Report from runs already gathered, without calling GitHub
+octometrics org kalverra --no-gather --format json
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		org := strings.TrimSpace(args[0])
+		if org == "" || strings.Contains(org, "/") {
+			return fmt.Errorf("invalid org %q", args[0])
+		}
+		from, to := orgRange(cfg.From, cfg.To)
+		if !to.After(from) {
+			return fmt.Errorf("--to %s must be after --from %s", to.Format(time.DateOnly), from.Format(time.DateOnly))
+		}
+
+		format, toStdout, err := determineFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != "html" && format != "md" && format != "json" {
+			return fmt.Errorf("invalid format %q: org reports can be 'html', 'md', or 'json'", format)
+		}
+
+		reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
+		defer reporter.Stop("")
+
+		if noGather, _ := cmd.Flags().GetBool("no-gather"); !noGather {
+			githubClient, err = newGitHubClient(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create GitHub client: %w", err)
+			}
+			reserve, _ := cmd.Flags().GetInt("rate-limit-reserve")
+			gatherOpts := append(buildGatherOptions(cfg, reporter), gather.WithRateLimitReserve(reserve))
+			result, err := gather.Org(cmd.Context(), logger, githubClient, org, from, to, cfg.Event, gatherOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to gather %s: %w", org, err)
+			}
+			logger.Info().
+				Int("repos", len(result.Repos)).
+				Int("runs", result.Runs).
+				Int("failed", result.Failed).
+				Int("skipped", result.Skipped).
+				Strs("failed_repos", result.FailedRepos).
+				Strs("skipped_repos", result.SkippedRepos).
+				Strs("truncated_repos", result.TruncatedRepos).
+				Msg("Gathered org workflow runs")
+			if result.Failed > 0 || result.Skipped > 0 || len(result.FailedRepos) > 0 || len(result.SkippedRepos) > 0 {
+				fmt.Fprintf(
+					os.Stderr,
+					"WARNING: report is incomplete, %d workflow run(s) failed, %d run(s) and %d repo(s) were "+
+						"skipped at the rate limit reserve, and %d repo(s) couldn't be listed. "+
+						"Run again to fill in the gaps.\n",
+					result.Failed,
+					result.Skipped,
+					len(result.SkippedRepos),
+					len(result.FailedRepos),
+				)
+			}
+			if len(result.TruncatedRepos) > 0 {
+				fmt.Fprintf(
+					os.Stderr,
+					"WARNING: report is incomplete, %s had more workflow runs created in a single second than "+
+						"GitHub lists, so some of their runs were left out.\n",
+					strings.Join(result.TruncatedRepos, ", "),
+				)
+			}
+		}
+
+		obsOpts := buildObserveOptions(cfg, reporter)
+		if toStdout {
+			report, err := observe.LoadOrgReport(dataStore, org, from, to, cfg.Event, obsOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to build org report: %w", err)
+			}
+			outStr, err := report.RenderString(format)
+			if err != nil {
+				return err
+			}
+			return writeOutput(cfg.OutputFile, outStr)
+		}
+
+		pagePath := orgPagePath(org, from, to, cfg.Event)
+		return observe.Interactive(cmd.Context(), logger, githubClient, pagePath, dataStore, obsOpts...)
+	},
+}
+
+func init() {
+	orgCmd.Flags().StringP("github-token", "t", "", "GitHub API token (env: GITHUB_TOKEN)")
+	orgCmd.Flags().Time(
+		"from",
+		time.Time{},
+		[]string{"2006-01-02", "2006-01-02T15:04:05Z"},
+		"Only include runs started on or after this date (YYYY-MM-DD), defaults to 30 days before --to",
+	)
+	orgCmd.Flags().Time(
+		"to",
+		time.Time{},
+		[]string{"2006-01-02", "2006-01-02T15:04:05Z"},
+		"Only include runs started before this date (YYYY-MM-DD), defaults to the end of today",
+	)
+	orgCmd.Flags().String("event", "all", "Only include runs triggered by this event (all, pull_request, push, ...)")
+	orgCmd.Flags().Int("rate-limit-reserve", 500, "Stop gathering once fewer GitHub API requests than this remain")
+	orgCmd.Flags().Bool("no-gather", false, "Report from runs already in the data dir without calling GitHub")
+	orgCmd.Flags().Bool("exclude-costs", false, "Skip gathering cost data for workflow runs")
+	orgCmd.Flags().BoolP("force-update", "u", false, "Force update of existing data")
+	orgCmd.Flags().String("progress", "auto", "Progress output style (auto, human, ai, none)")
+	orgCmd.Flags().String("format", "html", "Output format: html, md, or json")
+	orgCmd.Flags().StringP("output-file", "f", "", "File path to write the org report to, instead of stdout")
+	orgCmd.Flags().StringSlice("exclude-workflows", nil,
+		"Omit workflow display names from the report (comma-separated or repeat flag)")
+	orgCmd.Flags().Bool("no-open", false, "Do not open browser window on startup")
+	orgCmd.Flags().Int("port", 8080, "Port for local web server")
+
+	rootCmd.AddCommand(orgCmd)
+}
+
+// orgRange fills in the default range of an org report, the 30 days up to the end of today.
+func orgRange(from, to time.Time) (time.Time, time.Time) {
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultOrgDays)
+	}
+	return from, to
+}
+
+// orgPagePath is the org report page of org for runs started in [from, to).
+func orgPagePath(org string, from, to time.Time, event string) string {
+	query := url.Values{}
+	query.Set("from", from.Format(time.DateOnly))
+	query.Set("to", to.Format(time.DateOnly))
+	if event != "" && event != "all" {
+		query.Set("event", event)
+	}
+	return fmt.Sprintf("/orgs/%s?%s", org, query.Encode())
+}
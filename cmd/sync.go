@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync owner/repo",
+	Short: "Incrementally mirror a repository's workflow runs into the data dir",
+	Long: `Incrementally mirror a repository's workflow runs into the data dir.
+
+Each sync remembers the newest workflow run it has seen, so the next one only gathers runs created since then,
+along with runs that were still in progress or failed to gather last time. Sync never waits for in-progress runs,
+which makes it safe to run from cron to keep a local copy of a repo's CI history up to date.
+
+The first sync of a repo reaches back to --from, or 30 days if unset.`,
+	Example: `
+# Sync the last 30 days of a repo, then only what's new on each later run
+octometrics sync kalverra/octometrics
+
+# Backfill from a specific date on the first sync
+octometrics sync kalverra/octometrics --from 2025-01-01
+
+# Keep a mirror up to date every 15 minutes
+*/15 * * * * octometrics sync kalverra/octometrics --progress none
+`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(_ *cobra.Command, args []string) error {
+		owner, repo, err := parseRepoArg(args[0])
+		if err != nil {
+			return err
+		}
+		cfg.Owner, cfg.Repo = owner, repo
+
+		githubClient, err = newGitHubClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		startTime := time.Now()
+		reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
+		defer reporter.Stop("")
+
+		result, err := gather.Sync(
+			cmd.Context(),
+			logger,
+			githubClient,
+			cfg.Owner,
+			cfg.Repo,
+			cfg.From,
+			buildGatherOptions(cfg, reporter)...,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to sync %s/%s: %w", cfg.Owner, cfg.Repo, err)
+		}
+
+		fmt.Printf(
+			"Synced %s/%s in %s: %d new, %d refreshed, %d still pending\n",
+			cfg.Owner,
+			cfg.Repo,
+			time.Since(startTime).Round(time.Millisecond),
+			result.New,
+			result.Refreshed,
+			len(result.State.PendingRunIDs),
+		)
+		if result.Failed > 0 {
+			return fmt.Errorf("%d workflow run(s) failed to sync and will be retried next time", result.Failed)
+		}
+		return nil
+	},
+}
+
+// parseRepoArg accepts "owner/repo" or a GitHub repository URL.
+func parseRepoArg(arg string) (owner, repo string, err error) {
+	if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+		u, parseErr := url.Parse(arg)
+		if parseErr != nil {
+			return "", "", fmt.Errorf("invalid repository URL %q: %w", arg, parseErr)
+		}
+		arg = strings.Trim(u.Path, "/")
+	}
+
+	owner, repo, ok := strings.Cut(arg, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("invalid repository %q, expected owner/repo", arg)
+	}
+	return owner, strings.TrimSuffix(repo, ".git"), nil
+}
+
+func init() {
+	syncCmd.Flags().StringP("github-token", "t", "", "GitHub API token (env: GITHUB_TOKEN)")
+	syncCmd.Flags().Time(
+		"from",
+		time.Time{},
+		[]string{"2006-01-02", "2006-01-02T15:04:05Z"},
+		"Start date for the first sync of a repo (YYYY-MM-DD)",
+	)
+	syncCmd.Flags().Bool("exclude-costs", false, "Skip gathering cost data for workflow runs")
+	syncCmd.Flags().Bool("download-logs", false, "Download raw job log files from GitHub")
+	syncCmd.Flags().String("progress", "auto", "Progress output style (auto, human, ai, none)")
+
+	rootCmd.AddCommand(syncCmd)
+}
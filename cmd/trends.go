@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/observe"
+)
+
+var trendsCmd = &cobra.Command{
+	Use:   "trends owner/repo",
+	Short: "Show how a repository's workflows trend over days or weeks",
+	Long: `Show how a repository's workflows trend over days or weeks.
+
+Builds time series of each workflow's and job's duration (p50 and p90), queue time, cost and failure rate from
+workflow runs already in the data dir. Cancelled, skipped and in-progress runs are left out.
+Nothing is gathered from GitHub, gather or sync the runs first.
+
+HTML opens the trends page with charts, md and json print tables of the same data.`,
+	Example: `
+# Weekly trends of every synced run
+octometrics trends kalverra/octometrics
+
+# Daily trends of pushes to main
+octometrics trends kalverra/octometrics --branch main --event push --interval day
+
+# Trends of the first quarter as JSON
+octometrics trends kalverra/octometrics --from 2025-01-01 --to 2025-04-01 --format json
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owner, repo, err := parseRepoArg(args[0])
+		if err != nil {
+			return err
+		}
+		branch, _ := cmd.Flags().GetString("branch")
+		interval, _ := cmd.Flags().GetString("interval")
+		filter := observe.TrendFilter{
+			Branch:   branch,
+			Event:    cfg.Event,
+			Interval: interval,
+			From:     cfg.From,
+			To:       cfg.To,
+		}
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+
+		format, toStdout, err := determineFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if format != "html" && format != "md" && format != "json" {
+			return fmt.Errorf("invalid format %q: trends can be 'html', 'md', or 'json'", format)
+		}
+
+		reporter := gather.NewAutoProgressReporter(cfg.Progress, term.IsTerminal(int(os.Stderr.Fd())), os.Stderr)
+		defer reporter.Stop("")
+		obsOpts := buildObserveOptions(cfg, reporter)
+
+		if toStdout {
+			trends, err := observe.LoadTrends(dataStore, owner, repo, filter, obsOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to build trends: %w", err)
+			}
+			outStr, err := trends.RenderString(format)
+			if err != nil {
+				return err
+			}
+			return writeOutput(cfg.OutputFile, outStr)
+		}
+
+		pagePath := trendsPagePath(owner, repo, filter)
+		return observe.Interactive(cmd.Context(), logger, nil, pagePath, dataStore, obsOpts...)
+	},
+}
+
+func init() {
+	trendsCmd.Flags().String("branch", "", "Only include runs of this branch")
+	trendsCmd.Flags().String("event", "all", "Only include runs triggered by this event (all, pull_request, push, ...)")
+	trendsCmd.Flags().String("interval", observe.TrendIntervalWeek, "Group runs by 'day' or 'week'")
+	trendsCmd.Flags().Time(
+		"from",
+		time.Time{},
+		[]string{"2006-01-02", "2006-01-02T15:04:05Z"},
+		"Only include runs started on or after this date (YYYY-MM-DD)",
+	)
+	trendsCmd.Flags().Time(
+		"to",
+		time.Time{},
+		[]string{"2006-01-02", "2006-01-02T15:04:05Z"},
+		"Only include runs started before this date (YYYY-MM-DD)",
+	)
+	trendsCmd.Flags().String("format", "html", "Output format: html, md, or json")
+	trendsCmd.Flags().StringP("output-file", "f", "", "File path to write trends to, instead of stdout")
+	trendsCmd.Flags().StringSlice("exclude-workflows", nil,
+		"Omit workflow display names from trends (comma-separated or repeat flag)")
+	trendsCmd.Flags().Bool("no-open", false, "Do not open browser window on startup")
+	trendsCmd.Flags().Int("port", 8080, "Port for local web server")
+
+	rootCmd.AddCommand(trendsCmd)
+}
+
+// trendsPagePath is the trends page of owner/repo, filtered like filter.
+func trendsPagePath(owner, repo string, filter observe.TrendFilter) string {
+	query := url.Values{}
+	if filter.Branch != "" {
+		query.Set("branch", filter.Branch)
+	}
+	if filter.Event != "" {
+		query.Set("event", filter.Event)
+	}
+	query.Set("interval", filter.Interval)
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.DateOnly))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("/%s/%s/trends?%s", owner, repo, query.Encode())
+}
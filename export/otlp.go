@@ -0,0 +1,439 @@
+// Package export converts gathered GitHub Actions data into formats understood by other observability tools.
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+const (
+	// OTLPTracesPath is the OTLP/HTTP traces path, added to endpoints that don't set their own path.
+	OTLPTracesPath = "/v1/traces"
+	// otlpServiceName is the service.name resource attribute of every exported trace.
+	otlpServiceName = "github-actions"
+	// otlpScopeName is the instrumentation scope of every exported span.
+	otlpScopeName = "github.com/kalverra/octometrics"
+)
+
+// OTLP span kinds and status codes, as defined by the OTLP protobuf schema.
+const (
+	spanKindInternal = 1
+
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
+
+// TracesData is an OTLP ExportTraceServiceRequest in the OTLP JSON encoding.
+type TracesData struct {
+	ResourceSpans []*ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans are the spans of a single workflow run attempt.
+type ResourceSpans struct {
+	Resource   *Resource     `json:"resource"`
+	ScopeSpans []*ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource describes the repository and workflow that produced the spans.
+type Resource struct {
+	Attributes []*KeyValue `json:"attributes"`
+}
+
+// ScopeSpans are spans produced by one instrumentation scope.
+type ScopeSpans struct {
+	Scope *Scope  `json:"scope"`
+	Spans []*Span `json:"spans"`
+}
+
+// Scope identifies what produced the spans.
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// Span is a workflow run, job or step. Times are Unix nanoseconds, encoded as strings like OTLP JSON expects.
+type Span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []*KeyValue `json:"attributes,omitempty"`
+	Status            *Status     `json:"status,omitempty"`
+}
+
+// Status is the outcome of a span, derived from the GitHub conclusion.
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// KeyValue is a span or resource attribute.
+type KeyValue struct {
+	Key   string    `json:"key"`
+	Value *AnyValue `json:"value"`
+}
+
+// AnyValue holds exactly one attribute value. OTLP JSON encodes 64 bit integers as strings.
+type AnyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	ArrayValue  *ArrayValue `json:"arrayValue,omitempty"`
+}
+
+// ArrayValue is a list of attribute values.
+type ArrayValue struct {
+	Values []*AnyValue `json:"values"`
+}
+
+// Spans returns every span in the traces.
+func (t *TracesData) Spans() []*Span {
+	if t == nil {
+		return nil
+	}
+	var spans []*Span
+	for _, rs := range t.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			spans = append(spans, ss.Spans...)
+		}
+	}
+	return spans
+}
+
+// OTLPTraces converts workflow runs to OTLP traces, one trace per run attempt.
+// The run is the root span, with a child span for each job and a grandchild span for each of its steps.
+// Trace and span IDs are derived from the GitHub IDs, so exporting the same run twice produces the same spans.
+// Runs, jobs and steps that never started are left out.
+func OTLPTraces(runs ...*gather.WorkflowRunData) *TracesData {
+	traces := &TracesData{ResourceSpans: []*ResourceSpans{}}
+	for _, run := range runs {
+		if rs := runResourceSpans(run); rs != nil {
+			traces.ResourceSpans = append(traces.ResourceSpans, rs)
+		}
+	}
+	return traces
+}
+
+func runResourceSpans(run *gather.WorkflowRunData) *ResourceSpans {
+	if run == nil || run.WorkflowRun == nil || run.RunStartedAt == nil {
+		return nil
+	}
+
+	repository := fmt.Sprintf("%s/%s", run.GetOwner(), run.GetRepo())
+	traceKey := fmt.Sprintf("%s/%d/%d", repository, run.GetID(), run.GetRunAttempt())
+	traceID := otlpID(traceKey, 16)
+
+	runEnd := run.GetRunCompletedAt()
+	if runEnd.IsZero() {
+		runEnd = run.GetUpdatedAt().Time
+	}
+	runSpan := newSpan(traceID, otlpID(traceKey, 8), "", run.GetName(), run.RunStartedAt.Time, runEnd)
+	runSpan.Attributes = append(runSpan.Attributes,
+		stringAttr("cicd.pipeline.run.id", strconv.FormatInt(run.GetID(), 10)),
+		stringAttr("cicd.pipeline.run.url.full", run.GetHTMLURL()),
+		intAttr("github.run_attempt", int64(run.GetRunAttempt())),
+		stringAttr("github.event", run.GetEvent()),
+		stringAttr("vcs.ref.head.name", run.GetHeadBranch()),
+		stringAttr("vcs.ref.head.revision", run.GetHeadSHA()),
+	)
+	runSpan.Attributes = append(runSpan.Attributes, conclusionAttrs("cicd.pipeline.result", run.GetConclusion())...)
+	runSpan.Attributes = append(runSpan.Attributes,
+		costAttrs(run.GetCost(), run.GetCostEstimate(), run.GetCostGathered())...)
+	runSpan.Status = conclusionStatus(run.GetConclusion())
+
+	spans := []*Span{runSpan}
+	for _, job := range run.GetJobs() {
+		spans = append(spans, jobSpans(traceID, traceKey, runSpan.SpanID, job)...)
+	}
+	for _, span := range spans {
+		span.Attributes = compactAttrs(span.Attributes)
+	}
+
+	return &ResourceSpans{
+		Resource: &Resource{Attributes: compactAttrs([]*KeyValue{
+			stringAttr("service.name", otlpServiceName),
+			stringAttr("vcs.repository.name", repository),
+			stringAttr("cicd.pipeline.name", run.GetName()),
+		})},
+		ScopeSpans: []*ScopeSpans{{Scope: &Scope{Name: otlpScopeName}, Spans: spans}},
+	}
+}
+
+func jobSpans(traceID, traceKey, parentID string, job *gather.JobData) []*Span {
+	if job == nil || job.WorkflowJob == nil || job.StartedAt == nil {
+		return nil
+	}
+
+	jobKey := fmt.Sprintf("%s/job/%d", traceKey, job.GetID())
+	jobSpan := newSpan(traceID, otlpID(jobKey, 8), parentID, job.GetName(), job.StartedAt.Time, timeOf(job.CompletedAt))
+	jobSpan.Attributes = append(jobSpan.Attributes,
+		stringAttr("cicd.pipeline.task.name", job.GetName()),
+		stringAttr("cicd.pipeline.task.run.id", strconv.FormatInt(job.GetID(), 10)),
+		stringAttr("cicd.pipeline.task.run.url.full", job.GetHTMLURL()),
+		stringAttr("github.job.runner", job.GetRunner()),
+		stringAttr("github.job.runner_name", job.GetRunnerName()),
+		stringArrayAttr("github.job.labels", job.Labels),
+	)
+	if job.CreatedAt != nil && !job.StartedAt.Before(job.CreatedAt.Time) {
+		jobSpan.Attributes = append(jobSpan.Attributes,
+			doubleAttr("github.job.queue_seconds", job.StartedAt.Sub(job.CreatedAt.Time).Seconds()))
+	}
+	jobSpan.Attributes = append(jobSpan.Attributes,
+		conclusionAttrs("cicd.pipeline.task.run.result", job.GetConclusion())...)
+	jobSpan.Attributes = append(jobSpan.Attributes, costAttrs(job.Cost, job.CostEstimate, job.CostGathered)...)
+	jobSpan.Status = conclusionStatus(job.GetConclusion())
+
+	spans := []*Span{jobSpan}
+	for _, step := range job.Steps {
+		if step == nil || step.StartedAt == nil {
+			continue
+		}
+		stepKey := fmt.Sprintf("%s/step/%d", jobKey, step.GetNumber())
+		stepSpan := newSpan(
+			traceID, otlpID(stepKey, 8), jobSpan.SpanID, step.GetName(), step.StartedAt.Time, timeOf(step.CompletedAt),
+		)
+		stepSpan.Attributes = append(stepSpan.Attributes, intAttr("github.step.number", step.GetNumber()))
+		stepSpan.Attributes = append(stepSpan.Attributes,
+			conclusionAttrs("github.step.result", step.GetConclusion())...)
+		stepSpan.Status = conclusionStatus(step.GetConclusion())
+		spans = append(spans, stepSpan)
+	}
+	return spans
+}
+
+// newSpan creates a span. Anything still running when it was gathered ends where it started.
+func newSpan(traceID, spanID, parentID, name string, start, end time.Time) *Span {
+	if end.Before(start) {
+		end = start
+	}
+	return &Span{
+		TraceID:           traceID,
+		SpanID:            spanID,
+		ParentSpanID:      parentID,
+		Name:              name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+	}
+}
+
+// otlpID derives a hex encoded trace (16 bytes) or span (8 bytes) ID from key.
+func otlpID(key string, size int) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:size])
+}
+
+func timeOf(ts *github.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.Time
+}
+
+// conclusionStatus maps a GitHub conclusion to a span status. Runs without a conclusion are left unset.
+func conclusionStatus(conclusion string) *Status {
+	switch conclusion {
+	case "":
+		return nil
+	case "failure", "cancelled", "timed_out", "startup_failure", "action_required":
+		return &Status{Code: statusCodeError, Message: conclusion}
+	default:
+		return &Status{Code: statusCodeOK}
+	}
+}
+
+func conclusionAttrs(key, conclusion string) []*KeyValue {
+	if conclusion == "" {
+		return nil
+	}
+	return []*KeyValue{stringAttr(key, conclusion)}
+}
+
+// costAttrs converts a cost in tenths of a cent to USD, when cost data was gathered.
+func costAttrs(cost int64, estimate, gathered bool) []*KeyValue {
+	if !gathered {
+		return nil
+	}
+	return []*KeyValue{
+		doubleAttr("github.cost_usd", float64(cost)/1000),
+		boolAttr("github.cost_estimate", estimate),
+	}
+}
+
+func stringAttr(key, value string) *KeyValue {
+	if value == "" {
+		return nil
+	}
+	return &KeyValue{Key: key, Value: &AnyValue{StringValue: &value}}
+}
+
+func stringArrayAttr(key string, values []string) *KeyValue {
+	if len(values) == 0 {
+		return nil
+	}
+	array := &ArrayValue{Values: make([]*AnyValue, 0, len(values))}
+	for _, v := range values {
+		array.Values = append(array.Values, &AnyValue{StringValue: &v})
+	}
+	return &KeyValue{Key: key, Value: &AnyValue{ArrayValue: array}}
+}
+
+func intAttr(key string, value int64) *KeyValue {
+	s := strconv.FormatInt(value, 10)
+	return &KeyValue{Key: key, Value: &AnyValue{IntValue: &s}}
+}
+
+func doubleAttr(key string, value float64) *KeyValue {
+	return &KeyValue{Key: key, Value: &AnyValue{DoubleValue: &value}}
+}
+
+func boolAttr(key string, value bool) *KeyValue {
+	return &KeyValue{Key: key, Value: &AnyValue{BoolValue: &value}}
+}
+
+// compactAttrs drops attributes that had no value.
+func compactAttrs(attrs []*KeyValue) []*KeyValue {
+	compacted := attrs[:0]
+	for _, attr := range attrs {
+		if attr != nil {
+			compacted = append(compacted, attr)
+		}
+	}
+	return compacted
+}
+
+// WriteOTLP writes traces as a single line of OTLP JSON, the format read by the collector's otlpjsonfile receiver.
+func WriteOTLP(w io.Writer, traces *TracesData) error {
+	data, err := json.Marshal(traces)
+	if err != nil {
+		return fmt.Errorf("failed to encode OTLP traces: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write OTLP traces: %w", err)
+	}
+	return nil
+}
+
+// OTLPOption configures how traces are sent to an OTLP endpoint.
+type OTLPOption func(*otlpOptions)
+
+type otlpOptions struct {
+	headers map[string]string
+	client  *http.Client
+}
+
+// WithOTLPHeaders adds headers to every request, e.g. for authentication.
+func WithOTLPHeaders(headers map[string]string) OTLPOption {
+	return func(opts *otlpOptions) {
+		for k, v := range headers {
+			opts.headers[k] = v
+		}
+	}
+}
+
+// WithOTLPClient sets the HTTP client used to send traces.
+func WithOTLPClient(client *http.Client) OTLPOption {
+	return func(opts *otlpOptions) {
+		opts.client = client
+	}
+}
+
+// SendOTLP posts traces to an OTLP/HTTP endpoint using the JSON encoding.
+// Endpoints without a path, like "http://localhost:4318", have OTLPTracesPath added.
+func SendOTLP(ctx context.Context, endpoint string, traces *TracesData, options ...OTLPOption) error {
+	opts := &otlpOptions{
+		headers: map[string]string{},
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	target, err := otlpTracesURL(endpoint)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(traces)
+	if err != nil {
+		return fmt.Errorf("failed to encode OTLP traces: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range opts.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := opts.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send OTLP traces to '%s': %w", target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("OTLP endpoint '%s' returned %s: %s", target, resp.Status, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func otlpTracesURL(endpoint string) (string, error) {
+	if endpoint == "" {
+		return "", errors.New("OTLP endpoint is required")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid OTLP endpoint '%s': %w", endpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint '%s', expected an http or https URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = OTLPTracesPath
+	}
+	return u.String(), nil
+}
+
+// ParseOTLPHeaders parses "key=value" pairs, as given on the command line, into headers.
+func ParseOTLPHeaders(pairs []string) (map[string]string, error) {
+	headers := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid OTLP header '%s', expected key=value", pair)
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// WorkflowRuns loads every workflow run in store, limited to owner/repo when both are set.
+func WorkflowRuns(store gather.Store, owner, repo string) ([]*gather.WorkflowRunData, error) {
+	if owner == "" || repo == "" {
+		owner, repo = "", ""
+	}
+	return gather.StoredWorkflowRuns(store, owner, repo)
+}
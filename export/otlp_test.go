@@ -0,0 +1,315 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+var testRunStart = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func testWorkflowRun() *gather.WorkflowRunData {
+	at := func(d time.Duration) *github.Timestamp {
+		return &github.Timestamp{Time: testRunStart.Add(d)}
+	}
+	return &gather.WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:           new(int64(100)),
+			Name:         new("CI"),
+			RunAttempt:   new(2),
+			Event:        new("push"),
+			HeadBranch:   new("main"),
+			HeadSHA:      new("abc123"),
+			Conclusion:   new("failure"),
+			RunStartedAt: at(0),
+			Repository: &github.Repository{
+				Name:  new("octometrics"),
+				Owner: &github.User{Login: new("kalverra")},
+			},
+		},
+		Cost:           24,
+		CostGathered:   true,
+		RunCompletedAt: testRunStart.Add(5 * time.Minute),
+		Jobs: []*gather.JobData{
+			{
+				WorkflowJob: &github.WorkflowJob{
+					ID:          new(int64(200)),
+					Name:        new("test"),
+					Conclusion:  new("failure"),
+					RunnerName:  new("GitHub Actions 3"),
+					Labels:      []string{"ubuntu-latest"},
+					CreatedAt:   at(0),
+					StartedAt:   at(30 * time.Second),
+					CompletedAt: at(4 * time.Minute),
+					Steps: []*github.TaskStep{
+						{
+							Name:        new("Set up job"),
+							Number:      new(int64(1)),
+							Conclusion:  new("success"),
+							StartedAt:   at(30 * time.Second),
+							CompletedAt: at(time.Minute),
+						},
+						{
+							Name:        new("Run tests"),
+							Number:      new(int64(2)),
+							Conclusion:  new("failure"),
+							StartedAt:   at(time.Minute),
+							CompletedAt: at(4 * time.Minute),
+						},
+						{
+							Name:       new("Never ran"),
+							Number:     new(int64(3)),
+							Conclusion: new("skipped"),
+						},
+					},
+				},
+				Runner:       "UBUNTU",
+				Cost:         24,
+				CostGathered: true,
+			},
+			{
+				WorkflowJob: &github.WorkflowJob{
+					ID:        new(int64(201)),
+					Name:      new("queued"),
+					CreatedAt: at(0),
+				},
+			},
+		},
+	}
+}
+
+func attrValues(attrs []*KeyValue) map[string]any {
+	values := map[string]any{}
+	for _, attr := range attrs {
+		switch v := attr.Value; {
+		case v.StringValue != nil:
+			values[attr.Key] = *v.StringValue
+		case v.IntValue != nil:
+			values[attr.Key] = *v.IntValue
+		case v.DoubleValue != nil:
+			values[attr.Key] = *v.DoubleValue
+		case v.BoolValue != nil:
+			values[attr.Key] = *v.BoolValue
+		case v.ArrayValue != nil:
+			var strs []string
+			for _, value := range v.ArrayValue.Values {
+				strs = append(strs, *value.StringValue)
+			}
+			values[attr.Key] = strs
+		}
+	}
+	return values
+}
+
+func TestOTLPTraces(t *testing.T) {
+	t.Parallel()
+
+	traces := OTLPTraces(testWorkflowRun(), nil, &gather.WorkflowRunData{})
+	require.Len(t, traces.ResourceSpans, 1, "runs that never started should be left out")
+	assert.Equal(t, map[string]any{
+		"service.name":        otlpServiceName,
+		"vcs.repository.name": "kalverra/octometrics",
+		"cicd.pipeline.name":  "CI",
+	}, attrValues(traces.ResourceSpans[0].Resource.Attributes))
+
+	spans := traces.Spans()
+	require.Len(t, spans, 4, "expected the run, one started job and its two started steps")
+	run, job, setup, tests := spans[0], spans[1], spans[2], spans[3]
+
+	for _, span := range spans {
+		assert.Equal(t, run.TraceID, span.TraceID, "all spans of a run attempt should share a trace")
+		assert.Len(t, span.SpanID, 16)
+	}
+	assert.Len(t, run.TraceID, 32)
+	assert.Empty(t, run.ParentSpanID)
+	assert.Equal(t, run.SpanID, job.ParentSpanID)
+	assert.Equal(t, job.SpanID, setup.ParentSpanID)
+	assert.Equal(t, job.SpanID, tests.ParentSpanID)
+
+	assert.Equal(t, "CI", run.Name)
+	assert.Equal(t, strconv.FormatInt(testRunStart.UnixNano(), 10), run.StartTimeUnixNano)
+	assert.Equal(t, strconv.FormatInt(testRunStart.Add(5*time.Minute).UnixNano(), 10), run.EndTimeUnixNano)
+	assert.Equal(t, &Status{Code: statusCodeError, Message: "failure"}, run.Status)
+	assert.Equal(t, map[string]any{
+		"cicd.pipeline.run.id":  "100",
+		"github.run_attempt":    "2",
+		"github.event":          "push",
+		"vcs.ref.head.name":     "main",
+		"vcs.ref.head.revision": "abc123",
+		"cicd.pipeline.result":  "failure",
+		"github.cost_usd":       0.024,
+		"github.cost_estimate":  false,
+	}, attrValues(run.Attributes))
+
+	assert.Equal(t, "test", job.Name)
+	assert.Equal(t, map[string]any{
+		"cicd.pipeline.task.name":       "test",
+		"cicd.pipeline.task.run.id":     "200",
+		"github.job.runner":             "UBUNTU",
+		"github.job.runner_name":        "GitHub Actions 3",
+		"github.job.labels":             []string{"ubuntu-latest"},
+		"github.job.queue_seconds":      30.0,
+		"cicd.pipeline.task.run.result": "failure",
+		"github.cost_usd":               0.024,
+		"github.cost_estimate":          false,
+	}, attrValues(job.Attributes))
+
+	assert.Equal(t, "Set up job", setup.Name)
+	assert.Equal(t, &Status{Code: statusCodeOK}, setup.Status)
+	assert.Equal(t, map[string]any{
+		"github.step.number": "1",
+		"github.step.result": "success",
+	}, attrValues(setup.Attributes))
+	assert.Equal(t, &Status{Code: statusCodeError, Message: "failure"}, tests.Status)
+
+	again := OTLPTraces(testWorkflowRun())
+	assert.Equal(t, traces.Spans(), again.Spans(), "exporting the same run twice should produce the same spans")
+}
+
+func TestOTLPTracesInProgress(t *testing.T) {
+	t.Parallel()
+
+	run := testWorkflowRun()
+	run.Conclusion = nil
+	run.RunCompletedAt = time.Time{}
+	run.UpdatedAt = &github.Timestamp{Time: testRunStart.Add(2 * time.Minute)}
+	run.Jobs[0].CompletedAt = nil
+
+	spans := OTLPTraces(run).Spans()
+	require.NotEmpty(t, spans)
+	assert.Nil(t, spans[0].Status, "runs without a conclusion should have no status")
+	assert.Equal(t, strconv.FormatInt(testRunStart.Add(2*time.Minute).UnixNano(), 10), spans[0].EndTimeUnixNano)
+	assert.Equal(t, spans[1].StartTimeUnixNano, spans[1].EndTimeUnixNano, "unfinished jobs should end where they start")
+}
+
+func TestWriteOTLP(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteOTLP(&buf, OTLPTraces(testWorkflowRun())))
+	require.NoError(t, WriteOTLP(&buf, OTLPTraces(testWorkflowRun())))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2, "each write should be a single JSON line")
+	assert.Contains(t, string(lines[0]), `"resourceSpans"`)
+	assert.Contains(t, string(lines[0]), `"startTimeUnixNano":"`, "nanosecond times should be encoded as strings")
+	assert.Contains(t, string(lines[0]), `"intValue":"2"`, "integers should be encoded as strings")
+}
+
+// otlpCollector is a stand-in for an OpenTelemetry collector's OTLP/HTTP receiver.
+type otlpCollector struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	traces   []*TracesData
+	status   int
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	var traces *TracesData
+	if err := json.Unmarshal(body, &traces); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, r)
+	c.traces = append(c.traces, traces)
+	if c.status != 0 {
+		http.Error(w, "collector unavailable", c.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
+
+func TestSendOTLP(t *testing.T) {
+	t.Parallel()
+
+	collector := &otlpCollector{}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	traces := OTLPTraces(testWorkflowRun())
+	err := SendOTLP(context.Background(), server.URL, traces, WithOTLPHeaders(map[string]string{"X-Api-Key": "secret"}))
+	require.NoError(t, err)
+
+	require.Len(t, collector.requests, 1)
+	req := collector.requests[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, OTLPTracesPath, req.URL.Path, "endpoints without a path should send to the traces path")
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, "secret", req.Header.Get("X-Api-Key"))
+	assert.Equal(t, traces.Spans(), collector.traces[0].Spans())
+
+	err = SendOTLP(context.Background(), server.URL+"/custom/traces", traces)
+	require.NoError(t, err)
+	assert.Equal(t, "/custom/traces", collector.requests[1].URL.Path, "endpoints with a path should be used as is")
+}
+
+func TestSendOTLPErrors(t *testing.T) {
+	t.Parallel()
+
+	collector := &otlpCollector{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	traces := OTLPTraces(testWorkflowRun())
+	err := SendOTLP(context.Background(), server.URL, traces)
+	require.ErrorContains(t, err, "503", "non-2xx responses should fail")
+	require.ErrorContains(t, err, "collector unavailable")
+
+	require.Error(t, SendOTLP(context.Background(), "", traces))
+	require.Error(t, SendOTLP(context.Background(), "localhost:4318", traces), "endpoints need a scheme")
+}
+
+func TestParseOTLPHeaders(t *testing.T) {
+	t.Parallel()
+
+	headers, err := ParseOTLPHeaders([]string{"x-api-key=secret", "Authorization=Bearer a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-api-key": "secret", "Authorization": "Bearer a=b"}, headers)
+
+	_, err = ParseOTLPHeaders([]string{"no-value"})
+	require.Error(t, err)
+	_, err = ParseOTLPHeaders([]string{"=value"})
+	require.Error(t, err)
+}
+
+func TestWorkflowRuns(t *testing.T) {
+	t.Parallel()
+
+	_, testDir := testhelpers.Setup(t)
+	store := gather.NewFileStore(testDir)
+
+	run := testWorkflowRun()
+	require.NoError(t, store.Save("kalverra", "octometrics", gather.WorkflowRunsDataDir, "100", run))
+	other := testWorkflowRun()
+	other.ID = new(int64(300))
+	require.NoError(t, store.Save("other", "repo", gather.WorkflowRunsDataDir, "300", other))
+
+	runs, err := WorkflowRuns(store, "", "")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	runs, err = WorkflowRuns(store, "kalverra", "octometrics")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, int64(100), runs[0].GetID())
+	assert.Equal(t, OTLPTraces(run).Spans(), OTLPTraces(runs[0]).Spans(), "stored runs should export the same spans")
+}
@@ -0,0 +1,262 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/monitor"
+)
+
+// Chrome Trace Event phases.
+const (
+	tracePhaseComplete = "X"
+	tracePhaseCounter  = "C"
+	tracePhaseMetadata = "M"
+)
+
+// runTracePID is the process holding the workflow run's own slice, jobs are numbered after it.
+const runTracePID = 1
+
+// Trace is a Chrome Trace Event Format document, which Perfetto UI and chrome://tracing can open.
+type Trace struct {
+	TraceEvents     []*TraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string        `json:"displayTimeUnit"`
+}
+
+// TraceEvent is a single slice, counter sample or metadata record. Timestamps and durations are in microseconds.
+type TraceEvent struct {
+	Name      string         `json:"name"`
+	Category  string         `json:"cat,omitempty"`
+	Phase     string         `json:"ph"`
+	Timestamp int64          `json:"ts"`
+	Duration  int64          `json:"dur"`
+	PID       int            `json:"pid"`
+	TID       int            `json:"tid"`
+	Args      map[string]any `json:"args,omitempty"`
+}
+
+// ChromeTrace converts a workflow run to Chrome Trace Event JSON, so runs with hundreds of jobs can be zoomed freely.
+// Each job is its own track with the time it spent queued, the job itself and its steps nested inside it.
+// Monitoring data gathered for a job becomes counter tracks next to it.
+func ChromeTrace(run *gather.WorkflowRunData) *Trace {
+	trace := &Trace{TraceEvents: []*TraceEvent{}, DisplayTimeUnit: "ms"}
+	if run == nil || run.WorkflowRun == nil {
+		return trace
+	}
+
+	runName := run.GetName()
+	if run.GetRunNumber() != 0 {
+		runName = fmt.Sprintf("%s #%d", runName, run.GetRunNumber())
+	}
+	trace.add(processMetadata(runTracePID, runName, 0)...)
+	if run.RunStartedAt != nil {
+		runEnd := run.GetRunCompletedAt()
+		if runEnd.IsZero() {
+			runEnd = run.GetUpdatedAt().Time
+		}
+		runSlice := traceSlice(run.GetName(), "run", runTracePID, run.RunStartedAt.Time, runEnd)
+		runSlice.Args = compactArgs(map[string]any{
+			"id":         run.GetID(),
+			"attempt":    run.GetRunAttempt(),
+			"event":      run.GetEvent(),
+			"branch":     run.GetHeadBranch(),
+			"sha":        run.GetHeadSHA(),
+			"conclusion": run.GetConclusion(),
+			"url":        run.GetHTMLURL(),
+		})
+		if run.GetCostGathered() {
+			runSlice.Args["cost_usd"] = float64(run.GetCost()) / 1000
+		}
+		trace.add(runSlice)
+	}
+
+	for i, job := range run.GetJobs() {
+		trace.add(jobTraceEvents(runTracePID+1+i, job)...)
+	}
+	return trace
+}
+
+func (t *Trace) add(events ...*TraceEvent) {
+	t.TraceEvents = append(t.TraceEvents, events...)
+}
+
+func jobTraceEvents(pid int, job *gather.JobData) []*TraceEvent {
+	if job == nil || job.WorkflowJob == nil {
+		return nil
+	}
+
+	events := processMetadata(pid, job.GetName(), pid)
+	if job.StartedAt == nil {
+		return events
+	}
+	jobStart := job.StartedAt.Time
+	jobEnd := timeOf(job.CompletedAt)
+	if jobEnd.Before(jobStart) {
+		jobEnd = jobStart
+	}
+
+	if job.CreatedAt != nil && job.CreatedAt.Before(jobStart) {
+		queued := traceSlice("Queued", "queue", pid, job.CreatedAt.Time, jobStart)
+		queued.Args = map[string]any{"queue_seconds": jobStart.Sub(job.CreatedAt.Time).Seconds()}
+		events = append(events, queued)
+	}
+
+	jobSlice := traceSlice(job.GetName(), "job", pid, jobStart, jobEnd)
+	jobSlice.Args = compactArgs(map[string]any{
+		"id":          job.GetID(),
+		"conclusion":  job.GetConclusion(),
+		"runner":      job.GetRunner(),
+		"runner_name": job.GetRunnerName(),
+		"labels":      job.Labels,
+		"url":         job.GetHTMLURL(),
+	})
+	if job.CostGathered {
+		jobSlice.Args["cost_usd"] = float64(job.Cost) / 1000
+	}
+	events = append(events, jobSlice)
+
+	for _, step := range job.Steps {
+		if step == nil || step.StartedAt == nil {
+			continue
+		}
+		// Step times are rounded to the second, keep them inside the job so they nest
+		start := clampTime(step.StartedAt.Time, jobStart, jobEnd)
+		end := clampTime(timeOf(step.CompletedAt), start, jobEnd)
+		stepSlice := traceSlice(step.GetName(), "step", pid, start, end)
+		stepSlice.Args = compactArgs(map[string]any{
+			"number":     step.GetNumber(),
+			"conclusion": step.GetConclusion(),
+		})
+		events = append(events, stepSlice)
+	}
+
+	return append(events, counterEvents(pid, job.Analysis)...)
+}
+
+// counterEvents turns monitoring observations into counter tracks.
+func counterEvents(pid int, analysis *monitor.Analysis) []*TraceEvent {
+	if analysis == nil {
+		return nil
+	}
+	var events []*TraceEvent
+	counter := func(name string, t time.Time, args map[string]any) {
+		events = append(events, &TraceEvent{
+			Name:      name,
+			Category:  "monitor",
+			Phase:     tracePhaseCounter,
+			Timestamp: t.UnixMicro(),
+			PID:       pid,
+			Args:      args,
+		})
+	}
+
+	cpuByTime := map[time.Time][]float64{}
+	for _, measurements := range analysis.CPUMeasurements {
+		for _, m := range measurements {
+			cpuByTime[m.Time] = append(cpuByTime[m.Time], m.UsedPercent)
+		}
+	}
+	for _, t := range slices.SortedFunc(maps.Keys(cpuByTime), time.Time.Compare) {
+		var sum float64
+		for _, p := range cpuByTime[t] {
+			sum += p
+		}
+		counter("CPU Used %", t, map[string]any{"average": sum / float64(len(cpuByTime[t]))})
+	}
+	for _, m := range analysis.MemoryMeasurements {
+		counter("Memory Bytes", m.Time, map[string]any{"used": m.Used, "available": m.Available})
+	}
+	for _, m := range analysis.IOMeasurements {
+		counter("Network Bytes", m.Time, map[string]any{"sent": m.BytesSent, "received": m.BytesRecv})
+	}
+
+	type diskIO struct{ read, written uint64 }
+	diskByTime := map[time.Time]*diskIO{}
+	for _, m := range analysis.DiskIOMeasurements {
+		if diskByTime[m.Time] == nil {
+			diskByTime[m.Time] = &diskIO{}
+		}
+		diskByTime[m.Time].read += m.ReadBytes
+		diskByTime[m.Time].written += m.WriteBytes
+	}
+	for _, t := range slices.SortedFunc(maps.Keys(diskByTime), time.Time.Compare) {
+		counter("Disk I/O Bytes", t, map[string]any{"read": diskByTime[t].read, "written": diskByTime[t].written})
+	}
+	for _, m := range analysis.LoadMeasurements {
+		counter("Load Average", m.Time, map[string]any{"load1": m.Load1})
+	}
+	return events
+}
+
+// processMetadata names a process track and keeps tracks in job order.
+func processMetadata(pid int, name string, sortIndex int) []*TraceEvent {
+	return []*TraceEvent{
+		{Name: "process_name", Phase: tracePhaseMetadata, PID: pid, Args: map[string]any{"name": name}},
+		{
+			Name:  "process_sort_index",
+			Phase: tracePhaseMetadata,
+			PID:   pid,
+			Args:  map[string]any{"sort_index": sortIndex},
+		},
+	}
+}
+
+// traceSlice creates a complete slice. Slices still running when they were gathered end where they start.
+func traceSlice(name, category string, pid int, start, end time.Time) *TraceEvent {
+	if end.Before(start) {
+		end = start
+	}
+	return &TraceEvent{
+		Name:      name,
+		Category:  category,
+		Phase:     tracePhaseComplete,
+		Timestamp: start.UnixMicro(),
+		Duration:  end.Sub(start).Microseconds(),
+		PID:       pid,
+		TID:       pid,
+	}
+}
+
+func clampTime(t, earliest, latest time.Time) time.Time {
+	if t.Before(earliest) {
+		return earliest
+	}
+	if t.After(latest) {
+		return latest
+	}
+	return t
+}
+
+// compactArgs drops empty strings and lists, so slices only show what GitHub reported.
+func compactArgs(args map[string]any) map[string]any {
+	for k, v := range args {
+		switch v := v.(type) {
+		case string:
+			if v == "" {
+				delete(args, k)
+			}
+		case []string:
+			if len(v) == 0 {
+				delete(args, k)
+			}
+		}
+	}
+	return args
+}
+
+// WriteTrace writes trace as Chrome Trace Event JSON.
+func WriteTrace(w io.Writer, trace *Trace) error {
+	data, err := json.Marshal(trace)
+	if err != nil {
+		return fmt.Errorf("failed to encode trace: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write trace: %w", err)
+	}
+	return nil
+}
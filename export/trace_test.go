@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/monitor"
+)
+
+func eventsByPhase(trace *Trace, phase string) []*TraceEvent {
+	var events []*TraceEvent
+	for _, e := range trace.TraceEvents {
+		if e.Phase == phase {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestChromeTrace(t *testing.T) {
+	t.Parallel()
+
+	run := testWorkflowRun()
+	run.Jobs[0].Analysis = &monitor.Analysis{
+		CPUMeasurements: map[int][]*monitor.CPUMeasurement{
+			0: {{Time: testRunStart.Add(time.Minute), Num: 0, UsedPercent: 20}},
+			1: {{Time: testRunStart.Add(time.Minute), Num: 1, UsedPercent: 60}},
+		},
+		MemoryMeasurements: []*monitor.MemoryMeasurement{
+			{Time: testRunStart.Add(time.Minute), Used: 100, Available: 300},
+		},
+		DiskIOMeasurements: []*monitor.DiskIOMeasurement{
+			{Time: testRunStart.Add(time.Minute), Device: "sda", ReadBytes: 10, WriteBytes: 1},
+			{Time: testRunStart.Add(time.Minute), Device: "sdb", ReadBytes: 5, WriteBytes: 2},
+		},
+	}
+	trace := ChromeTrace(run)
+
+	slices := eventsByPhase(trace, tracePhaseComplete)
+	require.Len(t, slices, 5, "expected the run, a queue slice, the started job and its two started steps")
+	runSlice, queued, job, setup, tests := slices[0], slices[1], slices[2], slices[3], slices[4]
+
+	assert.Equal(t, "CI", runSlice.Name)
+	assert.Equal(t, runTracePID, runSlice.PID)
+	assert.Equal(t, (5 * time.Minute).Microseconds(), runSlice.Duration)
+	assert.InDelta(t, 0.024, runSlice.Args["cost_usd"], 0.0001)
+
+	assert.Equal(t, "Queued", queued.Name)
+	assert.Equal(t, testRunStart.UnixMicro(), queued.Timestamp)
+	assert.Equal(t, (30 * time.Second).Microseconds(), queued.Duration)
+
+	assert.Equal(t, "test", job.Name)
+	assert.Equal(t, runTracePID+1, job.PID, "each job should get its own track")
+	assert.Equal(t, job.PID, queued.PID, "queue time should share the job's track")
+	assert.Equal(t, job.Timestamp, queued.Timestamp+queued.Duration, "queue time should end when the job starts")
+	assert.Equal(t, "UBUNTU", job.Args["runner"])
+	assert.Equal(t, "failure", job.Args["conclusion"])
+
+	for _, step := range []*TraceEvent{setup, tests} {
+		assert.Equal(t, job.PID, step.PID)
+		assert.Equal(t, job.TID, step.TID)
+		assert.GreaterOrEqual(t, step.Timestamp, job.Timestamp, "steps should nest inside their job")
+		assert.LessOrEqual(t, step.Timestamp+step.Duration, job.Timestamp+job.Duration,
+			"steps should nest inside their job")
+	}
+	assert.Equal(t, "Run tests", tests.Name)
+	assert.Equal(t, (3 * time.Minute).Microseconds(), tests.Duration)
+
+	names := map[int]string{}
+	for _, e := range eventsByPhase(trace, tracePhaseMetadata) {
+		if e.Name == "process_name" {
+			names[e.PID] = e.Args["name"].(string)
+		}
+	}
+	assert.Equal(t, map[int]string{1: "CI", 2: "test", 3: "queued"}, names)
+
+	counters := map[string]map[string]any{}
+	for _, e := range eventsByPhase(trace, tracePhaseCounter) {
+		assert.Equal(t, job.PID, e.PID, "counters should sit next to their job")
+		counters[e.Name] = e.Args
+	}
+	assert.Equal(t, map[string]map[string]any{
+		"CPU Used %":     {"average": 40.0},
+		"Memory Bytes":   {"used": uint64(100), "available": uint64(300)},
+		"Disk I/O Bytes": {"read": uint64(15), "written": uint64(3)},
+	}, counters)
+}
+
+func TestChromeTraceStepsClampedToJob(t *testing.T) {
+	t.Parallel()
+
+	run := testWorkflowRun()
+	// Steps are rounded to the second, so they can start before or end after their job
+	run.Jobs[0].Steps[0].StartedAt.Time = run.Jobs[0].StartedAt.Add(-time.Second)
+	run.Jobs[0].Steps[1].CompletedAt.Time = run.Jobs[0].CompletedAt.Add(time.Second)
+
+	slices := eventsByPhase(ChromeTrace(run), tracePhaseComplete)
+	require.Len(t, slices, 5)
+	job, setup, tests := slices[2], slices[3], slices[4]
+	assert.Equal(t, job.Timestamp, setup.Timestamp)
+	assert.Equal(t, job.Timestamp+job.Duration, tests.Timestamp+tests.Duration)
+}
+
+func TestWriteTrace(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTrace(&buf, ChromeTrace(testWorkflowRun())))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Contains(t, decoded, "traceEvents")
+	assert.Equal(t, "ms", decoded["displayTimeUnit"])
+
+	empty := ChromeTrace(&gather.WorkflowRunData{})
+	assert.Empty(t, empty.TraceEvents, "runs without data should produce an empty trace")
+}
@@ -0,0 +1,270 @@
+package gather
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+// cassetteFixtureNameLimit caps the readable part of fixture file names.
+const cassetteFixtureNameLimit = 80
+
+// Cassette is an http.RoundTripper that records GitHub API traffic to a directory of JSON fixtures,
+// or replays a recorded directory offline. Pass it as the optionalNext transport of NewGitHubClient.
+// Authorization headers are never recorded, so cassettes can be shared.
+type Cassette struct {
+	dir       string
+	next      http.RoundTripper // nil when replaying
+	log       zerolog.Logger
+	mu        sync.Mutex
+	fixtures  map[string]*cassetteFixture
+	playCount map[string]int
+	// rerecorded are the requests recorded by this Cassette, whose responses from earlier recordings were dropped
+	rerecorded map[string]bool
+}
+
+// cassetteFixture holds every response seen for one request, in order.
+// Repeated requests (e.g. polling an in-progress run) replay the responses in sequence, then repeat the last one.
+type cassetteFixture struct {
+	Method      string             `json:"method"`
+	URL         string             `json:"url"`
+	RequestBody string             `json:"request_body,omitempty"`
+	Responses   []cassetteResponse `json:"responses"`
+}
+
+type cassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"body_base64,omitempty"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+// NewRecorder returns a Cassette that sends requests on to next (http.DefaultTransport if nil)
+// and writes every request and response into dir.
+func NewRecorder(log zerolog.Logger, dir string, next http.RoundTripper) (*Cassette, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to make cassette dir '%s': %w", dir, err)
+	}
+	c := &Cassette{
+		dir:        dir,
+		next:       next,
+		log:        log.With().Str("cassette", dir).Str("cassette_mode", "record").Logger(),
+		fixtures:   map[string]*cassetteFixture{},
+		rerecorded: map[string]bool{},
+	}
+	// Keep earlier recordings so a cassette can be built up over several commands.
+	// Requests recorded again have their earlier responses replaced, so replays don't serve stale data.
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewReplayer returns a Cassette that answers requests from the fixtures in dir without touching the network.
+// Requests that were never recorded get a 404.
+func NewReplayer(log zerolog.Logger, dir string) (*Cassette, error) {
+	if !cacheFileExists(dir) {
+		return nil, fmt.Errorf("cassette '%s' does not exist", dir)
+	}
+	c := &Cassette{
+		dir:       dir,
+		log:       log.With().Str("cassette", dir).Str("cassette_mode", "replay").Logger(),
+		fixtures:  map[string]*cassetteFixture{},
+		playCount: map[string]int{},
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	if len(c.fixtures) == 0 {
+		return nil, fmt.Errorf("no recorded fixtures found in cassette '%s'", dir)
+	}
+	return c, nil
+}
+
+// RoundTrip records or replays req.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key := cassetteKey(req.Method, req.URL.String(), body)
+
+	if c.next == nil {
+		return c.replay(req, key), nil
+	}
+	return c.record(req, key, body)
+}
+
+func (c *Cassette) record(req *http.Request, key string, body []byte) (*http.Response, error) {
+	// Conditional requests would record 304s that a fresh replay can't make sense of
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body for cassette: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := cassetteResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		RecordedAt: time.Now(),
+	}
+	recorded.Header.Del("Set-Cookie")
+	if utf8.Valid(respBody) {
+		recorded.Body = string(respBody)
+	} else {
+		recorded.BodyBase64 = respBody
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fixture, ok := c.fixtures[key]
+	if !ok {
+		fixture = &cassetteFixture{Method: req.Method, URL: req.URL.String(), RequestBody: string(body)}
+		c.fixtures[key] = fixture
+	}
+	if !c.rerecorded[key] {
+		fixture.Responses = nil
+		c.rerecorded[key] = true
+	}
+	fixture.Responses = append(fixture.Responses, recorded)
+	fixtureFile := filepath.Join(c.dir, cassetteFileName(req.Method, req.URL.Path, key))
+	if err := writeJSONFile(fixtureFile, fixture); err != nil {
+		return nil, fmt.Errorf("failed to write cassette fixture: %w", err)
+	}
+	c.log.Trace().
+		Str("method", req.Method).
+		Str("request_url", req.URL.String()).
+		Str("fixture", fixtureFile).
+		Msg("Recorded GitHub API response")
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, key string) *http.Response {
+	c.mu.Lock()
+	fixture, ok := c.fixtures[key]
+	var recorded cassetteResponse
+	if ok {
+		idx := min(c.playCount[key], len(fixture.Responses)-1)
+		recorded = fixture.Responses[idx]
+		c.playCount[key]++
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		c.log.Warn().
+			Str("method", req.Method).
+			Str("request_url", req.URL.String()).
+			Msg("Request not found in cassette")
+		body := fmt.Sprintf(`{"message":"Not Found in cassette","url":%q}`, req.URL.String())
+		header := http.Header{}
+		header.Set("Content-Type", "application/json; charset=utf-8")
+		return cassetteHTTPResponse(req, http.StatusNotFound, header, []byte(body))
+	}
+
+	c.log.Trace().Str("method", req.Method).Str("request_url", req.URL.String()).Msg("Replayed GitHub API response")
+	body := []byte(recorded.Body)
+	if recorded.BodyBase64 != nil {
+		body = recorded.BodyBase64
+	}
+	return cassetteHTTPResponse(req, recorded.StatusCode, recorded.Header.Clone(), body)
+}
+
+// load reads every fixture in the cassette dir.
+func (c *Cassette) load() error {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list cassette '%s': %w", c.dir, err)
+	}
+	for _, file := range files {
+		fixture, err := readJSONFile[*cassetteFixture](file)
+		if err != nil {
+			return fmt.Errorf("failed to read cassette fixture '%s': %w", file, err)
+		}
+		if fixture == nil || len(fixture.Responses) == 0 {
+			continue
+		}
+		c.fixtures[cassetteKey(fixture.Method, fixture.URL, []byte(fixture.RequestBody))] = fixture
+	}
+	c.log.Debug().Int("fixtures", len(c.fixtures)).Msg("Loaded cassette")
+	return nil
+}
+
+// readRequestBody reads req's body and puts an unread copy back, so req can still be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body for cassette: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// cassetteKey identifies a request by method, URL and body, e.g. a GraphQL query and its variables.
+func cassetteKey(method, rawURL string, body []byte) string {
+	sum := sha256.Sum256([]byte(method + " " + rawURL + "\n" + string(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+// cassetteFileName keeps fixture names readable, e.g. GET_repos_owner_repo_actions_runs_123-1a2b3c4d5e6f.json.
+func cassetteFileName(method, urlPath, key string) string {
+	name := method + "_" + strings.Trim(urlPath, "/")
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if len(name) > cassetteFixtureNameLimit {
+		name = name[:cassetteFixtureNameLimit]
+	}
+	return name + "-" + key[:12] + ".json"
+}
+
+func cassetteHTTPResponse(req *http.Request, statusCode int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,157 @@
+package gather
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func TestCassette_RecordReplay(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	cassetteDir := filepath.Join(testDir, "cassette")
+
+	var (
+		requests atomic.Int32
+		status   atomic.Value
+	)
+	status.Store("in_progress")
+	live := &mockRoundTripper{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			requests.Add(1)
+			body := `{"id":1,"name":"recorded","status":"` + status.Load().(string) + `"}`
+			if req.Method == http.MethodPost {
+				body = `{"data":{"viewer":{"login":"octocat"}}}`
+			}
+			header := http.Header{}
+			header.Set("Content-Type", "application/json; charset=utf-8")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+				Request:    req,
+			}, nil
+		},
+	}
+
+	var viewerQuery struct {
+		Viewer struct {
+			Login githubv4.String
+		}
+	}
+
+	recorder, err := NewRecorder(log, cassetteDir, live)
+	require.NoError(t, err)
+	client, err := NewGitHubClient(log, "secret-token", recorder)
+	require.NoError(t, err)
+
+	run, _, err := client.Rest.Actions.GetWorkflowRunByID(t.Context(), "owner", "repo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "in_progress", run.GetStatus())
+	status.Store("completed")
+	run, _, err = client.Rest.Actions.GetWorkflowRunByID(t.Context(), "owner", "repo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", run.GetStatus())
+	require.NoError(t, client.GraphQL.Query(t.Context(), &viewerQuery, nil))
+	assert.Equal(t, "octocat", string(viewerQuery.Viewer.Login))
+	require.Equal(t, int32(3), requests.Load(), "GraphQL should go through the recorder too")
+
+	fixtures, err := filepath.Glob(filepath.Join(cassetteDir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, fixtures, 2, "one fixture per distinct request")
+	for _, fixture := range fixtures {
+		content, err := os.ReadFile(fixture) //nolint:gosec // test fixture path
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "secret-token", "tokens must never be recorded")
+	}
+
+	replayer, err := NewReplayer(log, cassetteDir)
+	require.NoError(t, err)
+	offline, err := NewGitHubClient(log, MockGitHubToken, replayer)
+	require.NoError(t, err)
+
+	for _, want := range []string{"in_progress", "completed", "completed"} {
+		run, _, err = offline.Rest.Actions.GetWorkflowRunByID(t.Context(), "owner", "repo", 1)
+		require.NoError(t, err)
+		assert.Equal(t, want, run.GetStatus(), "responses should replay in recorded order, then repeat the last")
+	}
+	viewerQuery.Viewer.Login = ""
+	require.NoError(t, offline.GraphQL.Query(t.Context(), &viewerQuery, nil))
+	assert.Equal(t, "octocat", string(viewerQuery.Viewer.Login))
+	assert.Equal(t, int32(3), requests.Load(), "replay should never reach the network")
+
+	_, resp, err := offline.Rest.Actions.GetWorkflowRunByID(t.Context(), "owner", "repo", 2)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	var ghErr *github.ErrorResponse
+	require.ErrorAs(t, err, &ghErr)
+	assert.Contains(t, ghErr.Message, "cassette")
+}
+
+func TestCassette_Rerecord(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	cassetteDir := filepath.Join(testDir, "cassette")
+
+	var status atomic.Value
+	live := &mockRoundTripper{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			header.Set("Content-Type", "application/json; charset=utf-8")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"id":1,"status":"` + status.Load().(string) + `"}`)),
+				Request:    req,
+			}, nil
+		},
+	}
+	record := func(statuses ...string) {
+		recorder, err := NewRecorder(log, cassetteDir, live)
+		require.NoError(t, err)
+		client, err := NewGitHubClient(log, MockGitHubToken, recorder)
+		require.NoError(t, err)
+		for _, s := range statuses {
+			status.Store(s)
+			_, _, err := client.Rest.Actions.GetWorkflowRunByID(t.Context(), "owner", "repo", 1)
+			require.NoError(t, err)
+		}
+	}
+
+	record("queued", "in_progress")
+	record("completed")
+
+	replayer, err := NewReplayer(log, cassetteDir)
+	require.NoError(t, err)
+	offline, err := NewGitHubClient(log, MockGitHubToken, replayer)
+	require.NoError(t, err)
+	run, _, err := offline.Rest.Actions.GetWorkflowRunByID(t.Context(), "owner", "repo", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", run.GetStatus(), "re-recording should replace the earlier responses")
+}
+
+func TestNewReplayer_MissingCassette(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+
+	_, err := NewReplayer(log, filepath.Join(testDir, "missing"))
+	require.ErrorContains(t, err, "does not exist")
+
+	_, err = NewReplayer(log, testDir)
+	require.ErrorContains(t, err, "no recorded fixtures")
+}
@@ -6,8 +6,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
@@ -188,9 +186,10 @@ func tryLoadCommitFromCache(
 	sha string,
 	opts []Option,
 	options *options,
-	cacheKey, targetFile string,
+	store Store,
+	cacheKey string,
 ) (*CommitData, bool) {
-	if options.ForceUpdate || !cacheFileExists(targetFile) {
+	if options.ForceUpdate || !storeHas(store, owner, repo, CommitsDataDir, sha) {
 		return nil, false
 	}
 	if !options.SkipMemoryCache {
@@ -201,13 +200,12 @@ func tryLoadCommitFromCache(
 			}
 		}
 	}
-	cData, loadErr := readJSONFile[*CommitData](targetFile)
-	if loadErr != nil {
+	var cData *CommitData
+	if loadErr := store.Load(owner, repo, CommitsDataDir, sha, &cData); loadErr != nil {
 		log.Warn().
 			Err(loadErr).
-			Str("target_file", targetFile).
 			Msg("Corrupted local cache file encountered; re-fetching from GitHub")
-		_ = os.Remove(targetFile)
+		_ = store.Delete(owner, repo, CommitsDataDir, sha)
 		return nil, false
 	}
 
@@ -242,13 +240,16 @@ func Commit(
 		opt(options)
 	}
 
+	store, err := options.getStore()
+	if err != nil {
+		return nil, err
+	}
 	var (
 		commitData = &CommitData{
 			Owner: owner,
 			Repo:  repo,
 		}
-		targetDir  = filepath.Join(options.DataDir, owner, repo, CommitsDataDir)
-		targetFile = filepath.Join(targetDir, fmt.Sprintf("%s.json", sha))
+		targetFile = store.Location(owner, repo, CommitsDataDir, sha)
 	)
 
 	if options.pullRequestData != nil {
@@ -260,11 +261,6 @@ func Commit(
 		Str("commit_sha", sha).
 		Logger()
 
-	err := ensureDataDir(targetDir, CommitsDataDir)
-	if err != nil {
-		return nil, err
-	}
-
 	startTime := time.Now()
 	cacheKey := fmt.Sprintf("%s:pr=%d", targetFile, commitData.CorrespondingPRNum)
 
@@ -277,8 +273,8 @@ func Commit(
 		sha,
 		opts,
 		options,
+		store,
 		cacheKey,
-		targetFile,
 	); ok {
 		log.Debug().
 			Str("duration", time.Since(startTime).String()).
@@ -297,8 +293,8 @@ func Commit(
 			sha,
 			opts,
 			options,
+			store,
 			cacheKey,
-			targetFile,
 		); ok {
 			return cached, nil
 		}
@@ -379,18 +375,11 @@ func Commit(
 			return nil, fmt.Errorf("failed to gather workflow runs for commit '%s': %w", sha, wfErr)
 		}
 
-		if writeErr := writeJSONFile(targetFile, commitData); writeErr != nil {
-			return nil, fmt.Errorf("failed to write commit data to file '%s': %w", sha, writeErr)
+		if saveErr := store.Save(owner, repo, CommitsDataDir, sha, commitData); saveErr != nil {
+			return nil, fmt.Errorf("failed to write commit data to file '%s': %w", sha, saveErr)
 		}
 
-		_ = AppendManifestRecord(options.DataDir, owner, repo, ManifestRecord{
-			Type:      "commit",
-			ID:        sha,
-			Name:      "Commit " + sha,
-			State:     commitData.Conclusion,
-			Actor:     commitData.GetCommit().GetAuthor().GetName(),
-			CreatedAt: commitData.GetCommit().GetAuthor().GetDate().Time,
-		})
+		_ = store.AppendManifestRecord(owner, repo, commitManifestRecord(sha, commitData))
 
 		commitCache.Store(cacheKey, commitData)
 		log.Debug().
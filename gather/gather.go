@@ -60,6 +60,7 @@ type options struct {
 	ForceUpdate     bool
 	SkipMemoryCache bool
 	DataDir         string
+	store           Store
 
 	// Wait controls whether octometrics waits for in-progress workflow/commit runs to finish
 	Wait         bool
@@ -72,7 +73,11 @@ type options struct {
 	commitData       *CommitData
 	repositoryCommit *github.RepositoryCommit
 	gatherCost       bool
+	pricing          *PricingCatalog
 	downloadLogs     bool
+
+	// rateLimitReserve is how many REST requests Org leaves unspent
+	rateLimitReserve int
 }
 
 func defaultDataDir() string {
@@ -127,6 +132,30 @@ func CustomDataFolder(folder string) Option {
 	}
 }
 
+// WithStore routes all reads and writes of gathered data through store,
+// and roots the data directory (used for logs and other artifacts) at the store's data dir.
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+		o.DataDir = store.DataDir()
+	}
+}
+
+// getStore returns the Store set by WithStore, or a FileStore rooted at DataDir.
+// A data dir holding a SQLite database has to be opened with OpenStore and passed with WithStore,
+// so it's never silently read and written as a FileStore.
+func (o *options) getStore() (Store, error) {
+	if o.store != nil {
+		return o.store, nil
+	}
+	if cacheFileExists(SQLitePath(o.DataDir)) {
+		return nil, fmt.Errorf(
+			"data dir '%s' holds a SQLite store, open it with OpenStore and pass it with WithStore", o.DataDir,
+		)
+	}
+	return NewFileStore(o.DataDir), nil
+}
+
 // WithWait configures whether to wait for in-progress runs to complete.
 func WithWait(wait bool) Option {
 	return func(o *options) {
@@ -205,28 +234,138 @@ func WithoutCost() Option {
 	}
 }
 
+// WithPricingCatalog prices jobs with a pricing catalog's rates and discounts before the built-in rates.
+// Cached workflow runs priced with another catalog are gathered again.
+func WithPricingCatalog(catalog *PricingCatalog) Option {
+	return func(o *options) {
+		o.pricing = catalog
+	}
+}
+
+// WithRateLimitReserve stops Org from gathering more workflow runs once fewer than reserve REST requests
+// are left in the rate limit window, leaving them for other tools sharing the token.
+func WithRateLimitReserve(reserve int) Option {
+	return func(o *options) {
+		o.rateLimitReserve = reserve
+	}
+}
+
 // GitHubClient wraps GitHub REST and GraphQL clients for API access.
 type GitHubClient struct {
 	Rest    *github.Client
 	GraphQL *githubv4.Client
+
+	httpCache *httpCacheTransport
+
+	// rateLimitRemaining is the REST rate limit left as of the last response that reported one.
+	rateLimitRemaining atomic.Int64
+	rateLimitSeen      atomic.Bool
+}
+
+// RateLimitRemaining returns how many REST requests are left in the current rate limit window,
+// as of the last response. ok is false until a response reported a rate limit.
+func (c *GitHubClient) RateLimitRemaining() (remaining int, ok bool) {
+	if c == nil || !c.rateLimitSeen.Load() {
+		return 0, false
+	}
+	return int(c.rateLimitRemaining.Load()), true
+}
+
+// HTTPCacheStats returns how many REST requests the on-disk HTTP cache has answered so far.
+// It is zero if the client was built without WithHTTPCache.
+func (c *GitHubClient) HTTPCacheStats() HTTPCacheStats {
+	if c == nil || c.httpCache == nil {
+		return HTTPCacheStats{}
+	}
+	return c.httpCache.Stats()
+}
+
+// defaultAPIURL is the github.com REST API base URL. Enterprise URLs matching it are treated as github.com.
+const defaultAPIURL = "https://api.github.com/"
+
+// ClientOption modifies how NewGitHubClient builds its REST and GraphQL clients.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	apiURL       string
+	graphQLURL   string
+	httpCacheDir string
+}
+
+// WithHTTPCache keeps GitHub REST responses in dir and revalidates them with ETags,
+// so repeat listings are answered with free 304s instead of spending rate limit.
+func WithHTTPCache(dir string) ClientOption {
+	return func(o *clientOptions) {
+		o.httpCacheDir = dir
+	}
+}
+
+// WithEnterpriseURLs points the clients at a GitHub Enterprise Server instance.
+// apiURL is the REST base URL (e.g. https://ghes.example.com/api/v3). graphQLURL may be
+// empty, in which case it is derived from apiURL (e.g. https://ghes.example.com/api/graphql).
+// Passing the github.com API URL, or an empty apiURL, leaves the default endpoints in place.
+func WithEnterpriseURLs(apiURL, graphQLURL string) ClientOption {
+	return func(o *clientOptions) {
+		apiURL = strings.TrimSpace(apiURL)
+		if apiURL == "" || strings.TrimSuffix(apiURL, "/")+"/" == defaultAPIURL {
+			return
+		}
+		o.apiURL = apiURL
+		o.graphQLURL = strings.TrimSpace(graphQLURL)
+		if o.graphQLURL == "" {
+			o.graphQLURL = enterpriseGraphQLURL(apiURL)
+		}
+	}
+}
+
+// enterpriseGraphQLURL derives the GraphQL endpoint from a GHES REST base URL.
+// GHES serves REST under /api/v3 and GraphQL under /api/graphql; GHE.com style
+// api.<host> URLs serve GraphQL under /graphql.
+func enterpriseGraphQLURL(apiURL string) string {
+	base := strings.TrimSuffix(apiURL, "/")
+	if trimmed, ok := strings.CutSuffix(base, "/api/v3"); ok {
+		return trimmed + "/api/graphql"
+	}
+	return base + "/graphql"
+}
+
+// enterpriseUploadURL derives the uploads endpoint from a GHES REST base URL.
+func enterpriseUploadURL(apiURL string) string {
+	base := strings.TrimSuffix(apiURL, "/")
+	if trimmed, ok := strings.CutSuffix(base, "/api/v3"); ok {
+		return trimmed + "/api/uploads/"
+	}
+	return base + "/"
 }
 
 // NewGitHubClient creates a new GitHub API and GraphQL client with the provided token and logger.
+// By default the clients talk to github.com; use WithEnterpriseURLs to target GitHub Enterprise Server.
 func NewGitHubClient(
 	logger zerolog.Logger,
 	githubToken string,
 	optionalNext http.RoundTripper,
+	opts ...ClientOption,
 ) (*GitHubClient, error) {
 	var (
-		next   http.RoundTripper
-		client = &GitHubClient{}
+		next       http.RoundTripper
+		client     = &GitHubClient{}
+		clientOpts = &clientOptions{}
 	)
 
+	for _, opt := range opts {
+		opt(clientOpts)
+	}
+
 	if optionalNext != nil {
 		next = optionalNext
 	}
 
-	rateLimiter := github_ratelimit.NewClient(gitHubClientRoundTripper("REST", logger, next),
+	restLogging := gitHubClientRoundTripper("REST", logger, next)
+	restLogging.onRateLimit = func(remaining int) {
+		client.rateLimitRemaining.Store(int64(remaining))
+		client.rateLimitSeen.Store(true)
+	}
+	rateLimiter := github_ratelimit.NewClient(restLogging,
 		github_primary_ratelimit.WithLimitDetectedCallback(func(ctx *github_primary_ratelimit.CallbackContext) {
 			logger.Warn().
 				Str("category", string(ctx.Category)).
@@ -240,8 +379,29 @@ func NewGitHubClient(
 		}),
 	)
 
+	restTransport := rateLimiter.Transport
+	if clientOpts.httpCacheDir != "" {
+		client.httpCache = newHTTPCacheTransport(logger, restTransport, clientOpts.httpCacheDir, githubToken)
+		restTransport = client.httpCache
+	}
+
+	restOpts := []github.ClientOptionsFunc{
+		github.WithAuthToken(githubToken),
+		github.WithTransport(restTransport),
+	}
+	if clientOpts.apiURL != "" {
+		restOpts = append(
+			restOpts,
+			github.WithEnterpriseURLs(clientOpts.apiURL, enterpriseUploadURL(clientOpts.apiURL)),
+		)
+		logger.Debug().
+			Str("api_url", clientOpts.apiURL).
+			Str("graphql_url", clientOpts.graphQLURL).
+			Msg("Using GitHub Enterprise Server endpoints")
+	}
+
 	var err error
-	client.Rest, err = github.NewClient(github.WithAuthToken(githubToken), github.WithTransport(rateLimiter.Transport))
+	client.Rest, err = github.NewClient(restOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
 	}
@@ -249,9 +409,17 @@ func NewGitHubClient(
 	src := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: githubToken},
 	)
-	graphqlClient := oauth2.NewClient(context.Background(), src)
+	graphqlCtx := context.Background()
+	if next != nil {
+		graphqlCtx = context.WithValue(graphqlCtx, oauth2.HTTPClient, &http.Client{Transport: next})
+	}
+	graphqlClient := oauth2.NewClient(graphqlCtx, src)
 	graphqlClient.Transport = gitHubClientRoundTripper("GraphQL", logger, graphqlClient.Transport)
-	client.GraphQL = githubv4.NewClient(graphqlClient)
+	if clientOpts.graphQLURL != "" {
+		client.GraphQL = githubv4.NewEnterpriseClient(clientOpts.graphQLURL, graphqlClient)
+	} else {
+		client.GraphQL = githubv4.NewClient(graphqlClient)
+	}
 
 	return client, nil
 }
@@ -338,7 +506,7 @@ func Range(
 
 // gitHubClientRoundTripper returns a RoundTripper that logs requests and responses to the GitHub API.
 // You can pass a custom RoundTripper to use a different transport, or nil to use the default transport.
-func gitHubClientRoundTripper(clientType string, logger zerolog.Logger, next http.RoundTripper) http.RoundTripper {
+func gitHubClientRoundTripper(clientType string, logger zerolog.Logger, next http.RoundTripper) *loggingTransport {
 	if next == nil {
 		next = http.DefaultTransport
 	}
@@ -354,6 +522,8 @@ type loggingTransport struct {
 	transport  http.RoundTripper
 	logger     zerolog.Logger
 	clientType string
+	// onRateLimit is called with the requests left whenever a response reports a rate limit.
+	onRateLimit func(remaining int)
 }
 
 // cancelOnClose wraps a ReadCloser to cancel a context when the body is closed.
@@ -449,7 +619,12 @@ func (lt *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 
 	mockRequest := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ") == MockGitHubToken
 
-	if !mockRequest && callsRemaining <= 50 && callsRemaining%10 == 0 {
+	if lt.onRateLimit != nil && callLimit > 0 {
+		lt.onRateLimit(callsRemaining)
+	}
+
+	// GHES instances may run with rate limiting disabled, in which case no limit headers are sent
+	if !mockRequest && callLimit > 0 && callsRemaining <= 50 && callsRemaining%10 == 0 {
 		logger.Warn().Msg("GitHub API request nearing rate limit")
 	}
 
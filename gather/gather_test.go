@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kalverra/octometrics/internal/testhelpers"
@@ -66,3 +70,102 @@ func TestNewGitHubClient(t *testing.T) {
 
 	require.NoError(t, writeBodyErr, "error writing mock response")
 }
+
+func TestNewGitHubClientEnterprise(t *testing.T) {
+	t.Parallel()
+
+	log, _ := testhelpers.Setup(t)
+
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	ghes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/repos/kalverra/octometrics":
+			_, _ = w.Write([]byte(`{"id": 1, "name": "octometrics", "html_url": "` +
+				"http://" + r.Host + `/kalverra/octometrics"}`))
+		case "/api/graphql":
+			_, _ = w.Write([]byte(`{"data": {"viewer": {"login": "octocat"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ghes.Close)
+
+	client, err := NewGitHubClient(log, "mock-token", nil, WithEnterpriseURLs(ghes.URL+"/api/v3", ""))
+	require.NoError(t, err, "error creating GitHub client")
+	assert.Equal(t, ghes.URL+"/api/v3/", client.Rest.BaseURL())
+	assert.Equal(t, ghes.URL+"/api/uploads/", client.Rest.UploadURL())
+
+	repo, _, err := client.Rest.Repositories.Get(t.Context(), testGatherOwner, testGatherRepo)
+	require.NoError(t, err, "error fetching repository from GHES stand-in")
+	assert.Equal(t, ghes.URL+"/kalverra/octometrics", repo.GetHTMLURL())
+
+	var query struct {
+		Viewer struct {
+			Login githubv4.String
+		}
+	}
+	require.NoError(t, client.GraphQL.Query(t.Context(), &query, nil), "error querying GHES GraphQL stand-in")
+	assert.Equal(t, "octocat", string(query.Viewer.Login))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/api/v3/repos/kalverra/octometrics", "/api/graphql"}, paths)
+}
+
+func TestWithEnterpriseURLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		apiURL         string
+		graphQLURL     string
+		wantAPIURL     string
+		wantGraphQLURL string
+	}{
+		{
+			name: "empty keeps github.com",
+		},
+		{
+			name:   "github.com API keeps defaults",
+			apiURL: "https://api.github.com",
+		},
+		{
+			name:           "GHES derives GraphQL",
+			apiURL:         "https://ghes.example.com/api/v3",
+			wantAPIURL:     "https://ghes.example.com/api/v3",
+			wantGraphQLURL: "https://ghes.example.com/api/graphql",
+		},
+		{
+			name:           "api subdomain derives GraphQL",
+			apiURL:         "https://api.acme.ghe.com/",
+			wantAPIURL:     "https://api.acme.ghe.com/",
+			wantGraphQLURL: "https://api.acme.ghe.com/graphql",
+		},
+		{
+			name:           "explicit GraphQL wins",
+			apiURL:         "https://ghes.example.com/api/v3",
+			graphQLURL:     "https://graphql.example.com/",
+			wantAPIURL:     "https://ghes.example.com/api/v3",
+			wantGraphQLURL: "https://graphql.example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &clientOptions{}
+			WithEnterpriseURLs(tt.apiURL, tt.graphQLURL)(o)
+			assert.Equal(t, tt.wantAPIURL, o.apiURL)
+			assert.Equal(t, tt.wantGraphQLURL, o.graphQLURL)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package gather
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// HTTPCacheDir is the data dir folder holding cached GitHub REST responses.
+const HTTPCacheDir = "http_cache"
+
+// maxHTTPCacheBodySize skips caching responses too large to be API listings.
+const maxHTTPCacheBodySize = 32 << 20 // 32 MiB
+
+// HTTPCacheStats counts how the on-disk HTTP cache served GitHub REST requests.
+type HTTPCacheStats struct {
+	// Hits are requests answered with 304 Not Modified and served from disk, costing no rate limit.
+	Hits int64
+	// Misses are cacheable requests that GitHub answered with fresh data.
+	Misses int64
+	// Stored is how many responses were written to the cache.
+	Stored int64
+}
+
+// httpCacheEntry is a cached GitHub REST response, revalidated with its ETag or Last-Modified.
+type httpCacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+// httpCacheTransport sends conditional requests for GET calls it has seen before and serves 304s from disk.
+// GitHub doesn't count 304 responses against the rate limit, so repeat listings are free.
+type httpCacheTransport struct {
+	next     http.RoundTripper
+	dir      string
+	identity string
+	logger   zerolog.Logger
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	stored atomic.Int64
+}
+
+// newHTTPCacheTransport caches responses in dir. token only feeds the cache key,
+// so different tokens never see each other's responses; it is never written to disk.
+func newHTTPCacheTransport(
+	logger zerolog.Logger,
+	next http.RoundTripper,
+	dir, token string,
+) *httpCacheTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	identity := sha256.Sum256([]byte(token))
+	return &httpCacheTransport{
+		next:     next,
+		dir:      dir,
+		identity: hex.EncodeToString(identity[:]),
+		logger:   logger,
+	}
+}
+
+// Stats returns the cache hit and miss counts so far.
+func (c *httpCacheTransport) Stats() HTTPCacheStats {
+	return HTTPCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Stored: c.stored.Load(),
+	}
+}
+
+// RoundTrip adds If-None-Match/If-Modified-Since to GET requests with a cached response,
+// and turns a 304 into the cached 200 so callers never notice the difference.
+func (c *httpCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return c.next.RoundTrip(req)
+	}
+
+	path := c.entryPath(req)
+	entry, err := readJSONFile[*httpCacheEntry](path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.logger.Debug().Err(err).Str("cache_file", path).Msg("Ignoring unreadable HTTP cache entry")
+	}
+	if entry != nil && entry.URL != req.URL.String() {
+		entry = nil
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	log := c.logger.With().Str("request_url", req.URL.String()).Int("status", resp.StatusCode).Logger()
+	if entry != nil && resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+		hits := c.hits.Add(1)
+		log.Debug().
+			Str("http_cache", "hit").
+			Int64("cache_hits", hits).
+			Int64("cache_misses", c.misses.Load()).
+			Msg("Served GitHub response from HTTP cache")
+		return entry.response(req, resp), nil
+	}
+
+	if !cacheableResponse(resp) {
+		return resp, nil
+	}
+
+	misses := c.misses.Add(1)
+	log = log.With().
+		Str("http_cache", "miss").
+		Int64("cache_hits", c.hits.Load()).
+		Int64("cache_misses", misses).
+		Logger()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPCacheBodySize+1))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body for HTTP cache: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	if len(body) > maxHTTPCacheBodySize {
+		log.Debug().Int("body_size", len(body)).Msg("Response too large for HTTP cache")
+		return resp, nil
+	}
+
+	newEntry := &httpCacheEntry{
+		URL:          req.URL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		StoredAt:     time.Now(),
+	}
+	if err := c.store(path, newEntry); err != nil {
+		log.Warn().Err(err).Msg("Failed to write HTTP cache entry")
+		return resp, nil
+	}
+	c.stored.Add(1)
+	log.Debug().Msg("Stored GitHub response in HTTP cache")
+	return resp, nil
+}
+
+// entryPath keys the cache on the token identity, the requested URL and the media type asked for.
+func (c *httpCacheTransport) entryPath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(c.identity + "\n" + req.Header.Get("Accept") + "\n" + req.URL.String()))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+func (c *httpCacheTransport) store(path string, entry *httpCacheEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to make HTTP cache dir: %w", err)
+	}
+	return writeJSONFile(path, entry)
+}
+
+// cacheableResponse reports whether resp is a validatable JSON API response.
+// Artifact and log downloads are left alone.
+func cacheableResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// response rebuilds the cached 200 response, keeping the live 304's rate limit headers.
+func (e *httpCacheEntry) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for key, values := range notModified.Header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(key), "X-Ratelimit-") {
+			header[key] = values
+		}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(e.Body)))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,140 @@
+package gather
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+// etagRoundTripper answers like GitHub: 304 when If-None-Match matches the current ETag.
+func etagRoundTripper(etag *atomic.Value, body func() string, requests, notModified *atomic.Int32) http.RoundTripper {
+	return &mockRoundTripper{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			requests.Add(1)
+			current := etag.Load().(string)
+			header := http.Header{}
+			header.Set("ETag", current)
+			header.Set("X-RateLimit-Remaining", "4999")
+			if req.Header.Get("If-None-Match") == current {
+				notModified.Add(1)
+				return &http.Response{
+					StatusCode: http.StatusNotModified,
+					Header:     header,
+					Body:       io.NopCloser(bytes.NewReader(nil)),
+					Request:    req,
+				}, nil
+			}
+			header.Set("Content-Type", "application/json; charset=utf-8")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body:       io.NopCloser(bytes.NewBufferString(body())),
+				Request:    req,
+			}, nil
+		},
+	}
+}
+
+func TestHTTPCache(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+
+	var (
+		etag                  atomic.Value
+		requests, notModified atomic.Int32
+		runName               atomic.Value
+	)
+	etag.Store(`"v1"`)
+	runName.Store("first")
+	body := func() string {
+		return `{"total_count":1,"workflow_runs":[{"id":1,"name":"` + runName.Load().(string) + `"}]}`
+	}
+
+	cacheDir := filepath.Join(testDir, HTTPCacheDir)
+	client, err := NewGitHubClient(
+		log,
+		"mock-token",
+		etagRoundTripper(&etag, body, &requests, &notModified),
+		WithHTTPCache(cacheDir),
+	)
+	require.NoError(t, err)
+
+	listRuns := func() *github.WorkflowRuns {
+		t.Helper()
+		runs, _, err := client.Rest.Actions.ListRepositoryWorkflowRuns(t.Context(), "owner", "repo", nil)
+		require.NoError(t, err)
+		require.Len(t, runs.WorkflowRuns, 1)
+		return runs
+	}
+
+	assert.Equal(t, "first", listRuns().WorkflowRuns[0].GetName())
+	assert.Equal(t, HTTPCacheStats{Misses: 1, Stored: 1}, client.HTTPCacheStats())
+
+	assert.Equal(t, "first", listRuns().WorkflowRuns[0].GetName(), "304 should be served from the cache")
+	assert.Equal(t, HTTPCacheStats{Hits: 1, Misses: 1, Stored: 1}, client.HTTPCacheStats())
+	assert.Equal(t, int32(1), notModified.Load())
+
+	etag.Store(`"v2"`)
+	runName.Store("second")
+	assert.Equal(t, "second", listRuns().WorkflowRuns[0].GetName(), "changed data should replace the cached copy")
+	assert.Equal(t, HTTPCacheStats{Hits: 1, Misses: 2, Stored: 2}, client.HTTPCacheStats())
+	assert.Equal(t, int32(3), requests.Load(), "every call should still revalidate with GitHub")
+
+	// A different token must not reuse another token's responses
+	otherClient, err := NewGitHubClient(
+		log,
+		"other-token",
+		etagRoundTripper(&etag, body, &requests, &notModified),
+		WithHTTPCache(cacheDir),
+	)
+	require.NoError(t, err)
+	_, _, err = otherClient.Rest.Actions.ListRepositoryWorkflowRuns(t.Context(), "owner", "repo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, HTTPCacheStats{Misses: 1, Stored: 1}, otherClient.HTTPCacheStats())
+	assert.Equal(t, int32(1), notModified.Load())
+}
+
+func TestHTTPCache_SkipsNonJSON(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+
+	var requests atomic.Int32
+	next := &mockRoundTripper{
+		roundTrip: func(req *http.Request) (*http.Response, error) {
+			requests.Add(1)
+			header := http.Header{}
+			header.Set("ETag", `"zip"`)
+			header.Set("Content-Type", "application/zip")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body:       io.NopCloser(bytes.NewBufferString("PK")),
+				Request:    req,
+			}, nil
+		},
+	}
+	cache := newHTTPCacheTransport(log, next, testDir, "mock-token")
+	httpClient := &http.Client{Transport: cache}
+
+	for range 2 {
+		resp, err := httpClient.Get("https://api.github.com/repos/owner/repo/actions/artifacts/1/zip")
+		require.NoError(t, err)
+		got, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, "PK", string(got))
+	}
+	assert.Equal(t, HTTPCacheStats{}, cache.Stats(), "downloads should bypass the cache")
+	assert.Equal(t, int32(2), requests.Load())
+}
@@ -0,0 +1,82 @@
+package gather
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+)
+
+// maxFilteredWorkflowRuns is the most workflow runs GitHub lists for a filtered query, newest first.
+// total_count still reports every run that matched.
+const maxFilteredWorkflowRuns = 1000
+
+// createdWindow is a range of workflow run creation times, both ends included. A zero until leaves it open.
+type createdWindow struct {
+	from, until time.Time
+}
+
+// filter is the window as a created filter of the workflow runs API.
+func (w createdWindow) filter() string {
+	if w.until.IsZero() {
+		return ">=" + w.from.UTC().Format(time.RFC3339)
+	}
+	return w.from.UTC().Format(time.RFC3339) + ".." + w.until.UTC().Format(time.RFC3339)
+}
+
+// listWorkflowRunsCreated calls fn with every workflow run of owner/repo created in window, of event when set.
+// GitHub only lists the newest 1,000 runs of a filtered query, so windows with more are split in half until each
+// can be listed in full. truncated reports whether a single second still had more, leaving some runs out.
+func listWorkflowRunsCreated(
+	ctx context.Context,
+	client *GitHubClient,
+	owner, repo, event string,
+	window createdWindow,
+	fn func(*github.WorkflowRun) error,
+) (truncated bool, err error) {
+	window.from = window.from.Truncate(time.Second)
+	windows := []createdWindow{window}
+
+nextWindow:
+	for len(windows) > 0 {
+		w := windows[len(windows)-1]
+		windows = windows[:len(windows)-1]
+
+		listOpts := &github.ListWorkflowRunsOptions{
+			Created:     w.filter(),
+			Event:       event,
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			runs, resp, err := client.Rest.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, listOpts)
+			if err != nil {
+				return truncated, fmt.Errorf("failed to list workflow runs created %s: %w", w.filter(), err)
+			}
+			if listOpts.Page == 0 && runs.GetTotalCount() > maxFilteredWorkflowRuns {
+				if w.until.IsZero() {
+					w.until = time.Now().Truncate(time.Second)
+				}
+				if w.until.After(w.from) {
+					mid := w.from.Add(w.until.Sub(w.from) / 2).Truncate(time.Second)
+					windows = append(windows,
+						createdWindow{from: w.from, until: mid},
+						createdWindow{from: mid.Add(time.Second), until: w.until},
+					)
+					continue nextWindow
+				}
+				truncated = true
+			}
+			for _, run := range runs.WorkflowRuns {
+				if err := fn(run); err != nil {
+					return truncated, err
+				}
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			listOpts.Page = resp.NextPage
+		}
+	}
+	return truncated, nil
+}
@@ -1,15 +1,11 @@
 package gather
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -25,179 +21,66 @@ type ManifestRecord struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-var manifestMu sync.Mutex
-
-// ManifestPath returns the path to manifest.jsonl for an owner/repo.
+// ManifestPath returns the path to manifest.jsonl for an owner/repo in a FileStore.
 func ManifestPath(dataDir, owner, repo string) string {
 	return filepath.Join(dataDir, owner, repo, "manifest.jsonl")
 }
 
-// AppendManifestRecord appends a record to data/<owner>/<repo>/manifest.jsonl.
-func AppendManifestRecord(dataDir, owner, repo string, rec ManifestRecord) error {
-	manifestMu.Lock()
-	defer manifestMu.Unlock()
-
-	p := ManifestPath(dataDir, owner, repo)
-	p = filepath.Clean(p)
-	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
-		return fmt.Errorf("failed to create manifest directory: %w", err)
-	}
-
-	//nolint:gosec // ManifestPath constructs path within data directory
-	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
-	if err != nil {
-		return fmt.Errorf("failed to open manifest file: %w", err)
-	}
-	defer func() { _ = f.Close() }()
-
-	data, err := json.Marshal(rec)
-	if err != nil {
-		return fmt.Errorf("failed to marshal manifest record: %w", err)
-	}
-
-	data = append(data, '\n')
-	if _, err := f.Write(data); err != nil {
-		return fmt.Errorf("failed to write manifest record: %w", err)
-	}
-
-	return nil
+// AppendManifestRecord appends a record to the owner/repo manifest of store.
+func AppendManifestRecord(store Store, owner, repo string, rec ManifestRecord) error {
+	return store.AppendManifestRecord(owner, repo, rec)
 }
 
-// LoadManifest loads all records from data/<owner>/<repo>/manifest.jsonl.
-func LoadManifest(dataDir, owner, repo string) ([]ManifestRecord, error) {
-	manifestMu.Lock()
-	defer manifestMu.Unlock()
+// LoadManifest loads all records from the owner/repo manifest of store.
+func LoadManifest(store Store, owner, repo string) ([]ManifestRecord, error) {
+	return store.LoadManifest(owner, repo)
+}
 
-	p := filepath.Clean(ManifestPath(dataDir, owner, repo))
-	//nolint:gosec // ManifestPath constructs path within data directory
-	f, err := os.Open(p)
+// RebuildManifest reconstructs the manifests of store from its stored entities.
+func RebuildManifest(ctx context.Context, log zerolog.Logger, store Store) error {
+	entries, err := store.Entries()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to open manifest file: %w", err)
-	}
-	defer func() { _ = f.Close() }()
-
-	var records []ManifestRecord
-	indexMap := make(map[string]int)
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		var rec ManifestRecord
-		if err := json.Unmarshal(line, &rec); err == nil {
-			key := rec.Type + ":" + rec.ID
-			if idx, ok := indexMap[key]; ok {
-				records[idx] = rec
-			} else {
-				indexMap[key] = len(records)
-				records = append(records, rec)
-			}
-		}
+		return err
 	}
 
-	return records, scanner.Err()
-}
-
-// RebuildManifest walks dataDir and reconstructs manifest.jsonl files.
-func RebuildManifest(ctx context.Context, log zerolog.Logger, dataDir string) error {
 	byRepo := make(map[string][]ManifestRecord)
-
-	err := filepath.WalkDir(dataDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
-			return nil
-		}
-
-		relPath, relErr := filepath.Rel(dataDir, path)
-		if relErr != nil {
-			return nil
-		}
-		parts := strings.Split(relPath, string(filepath.Separator))
-		if len(parts) != 4 {
-			return nil
-		}
-		owner, repo, cat, name := parts[0], parts[1], parts[2], strings.TrimSuffix(parts[3], ".json")
+	for _, entry := range entries {
+		owner, repo, name := entry.Owner, entry.Repo, entry.ID
 		repoKey := owner + "/" + repo
 
-		switch cat {
+		switch entry.Category {
 		case WorkflowRunsDataDir:
 			runID, pErr := strconv.ParseInt(name, 10, 64)
 			if pErr == nil {
-				wfData, _, loadErr := WorkflowRun(ctx, log, nil, owner, repo, runID, CustomDataFolder(dataDir))
+				wfData, _, loadErr := WorkflowRun(ctx, log, nil, owner, repo, runID, WithStore(store))
 				if loadErr == nil && wfData != nil {
-					state := wfData.GetConclusion()
-					if state == "" {
-						state = wfData.GetStatus()
-					}
-					byRepo[repoKey] = append(byRepo[repoKey], ManifestRecord{
-						Type:      "workflow_run",
-						ID:        name,
-						Name:      wfData.GetName(),
-						State:     state,
-						Actor:     wfData.GetActor().GetLogin(),
-						CreatedAt: wfData.GetCreatedAt().Time,
-					})
-					for _, job := range wfData.Jobs {
-						jState := job.GetConclusion()
-						if jState == "" {
-							jState = job.GetStatus()
-						}
-						byRepo[repoKey] = append(byRepo[repoKey], ManifestRecord{
-							Type:      "job_run",
-							ID:        fmt.Sprint(job.GetID()),
-							Name:      job.GetName(),
-							State:     jState,
-							Actor:     wfData.GetActor().GetLogin(),
-							CreatedAt: job.GetStartedAt().Time,
-						})
-					}
+					byRepo[repoKey] = append(byRepo[repoKey], workflowRunManifestRecords(wfData)...)
 				}
 			}
 		case CommitsDataDir:
-			cData, cErr := Commit(ctx, log, nil, owner, repo, name, CustomDataFolder(dataDir))
+			cData, cErr := Commit(ctx, log, nil, owner, repo, name, WithStore(store))
 			if cErr == nil && cData != nil {
-				byRepo[repoKey] = append(byRepo[repoKey], ManifestRecord{
-					Type:      "commit",
-					ID:        name,
-					Name:      "Commit " + name,
-					State:     cData.Conclusion,
-					Actor:     cData.GetCommit().GetAuthor().GetName(),
-					CreatedAt: cData.GetCommit().GetAuthor().GetDate().Time,
-				})
+				byRepo[repoKey] = append(byRepo[repoKey], commitManifestRecord(name, cData))
 			}
 		case PullRequestsDataDir:
 			prNum, pErr := strconv.Atoi(name)
 			if pErr == nil {
-				prData, prErr := PullRequest(ctx, log, nil, owner, repo, prNum, CustomDataFolder(dataDir))
+				prData, prErr := PullRequest(ctx, log, nil, owner, repo, prNum, WithStore(store))
 				if prErr == nil && prData != nil {
-					byRepo[repoKey] = append(byRepo[repoKey], ManifestRecord{
-						Type:      "pull_request",
-						ID:        name,
-						Name:      prData.GetTitle(),
-						State:     prData.GetState(),
-						Actor:     prData.GetUser().GetLogin(),
-						CreatedAt: prData.GetCreatedAt().Time,
-					})
+					byRepo[repoKey] = append(byRepo[repoKey], pullRequestManifestRecord(prNum, prData))
 				}
 			}
 		}
-
-		return nil
-	})
-	if err != nil {
-		return err
 	}
 
 	for repoKey, records := range byRepo {
 		parts := strings.SplitN(repoKey, "/", 2)
 		owner, repo := parts[0], parts[1]
-		p := ManifestPath(dataDir, owner, repo)
-		_ = os.Remove(p)
+		if err := store.ResetManifest(owner, repo); err != nil {
+			return err
+		}
 		for _, rec := range records {
-			if err := AppendManifestRecord(dataDir, owner, repo, rec); err != nil {
+			if err := store.AppendManifestRecord(owner, repo, rec); err != nil {
 				return err
 			}
 		}
@@ -205,3 +88,58 @@ func RebuildManifest(ctx context.Context, log zerolog.Logger, dataDir string) er
 
 	return nil
 }
+
+// workflowRunManifestRecords returns the manifest records for a workflow run and its jobs.
+func workflowRunManifestRecords(data *WorkflowRunData) []ManifestRecord {
+	state := data.GetConclusion()
+	if state == "" {
+		state = data.GetStatus()
+	}
+	records := []ManifestRecord{{
+		Type:      "workflow_run",
+		ID:        fmt.Sprint(data.GetID()),
+		Name:      data.GetName(),
+		State:     state,
+		Actor:     data.GetActor().GetLogin(),
+		CreatedAt: data.GetCreatedAt().Time,
+	}}
+	for _, job := range data.Jobs {
+		jState := job.GetConclusion()
+		if jState == "" {
+			jState = job.GetStatus()
+		}
+		records = append(records, ManifestRecord{
+			Type:      "job_run",
+			ID:        fmt.Sprint(job.GetID()),
+			Name:      job.GetName(),
+			State:     jState,
+			Actor:     data.GetActor().GetLogin(),
+			CreatedAt: job.GetStartedAt().Time,
+		})
+	}
+	return records
+}
+
+// commitManifestRecord returns the manifest record for a commit.
+func commitManifestRecord(sha string, data *CommitData) ManifestRecord {
+	return ManifestRecord{
+		Type:      "commit",
+		ID:        sha,
+		Name:      "Commit " + sha,
+		State:     data.Conclusion,
+		Actor:     data.GetCommit().GetAuthor().GetName(),
+		CreatedAt: data.GetCommit().GetAuthor().GetDate().Time,
+	}
+}
+
+// pullRequestManifestRecord returns the manifest record for a pull request.
+func pullRequestManifestRecord(number int, data *PullRequestData) ManifestRecord {
+	return ManifestRecord{
+		Type:      "pull_request",
+		ID:        fmt.Sprint(number),
+		Name:      data.GetTitle(),
+		State:     data.GetState(),
+		Actor:     data.GetUser().GetLogin(),
+		CreatedAt: data.GetCreatedAt().Time,
+	}
+}
@@ -29,7 +29,7 @@ func TestLoadManifest_DedupesByTypeAndID(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, f.Close())
 
-	recs, err := LoadManifest(dir, owner, repo)
+	recs, err := LoadManifest(NewFileStore(dir), owner, repo)
 	require.NoError(t, err)
 	require.Len(t, recs, 1, "duplicate type+id records should be collapsed to the latest")
 	require.Equal(t, "failure", recs[0].State)
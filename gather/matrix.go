@@ -0,0 +1,318 @@
+package gather
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Strategy is a job's strategy, which runs the job once for every combination of its matrix.
+type Strategy struct {
+	Matrix *Matrix `json:",omitempty"`
+	// FailFast cancels the other legs once one fails, nil when the workflow leaves the default of true
+	// or sets it with an expression.
+	FailFast *bool `json:",omitempty"`
+	// MaxParallel limits how many legs run at once, zero when unlimited or set with an expression.
+	MaxParallel int `json:",omitempty"`
+}
+
+// UnmarshalYAML decodes a strategy, leaving fail-fast and max-parallel unset when they're expressions.
+func (s *Strategy) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		// The whole strategy comes from an expression like ${{ fromJSON(inputs.strategy) }}
+		s.Matrix = &Matrix{Expression: value.Value}
+		return nil
+	}
+	var raw struct {
+		Matrix      *Matrix   `yaml:"matrix"`
+		FailFast    yaml.Node `yaml:"fail-fast"`
+		MaxParallel yaml.Node `yaml:"max-parallel"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	s.Matrix = raw.Matrix
+	var failFast bool
+	if raw.FailFast.Kind == yaml.ScalarNode && raw.FailFast.Decode(&failFast) == nil {
+		s.FailFast = &failFast
+	}
+	var maxParallel int
+	if raw.MaxParallel.Kind == yaml.ScalarNode && raw.MaxParallel.Decode(&maxParallel) == nil {
+		s.MaxParallel = maxParallel
+	}
+	return nil
+}
+
+// GetFailFast returns whether a failing leg cancels the others, true unless the workflow turns it off.
+func (s *Strategy) GetFailFast() bool {
+	if s == nil || s.FailFast == nil {
+		return true
+	}
+	return *s.FailFast
+}
+
+// Matrix is a job's strategy.matrix.
+type Matrix struct {
+	// Dimensions are the matrix keys with their values, in the order they appear in the workflow.
+	Dimensions []MatrixDimension   `json:",omitempty"`
+	Include    []MatrixCombination `json:",omitempty"`
+	Exclude    []MatrixCombination `json:",omitempty"`
+	// Expression is set when any of the matrix comes from an expression like
+	// ${{ fromJSON(needs.setup.outputs.matrix) }}, and its legs are only known once the run starts.
+	Expression string `json:",omitempty"`
+}
+
+// MatrixDimension is a matrix key and the values it takes.
+type MatrixDimension struct {
+	Name   string
+	Values []any
+}
+
+// MatrixValue is a matrix key and one of its values.
+type MatrixValue struct {
+	Key   string
+	Value any
+}
+
+// MatrixCombination is a set of matrix values, in the order their keys appear in the workflow.
+type MatrixCombination []MatrixValue
+
+// Get returns the value of key.
+func (c MatrixCombination) Get(key string) (any, bool) {
+	for _, v := range c {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return nil, false
+}
+
+// set overwrites the value of key, appending it when c doesn't have it yet.
+func (c MatrixCombination) set(key string, value any) MatrixCombination {
+	for i, v := range c {
+		if v.Key == key {
+			c[i].Value = value
+			return c
+		}
+	}
+	return append(c, MatrixValue{Key: key, Value: value})
+}
+
+// UnmarshalYAML decodes a matrix, keeping its keys in order.
+func (m *Matrix) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		m.Expression = value.Value
+		return nil
+	case yaml.MappingNode:
+	default:
+		return fmt.Errorf("unexpected node kind for matrix: %v", value.Kind)
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, node := value.Content[i].Value, resolveAlias(value.Content[i+1])
+		if node.Kind != yaml.SequenceNode {
+			// A key whose values come from an expression
+			m.Expression = node.Value
+			continue
+		}
+		switch key {
+		case "include", "exclude":
+			combinations, err := decodeMatrixCombinations(node)
+			if err != nil {
+				return fmt.Errorf("failed to decode matrix %s: %w", key, err)
+			}
+			if key == "include" {
+				m.Include = combinations
+			} else {
+				m.Exclude = combinations
+			}
+		default:
+			var values []any
+			if err := node.Decode(&values); err != nil {
+				return fmt.Errorf("failed to decode matrix key %s: %w", key, err)
+			}
+			m.Dimensions = append(m.Dimensions, MatrixDimension{Name: key, Values: values})
+		}
+	}
+	return nil
+}
+
+func decodeMatrixCombinations(node *yaml.Node) ([]MatrixCombination, error) {
+	combinations := make([]MatrixCombination, 0, len(node.Content))
+	for _, item := range node.Content {
+		item = resolveAlias(item)
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("unexpected node kind for matrix combination: %v", item.Kind)
+		}
+		combination := make(MatrixCombination, 0, len(item.Content)/2)
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			var value any
+			if err := item.Content[i+1].Decode(&value); err != nil {
+				return nil, err
+			}
+			combination = append(combination, MatrixValue{Key: item.Content[i].Value, Value: value})
+		}
+		combinations = append(combinations, combination)
+	}
+	return combinations, nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return node.Alias
+	}
+	return node
+}
+
+// Combinations expands the matrix into the combinations GitHub runs, in the order it runs them: every combination
+// of the dimensions with the first key varying slowest, less the excluded ones, then the included ones.
+// An include entry extends every combination it matches on the original keys, and becomes a combination of its own
+// when it matches none. Returns false when the matrix is only known at runtime.
+func (m *Matrix) Combinations() ([]MatrixCombination, bool) {
+	if m == nil || m.Expression != "" {
+		return nil, false
+	}
+
+	var combinations []MatrixCombination
+	if len(m.Dimensions) > 0 {
+		combinations = []MatrixCombination{{}}
+		for _, dimension := range m.Dimensions {
+			next := make([]MatrixCombination, 0, len(combinations)*len(dimension.Values))
+			for _, combination := range combinations {
+				for _, value := range dimension.Values {
+					matrixValue := MatrixValue{Key: dimension.Name, Value: value}
+					next = append(next, append(slices.Clone(combination), matrixValue))
+				}
+			}
+			combinations = next
+		}
+	}
+
+	combinations = slices.DeleteFunc(combinations, func(combination MatrixCombination) bool {
+		return slices.ContainsFunc(m.Exclude, func(exclude MatrixCombination) bool {
+			return matrixMatches(combination, exclude, nil)
+		})
+	})
+
+	original := make(map[string]bool, len(m.Dimensions))
+	for _, dimension := range m.Dimensions {
+		original[dimension.Name] = true
+	}
+	extended := len(combinations)
+	for _, include := range m.Include {
+		matched := false
+		for i := range combinations[:extended] {
+			if !matrixMatches(combinations[i], include, original) {
+				continue
+			}
+			matched = true
+			for _, value := range include {
+				if !original[value.Key] {
+					combinations[i] = combinations[i].set(value.Key, value.Value)
+				}
+			}
+		}
+		if !matched {
+			combinations = append(combinations, slices.Clone(include))
+		}
+	}
+	return combinations, true
+}
+
+// matrixMatches reports whether combination has the values of partial, only comparing the keys in only when set.
+func matrixMatches(combination, partial MatrixCombination, only map[string]bool) bool {
+	for _, want := range partial {
+		if only != nil && !only[want.Key] {
+			continue
+		}
+		got, ok := combination.Get(want.Key)
+		if !ok || matrixValueString(got) != matrixValueString(want.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matrixValueString formats a matrix value the way GitHub shows it in job names.
+func matrixValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// matrixExpression matches the ${{ matrix.<key> }} expressions of a job name.
+var matrixExpression = regexp.MustCompile(`\$\{\{\s*matrix\.([\w.-]+)\s*\}\}`)
+
+// IsMatrix reports whether the job runs once for every combination of a matrix.
+func (j JobDef) IsMatrix() bool {
+	return j.Strategy != nil && j.Strategy.Matrix != nil
+}
+
+// MatrixLegName is the name GitHub gives the job's leg for combination. Job names that use matrix values
+// have them filled in, other names get the values appended, like "test (ubuntu-latest, 1.22)".
+func (j JobDef) MatrixLegName(combination MatrixCombination) string {
+	if matrixExpression.MatchString(j.Name) {
+		return matrixExpression.ReplaceAllStringFunc(j.Name, func(expr string) string {
+			path := strings.Split(matrixExpression.FindStringSubmatch(expr)[1], ".")
+			value, ok := combination.Get(path[0])
+			for _, key := range path[1:] {
+				object, isObject := value.(map[string]any)
+				if !ok || !isObject {
+					break
+				}
+				value, ok = object[key]
+			}
+			return matrixValueString(value)
+		})
+	}
+	values := make([]string, len(combination))
+	for i, value := range combination {
+		values[i] = matrixValueString(value.Value)
+	}
+	return fmt.Sprintf("%s (%s)", j.Name, strings.Join(values, ", "))
+}
+
+// MatrixLegNames returns the names of the job's matrix legs, in the order GitHub runs them.
+// Returns nil when the job isn't a matrix or its matrix is only known at runtime.
+func (j JobDef) MatrixLegNames() []string {
+	if !j.IsMatrix() {
+		return nil
+	}
+	combinations, ok := j.Strategy.Matrix.Combinations()
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(combinations))
+	for _, combination := range combinations {
+		names = append(names, j.MatrixLegName(combination))
+	}
+	return names
+}
+
+// IsMatrixLeg reports whether a job named name in a run is one of the job's matrix legs, either one of
+// MatrixLegNames or, when those can't be told from the workflow, the job's name followed by its matrix values.
+func (j JobDef) IsMatrixLeg(name string) bool {
+	if !j.IsMatrix() {
+		return false
+	}
+	if slices.Contains(j.MatrixLegNames(), name) {
+		return true
+	}
+	return !matrixExpression.MatchString(j.Name) && strings.HasPrefix(name, j.Name+" (") && strings.HasSuffix(name, ")")
+}
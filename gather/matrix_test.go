@@ -0,0 +1,124 @@
+package gather
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMatrixWorkflow = `
+name: CI
+on: push
+jobs:
+  test:
+    runs-on: ${{ matrix.os }}
+    strategy:
+      fail-fast: false
+      max-parallel: 2
+      matrix:
+        os: [ubuntu-latest, windows-latest]
+        go: ["1.22", "1.23"]
+        exclude:
+          - os: windows-latest
+            go: "1.22"
+        include:
+          - os: ubuntu-latest
+            race: true
+          - os: macos-latest
+            go: "1.23"
+  lint:
+    name: Lint ${{ matrix.config.name }} on ${{ matrix.os }}
+    runs-on: ubuntu-latest
+    strategy:
+      fail-fast: ${{ github.event_name == 'push' }}
+      matrix:
+        os: [ubuntu-latest]
+        config: [{name: strict}, {name: loose}]
+  e2e:
+    needs: setup
+    runs-on: ubuntu-latest
+    strategy:
+      matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}
+  shards:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        shard: [1, 2]
+        suite: ${{ fromJSON(inputs.suites) }}
+  setup:
+    runs-on: ubuntu-latest
+`
+
+func TestMatrixLegNames(t *testing.T) {
+	t.Parallel()
+
+	def, err := ParseWorkflowDef([]byte(testMatrixWorkflow))
+	require.NoError(t, err)
+
+	test := def.Jobs["test"]
+	require.True(t, test.IsMatrix())
+	assert.False(t, test.Strategy.GetFailFast())
+	assert.Equal(t, 2, test.Strategy.MaxParallel)
+	assert.Equal(t, []string{
+		"test (ubuntu-latest, 1.22, true)",
+		"test (ubuntu-latest, 1.23, true)",
+		"test (windows-latest, 1.23)",
+		"test (macos-latest, 1.23)",
+	}, test.MatrixLegNames())
+
+	lint := def.Jobs["lint"]
+	assert.True(t, lint.Strategy.GetFailFast(), "fail-fast set with an expression should default to true")
+	assert.Equal(t, []string{
+		"Lint strict on ubuntu-latest",
+		"Lint loose on ubuntu-latest",
+	}, lint.MatrixLegNames())
+
+	for _, id := range []string{"e2e", "shards"} {
+		job := def.Jobs[id]
+		assert.True(t, job.IsMatrix(), id)
+		assert.Nil(t, job.MatrixLegNames(), "legs of %s are only known at runtime", id)
+		assert.True(t, job.IsMatrixLeg(id+" (1, unit)"), id)
+	}
+
+	setup := def.Jobs["setup"]
+	assert.False(t, setup.IsMatrix())
+	assert.Nil(t, setup.MatrixLegNames())
+	assert.False(t, setup.IsMatrixLeg("setup (1)"))
+}
+
+func TestWorkflowDefGetJobIDByMatrixLeg(t *testing.T) {
+	t.Parallel()
+
+	def, err := ParseWorkflowDef([]byte(testMatrixWorkflow))
+	require.NoError(t, err)
+
+	for name, wantID := range map[string]string{
+		"test (windows-latest, 1.23)":  "test",
+		"Lint loose on ubuntu-latest":  "lint",
+		"e2e (ubuntu-latest, 1.24)":    "e2e",
+		"test (ubuntu-latest, 1.24)":   "test",
+		"shards (2, integration)":      "shards",
+		"setup":                        "setup",
+		"Lint strict on ubuntu-latest": "lint",
+	} {
+		id, ok := def.GetJobIDByName(name)
+		require.True(t, ok, name)
+		assert.Equal(t, wantID, id, name)
+	}
+}
+
+func TestMatrixCombinationsIncludeOnly(t *testing.T) {
+	t.Parallel()
+
+	matrix := &Matrix{Include: []MatrixCombination{
+		{{Key: "target", Value: "linux"}, {Key: "arch", Value: "amd64"}},
+		{{Key: "target", Value: "darwin"}, {Key: "arch", Value: "arm64"}},
+	}}
+	combinations, ok := matrix.Combinations()
+	require.True(t, ok)
+	assert.Equal(t, matrix.Include, combinations)
+
+	_, ok = (&Matrix{Expression: "${{ fromJSON(inputs.matrix) }}"}).Combinations()
+	assert.False(t, ok)
+}
@@ -0,0 +1,224 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+// orgListConcurrency limits how many of an org's repositories have their workflow runs listed at once.
+const orgListConcurrency = 4
+
+// errRateLimitReserve stops listing a repository's workflow runs once the rate limit reserve is reached.
+var errRateLimitReserve = errors.New("rate limit reserve reached")
+
+// OrgResult summarizes gathering an org's workflow runs.
+type OrgResult struct {
+	// Repos are the org's repositories, leaving out archived and disabled ones.
+	Repos []string
+	// Runs is the number of workflow runs gathered.
+	Runs int
+	// Failed is the number of workflow runs that failed to gather.
+	Failed int
+	// Skipped is the number of workflow runs left ungathered once the rate limit reserve was reached.
+	Skipped int
+	// FailedRepos are the repositories whose workflow runs couldn't be listed.
+	FailedRepos []string
+	// SkippedRepos are the repositories whose workflow runs weren't all listed once the rate limit reserve was reached.
+	SkippedRepos []string
+	// TruncatedRepos are the repositories that had more workflow runs created in a single second than GitHub lists,
+	// so some of their runs were left out.
+	TruncatedRepos []string
+}
+
+// orgRun is a workflow run of one of an org's repositories.
+type orgRun struct {
+	repo  string
+	runID int64
+}
+
+// OrgRepositories lists the names of an org's repositories, leaving out archived and disabled ones.
+func OrgRepositories(ctx context.Context, client *GitHubClient, org string) ([]string, error) {
+	ghCtxInst, cancel := ghCtx(ctx)
+	defer cancel()
+
+	listOpts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var repos []string
+	for repo, err := range client.Rest.Repositories.ListByOrgIter(ghCtxInst, org, listOpts) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", org, err)
+		}
+		if repo.GetArchived() || repo.GetDisabled() {
+			continue
+		}
+		repos = append(repos, repo.GetName())
+	}
+	return repos, nil
+}
+
+// Org gathers the workflow runs created between since and until in every repository of an org.
+// All repositories share one pool of workers, so the org as a whole gathers as many runs at once as Range does
+// for a single repository. Busy repositories have their listings split into smaller windows, as GitHub lists at
+// most 1,000 runs per query. With WithRateLimitReserve, repositories and runs left once the reserve is reached
+// are skipped.
+// Org never waits for in-progress runs.
+func Org(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *GitHubClient,
+	org string,
+	since, until time.Time,
+	event string,
+	opts ...Option,
+) (*OrgResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("github client is nil")
+	}
+
+	opts = append(slices.Clip(opts), WithWait(false))
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	repos, err := OrgRepositories(ctx, client, org)
+	if err != nil {
+		return nil, err
+	}
+	log = log.With().Str("org", org).Logger()
+	log.Info().
+		Int("repos", len(repos)).
+		Time("since", since).
+		Time("until", until).
+		Str("event", event).
+		Msg("Gathering org workflow runs")
+
+	reporter := GetReporter(opts...)
+	reporter.Start(fmt.Sprintf("Collecting %d repositories of %s", len(repos), org))
+
+	if event == "all" {
+		event = ""
+	}
+	window := createdWindow{from: since, until: until}
+
+	result := &OrgResult{Repos: repos}
+	var (
+		gathered, failed, skipped atomic.Int32
+		reposMu                   sync.Mutex
+		runCh                     = make(chan orgRun, defaultGatherConcurrency)
+	)
+	reserveReached := func() bool {
+		remaining, ok := client.RateLimitRemaining()
+		return ok && remaining < o.rateLimitReserve
+	}
+	skipRepo := func(repo string) {
+		reposMu.Lock()
+		result.SkippedRepos = append(result.SkippedRepos, repo)
+		reposMu.Unlock()
+	}
+
+	ghCtxInst, cancel := ghCtx(ctx)
+	defer cancel()
+	eg, egCtx := errgroup.WithContext(ghCtxInst)
+
+	eg.Go(func() error {
+		defer close(runCh)
+		listGroup, listCtx := errgroup.WithContext(egCtx)
+		listGroup.SetLimit(orgListConcurrency)
+		for _, repo := range repos {
+			listGroup.Go(func() error {
+				if reserveReached() {
+					skipRepo(repo)
+					return nil
+				}
+				truncated, err := listWorkflowRunsCreated(listCtx, client, org, repo, event, window,
+					func(run *github.WorkflowRun) error {
+						// Listing pages costs requests too, so stop paging through a repo's runs at the reserve
+						if reserveReached() {
+							return errRateLimitReserve
+						}
+						select {
+						case runCh <- orgRun{repo: repo, runID: run.GetID()}:
+							return nil
+						case <-listCtx.Done():
+							return listCtx.Err()
+						}
+					},
+				)
+				switch {
+				case errors.Is(err, errRateLimitReserve):
+					skipRepo(repo)
+					return nil
+				case listCtx.Err() != nil:
+					return listCtx.Err()
+				case err != nil:
+					log.Warn().Err(err).Str("repo", repo).Msg("Failed to list workflow runs of repository")
+					reposMu.Lock()
+					result.FailedRepos = append(result.FailedRepos, repo)
+					reposMu.Unlock()
+					return nil
+				}
+				if truncated {
+					log.Warn().
+						Str("repo", repo).
+						Msg("More workflow runs were created in a single second than GitHub lists, some were left out")
+					reposMu.Lock()
+					result.TruncatedRepos = append(result.TruncatedRepos, repo)
+					reposMu.Unlock()
+				}
+				return nil
+			})
+		}
+		return listGroup.Wait()
+	})
+
+	for range defaultGatherConcurrency {
+		eg.Go(func() error {
+			for run := range runCh {
+				if reserveReached() {
+					skipped.Add(1)
+					continue
+				}
+				_, _, err := WorkflowRun(egCtx, log, client, org, run.repo, run.runID, opts...)
+				if err != nil {
+					failed.Add(1)
+					log.Error().
+						Err(err).
+						Str("repo", run.repo).
+						Int64("workflow_run_id", run.runID).
+						Msg("Failed to gather workflow run")
+					continue
+				}
+				if n := gathered.Add(1); n%50 == 0 {
+					reporter.Update(fmt.Sprintf("Collected %d workflow runs of %s", n, org), 0)
+				}
+			}
+			return nil
+		})
+	}
+
+	err = eg.Wait()
+	result.Runs, result.Failed, result.Skipped = int(gathered.Load()), int(failed.Load()), int(skipped.Load())
+	slices.Sort(result.FailedRepos)
+	slices.Sort(result.SkippedRepos)
+	slices.Sort(result.TruncatedRepos)
+	if result.Skipped > 0 || len(result.SkippedRepos) > 0 {
+		log.Warn().
+			Int("skipped", result.Skipped).
+			Strs("skipped_repos", result.SkippedRepos).
+			Int("rate_limit_reserve", o.rateLimitReserve).
+			Msg("Rate limit reserve reached, skipped the remaining repositories and workflow runs")
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to gather workflow runs of %s: %w", org, err)
+	}
+	return result, nil
+}
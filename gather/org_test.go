@@ -0,0 +1,170 @@
+package gather
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+// orgMockClient serves an org with the repos alpha and beta, each with one workflow run, and an archived repo.
+// Listing responses report remaining REST requests. Listings of busyRepo report more runs than GitHub lists for
+// every window holding its run.
+func orgMockClient(t *testing.T, log zerolog.Logger, remaining int, busyRepo string) (*GitHubClient, *[]string) {
+	t.Helper()
+
+	var (
+		mu        sync.Mutex
+		createdAt []string
+	)
+	runOf := func(repo string, id int64) *github.WorkflowRun {
+		return &github.WorkflowRun{
+			ID:         new(id),
+			Name:       new("CI"),
+			Status:     new("completed"),
+			Conclusion: new("success"),
+			CreatedAt:  new(github.Timestamp{Time: time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)}),
+			Actor:      &github.User{Login: new("octocat")},
+			Repository: &github.Repository{Name: new(repo), Owner: &github.User{Login: new("acme")}},
+		}
+	}
+	runIDs := map[string]int64{"alpha": 1, "beta": 2}
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	}
+
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetOrgsReposByOrg,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				rateLimited(w)
+				_, _ = w.Write(mock.MustMarshal([]*github.Repository{
+					{Name: new("alpha")},
+					{Name: new("beta")},
+					{Name: new("old"), Archived: new(true)},
+				}))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				repo := strings.Split(r.URL.Path, "/")[3]
+				mu.Lock()
+				createdAt = append(createdAt, r.URL.Query().Get("created"))
+				mu.Unlock()
+				rateLimited(w)
+				run := runOf(repo, runIDs[repo])
+				list := &github.WorkflowRuns{TotalCount: new(1), WorkflowRuns: []*github.WorkflowRun{run}}
+				if repo == busyRepo {
+					list = &github.WorkflowRuns{TotalCount: new(0)}
+					if createdIn(r.URL.Query().Get("created"), run) {
+						list.TotalCount = new(2 * maxFilteredWorkflowRuns)
+						list.WorkflowRuns = []*github.WorkflowRun{run}
+					}
+				}
+				_, _ = w.Write(mock.MustMarshal(list))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, err := strconv.ParseInt(path.Base(r.URL.Path), 10, 64)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				_, _ = w.Write(mock.MustMarshal(runOf(strings.Split(r.URL.Path, "/")[3], id)))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsJobsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.Jobs{TotalCount: new(0), Jobs: []*github.WorkflowJob{}}))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsArtifactsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.ArtifactList{TotalCount: new(int64(0))}))
+			}),
+		),
+	)
+
+	client, err := NewGitHubClient(log, "mock-token", mockedHTTPClient.Transport)
+	require.NoError(t, err)
+	return client, &createdAt
+}
+
+func TestOrg(t *testing.T) {
+	t.Parallel()
+
+	log, testDataDir := testhelpers.Setup(t)
+	client, createdAt := orgMockClient(t, log, 4000, "")
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	result, err := Org(t.Context(), log, client, "acme", since, until, "all",
+		CustomDataFolder(testDataDir), WithoutCost(), WithRateLimitReserve(100))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "beta"}, result.Repos, "archived repos should be left out")
+	assert.Equal(t, 2, result.Runs)
+	assert.Zero(t, result.Failed)
+	assert.Zero(t, result.Skipped)
+	assert.Empty(t, result.SkippedRepos)
+	assert.Empty(t, result.TruncatedRepos)
+	created := "2025-06-01T00:00:00Z..2025-07-01T00:00:00Z"
+	assert.Equal(t, []string{created, created}, *createdAt, "every repo should be listed for the same range")
+
+	remaining, ok := client.RateLimitRemaining()
+	require.True(t, ok)
+	assert.Equal(t, 4000, remaining)
+
+	runs, err := StoredWorkflowRuns(NewFileStore(testDataDir), "acme", "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+}
+
+func TestOrgRateLimitReserve(t *testing.T) {
+	t.Parallel()
+
+	log, testDataDir := testhelpers.Setup(t)
+	client, createdAt := orgMockClient(t, log, 50, "")
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	result, err := Org(t.Context(), log, client, "acme", since, since.AddDate(0, 1, 0), "",
+		CustomDataFolder(testDataDir), WithoutCost(), WithRateLimitReserve(100))
+	require.NoError(t, err)
+	assert.Zero(t, result.Runs)
+	assert.Equal(t, []string{"alpha", "beta"}, result.SkippedRepos, "repos past the reserve should be skipped")
+	assert.Empty(t, *createdAt, "skipped repos shouldn't have their runs listed")
+}
+
+func TestOrgSplitsCappedListings(t *testing.T) {
+	t.Parallel()
+
+	log, testDataDir := testhelpers.Setup(t)
+	client, createdAt := orgMockClient(t, log, 4000, "alpha")
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	result, err := Org(t.Context(), log, client, "acme", since, since.AddDate(0, 1, 0), "",
+		CustomDataFolder(testDataDir), WithoutCost(), WithRateLimitReserve(100))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Runs, "the busy repo's run should still be gathered")
+	assert.Empty(t, result.FailedRepos)
+	assert.Equal(t, []string{"alpha"}, result.TruncatedRepos,
+		"a repo still capped at a single second should be reported as truncated")
+	assert.Contains(t, *createdAt, "2025-06-02T12:00:00Z..2025-06-02T12:00:00Z",
+		"the busy repo's listing should be split down to the second of its run")
+}
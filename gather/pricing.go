@@ -0,0 +1,277 @@
+package gather
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"gopkg.in/yaml.v3"
+)
+
+// PricingCatalog overrides and extends the built-in runner rates, e.g. with negotiated rates
+// or internal chargeback for self-hosted runners.
+//
+//	version: acme-2026-07
+//	rates:
+//	  - runner: UBUNTU_4_CORE
+//	    per_minute: 0.012
+//	    effective_from: 2026-07-01
+//	  - runner_group: build-farm
+//	    per_minute: 0.004
+//	runner_classes:
+//	  - name: gpu-box
+//	    labels: [self-hosted, a100]
+//	    hourly: 3.20
+//	    idle_overhead_percent: 25
+//	discounts:
+//	  - org: acme
+//	    percent: 15
+type PricingCatalog struct {
+	// Version identifies the catalog and is recorded on every job it priced.
+	Version       string            `yaml:"version"`
+	Rates         []PricingRate     `yaml:"rates"`
+	RunnerClasses []RunnerClass     `yaml:"runner_classes"`
+	Discounts     []PricingDiscount `yaml:"discounts"`
+}
+
+// PricingRate is the per-minute price of the runners it matches by exactly one of Runner, Label or RunnerGroup.
+// When several rates match a job, runner groups win over labels, labels over runners,
+// and earlier rates in the catalog over later ones.
+type PricingRate struct {
+	// Runner is a GitHub billing SKU, e.g. UBUNTU_4_CORE, or a runs-on runner key, e.g. 4cpu-linux-x64.
+	Runner string `yaml:"runner"`
+	// Label is one of the labels the job asked for with runs-on, e.g. gpu for a self-hosted runner.
+	Label string `yaml:"label"`
+	// RunnerGroup is the runner group the job ran in.
+	RunnerGroup string `yaml:"runner_group"`
+	// PerMinute is in USD.
+	PerMinute float64 `yaml:"per_minute"`
+	// EffectiveFrom and EffectiveUntil bound when jobs start for the rate to apply.
+	// EffectiveUntil is exclusive, and zero leaves a bound open.
+	EffectiveFrom  time.Time `yaml:"effective_from"`
+	EffectiveUntil time.Time `yaml:"effective_until"`
+}
+
+// RunnerClass estimates the cost of the self-hosted runners it matches from what they cost to run.
+// A job matches the first class whose labels and runner name pattern it has.
+type RunnerClass struct {
+	Name string `yaml:"name"`
+	// Labels must all be among the labels the job asked for with runs-on.
+	Labels []string `yaml:"labels"`
+	// RunnerName is a glob pattern, e.g. gpu-*, for the name of the runner the job ran on.
+	RunnerName string `yaml:"runner_name"`
+	// Hourly is the cost of a runner for an hour in USD.
+	Hourly float64 `yaml:"hourly"`
+	// IdleOverheadPercent is added to every job for the time runners sit idle between jobs.
+	IdleOverheadPercent float64 `yaml:"idle_overhead_percent"`
+	// BillingGranularity rounds job durations up to a multiple of it, one minute when unset.
+	BillingGranularity time.Duration `yaml:"billing_granularity"`
+}
+
+// PricingDiscount takes a percentage off the cost of every job of an org that is priced from the catalog's rates.
+// Runner class estimates are left as they are, since they're what the runners cost rather than a negotiated price.
+type PricingDiscount struct {
+	Org            string    `yaml:"org"`
+	Percent        float64   `yaml:"percent"`
+	EffectiveFrom  time.Time `yaml:"effective_from"`
+	EffectiveUntil time.Time `yaml:"effective_until"`
+}
+
+// LoadPricingCatalog reads a pricing catalog from a YAML file.
+func LoadPricingCatalog(path string) (*PricingCatalog, error) {
+	//nolint:gosec // user specified pricing catalog
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing catalog %q: %w", path, err)
+	}
+	catalog := &PricingCatalog{}
+	if err := yaml.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse pricing catalog %q: %w", path, err)
+	}
+	if err := catalog.validate(); err != nil {
+		return nil, fmt.Errorf("invalid pricing catalog %q: %w", path, err)
+	}
+	return catalog, nil
+}
+
+func (c *PricingCatalog) validate() error {
+	if c.Version == "" {
+		return errors.New("version is required")
+	}
+	for i, rate := range c.Rates {
+		matchers := 0
+		for _, matcher := range []string{rate.Runner, rate.Label, rate.RunnerGroup} {
+			if matcher != "" {
+				matchers++
+			}
+		}
+		if matchers != 1 {
+			return fmt.Errorf("rate %d must set exactly one of runner, label or runner_group", i+1)
+		}
+		if rate.PerMinute < 0 {
+			return fmt.Errorf("rate %d has a negative per_minute price", i+1)
+		}
+	}
+	for i, class := range c.RunnerClasses {
+		if class.Name == "" {
+			return fmt.Errorf("runner class %d has no name", i+1)
+		}
+		if len(class.Labels) == 0 && class.RunnerName == "" {
+			return fmt.Errorf("runner class %q must set labels or runner_name", class.Name)
+		}
+		if _, err := path.Match(class.RunnerName, ""); err != nil {
+			return fmt.Errorf("runner class %q has an invalid runner_name pattern: %w", class.Name, err)
+		}
+		if class.Hourly < 0 || class.IdleOverheadPercent < 0 || class.BillingGranularity < 0 {
+			return fmt.Errorf(
+				"runner class %q has a negative hourly, idle_overhead_percent or billing_granularity",
+				class.Name,
+			)
+		}
+	}
+	for i, discount := range c.Discounts {
+		if discount.Org == "" {
+			return fmt.Errorf("discount %d has no org", i+1)
+		}
+		if discount.Percent < 0 || discount.Percent > 100 {
+			return fmt.Errorf("discount %d must be between 0 and 100 percent", i+1)
+		}
+	}
+	return nil
+}
+
+// GetVersion returns the version of the catalog, or an empty string without one.
+func (c *PricingCatalog) GetVersion() string {
+	if c == nil {
+		return ""
+	}
+	return c.Version
+}
+
+// effective reports whether at falls within [from, until), leaving zero bounds open.
+func effective(from, until, at time.Time) bool {
+	return (from.IsZero() || !at.Before(from)) && (until.IsZero() || at.Before(until))
+}
+
+// rate returns the first rate matching and in effect at a time, in tenths of a cent per minute.
+func (c *PricingCatalog) rate(match func(PricingRate) bool, at time.Time) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	for _, rate := range c.Rates {
+		if match(rate) && effective(rate.EffectiveFrom, rate.EffectiveUntil, at) {
+			return rate.PerMinute * 1000, true
+		}
+	}
+	return 0, false
+}
+
+// runnerRate returns the catalog's rate for a GitHub billing SKU or runs-on runner key.
+func (c *PricingCatalog) runnerRate(runner string, at time.Time) (float64, bool) {
+	return c.rate(func(r PricingRate) bool { return r.Runner != "" && r.Runner == runner }, at)
+}
+
+// groupOrLabelRate returns the catalog's rate for the job's runner group or one of its labels,
+// along with the runner group or label it matched.
+func (c *PricingCatalog) groupOrLabelRate(job *github.WorkflowJob) (rate float64, matched string, ok bool) {
+	at := job.GetStartedAt().Time
+	if group := job.GetRunnerGroupName(); group != "" {
+		if rate, ok := c.rate(func(r PricingRate) bool { return strings.EqualFold(r.RunnerGroup, group) }, at); ok {
+			return rate, group, true
+		}
+	}
+	for _, label := range job.Labels {
+		if rate, ok := c.rate(func(r PricingRate) bool { return strings.EqualFold(r.Label, label) }, at); ok {
+			return rate, label, true
+		}
+	}
+	return 0, "", false
+}
+
+// runnerClass returns the first runner class matching the job.
+func (c *PricingCatalog) runnerClass(job *github.WorkflowJob) (*RunnerClass, bool) {
+	if c == nil {
+		return nil, false
+	}
+	for i := range c.RunnerClasses {
+		if c.RunnerClasses[i].matches(job) {
+			return &c.RunnerClasses[i], true
+		}
+	}
+	return nil, false
+}
+
+func (r *RunnerClass) matches(job *github.WorkflowJob) bool {
+	if r.RunnerName != "" {
+		if ok, _ := path.Match(r.RunnerName, job.GetRunnerName()); !ok {
+			return false
+		}
+	}
+	for _, want := range r.Labels {
+		if !slices.ContainsFunc(job.Labels, func(label string) bool { return strings.EqualFold(label, want) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// cost estimates the cost of a job that ran on the class for a duration, in tenths of a cent.
+func (r *RunnerClass) cost(duration time.Duration) int64 {
+	granularity := r.BillingGranularity
+	if granularity <= 0 {
+		granularity = time.Minute
+	}
+	billed := (duration + granularity - 1) / granularity * granularity
+	return int64(math.Round(billed.Hours() * r.Hourly * 1000 * (1 + r.IdleOverheadPercent/100)))
+}
+
+// discounted applies the discount of the first of the org's discounts in effect at a time.
+func (c *PricingCatalog) discounted(org string, at time.Time, cost int64) int64 {
+	if c == nil {
+		return cost
+	}
+	for _, discount := range c.Discounts {
+		if strings.EqualFold(discount.Org, org) && effective(discount.EffectiveFrom, discount.EffectiveUntil, at) {
+			return int64(math.Round(float64(cost) * (1 - discount.Percent/100)))
+		}
+	}
+	return cost
+}
+
+// billedJobRate returns the per-minute rate of a job GitHub billed under a runner SKU,
+// from the catalog's runner group, label or runner rates before the built-in ones.
+// fromCatalog reports whether the catalog had a rate for the job.
+func (c *PricingCatalog) billedJobRate(job *github.WorkflowJob, runner string) (rate float64, fromCatalog, ok bool) {
+	if rate, _, ok := c.groupOrLabelRate(job); ok {
+		return rate, true, true
+	}
+	if rate, ok := c.runnerRate(runner, job.GetStartedAt().Time); ok {
+		return rate, true, true
+	}
+	builtIn, ok := rateByRunner[runner]
+	return float64(builtIn), false, ok
+}
+
+// runsOnCost estimates the cost of a runs-on job like calculateRunsOnCost,
+// at the catalog's rate for its runner key when there is one. fromCatalog reports whether there was.
+func (c *PricingCatalog) runsOnCost(
+	job *github.WorkflowJob,
+	duration time.Duration,
+) (cost int64, isEstimate, fromCatalog bool) {
+	key, ok := parseRunsOnLabel(job.Labels)
+	if !ok {
+		return 0, false, false
+	}
+	rate, ok := c.runnerRate(key, job.GetStartedAt().Time)
+	if !ok || duration <= 0 {
+		cost, isEstimate = calculateRunsOnCost(job.Labels, duration)
+		return cost, isEstimate, false
+	}
+	return runsOnCostAtRate(rate, duration), true, true
+}
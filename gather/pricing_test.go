@@ -0,0 +1,258 @@
+package gather
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+const testPricingCatalog = `
+version: acme-2026-07
+rates:
+  - runner: UBUNTU_4_CORE
+    per_minute: 0.012
+    effective_from: 2026-07-01
+  - runner: 2cpu-linux-x64
+    per_minute: 0.002
+  - label: gpu
+    per_minute: 0.25
+  - runner_group: build-farm
+    per_minute: 0.004
+runner_classes:
+  - name: gpu-box
+    labels: [self-hosted, a100]
+    hourly: 3.00
+    idle_overhead_percent: 25
+    billing_granularity: 5m
+  - name: build-box
+    runner_name: build-*
+    hourly: 0.60
+discounts:
+  - org: acme
+    percent: 10
+    effective_until: 2027-01-01
+`
+
+func pricingJob(id int64, started time.Time, duration time.Duration, labels ...string) *github.WorkflowJob {
+	return &github.WorkflowJob{
+		ID:          new(id),
+		Status:      new("completed"),
+		Conclusion:  new("success"),
+		StartedAt:   &github.Timestamp{Time: started},
+		CompletedAt: &github.Timestamp{Time: started.Add(duration)},
+		Labels:      labels,
+	}
+}
+
+func loadTestPricingCatalog(t *testing.T) *PricingCatalog {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPricingCatalog), 0o600))
+	catalog, err := LoadPricingCatalog(path)
+	require.NoError(t, err)
+	return catalog
+}
+
+func TestLoadPricingCatalog(t *testing.T) {
+	t.Parallel()
+
+	catalog := loadTestPricingCatalog(t)
+	assert.Equal(t, "acme-2026-07", catalog.GetVersion())
+	require.Len(t, catalog.Rates, 4)
+	require.Len(t, catalog.RunnerClasses, 2)
+	assert.Equal(t, 5*time.Minute, catalog.RunnerClasses[0].BillingGranularity)
+	assert.Equal(t, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), catalog.Rates[0].EffectiveFrom)
+	assert.InDelta(t, 0.25, catalog.Rates[2].PerMinute, 0.0001)
+	require.Len(t, catalog.Discounts, 1)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), catalog.Discounts[0].EffectiveUntil)
+
+	invalid := map[string]string{
+		"no version":            "rates: []",
+		"no matcher":            "version: v1\nrates:\n  - per_minute: 0.01",
+		"two matchers":          "version: v1\nrates:\n  - runner: UBUNTU\n    label: gpu\n    per_minute: 0.01",
+		"negative rate":         "version: v1\nrates:\n  - runner: UBUNTU\n    per_minute: -1",
+		"no org":                "version: v1\ndiscounts:\n  - percent: 10",
+		"too much off":          "version: v1\ndiscounts:\n  - org: acme\n    percent: 110",
+		"unnamed class":         "version: v1\nrunner_classes:\n  - labels: [gpu]",
+		"class matches nothing": "version: v1\nrunner_classes:\n  - name: gpu",
+		"bad pattern":           "version: v1\nrunner_classes:\n  - name: gpu\n    runner_name: '[gpu'",
+		"negative hourly":       "version: v1\nrunner_classes:\n  - name: gpu\n    labels: [gpu]\n    hourly: -1",
+		"malformed":             "version: [",
+	}
+	for name, content := range invalid {
+		path := filepath.Join(t.TempDir(), "pricing.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := LoadPricingCatalog(path)
+		require.Error(t, err, name)
+	}
+
+	_, err := LoadPricingCatalog(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestCalculateJobCostAndRunnerWithPricingCatalog(t *testing.T) {
+	t.Parallel()
+
+	log, _ := testhelpers.Setup(t)
+	catalog := loadTestPricingCatalog(t)
+	july := time.Date(2026, 7, 15, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	billingIndex := map[int64]jobBillingEntry{
+		1:  {runner: "UBUNTU_4_CORE", minutes: 10},
+		2:  {runner: "UBUNTU_4_CORE", minutes: 10},
+		3:  {runner: "UBUNTU", minutes: 10},
+		10: {runner: "UBUNTU_4_CORE", minutes: 10},
+	}
+
+	selfHosted := pricingJob(5, july, 90*time.Second, "self-hosted")
+	selfHosted.RunnerGroupName = new("build-farm")
+	buildBox := pricingJob(9, july, 10*time.Second, "self-hosted")
+	buildBox.RunnerName = new("build-17")
+
+	tests := []struct {
+		name         string
+		job          *github.WorkflowJob
+		owner        string
+		catalog      *PricingCatalog
+		wantRunner   string
+		wantCost     int64
+		wantEstimate bool
+		wantVersion  string
+	}{
+		{
+			name:        "negotiated rate with discount",
+			job:         pricingJob(1, july, 10*time.Minute),
+			owner:       "acme",
+			catalog:     catalog,
+			wantRunner:  "UBUNTU_4_CORE",
+			wantCost:    108, // 10 minutes at $0.012, 10% off
+			wantVersion: "acme-2026-07",
+		},
+		{
+			name:       "before the negotiated rate",
+			job:        pricingJob(2, june, 10*time.Minute),
+			owner:      "other",
+			catalog:    catalog,
+			wantRunner: "UBUNTU_4_CORE",
+			wantCost:   160,
+		},
+		{
+			name:       "built-in rate isn't discounted",
+			job:        pricingJob(10, june, 10*time.Minute),
+			owner:      "acme",
+			catalog:    catalog,
+			wantRunner: "UBUNTU_4_CORE",
+			wantCost:   160, // the catalog's rate starts in July, so acme's discount doesn't apply
+		},
+		{
+			name:       "built-in rate without a catalog",
+			job:        pricingJob(3, july, 10*time.Minute),
+			owner:      "acme",
+			wantRunner: "UBUNTU",
+			wantCost:   80,
+		},
+		{
+			name:        "self-hosted label",
+			job:         pricingJob(4, july, 2*time.Minute, "self-hosted", "GPU"),
+			owner:       "other",
+			catalog:     catalog,
+			wantRunner:  "GPU",
+			wantCost:    500,
+			wantVersion: "acme-2026-07",
+		},
+		{
+			name:        "self-hosted runner group",
+			job:         selfHosted,
+			owner:       "other",
+			catalog:     catalog,
+			wantRunner:  "build-farm",
+			wantCost:    8, // billed per started minute
+			wantVersion: "acme-2026-07",
+		},
+		{
+			name:         "runs-on runner key",
+			job:          pricingJob(6, july, 10*time.Minute, "2cpu-linux-x64"),
+			owner:        "other",
+			catalog:      catalog,
+			wantRunner:   "runs-on:2cpu-linux-x64",
+			wantCost:     20,
+			wantEstimate: true,
+			wantVersion:  "acme-2026-07",
+		},
+		{
+			name:         "built-in runs-on rate",
+			job:          pricingJob(11, july, 10*time.Minute, "4cpu-linux-x64"),
+			owner:        "acme",
+			catalog:      catalog,
+			wantRunner:   "runs-on:4cpu-linux-x64",
+			wantCost:     170,
+			wantEstimate: true,
+		},
+		{
+			name:         "runner class by labels",
+			job:          pricingJob(8, july, 7*time.Minute, "self-hosted", "linux", "a100"),
+			owner:        "other",
+			catalog:      catalog,
+			wantRunner:   "gpu-box",
+			wantCost:     625, // 10 minutes at $3.00 an hour, 25% idle overhead
+			wantEstimate: true,
+			wantVersion:  "acme-2026-07",
+		},
+		{
+			name:         "runner class isn't discounted",
+			job:          pricingJob(12, july, 7*time.Minute, "self-hosted", "linux", "a100"),
+			owner:        "acme",
+			catalog:      catalog,
+			wantRunner:   "gpu-box",
+			wantCost:     625,
+			wantEstimate: true,
+			wantVersion:  "acme-2026-07",
+		},
+		{
+			name:         "runner class by runner name",
+			job:          buildBox,
+			owner:        "other",
+			catalog:      catalog,
+			wantRunner:   "build-box",
+			wantCost:     10, // a minute at $0.60 an hour
+			wantEstimate: true,
+			wantVersion:  "acme-2026-07",
+		},
+		{
+			name:       "unpriced self-hosted runner",
+			job:        pricingJob(7, july, 10*time.Minute, "self-hosted"),
+			owner:      "acme",
+			catalog:    catalog,
+			wantRunner: "Free",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner, cost, estimate, version := calculateJobCostAndRunner(
+				log,
+				tt.job,
+				tt.owner,
+				true,
+				true,
+				billingIndex,
+				&sync.Map{},
+				tt.catalog,
+			)
+			assert.Equal(t, tt.wantRunner, runner)
+			assert.Equal(t, tt.wantCost, cost)
+			assert.Equal(t, tt.wantEstimate, estimate)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+}
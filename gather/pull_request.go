@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"slices"
 	"sort"
 	"sync"
@@ -55,9 +53,13 @@ func tryLoadPullRequestFromCache(
 	log zerolog.Logger,
 	client *GitHubClient,
 	options *options,
-	cacheKey, targetFile string,
+	store Store,
+	owner, repo string,
+	pullRequestNumber int,
+	cacheKey string,
 ) (*PullRequestData, bool) {
-	if options.ForceUpdate || !cacheFileExists(targetFile) {
+	id := fmt.Sprint(pullRequestNumber)
+	if options.ForceUpdate || !storeHas(store, owner, repo, PullRequestsDataDir, id) {
 		return nil, false
 	}
 	if !options.SkipMemoryCache {
@@ -68,13 +70,13 @@ func tryLoadPullRequestFromCache(
 			}
 		}
 	}
-	prData, loadErr := readJSONFile[*PullRequestData](targetFile)
-	if loadErr != nil {
+	var prData *PullRequestData
+	if loadErr := store.Load(owner, repo, PullRequestsDataDir, id, &prData); loadErr != nil {
 		log.Warn().
 			Err(loadErr).
-			Str("target_file", targetFile).
+			Str("target_file", cacheKey).
 			Msg("Corrupted local cache file encountered; re-fetching from GitHub")
-		_ = os.Remove(targetFile)
+		_ = store.Delete(owner, repo, PullRequestsDataDir, id)
 		return nil, false
 	}
 	if client == nil || !prData.IsInProgress() {
@@ -98,25 +100,23 @@ func PullRequest(
 		opt(options)
 	}
 
+	store, err := options.getStore()
+	if err != nil {
+		return nil, err
+	}
 	var (
 		pullRequestData = &PullRequestData{}
-		targetDir       = filepath.Join(options.DataDir, owner, repo, PullRequestsDataDir)
-		targetFile      = filepath.Join(targetDir, fmt.Sprintf("%d.json", pullRequestNumber))
+		cacheKey        = store.Location(owner, repo, PullRequestsDataDir, fmt.Sprint(pullRequestNumber))
 	)
 
 	log = log.With().
 		Int("pull_request_number", pullRequestNumber).
 		Logger()
 
-	err := ensureDataDir(targetDir, PullRequestsDataDir)
-	if err != nil {
-		return nil, err
-	}
-
 	startTime := time.Now()
-	cacheKey := targetFile
 
-	if cached, ok := tryLoadPullRequestFromCache(log, client, options, cacheKey, targetFile); ok {
+	cached, ok := tryLoadPullRequestFromCache(log, client, options, store, owner, repo, pullRequestNumber, cacheKey)
+	if ok {
 		log.Debug().
 			Str("duration", time.Since(startTime).String()).
 			Str("source", "cache").
@@ -125,7 +125,16 @@ func PullRequest(
 	}
 
 	val, err := pullRequestGroup.Do(cacheKey, func() (any, error) {
-		if cached, ok := tryLoadPullRequestFromCache(log, client, options, cacheKey, targetFile); ok {
+		if cached, ok := tryLoadPullRequestFromCache(
+			log,
+			client,
+			options,
+			store,
+			owner,
+			repo,
+			pullRequestNumber,
+			cacheKey,
+		); ok {
 			return cached, nil
 		}
 
@@ -186,22 +195,16 @@ func PullRequest(
 			)
 		}
 
-		if writeErr := writeJSONFile(targetFile, pullRequestData); writeErr != nil {
+		saveErr := store.Save(owner, repo, PullRequestsDataDir, fmt.Sprint(pullRequestNumber), pullRequestData)
+		if saveErr != nil {
 			return nil, fmt.Errorf(
 				"failed to write pull request data to file for pull request %d: %w",
 				pullRequestNumber,
-				writeErr,
+				saveErr,
 			)
 		}
 
-		_ = AppendManifestRecord(options.DataDir, owner, repo, ManifestRecord{
-			Type:      "pull_request",
-			ID:        fmt.Sprint(pullRequestNumber),
-			Name:      pullRequestData.GetTitle(),
-			State:     pullRequestData.GetState(),
-			Actor:     pullRequestData.GetUser().GetLogin(),
-			CreatedAt: pullRequestData.GetCreatedAt().Time,
-		})
+		_ = store.AppendManifestRecord(owner, repo, pullRequestManifestRecord(pullRequestNumber, pullRequestData))
 
 		pullRequestCache.Store(cacheKey, pullRequestData)
 		log.Debug().
@@ -189,14 +189,18 @@ func calculateRunsOnCost(labels []string, duration time.Duration) (int64, bool)
 		return 0, false
 	}
 
+	return runsOnCostAtRate(float64(rate), duration), true
+}
+
+// runsOnCostAtRate computes the cost of a runs-on runner at a per-minute rate in tenths-of-cent.
+func runsOnCostAtRate(rate float64, duration time.Duration) int64 {
 	// Per-second billing: ceil(duration_seconds / 60) * rate
 	durationMinutes := duration.Seconds() / 60.0
-	cost := int64(math.Ceil(durationMinutes * float64(rate)))
+	cost := int64(math.Ceil(durationMinutes * rate))
 	if cost == 0 {
-		cost = rate
+		cost = int64(math.Ceil(rate))
 	}
-
-	return cost, true
+	return cost
 }
 
 // runsOnRunnerName returns a human-readable name for the runs-on runner.
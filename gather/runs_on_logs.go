@@ -59,18 +59,19 @@ func CleanLog(raw string) string {
 	return strings.Join(lines, "\n")
 }
 
-// GetCleanJobLogs fetches and cleans logs for a job ID from local disk cache or GitHub.
+// GetCleanJobLogs fetches and cleans logs for a job ID from the store's data dir or GitHub.
 func GetCleanJobLogs(
 	ctx context.Context,
 	_ zerolog.Logger,
 	client *GitHubClient,
 	owner, repo string,
 	jobID int64,
-	dataDir string,
+	store Store,
 ) (string, error) {
+	dataDir := store.DataDir()
 	if owner == "" || repo == "" {
 		if autoOwner, autoRepo, _, autoErr := FindOwnerRepoForJob(
-			dataDir,
+			store,
 			jobID,
 		); autoErr == nil && autoOwner != "" &&
 			autoRepo != "" {
@@ -79,7 +80,7 @@ func GetCleanJobLogs(
 		}
 	}
 
-	wfID, err := FindWorkflowRunIDForJob(dataDir, owner, repo, jobID)
+	wfID, err := FindWorkflowRunIDForJob(store, owner, repo, jobID)
 	if err == nil {
 		jobLogPath := filepath.Join(dataDir, owner, repo, "logs", fmt.Sprintf("%d", wfID), fmt.Sprintf("%d.log", jobID))
 		if cacheFileExists(jobLogPath) {
@@ -37,7 +37,7 @@ func TestGetCleanJobLogs_FromDisk(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(wfDir, "100.json"), []byte(wfData), 0o600))
 
 	log, _ := testhelpers.Setup(t)
-	cleaned, err := GetCleanJobLogs(t.Context(), log, nil, "", "", 500, dataDir)
+	cleaned, err := GetCleanJobLogs(t.Context(), log, nil, "", "", 500, NewFileStore(dataDir))
 	require.NoError(t, err)
 	assert.NotContains(t, cleaned, "\x1b")
 	assert.Contains(t, cleaned, "2026-08-03T19:57:55.123456Z Error!")
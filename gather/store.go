@@ -0,0 +1,221 @@
+package gather
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Supported Store implementations, selected with the `store` config key.
+const (
+	// StoreAuto picks SQLiteStore when the data dir already holds a SQLite database, FileStore otherwise.
+	StoreAuto = ""
+	// StoreFile keeps each entity as a JSON file under the data dir, with a manifest.jsonl per repo.
+	StoreFile = "file"
+	// StoreSQLite keeps entities and manifests in a single embedded SQLite database inside the data dir.
+	StoreSQLite = "sqlite"
+)
+
+// StoreEntry identifies a single entity persisted in a Store.
+type StoreEntry struct {
+	Owner     string
+	Repo      string
+	Category  string // WorkflowRunsDataDir, CommitsDataDir or PullRequestsDataDir
+	ID        string
+	UpdatedAt time.Time
+}
+
+// Store persists gathered GitHub data and the per-repo manifest index.
+// Raw job logs and other artifacts always live on disk under DataDir, regardless of the Store.
+// Load, Delete and ModTime return an error wrapping os.ErrNotExist when the entity is missing.
+type Store interface {
+	// Load decodes the stored entity into v.
+	Load(owner, repo, category, id string, v any) error
+	// Save stores v as the entity, replacing any previous version.
+	Save(owner, repo, category, id string, v any) error
+	// Delete removes the entity, e.g. when it is found to be corrupted.
+	Delete(owner, repo, category, id string) error
+	// ModTime returns when the entity was last saved.
+	ModTime(owner, repo, category, id string) (time.Time, error)
+	// Location describes where the entity lives, for logs and cache keys.
+	Location(owner, repo, category, id string) string
+	// Entries lists every stored workflow run, commit and pull request, ordered by owner, repo, category and ID.
+	// Bookkeeping categories such as SyncDataDir are not listed.
+	Entries() ([]StoreEntry, error)
+	// Repos lists the "owner/repo" pairs that have entities Entries lists, sorted.
+	Repos() ([]string, error)
+	// IDs lists the IDs stored in owner/repo's category, sorted, including bookkeeping categories.
+	IDs(owner, repo, category string) ([]string, error)
+	// FindWorkflowRunForJob finds the stored workflow run containing jobID.
+	// Empty owner and repo search across all repos.
+	FindWorkflowRunForJob(owner, repo string, jobID int64) (StoreEntry, error)
+	// AppendManifestRecord adds or replaces rec in the owner/repo manifest.
+	AppendManifestRecord(owner, repo string, rec ManifestRecord) error
+	// LoadManifest returns the owner/repo manifest, deduplicated by type and ID.
+	LoadManifest(owner, repo string) ([]ManifestRecord, error)
+	// ResetManifest drops all records from the owner/repo manifest.
+	ResetManifest(owner, repo string) error
+	// DataDir returns the directory the Store is rooted in.
+	DataDir() string
+	// Close releases any resources held by the Store.
+	Close() error
+}
+
+// listedCategories are the categories Entries and Repos list, leaving out bookkeeping such as SyncDataDir.
+var listedCategories = []string{WorkflowRunsDataDir, CommitsDataDir, PullRequestsDataDir}
+
+// OpenStore opens a Store of the given kind rooted at dataDir.
+// Pass it to everything that reads or writes gathered data, e.g. with WithStore, and Close it when done.
+func OpenStore(kind, dataDir string) (Store, error) {
+	if kind == StoreAuto {
+		kind = StoreFile
+		if cacheFileExists(SQLitePath(dataDir)) {
+			kind = StoreSQLite
+		}
+	}
+
+	var (
+		store Store
+		err   error
+	)
+	switch kind {
+	case StoreFile:
+		store = NewFileStore(dataDir)
+	case StoreSQLite:
+		store, err = NewSQLiteStore(dataDir)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown store '%s', expected '%s' or '%s'", kind, StoreFile, StoreSQLite)
+	}
+	return store, nil
+}
+
+// MigrateStore copies every entity, manifest record, and each repo's sync state and workflow file versions from
+// src into dst.
+// It returns the number of entities copied.
+func MigrateStore(src, dst Store) (int, error) {
+	entries, err := src.Entries()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list source entries: %w", err)
+	}
+
+	migrated := 0
+	repos := make(map[[2]string]bool)
+	for _, entry := range entries {
+		var raw rawEntity
+		if err := src.Load(entry.Owner, entry.Repo, entry.Category, entry.ID, &raw); err != nil {
+			return migrated, fmt.Errorf(
+				"failed to load %s/%s %s %s: %w", entry.Owner, entry.Repo, entry.Category, entry.ID, err,
+			)
+		}
+		if err := dst.Save(entry.Owner, entry.Repo, entry.Category, entry.ID, raw); err != nil {
+			return migrated, fmt.Errorf(
+				"failed to save %s/%s %s %s: %w", entry.Owner, entry.Repo, entry.Category, entry.ID, err,
+			)
+		}
+		repos[[2]string{entry.Owner, entry.Repo}] = true
+		migrated++
+	}
+
+	for repo := range repos {
+		records, err := src.LoadManifest(repo[0], repo[1])
+		if err != nil {
+			return migrated, fmt.Errorf("failed to load manifest for %s/%s: %w", repo[0], repo[1], err)
+		}
+		for _, rec := range records {
+			if err := dst.AppendManifestRecord(repo[0], repo[1], rec); err != nil {
+				return migrated, fmt.Errorf("failed to migrate manifest for %s/%s: %w", repo[0], repo[1], err)
+			}
+		}
+		if err := migrateBookkeeping(src, dst, repo[0], repo[1], SyncDataDir, syncStateID); err != nil {
+			return migrated, err
+		}
+		shas, err := src.IDs(repo[0], repo[1], WorkflowDefsDataDir)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to list workflow file versions of %s/%s: %w", repo[0], repo[1], err)
+		}
+		for _, sha := range shas {
+			if err := migrateBookkeeping(src, dst, repo[0], repo[1], WorkflowDefsDataDir, sha); err != nil {
+				return migrated, err
+			}
+		}
+	}
+
+	return migrated, nil
+}
+
+// migrateBookkeeping copies an entity Entries doesn't list, if src has it.
+func migrateBookkeeping(src, dst Store, owner, repo, category, id string) error {
+	var raw rawEntity
+	if err := src.Load(owner, repo, category, id, &raw); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to load %s/%s %s %s: %w", owner, repo, category, id, err)
+	}
+	if err := dst.Save(owner, repo, category, id, raw); err != nil {
+		return fmt.Errorf("failed to save %s/%s %s %s: %w", owner, repo, category, id, err)
+	}
+	return nil
+}
+
+// StoredWorkflowRuns loads every workflow run in store, limited to owner's repos when owner is set,
+// and to owner/repo when both are set.
+func StoredWorkflowRuns(store Store, owner, repo string) ([]*WorkflowRunData, error) {
+	entries, err := store.Entries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored data: %w", err)
+	}
+
+	var runs []*WorkflowRunData
+	for _, entry := range entries {
+		if entry.Category != WorkflowRunsDataDir {
+			continue
+		}
+		if owner != "" && entry.Owner != owner {
+			continue
+		}
+		if owner != "" && repo != "" && entry.Repo != repo {
+			continue
+		}
+		var run *WorkflowRunData
+		if err := store.Load(entry.Owner, entry.Repo, entry.Category, entry.ID, &run); err != nil {
+			return nil, fmt.Errorf(
+				"failed to load workflow run '%s' of %s/%s: %w", entry.ID, entry.Owner, entry.Repo, err,
+			)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// rawEntity round-trips stored JSON untouched, so migrations don't depend on the current data structs.
+type rawEntity []byte
+
+// MarshalJSON returns the stored JSON as is.
+func (r rawEntity) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON keeps a copy of the stored JSON.
+func (r *rawEntity) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// storeHas reports whether the store holds the entity.
+func storeHas(store Store, owner, repo, category, id string) bool {
+	_, err := store.ModTime(owner, repo, category, id)
+	return err == nil
+}
+
+// errNotFound wraps os.ErrNotExist so callers can use errors.Is regardless of the Store.
+func errNotFound(owner, repo, category, id string) error {
+	return fmt.Errorf("%s/%s %s '%s': %w", owner, repo, category, id, os.ErrNotExist)
+}
@@ -0,0 +1,301 @@
+package gather
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var manifestMu sync.Mutex
+
+// FileStore is the original Store layout: one JSON file per entity at
+// <data_dir>/<owner>/<repo>/<category>/<id>.json, plus an append-only manifest.jsonl per repo.
+type FileStore struct {
+	dataDir string
+}
+
+// NewFileStore returns a FileStore rooted at dataDir.
+func NewFileStore(dataDir string) *FileStore {
+	return &FileStore{dataDir: dataDir}
+}
+
+// DataDir returns the directory the store is rooted in.
+func (s *FileStore) DataDir() string {
+	return s.dataDir
+}
+
+// Location returns the path of the entity's JSON file.
+func (s *FileStore) Location(owner, repo, category, id string) string {
+	return filepath.Join(s.dataDir, owner, repo, category, id+".json")
+}
+
+// Load reads the entity's JSON file into v.
+func (s *FileStore) Load(owner, repo, category, id string, v any) error {
+	bytes, err := os.ReadFile(filepath.Clean(s.Location(owner, repo, category, id)))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bytes, v); err != nil {
+		return fmt.Errorf("failed to unmarshal cached data: %w", err)
+	}
+	return nil
+}
+
+// Save atomically writes v as the entity's JSON file.
+func (s *FileStore) Save(owner, repo, category, id string, v any) error {
+	targetDir := filepath.Join(s.dataDir, owner, repo, category)
+	if err := ensureDataDir(targetDir, category); err != nil {
+		return err
+	}
+	return writeJSONFile(s.Location(owner, repo, category, id), v)
+}
+
+// Delete removes the entity's JSON file.
+func (s *FileStore) Delete(owner, repo, category, id string) error {
+	return os.Remove(s.Location(owner, repo, category, id))
+}
+
+// ModTime returns the modification time of the entity's JSON file.
+func (s *FileStore) ModTime(owner, repo, category, id string) (time.Time, error) {
+	stat, err := os.Stat(s.Location(owner, repo, category, id))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return stat.ModTime(), nil
+}
+
+// Entries walks the data dir for entity JSON files.
+func (s *FileStore) Entries() ([]StoreEntry, error) {
+	var entries []StoreEntry
+	err := filepath.WalkDir(s.dataDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == s.dataDir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		entry, ok := s.entryForPath(path)
+		if !ok {
+			return nil
+		}
+		if info, infoErr := d.Info(); infoErr == nil {
+			entry.UpdatedAt = info.ModTime()
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk data dir '%s': %w", s.dataDir, err)
+	}
+	return entries, nil
+}
+
+// Repos lists the <owner>/<repo> directories in the data dir holding workflow runs, commits or pull requests.
+func (s *FileStore) Repos() ([]string, error) {
+	owners, err := os.ReadDir(s.dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read data dir '%s': %w", s.dataDir, err)
+	}
+
+	var repos []string
+	for _, ownerEntry := range owners {
+		if !ownerEntry.IsDir() || ownerEntry.Name() == HTTPCacheDir {
+			continue
+		}
+		repoEntries, rErr := os.ReadDir(filepath.Join(s.dataDir, ownerEntry.Name()))
+		if rErr != nil {
+			continue
+		}
+		for _, repoEntry := range repoEntries {
+			repoDir := filepath.Join(s.dataDir, ownerEntry.Name(), repoEntry.Name())
+			if repoEntry.IsDir() && slices.ContainsFunc(listedCategories, func(category string) bool {
+				return cacheFileExists(filepath.Join(repoDir, category))
+			}) {
+				repos = append(repos, ownerEntry.Name()+"/"+repoEntry.Name())
+			}
+		}
+	}
+	return repos, nil
+}
+
+// IDs lists the JSON files in the <owner>/<repo>/<category> directory.
+func (s *FileStore) IDs(owner, repo, category string) ([]string, error) {
+	dir := filepath.Join(s.dataDir, owner, repo, category)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read '%s': %w", dir, err)
+	}
+
+	var ids []string
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
+			ids = append(ids, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+	return ids, nil
+}
+
+// entryForPath parses <owner>/<repo>/<category>/<id>.json relative to the data dir.
+func (s *FileStore) entryForPath(path string) (StoreEntry, bool) {
+	relPath, err := filepath.Rel(s.dataDir, path)
+	if err != nil {
+		return StoreEntry{}, false
+	}
+	parts := strings.Split(relPath, string(filepath.Separator))
+	if len(parts) != 4 {
+		return StoreEntry{}, false
+	}
+	switch parts[2] {
+	case WorkflowRunsDataDir, CommitsDataDir, PullRequestsDataDir:
+	default:
+		return StoreEntry{}, false
+	}
+	return StoreEntry{
+		Owner:    parts[0],
+		Repo:     parts[1],
+		Category: parts[2],
+		ID:       strings.TrimSuffix(parts[3], ".json"),
+	}, true
+}
+
+// FindWorkflowRunForJob scans the stored workflow runs for the one containing jobID.
+func (s *FileStore) FindWorkflowRunForJob(owner, repo string, jobID int64) (StoreEntry, error) {
+	var (
+		found StoreEntry
+		ok    bool
+	)
+	err := filepath.WalkDir(s.dataDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		entry, isEntry := s.entryForPath(path)
+		if !isEntry || entry.Category != WorkflowRunsDataDir {
+			return nil
+		}
+		if owner != "" && repo != "" && (entry.Owner != owner || entry.Repo != repo) {
+			return nil
+		}
+		if _, parseErr := strconv.ParseInt(entry.ID, 10, 64); parseErr != nil {
+			return nil
+		}
+		data, loadErr := readJSONFile[*WorkflowRunData](path)
+		if loadErr != nil || data == nil {
+			return nil
+		}
+		for _, job := range data.Jobs {
+			if job.GetID() == jobID {
+				found, ok = entry, true
+				if data.GetOwner() != "" && data.GetRepo() != "" {
+					found.Owner, found.Repo = data.GetOwner(), data.GetRepo()
+				}
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	if err != nil || !ok {
+		return StoreEntry{}, fmt.Errorf("job %d not found in dataDir: %w", jobID, os.ErrNotExist)
+	}
+	return found, nil
+}
+
+// AppendManifestRecord appends rec to <data_dir>/<owner>/<repo>/manifest.jsonl.
+func (s *FileStore) AppendManifestRecord(owner, repo string, rec ManifestRecord) error {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	p := filepath.Clean(ManifestPath(s.dataDir, owner, repo))
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+
+	//nolint:gosec // ManifestPath constructs path within data directory
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest record: %w", err)
+	}
+
+	data = append(data, '\n')
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write manifest record: %w", err)
+	}
+
+	return nil
+}
+
+// LoadManifest reads <data_dir>/<owner>/<repo>/manifest.jsonl, keeping the latest record per type and ID.
+func (s *FileStore) LoadManifest(owner, repo string) ([]ManifestRecord, error) {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	p := filepath.Clean(ManifestPath(s.dataDir, owner, repo))
+	//nolint:gosec // ManifestPath constructs path within data directory
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []ManifestRecord
+	indexMap := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rec ManifestRecord
+		if err := json.Unmarshal(line, &rec); err == nil {
+			key := rec.Type + ":" + rec.ID
+			if idx, ok := indexMap[key]; ok {
+				records[idx] = rec
+			} else {
+				indexMap[key] = len(records)
+				records = append(records, rec)
+			}
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// ResetManifest removes the owner/repo manifest.jsonl.
+func (s *FileStore) ResetManifest(owner, repo string) error {
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+
+	if err := os.Remove(ManifestPath(s.dataDir, owner, repo)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove manifest file: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op; files need no cleanup.
+func (s *FileStore) Close() error {
+	return nil
+}
@@ -0,0 +1,361 @@
+package gather
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	// Pure Go SQLite driver, registered as "sqlite".
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteFileName is the database file SQLiteStore keeps inside the data dir.
+const SQLiteFileName = "octometrics.db"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entities (
+	owner      TEXT    NOT NULL,
+	repo       TEXT    NOT NULL,
+	category   TEXT    NOT NULL,
+	id         TEXT    NOT NULL,
+	data       BLOB    NOT NULL,
+	updated_at INTEGER NOT NULL,
+	PRIMARY KEY (owner, repo, category, id)
+);
+CREATE TABLE IF NOT EXISTS jobs (
+	job_id          INTEGER NOT NULL PRIMARY KEY,
+	owner           TEXT    NOT NULL,
+	repo            TEXT    NOT NULL,
+	workflow_run_id TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS jobs_workflow_run ON jobs (owner, repo, workflow_run_id);
+CREATE TABLE IF NOT EXISTS manifest (
+	owner      TEXT    NOT NULL,
+	repo       TEXT    NOT NULL,
+	type       TEXT    NOT NULL,
+	id         TEXT    NOT NULL,
+	name       TEXT    NOT NULL,
+	state      TEXT    NOT NULL,
+	actor      TEXT    NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (owner, repo, type, id)
+);
+`
+
+// SQLitePath returns the path of the SQLite database inside dataDir.
+func SQLitePath(dataDir string) string {
+	return filepath.Join(dataDir, SQLiteFileName)
+}
+
+// SQLiteStore keeps entities and manifests in an embedded SQLite database at <data_dir>/octometrics.db.
+// Entities are stored as the same JSON documents FileStore writes, so the two are interchangeable.
+type SQLiteStore struct {
+	dataDir string
+	db      *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) the SQLite database inside dataDir.
+func NewSQLiteStore(dataDir string) (*SQLiteStore, error) {
+	if err := ensureDataDir(dataDir, filepath.Base(dataDir)); err != nil {
+		return nil, err
+	}
+	dsn := "file:" + SQLitePath(dataDir) + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite store: %w", err)
+	}
+	// A single connection serializes writers, sidestepping SQLITE_BUSY under concurrent gathers.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	return &SQLiteStore{dataDir: dataDir, db: db}, nil
+}
+
+// DataDir returns the directory the store is rooted in.
+func (s *SQLiteStore) DataDir() string {
+	return s.dataDir
+}
+
+// Location describes the entity's row in the database.
+func (s *SQLiteStore) Location(owner, repo, category, id string) string {
+	return fmt.Sprintf("%s#%s/%s/%s/%s", SQLitePath(s.dataDir), owner, repo, category, id)
+}
+
+// Load decodes the entity's JSON document into v.
+func (s *SQLiteStore) Load(owner, repo, category, id string, v any) error {
+	var data []byte
+	err := s.db.QueryRow(
+		`SELECT data FROM entities WHERE owner = ? AND repo = ? AND category = ? AND id = ?`,
+		owner, repo, category, id,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNotFound(owner, repo, category, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query sqlite store: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal cached data: %w", err)
+	}
+	return nil
+}
+
+// Save stores v as the entity's JSON document, indexing workflow run jobs for FindWorkflowRunForJob.
+func (s *SQLiteStore) Save(owner, repo, category, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data to json: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(
+		`INSERT INTO entities (owner, repo, category, id, data, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (owner, repo, category, id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		owner, repo, category, id, data, time.Now().UnixNano(),
+	); err != nil {
+		return fmt.Errorf("failed to save %s '%s' to sqlite store: %w", category, id, err)
+	}
+
+	if category == WorkflowRunsDataDir {
+		var run struct {
+			Jobs []struct {
+				ID int64 `json:"id"`
+			} `json:"jobs"`
+		}
+		if err := json.Unmarshal(data, &run); err != nil {
+			return fmt.Errorf("failed to index jobs for workflow run '%s': %w", id, err)
+		}
+		if _, err := tx.Exec(
+			`DELETE FROM jobs WHERE owner = ? AND repo = ? AND workflow_run_id = ?`, owner, repo, id,
+		); err != nil {
+			return fmt.Errorf("failed to index jobs for workflow run '%s': %w", id, err)
+		}
+		for _, job := range run.Jobs {
+			if _, err := tx.Exec(
+				`INSERT OR REPLACE INTO jobs (job_id, owner, repo, workflow_run_id) VALUES (?, ?, ?, ?)`,
+				job.ID, owner, repo, id,
+			); err != nil {
+				return fmt.Errorf("failed to index job %d for workflow run '%s': %w", job.ID, id, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sqlite transaction: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entity and, for workflow runs, its job index.
+func (s *SQLiteStore) Delete(owner, repo, category, id string) error {
+	res, err := s.db.Exec(
+		`DELETE FROM entities WHERE owner = ? AND repo = ? AND category = ? AND id = ?`,
+		owner, repo, category, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s '%s' from sqlite store: %w", category, id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errNotFound(owner, repo, category, id)
+	}
+	if category == WorkflowRunsDataDir {
+		if _, err := s.db.Exec(
+			`DELETE FROM jobs WHERE owner = ? AND repo = ? AND workflow_run_id = ?`, owner, repo, id,
+		); err != nil {
+			return fmt.Errorf("failed to delete job index for workflow run '%s': %w", id, err)
+		}
+	}
+	return nil
+}
+
+// ModTime returns when the entity was last saved.
+func (s *SQLiteStore) ModTime(owner, repo, category, id string) (time.Time, error) {
+	var updatedAt int64
+	err := s.db.QueryRow(
+		`SELECT updated_at FROM entities WHERE owner = ? AND repo = ? AND category = ? AND id = ?`,
+		owner, repo, category, id,
+	).Scan(&updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, errNotFound(owner, repo, category, id)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query sqlite store: %w", err)
+	}
+	return time.Unix(0, updatedAt), nil
+}
+
+// Entries lists every stored workflow run, commit and pull request.
+func (s *SQLiteStore) Entries() ([]StoreEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT owner, repo, category, id, updated_at FROM entities
+		WHERE category IN (?, ?, ?)
+		ORDER BY owner, repo, category, id`,
+		WorkflowRunsDataDir, CommitsDataDir, PullRequestsDataDir,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sqlite store entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []StoreEntry
+	for rows.Next() {
+		var (
+			entry     StoreEntry
+			updatedAt int64
+		)
+		if err := rows.Scan(&entry.Owner, &entry.Repo, &entry.Category, &entry.ID, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sqlite store entry: %w", err)
+		}
+		entry.UpdatedAt = time.Unix(0, updatedAt)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Repos lists the repos that have stored workflow runs, commits or pull requests.
+func (s *SQLiteStore) Repos() ([]string, error) {
+	rows, err := s.db.Query(
+		`SELECT DISTINCT owner, repo FROM entities
+		WHERE category IN (?, ?, ?)
+		ORDER BY owner, repo`,
+		WorkflowRunsDataDir, CommitsDataDir, PullRequestsDataDir,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sqlite store repos: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var repos []string
+	for rows.Next() {
+		var owner, repo string
+		if err := rows.Scan(&owner, &repo); err != nil {
+			return nil, fmt.Errorf("failed to scan sqlite store repo: %w", err)
+		}
+		repos = append(repos, owner+"/"+repo)
+	}
+	return repos, rows.Err()
+}
+
+// IDs lists the IDs stored in owner/repo's category.
+func (s *SQLiteStore) IDs(owner, repo, category string) ([]string, error) {
+	rows, err := s.db.Query(
+		`SELECT id FROM entities WHERE owner = ? AND repo = ? AND category = ? ORDER BY id`,
+		owner, repo, category,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sqlite store IDs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan sqlite store ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// FindWorkflowRunForJob looks jobID up in the job index.
+func (s *SQLiteStore) FindWorkflowRunForJob(owner, repo string, jobID int64) (StoreEntry, error) {
+	query := `SELECT owner, repo, workflow_run_id FROM jobs WHERE job_id = ?`
+	args := []any{jobID}
+	if owner != "" && repo != "" {
+		query += ` AND owner = ? AND repo = ?`
+		args = append(args, owner, repo)
+	}
+
+	entry := StoreEntry{Category: WorkflowRunsDataDir}
+	err := s.db.QueryRow(query, args...).Scan(&entry.Owner, &entry.Repo, &entry.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StoreEntry{}, errNotFound(owner, repo, "job", strconv.FormatInt(jobID, 10))
+	}
+	if err != nil {
+		return StoreEntry{}, fmt.Errorf("failed to query job index: %w", err)
+	}
+	return entry, nil
+}
+
+// AppendManifestRecord adds rec to the owner/repo manifest, replacing any record with the same type and ID.
+func (s *SQLiteStore) AppendManifestRecord(owner, repo string, rec ManifestRecord) error {
+	_, err := s.db.Exec(
+		`INSERT INTO manifest (owner, repo, type, id, name, state, actor, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (owner, repo, type, id) DO UPDATE SET
+			name = excluded.name, state = excluded.state, actor = excluded.actor, created_at = excluded.created_at`,
+		owner, repo, rec.Type, rec.ID, rec.Name, rec.State, rec.Actor, unixNano(rec.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest record: %w", err)
+	}
+	return nil
+}
+
+// LoadManifest returns the owner/repo manifest in insertion order.
+func (s *SQLiteStore) LoadManifest(owner, repo string) ([]ManifestRecord, error) {
+	rows, err := s.db.Query(
+		`SELECT type, id, name, state, actor, created_at FROM manifest
+		WHERE owner = ? AND repo = ? ORDER BY rowid`,
+		owner, repo,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []ManifestRecord
+	for rows.Next() {
+		var (
+			rec       ManifestRecord
+			createdAt int64
+		)
+		if err := rows.Scan(&rec.Type, &rec.ID, &rec.Name, &rec.State, &rec.Actor, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan manifest record: %w", err)
+		}
+		rec.CreatedAt = fromUnixNano(createdAt).UTC()
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// ResetManifest drops all records from the owner/repo manifest.
+func (s *SQLiteStore) ResetManifest(owner, repo string) error {
+	if _, err := s.db.Exec(`DELETE FROM manifest WHERE owner = ? AND repo = ?`, owner, repo); err != nil {
+		return fmt.Errorf("failed to reset manifest: %w", err)
+	}
+	return nil
+}
+
+// unixNano converts t for storage, keeping the zero time as 0 since it is outside UnixNano's range.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano reverses unixNano.
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,217 @@
+package gather
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func testWorkflowRunData(id int64, jobIDs ...int64) *WorkflowRunData {
+	data := &WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:         new(id),
+			Name:       new("store-test"),
+			Status:     new("completed"),
+			Conclusion: new("success"),
+			Actor:      &github.User{Login: new("octocat")},
+			Repository: &github.Repository{
+				Name:  new("repo"),
+				Owner: &github.User{Login: new("owner")},
+			},
+		},
+	}
+	for _, jobID := range jobIDs {
+		data.Jobs = append(data.Jobs, &JobData{WorkflowJob: &github.WorkflowJob{ID: new(jobID), Name: new("job")}})
+	}
+	return data
+}
+
+func TestStores(t *testing.T) {
+	t.Parallel()
+
+	newStores := map[string]func(t *testing.T, dataDir string) Store{
+		StoreFile: func(_ *testing.T, dataDir string) Store {
+			return NewFileStore(dataDir)
+		},
+		StoreSQLite: func(t *testing.T, dataDir string) Store {
+			store, err := NewSQLiteStore(dataDir)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = store.Close() })
+			return store
+		},
+	}
+
+	for name, newStore := range newStores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, testDir := testhelpers.Setup(t)
+			store := newStore(t, testDir)
+
+			var missing *WorkflowRunData
+			err := store.Load("owner", "repo", WorkflowRunsDataDir, "1", &missing)
+			require.ErrorIs(t, err, os.ErrNotExist)
+			assert.False(t, storeHas(store, "owner", "repo", WorkflowRunsDataDir, "1"))
+
+			require.NoError(t, saveWorkflowRun(store, "owner", "repo", 1, testWorkflowRunData(1, 10, 11)))
+			require.NoError(t, store.Save("other", "repo", CommitsDataDir, "abc", &CommitData{Conclusion: "success"}))
+			require.NoError(t, store.Save("owner", "repo", SyncDataDir, "state", &SyncState{LastRunID: 1}))
+			require.NoError(t, store.Save("synced", "repo", SyncDataDir, "state", &SyncState{LastRunID: 1}))
+			assert.True(t, storeHas(store, "owner", "repo", WorkflowRunsDataDir, "1"))
+
+			loaded, err := loadWorkflowRun(store, "owner", "repo", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "store-test", loaded.GetName())
+			require.Len(t, loaded.Jobs, 2)
+
+			modTime, err := store.ModTime("owner", "repo", WorkflowRunsDataDir, "1")
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now(), modTime, time.Minute)
+
+			entries, err := store.Entries()
+			require.NoError(t, err)
+			require.Len(t, entries, 2, "sync state should not be listed")
+			assert.Equal(t, "other", entries[0].Owner)
+			assert.Equal(t, WorkflowRunsDataDir, entries[1].Category)
+			assert.Equal(t, "1", entries[1].ID)
+
+			require.NoError(t, os.MkdirAll(filepath.Join(testDir, HTTPCacheDir, "ab"), 0o750))
+			repos, err := store.Repos()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"other/repo", "owner/repo"}, repos,
+				"repos with only sync state should not be listed")
+
+			ids, err := store.IDs("owner", "repo", SyncDataDir)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"state"}, ids, "IDs should list bookkeeping categories")
+			ids, err = store.IDs("owner", "repo", WorkflowDefsDataDir)
+			require.NoError(t, err)
+			assert.Empty(t, ids)
+
+			found, err := store.FindWorkflowRunForJob("owner", "repo", 11)
+			require.NoError(t, err)
+			assert.Equal(t, "1", found.ID)
+			found, err = store.FindWorkflowRunForJob("", "", 10)
+			require.NoError(t, err)
+			assert.Equal(t, "owner", found.Owner)
+			_, err = store.FindWorkflowRunForJob("other", "repo", 10)
+			require.Error(t, err)
+
+			records, err := store.LoadManifest("owner", "repo")
+			require.NoError(t, err)
+			require.Len(t, records, 3, "workflow run and both jobs should be in the manifest")
+			assert.Equal(t, "workflow_run", records[0].Type)
+
+			require.NoError(t, store.AppendManifestRecord("owner", "repo", ManifestRecord{
+				Type:  "workflow_run",
+				ID:    "1",
+				Name:  "renamed",
+				State: "failure",
+			}))
+			records, err = store.LoadManifest("owner", "repo")
+			require.NoError(t, err)
+			require.Len(t, records, 3, "records should be deduplicated by type and ID")
+			assert.Equal(t, "renamed", records[0].Name)
+
+			require.NoError(t, store.ResetManifest("owner", "repo"))
+			records, err = store.LoadManifest("owner", "repo")
+			require.NoError(t, err)
+			assert.Empty(t, records)
+
+			require.NoError(t, store.Delete("owner", "repo", WorkflowRunsDataDir, "1"))
+			_, err = store.ModTime("owner", "repo", WorkflowRunsDataDir, "1")
+			require.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+func TestMigrateStore(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	srcDir := filepath.Join(testDir, "json")
+	dstDir := filepath.Join(testDir, "sqlite")
+
+	src := NewFileStore(srcDir)
+	require.NoError(t, saveWorkflowRun(src, "owner", "repo", 42, testWorkflowRunData(42, 420)))
+	require.NoError(t, src.Save("owner", "repo", SyncDataDir, syncStateID, &SyncState{LastRunID: 42}))
+	defVersion := newWorkflowDefVersion(".github/workflows/ci.yml", "abc", []byte("on: push\n"))
+	require.NoError(t, saveWorkflowDefVersion(src, "owner", "repo", defVersion))
+	// Files that aren't entities must be left alone
+	costsDir := filepath.Join(srcDir, "owner", "repo", "runs_on_costs")
+	require.NoError(t, os.MkdirAll(costsDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(costsDir, "420.json"), []byte(`{}`), 0o600))
+
+	dst, err := OpenStore(StoreSQLite, dstDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dst.Close() })
+
+	migrated, err := MigrateStore(src, dst)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	records, err := LoadManifest(dst, "owner", "repo")
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	wfID, err := FindWorkflowRunIDForJob(dst, "owner", "repo", 420)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), wfID)
+
+	state, err := LoadSyncState("owner", "repo", WithStore(dst))
+	require.NoError(t, err)
+	require.NotNil(t, state, "sync state should be migrated")
+	assert.Equal(t, int64(42), state.LastRunID)
+
+	var migratedVersion *WorkflowDefVersion
+	require.NoError(t, dst.Load("owner", "repo", WorkflowDefsDataDir, defVersion.SHA, &migratedVersion),
+		"workflow file versions should be migrated")
+	assert.Equal(t, defVersion.Content, migratedVersion.Content)
+
+	wfData, location, err := WorkflowRun(t.Context(), log, nil, "owner", "repo", 42, WithStore(dst))
+	require.NoError(t, err, "workflow run should load from the sqlite store without a client")
+	assert.Equal(t, "store-test", wfData.GetName())
+	assert.Contains(t, location, SQLiteFileName)
+
+	_, _, err = WorkflowRun(t.Context(), log, nil, "owner", "repo", 42, CustomDataFolder(dstDir))
+	require.Error(t, err, "a sqlite data dir should not be read as a file store")
+
+	require.NoError(t, dst.Close())
+
+	reopened, err := OpenStore(StoreAuto, dstDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = reopened.Close() })
+	assert.IsType(t, &SQLiteStore{}, reopened, "auto should pick sqlite once the database exists")
+}
+
+func TestStoredWorkflowRuns(t *testing.T) {
+	t.Parallel()
+
+	_, testDir := testhelpers.Setup(t)
+	store := NewFileStore(testDir)
+	require.NoError(t, store.Save("owner", "repo", WorkflowRunsDataDir, "1", testWorkflowRunData(1)))
+	require.NoError(t, store.Save("owner", "repo", WorkflowRunsDataDir, "2", testWorkflowRunData(2)))
+	require.NoError(t, store.Save("other", "repo", WorkflowRunsDataDir, "3", testWorkflowRunData(3)))
+	require.NoError(t, store.Save("owner", "repo", CommitsDataDir, "abc", &CommitData{}))
+
+	runs, err := StoredWorkflowRuns(store, "", "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 3, "empty owner and repo should load every repo's runs")
+
+	runs, err = StoredWorkflowRuns(store, "other", "")
+	require.NoError(t, err)
+	require.Len(t, runs, 1, "an owner alone should load every one of its repos' runs")
+	assert.Equal(t, int64(3), runs[0].GetID())
+
+	runs, err = StoredWorkflowRuns(store, "owner", "repo")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.ElementsMatch(t, []int64{1, 2}, []int64{runs[0].GetID(), runs[1].GetID()})
+}
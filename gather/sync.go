@@ -0,0 +1,214 @@
+package gather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// SyncDataDir is the Store category holding the per-repo sync state.
+	SyncDataDir = "sync"
+	// DefaultSyncLookback is how far back the first sync of a repo reaches when no start date is given.
+	DefaultSyncLookback = 30 * 24 * time.Hour
+
+	syncStateID = "state"
+)
+
+// SyncState is the high-water mark Sync keeps per repo between runs.
+type SyncState struct {
+	// LastRunID is the highest workflow run ID seen so far. Run IDs only grow, so anything above it is new.
+	LastRunID int64 `json:"last_run_id"`
+	// LastRunCreatedAt is when LastRunID was created, used to narrow the listing on the next sync.
+	LastRunCreatedAt time.Time `json:"last_run_created_at"`
+	// PendingRunIDs are runs that were in progress or failed to gather, refreshed on the next sync.
+	PendingRunIDs []int64 `json:"pending_run_ids,omitempty"`
+	// LastSyncedAt is when the last successful sync finished.
+	LastSyncedAt time.Time `json:"last_synced_at"`
+}
+
+// SyncResult summarizes a single Sync.
+type SyncResult struct {
+	// New is the number of workflow runs gathered for the first time.
+	New int
+	// Refreshed is the number of pending workflow runs gathered again.
+	Refreshed int
+	// Failed is the number of workflow runs that failed to gather. They stay pending.
+	Failed int
+	// State is the sync state saved for the next run.
+	State *SyncState
+}
+
+// LoadSyncState returns the sync state of owner/repo, or nil if the repo was never synced.
+func LoadSyncState(owner, repo string, opts ...Option) (*SyncState, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	store, err := o.getStore()
+	if err != nil {
+		return nil, err
+	}
+	var state *SyncState
+	if err := store.Load(owner, repo, SyncDataDir, syncStateID, &state); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load sync state for %s/%s: %w", owner, repo, err)
+	}
+	return state, nil
+}
+
+// Sync brings the local copy of a repo's workflow runs up to date.
+// It gathers runs created since the last sync, plus any runs that were still in progress or failed last time,
+// then saves a new high-water mark. since only applies to the first sync of a repo; zero means DefaultSyncLookback.
+// Busy stretches with more runs than GitHub lists for one query are listed in smaller windows, so none are missed.
+// Sync never waits for in-progress runs, so it is safe to run on a schedule.
+func Sync(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *GitHubClient,
+	owner, repo string,
+	since time.Time,
+	opts ...Option,
+) (*SyncResult, error) {
+	if client == nil {
+		return nil, fmt.Errorf("github client is nil")
+	}
+
+	opts = append(slices.Clip(opts), WithWait(false))
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	store, err := o.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := LoadSyncState(owner, repo, WithStore(store))
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &SyncState{}
+	}
+
+	listFrom := state.LastRunCreatedAt
+	if state.LastRunID == 0 {
+		listFrom = since
+		if listFrom.IsZero() {
+			listFrom = time.Now().Add(-DefaultSyncLookback)
+		}
+	}
+
+	log = log.With().Str("owner", owner).Str("repo", repo).Logger()
+	log.Info().
+		Int64("last_run_id", state.LastRunID).
+		Time("list_from", listFrom).
+		Int("pending", len(state.PendingRunIDs)).
+		Msg("Syncing workflow runs")
+	o.Reporter.Start(fmt.Sprintf("Syncing %s/%s", owner, repo))
+
+	ghCtxInst, cancel := ghCtx(ctx)
+	defer cancel()
+
+	next := &SyncState{
+		LastRunID:        state.LastRunID,
+		LastRunCreatedAt: state.LastRunCreatedAt,
+	}
+	var (
+		newRunIDs []int64
+		seen      = make(map[int64]struct{})
+	)
+	// The high-water mark is only saved once every run since it was listed, so a failed listing is retried in full
+	truncated, err := listWorkflowRunsCreated(ghCtxInst, client, owner, repo, "", createdWindow{from: listFrom},
+		func(run *github.WorkflowRun) error {
+			if run.GetID() <= state.LastRunID {
+				return nil
+			}
+			if _, ok := seen[run.GetID()]; ok {
+				return nil
+			}
+			seen[run.GetID()] = struct{}{}
+			newRunIDs = append(newRunIDs, run.GetID())
+			if run.GetID() > next.LastRunID {
+				next.LastRunID = run.GetID()
+				next.LastRunCreatedAt = run.GetCreatedAt().Time
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if truncated {
+		return nil, fmt.Errorf(
+			"more than %d workflow runs of %s/%s were created in a single second, not all of them could be listed",
+			maxFilteredWorkflowRuns, owner, repo,
+		)
+	}
+
+	result := &SyncResult{State: next}
+	runIDs := make([]int64, 0, len(newRunIDs)+len(state.PendingRunIDs))
+	runIDs = append(runIDs, newRunIDs...)
+	for _, runID := range state.PendingRunIDs {
+		if !slices.Contains(newRunIDs, runID) {
+			runIDs = append(runIDs, runID)
+			result.Refreshed++
+		}
+	}
+	result.New = len(newRunIDs)
+	log.Info().Int("new", result.New).Int("refreshed", result.Refreshed).Msg("Found workflow runs to sync")
+
+	var pendingMu sync.Mutex
+	eg, egCtx := errgroup.WithContext(ghCtxInst)
+	eg.SetLimit(defaultGatherConcurrency)
+	for _, runID := range runIDs {
+		eg.Go(func() error {
+			data, _, err := WorkflowRun(egCtx, log, client, owner, repo, runID, opts...)
+			if err != nil {
+				log.Error().Err(err).Int64("workflow_run_id", runID).Msg("Failed to sync workflow run")
+			}
+			if err != nil || data.IsInProgress() {
+				pendingMu.Lock()
+				next.PendingRunIDs = append(next.PendingRunIDs, runID)
+				if err != nil {
+					result.Failed++
+				}
+				pendingMu.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(next.PendingRunIDs)
+	next.LastSyncedAt = time.Now()
+	if err := store.Save(owner, repo, SyncDataDir, syncStateID, next); err != nil {
+		return nil, fmt.Errorf("failed to save sync state for %s/%s: %w", owner, repo, err)
+	}
+
+	log.Info().
+		Int64("last_run_id", next.LastRunID).
+		Int("new", result.New).
+		Int("refreshed", result.Refreshed).
+		Int("failed", result.Failed).
+		Int("pending", len(next.PendingRunIDs)).
+		Msg("Synced workflow runs")
+	return result, nil
+}
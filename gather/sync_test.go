@@ -0,0 +1,253 @@
+package gather
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		runs     = map[int64]*github.WorkflowRun{}
+		listed   []int64
+		fetched  = map[int64]int{}
+		listedAt []string
+	)
+	setRun := func(id int64, status string) {
+		mu.Lock()
+		defer mu.Unlock()
+		conclusion := ""
+		if status == "completed" {
+			conclusion = "success"
+		}
+		runs[id] = &github.WorkflowRun{
+			ID:         new(id),
+			Name:       new("sync-test"),
+			Status:     new(status),
+			Conclusion: new(conclusion),
+			CreatedAt:  new(github.Timestamp{Time: time.Date(2025, 6, int(id), 12, 0, 0, 0, time.UTC)}),
+			Actor:      &github.User{Login: new("octocat")},
+			Repository: &github.Repository{
+				Name:  new(testGatherRepo),
+				Owner: &github.User{Login: new(testGatherOwner)},
+			},
+		}
+	}
+
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				listedAt = append(listedAt, r.URL.Query().Get("created"))
+				list := &github.WorkflowRuns{TotalCount: new(len(listed))}
+				for i := len(listed) - 1; i >= 0; i-- {
+					list.WorkflowRuns = append(list.WorkflowRuns, runs[listed[i]])
+				}
+				_, _ = w.Write(mock.MustMarshal(list))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, err := strconv.ParseInt(path.Base(r.URL.Path), 10, 64)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				fetched[id]++
+				_, _ = w.Write(mock.MustMarshal(runs[id]))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsJobsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.Jobs{TotalCount: new(0), Jobs: []*github.WorkflowJob{}}))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsArtifactsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.ArtifactList{TotalCount: new(int64(0))}))
+			}),
+		),
+	)
+
+	log, testDataDir := testhelpers.Setup(t)
+	client, err := NewGitHubClient(log, "mock-token", mockedHTTPClient.Transport)
+	require.NoError(t, err, "error creating GitHub client")
+
+	state, err := LoadSyncState(testGatherOwner, testGatherRepo, CustomDataFolder(testDataDir))
+	require.NoError(t, err)
+	assert.Nil(t, state, "repo should not have a sync state before the first sync")
+
+	// First sync: one finished run, one still in progress
+	setRun(1, "completed")
+	setRun(2, "in_progress")
+	listed = []int64{1, 2}
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := []Option{CustomDataFolder(testDataDir), WithoutCost()}
+	result, err := Sync(t.Context(), log, client, testGatherOwner, testGatherRepo, since, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.New)
+	assert.Equal(t, 0, result.Refreshed)
+	assert.Equal(t, int64(2), result.State.LastRunID)
+	assert.Equal(t, []int64{2}, result.State.PendingRunIDs)
+
+	// Second sync: the in-progress run finished and a new run started
+	setRun(2, "completed")
+	setRun(3, "completed")
+	listed = []int64{1, 2, 3}
+	result, err = Sync(t.Context(), log, client, testGatherOwner, testGatherRepo, since, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.New)
+	assert.Equal(t, 1, result.Refreshed)
+	assert.Equal(t, int64(3), result.State.LastRunID)
+	assert.Empty(t, result.State.PendingRunIDs)
+
+	state, err = LoadSyncState(testGatherOwner, testGatherRepo, CustomDataFolder(testDataDir))
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, int64(3), state.LastRunID)
+	assert.WithinDuration(t, time.Now(), state.LastSyncedAt, time.Minute)
+
+	loaded, err := loadWorkflowRun(NewFileStore(testDataDir), testGatherOwner, testGatherRepo, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", loaded.GetStatus(), "pending run should be refreshed")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(
+		t,
+		[]string{">=2025-06-01T00:00:00Z", ">=2025-06-02T12:00:00Z"},
+		listedAt,
+		"second sync should list from the high-water mark",
+	)
+	assert.Equal(t, 1, fetched[1], "completed runs should not be fetched again")
+	assert.Equal(t, 2, fetched[2], "in-progress run should be fetched again")
+	assert.Equal(t, 1, fetched[3])
+
+	entries, err := NewFileStore(testDataDir).Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "sync state should not be listed as an entry")
+}
+
+func TestSyncSplitsCappedListings(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		runs   []*github.WorkflowRun
+		listed []string
+	)
+	for day := 1; day <= 8; day++ {
+		runs = append(runs, &github.WorkflowRun{
+			ID:         new(int64(day)),
+			Name:       new("sync-test"),
+			Status:     new("completed"),
+			Conclusion: new("success"),
+			CreatedAt:  new(github.Timestamp{Time: time.Date(2025, 6, day, 12, 0, 0, 0, time.UTC)}),
+			Actor:      &github.User{Login: new("octocat")},
+			Repository: &github.Repository{
+				Name:  new(testGatherRepo),
+				Owner: &github.User{Login: new(testGatherOwner)},
+			},
+		})
+	}
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				created := r.URL.Query().Get("created")
+				mu.Lock()
+				listed = append(listed, created)
+				mu.Unlock()
+				// Pretend every run stands for 400, so windows of three runs or more are capped to the newest two
+				var matched []*github.WorkflowRun
+				for i := len(runs) - 1; i >= 0; i-- {
+					if createdIn(created, runs[i]) {
+						matched = append(matched, runs[i])
+					}
+				}
+				list := &github.WorkflowRuns{TotalCount: new(len(matched) * 400), WorkflowRuns: matched}
+				if len(matched)*400 > maxFilteredWorkflowRuns {
+					list.WorkflowRuns = matched[:2]
+				}
+				_, _ = w.Write(mock.MustMarshal(list))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, err := strconv.ParseInt(path.Base(r.URL.Path), 10, 64)
+				if err != nil || id < 1 || id > int64(len(runs)) {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write(mock.MustMarshal(runs[id-1]))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsJobsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.Jobs{TotalCount: new(0), Jobs: []*github.WorkflowJob{}}))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposActionsRunsArtifactsByOwnerByRepoByRunId,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.ArtifactList{TotalCount: new(int64(0))}))
+			}),
+		),
+	)
+
+	log, testDataDir := testhelpers.Setup(t)
+	client, err := NewGitHubClient(log, "mock-token", mockedHTTPClient.Transport)
+	require.NoError(t, err, "error creating GitHub client")
+
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	result, err := Sync(t.Context(), log, client, testGatherOwner, testGatherRepo, since,
+		CustomDataFolder(testDataDir), WithoutCost())
+	require.NoError(t, err)
+	assert.Equal(t, len(runs), result.New, "runs past the listing cap should be listed in smaller windows")
+	assert.Equal(t, int64(len(runs)), result.State.LastRunID)
+
+	stored, err := StoredWorkflowRuns(NewFileStore(testDataDir), testGatherOwner, testGatherRepo)
+	require.NoError(t, err)
+	assert.Len(t, stored, len(runs))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Greater(t, len(listed), 1, "the capped listing should have been split")
+	assert.Equal(t, ">=2025-06-01T00:00:00Z", listed[0])
+}
+
+// createdIn reports whether run was created in the window of a workflow runs created filter.
+func createdIn(created string, run *github.WorkflowRun) bool {
+	at := run.GetCreatedAt().Time
+	if from, ok := strings.CutPrefix(created, ">="); ok {
+		start, err := time.Parse(time.RFC3339, from)
+		return err == nil && !at.Before(start)
+	}
+	from, until, _ := strings.Cut(created, "..")
+	start, errFrom := time.Parse(time.RFC3339, from)
+	end, errUntil := time.Parse(time.RFC3339, until)
+	return errFrom == nil && errUntil == nil && !at.Before(start) && !at.After(end)
+}
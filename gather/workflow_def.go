@@ -5,16 +5,24 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v89/github"
 	"github.com/rs/zerolog"
 	"gopkg.in/yaml.v3"
 )
 
+// defaultJobTimeout is how long GitHub lets a job run without timeout-minutes.
+const defaultJobTimeout = 360 * time.Minute
+
 // WorkflowDef represents the parsed workflow definition.
 type WorkflowDef struct {
 	Jobs map[string]JobDef `yaml:"jobs"`
+	// Concurrency is the workflow's concurrency group, which applies to the whole run.
+	Concurrency *Concurrency `yaml:"concurrency" json:",omitempty"`
 }
 
 // JobDef represents a single job definition in the workflow.
@@ -23,6 +31,21 @@ type JobDef struct {
 	Uses   string `yaml:"uses"`
 	Needs  Needs  `yaml:"needs"`
 	RunsOn RunsOn `yaml:"runs-on"`
+	// If is the raw condition the job runs on. See Condition.
+	If string `yaml:"if" json:",omitempty"`
+	// TimeoutMinutes is the raw timeout-minutes, a number or an expression. See Timeout.
+	TimeoutMinutes string `yaml:"timeout-minutes" json:",omitempty"`
+	// ContinueOnError is the raw continue-on-error, "true", "false" or an expression.
+	ContinueOnError string       `yaml:"continue-on-error" json:",omitempty"`
+	Concurrency     *Concurrency `yaml:"concurrency" json:",omitempty"`
+	Environment     *Environment `yaml:"environment" json:",omitempty"`
+	// Container is the container the job's steps run in, and Services the service containers it starts, by name.
+	Container *Container           `yaml:"container" json:",omitempty"`
+	Services  map[string]Container `yaml:"services" json:",omitempty"`
+	// Strategy is set for jobs that run as a matrix.
+	Strategy *Strategy `yaml:"strategy" json:",omitempty"`
+	// Calls is the reusable workflow the job calls with Uses, once it has been fetched and parsed.
+	Calls *WorkflowDef `yaml:"-" json:",omitempty"`
 }
 
 // Needs handles the YAML union type: needs can be a single string or a list.
@@ -73,6 +96,90 @@ func (r *RunsOn) UnmarshalYAML(value *yaml.Node) error {
 	return fmt.Errorf("unexpected node kind for runs-on: %v", value.Kind)
 }
 
+// Concurrency is a concurrency group. Runs and jobs in the same group wait for each other, and with
+// cancel-in-progress a new one cancels the one already running.
+type Concurrency struct {
+	Group string `yaml:"group"`
+	// CancelInProgress is the raw cancel-in-progress, "true", "false" or an expression.
+	CancelInProgress string `yaml:"cancel-in-progress" json:",omitempty"`
+}
+
+// UnmarshalYAML handles both a group name and a mapping for concurrency.
+func (c *Concurrency) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		c.Group = value.Value
+		return nil
+	}
+	type plain Concurrency
+	return value.Decode((*plain)(c))
+}
+
+// Cancels reports whether a new run in the group cancels the one in progress. Expressions count as cancelling,
+// since GitHub only evaluates them at runtime.
+func (c *Concurrency) Cancels() bool {
+	if c == nil {
+		return false
+	}
+	cancel := strings.TrimSpace(c.CancelInProgress)
+	return cancel != "" && cancel != "false"
+}
+
+// Environment is the deployment environment a job runs in.
+type Environment struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url" json:",omitempty"`
+}
+
+// UnmarshalYAML handles both a name and a mapping for environment.
+func (e *Environment) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Name = value.Value
+		return nil
+	}
+	type plain Environment
+	return value.Decode((*plain)(e))
+}
+
+// Container is a job or service container.
+type Container struct {
+	Image string `yaml:"image"`
+}
+
+// UnmarshalYAML handles both an image and a mapping for container and services.
+func (c *Container) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		c.Image = value.Value
+		return nil
+	}
+	type plain Container
+	return value.Decode((*plain)(c))
+}
+
+// Timeout returns how long GitHub lets the job run, 360 minutes unless timeout-minutes says otherwise.
+// Returns false when timeout-minutes is an expression and the timeout is only known at runtime.
+func (j JobDef) Timeout() (time.Duration, bool) {
+	minutes := strings.TrimSpace(j.TimeoutMinutes)
+	if minutes == "" {
+		return defaultJobTimeout, true
+	}
+	parsed, err := strconv.ParseFloat(minutes, 64)
+	if err != nil || parsed <= 0 {
+		return 0, false
+	}
+	return time.Duration(parsed * float64(time.Minute)), true
+}
+
+// Condition returns the job's if condition without the ${{ }} around it.
+func (j JobDef) Condition() string {
+	condition := strings.TrimSpace(j.If)
+	if inner, ok := strings.CutPrefix(condition, "${{"); ok {
+		if inner, ok = strings.CutSuffix(inner, "}}"); ok {
+			condition = strings.TrimSpace(inner)
+		}
+	}
+	return condition
+}
+
 // ParseWorkflowDef parses a workflow YAML file into a WorkflowDef.
 func ParseWorkflowDef(content []byte) (*WorkflowDef, error) {
 	var def WorkflowDef
@@ -91,6 +198,78 @@ func ParseWorkflowDef(content []byte) (*WorkflowDef, error) {
 	return &def, nil
 }
 
+// Expanded returns the workflow with every job that calls a reusable workflow replaced by the called workflow's
+// jobs, with IDs like "caller/callee" and names like "caller / callee", the way GitHub names them in a run.
+// Called jobs without needs wait on what the caller needs, and jobs that need the caller wait on the called jobs
+// that no other called job needs.
+func (d *WorkflowDef) Expanded() *WorkflowDef {
+	if d == nil {
+		return nil
+	}
+
+	var (
+		called = map[string]*WorkflowDef{}
+		// exits are the jobs that have to finish for a job to count as done
+		exits = map[string][]string{}
+	)
+	for id, job := range d.Jobs {
+		calledDef := job.Calls.Expanded()
+		if calledDef == nil || len(calledDef.Jobs) == 0 {
+			exits[id] = []string{id}
+			continue
+		}
+		called[id] = calledDef
+		needed := map[string]bool{}
+		for _, calledJob := range calledDef.Jobs {
+			for _, need := range calledJob.Needs {
+				needed[need] = true
+			}
+		}
+		for calledID := range calledDef.Jobs {
+			if !needed[calledID] {
+				exits[id] = append(exits[id], id+"/"+calledID)
+			}
+		}
+		slices.Sort(exits[id])
+	}
+
+	resolveNeeds := func(needs Needs) Needs {
+		var resolved Needs
+		for _, need := range needs {
+			if jobs, ok := exits[need]; ok {
+				resolved = append(resolved, jobs...)
+			} else {
+				resolved = append(resolved, need)
+			}
+		}
+		return resolved
+	}
+
+	expanded := &WorkflowDef{Jobs: make(map[string]JobDef, len(d.Jobs)), Concurrency: d.Concurrency}
+	for id, job := range d.Jobs {
+		calledDef, ok := called[id]
+		if !ok {
+			job.Needs = resolveNeeds(job.Needs)
+			expanded.Jobs[id] = job
+			continue
+		}
+		for calledID, calledJob := range calledDef.Jobs {
+			calledJob.Name = job.Name + " / " + calledJob.Name
+			if len(calledJob.Needs) == 0 {
+				calledJob.Needs = resolveNeeds(job.Needs)
+			} else {
+				needs := make(Needs, len(calledJob.Needs))
+				for i, need := range calledJob.Needs {
+					needs[i] = id + "/" + need
+				}
+				calledJob.Needs = needs
+			}
+			expanded.Jobs[id+"/"+calledID] = calledJob
+		}
+	}
+	return expanded
+}
+
 // GetJobIDByName finds a job ID by its display name or job ID.
 // Returns the job ID and true if found.
 func (d *WorkflowDef) GetJobIDByName(name string) (string, bool) {
@@ -110,6 +289,18 @@ func (d *WorkflowDef) GetJobIDByName(name string) (string, bool) {
 		return name, true
 	}
 
+	// Matrix legs, like "test (ubuntu-latest, 1.22)"
+	for id, job := range d.Jobs {
+		if slices.Contains(job.MatrixLegNames(), name) {
+			return id, true
+		}
+	}
+	for id, job := range d.Jobs {
+		if job.IsMatrixLeg(name) {
+			return id, true
+		}
+	}
+
 	// Case-insensitive job.Name or job ID match
 	for id, job := range d.Jobs {
 		if strings.EqualFold(job.Name, name) || strings.EqualFold(id, name) {
@@ -141,44 +332,58 @@ func sanitizeID(s string) string {
 	return b.String()
 }
 
-// workflowDefData fetches and parses the workflow YAML file at the run's HeadSHA.
-// Returns nil (no error) if the file is not found (404) — the flow chart is omitted gracefully.
-func workflowDefData(
-	parentCtx context.Context,
-	log zerolog.Logger,
-	client *GitHubClient,
-	owner, repo string,
-	workflowRun *github.WorkflowRun,
-) (*WorkflowDef, error) {
-	if client == nil || workflowRun == nil {
-		return nil, nil
-	}
+// maxReusableWorkflowDepth bounds how deep reusable workflows that call other reusable workflows are resolved.
+const maxReusableWorkflowDepth = 10
 
-	workflowPath := workflowRun.GetPath()
-	if workflowPath == "" {
-		return nil, nil
-	}
+// workflowFile is a workflow file of a repo at a ref.
+type workflowFile struct {
+	owner, repo, path, ref string
+}
 
-	headSHA := workflowRun.GetHeadSHA()
-	if headSHA == "" {
-		return nil, nil
+func (f workflowFile) String() string {
+	return fmt.Sprintf("%s/%s/%s@%s", f.owner, f.repo, f.path, f.ref)
+}
+
+// calledWorkflowFile resolves a job's uses: reference, either ./.github/workflows/x.yml in the same repo and
+// commit as the calling workflow file, or owner/repo/.github/workflows/x.yml@ref.
+func (f workflowFile) calledWorkflowFile(uses string) (workflowFile, bool) {
+	uses = strings.TrimSpace(uses)
+	if path, ok := strings.CutPrefix(uses, "./"); ok {
+		return workflowFile{owner: f.owner, repo: f.repo, path: path, ref: f.ref}, isWorkflowPath(path)
 	}
+	target, ref, ok := strings.Cut(uses, "@")
+	if !ok || ref == "" {
+		return workflowFile{}, false
+	}
+	parts := strings.SplitN(target, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return workflowFile{}, false
+	}
+	return workflowFile{owner: parts[0], repo: parts[1], path: parts[2], ref: ref}, isWorkflowPath(parts[2])
+}
+
+func isWorkflowPath(path string) bool {
+	return strings.HasSuffix(path, ".yml") || strings.HasSuffix(path, ".yaml")
+}
 
+// fetchWorkflowFile fetches a workflow file at its ref.
+// Returns nil (no error) if the file is not found (404).
+func fetchWorkflowFile(
+	parentCtx context.Context,
+	client *GitHubClient,
+	file workflowFile,
+) (*WorkflowDefVersion, error) {
 	ctx, cancel := ghCtx(parentCtx)
 	defer cancel()
 
-	fileContent, _, resp, err := client.Rest.Repositories.GetContents(ctx, owner, repo, workflowPath,
-		&github.RepositoryContentGetOptions{Ref: headSHA},
+	fileContent, _, resp, err := client.Rest.Repositories.GetContents(ctx, file.owner, file.repo, file.path,
+		&github.RepositoryContentGetOptions{Ref: file.ref},
 	)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
-			log.Warn().
-				Str("workflow_path", workflowPath).
-				Str("head_sha", headSHA).
-				Msg("Workflow file not found at run SHA; skipping flow chart")
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to fetch workflow file '%s' at SHA '%s': %w", workflowPath, headSHA, err)
+		return nil, fmt.Errorf("failed to fetch workflow file '%s' at '%s': %w", file.path, file.ref, err)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code %d fetching workflow file", resp.StatusCode)
@@ -191,15 +396,134 @@ func workflowDefData(
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode workflow file content: %w", err)
 	}
+	return newWorkflowDefVersion(file.path, file.ref, rawContent), nil
+}
 
-	def, err := ParseWorkflowDef(rawContent)
+// workflowDefData fetches and parses the workflow YAML file at the run's HeadSHA, along with the reusable
+// workflows its jobs call. It also returns the version of the file it parsed, even when parsing fails.
+// Returns nil (no error) if the file is not found (404) — the flow chart is omitted gracefully.
+func workflowDefData(
+	parentCtx context.Context,
+	log zerolog.Logger,
+	client *GitHubClient,
+	owner, repo string,
+	workflowRun *github.WorkflowRun,
+) (*WorkflowDef, *WorkflowDefVersion, error) {
+	file, ok := runWorkflowFile(owner, repo, workflowRun)
+	if client == nil || !ok {
+		return nil, nil, nil
+	}
+
+	version, err := fetchWorkflowFile(parentCtx, client, file)
 	if err != nil {
+		return nil, nil, err
+	}
+	if version == nil {
 		log.Warn().
-			Str("workflow_path", workflowPath).
+			Str("workflow_path", file.path).
+			Str("head_sha", file.ref).
+			Msg("Workflow file not found at run SHA; skipping flow chart")
+		return nil, nil, nil
+	}
+
+	def, err := ParseWorkflowDef([]byte(version.Content))
+	if err != nil {
+		log.Warn().
+			Str("workflow_path", file.path).
 			Err(err).
 			Msg("Failed to parse workflow YAML; skipping flow chart")
-		return nil, nil
+		return nil, version, nil
 	}
 
+	if err := resolveReusableWorkflows(parentCtx, log, client, def, file, 0, map[string]*WorkflowDef{}); err != nil {
+		return nil, nil, err
+	}
+	return def, version, nil
+}
+
+// runWorkflowFile is the workflow file a run was started from, at the run's HeadSHA.
+func runWorkflowFile(owner, repo string, workflowRun *github.WorkflowRun) (workflowFile, bool) {
+	if workflowRun == nil || workflowRun.GetPath() == "" || workflowRun.GetHeadSHA() == "" {
+		return workflowFile{}, false
+	}
+	return workflowFile{owner: owner, repo: repo, path: workflowRun.GetPath(), ref: workflowRun.GetHeadSHA()}, true
+}
+
+// resolveReusableWorkflows fetches and parses the reusable workflows def's jobs call, and the ones those call in
+// turn, setting each job's Calls. def was read from file. Reusable workflows that can't be fetched or parsed are
+// left unresolved, and the jobs calling them stay opaque. resolved caches workflows by file across the run.
+func resolveReusableWorkflows(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *GitHubClient,
+	def *WorkflowDef,
+	file workflowFile,
+	depth int,
+	resolved map[string]*WorkflowDef,
+) error {
+	for id, job := range def.Jobs {
+		if job.Uses == "" {
+			continue
+		}
+		calledFile, ok := file.calledWorkflowFile(job.Uses)
+		if !ok {
+			log.Debug().Str("uses", job.Uses).Msg("Unsupported reusable workflow reference; leaving job unexpanded")
+			continue
+		}
+		if depth >= maxReusableWorkflowDepth {
+			log.Warn().Str("uses", job.Uses).Msg("Reusable workflows nested too deep; leaving job unexpanded")
+			continue
+		}
+
+		key := calledFile.String()
+		calledDef, ok := resolved[key]
+		if !ok {
+			// Workflows calling themselves, directly or not, find nil here and are left unexpanded
+			resolved[key] = nil
+			var err error
+			calledDef, err = reusableWorkflowDef(ctx, log, client, calledFile)
+			if err != nil {
+				return err
+			}
+			if calledDef != nil {
+				err = resolveReusableWorkflows(ctx, log, client, calledDef, calledFile, depth+1, resolved)
+				if err != nil {
+					return err
+				}
+			}
+			resolved[key] = calledDef
+		}
+		job.Calls = calledDef
+		def.Jobs[id] = job
+	}
+	return nil
+}
+
+// reusableWorkflowDef fetches and parses a reusable workflow, returning nil when that fails
+// unless the context is done.
+func reusableWorkflowDef(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *GitHubClient,
+	file workflowFile,
+) (*WorkflowDef, error) {
+	log = log.With().Str("reusable_workflow", file.String()).Logger()
+	version, err := fetchWorkflowFile(ctx, client, file)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Warn().Err(err).Msg("Failed to fetch reusable workflow; leaving job unexpanded")
+		return nil, nil
+	}
+	if version == nil {
+		log.Warn().Msg("Reusable workflow not found; leaving job unexpanded")
+		return nil, nil
+	}
+	def, err := ParseWorkflowDef([]byte(version.Content))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse reusable workflow; leaving job unexpanded")
+		return nil, nil
+	}
 	return def, nil
 }
@@ -1,10 +1,18 @@
 package gather
 
 import (
+	"encoding/base64"
+	"net/http"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/google/go-github/v89/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
 )
 
 func TestParseWorkflowDef(t *testing.T) {
@@ -110,6 +118,11 @@ jobs:
 					Name:   "test",
 					Needs:  nil,
 					RunsOn: "ubuntu-latest",
+					Strategy: &Strategy{
+						Matrix: &Matrix{
+							Dimensions: []MatrixDimension{{Name: "node", Values: []any{14, 16, 18}}},
+						},
+					},
 				},
 			},
 		},
@@ -162,6 +175,75 @@ on: push
 	}
 }
 
+func TestParseWorkflowDefJobSettings(t *testing.T) {
+	t.Parallel()
+
+	def, err := ParseWorkflowDef([]byte(`
+name: CI
+on: push
+concurrency: ci-${{ github.ref }}
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    if: ${{ github.event_name == 'push' }}
+    timeout-minutes: 30
+    continue-on-error: true
+    environment: staging
+    container: golang:1.24
+    services:
+      postgres:
+        image: postgres:16
+        ports: [5432]
+      redis: redis:7
+  deploy:
+    runs-on: ubuntu-latest
+    if: always()
+    timeout-minutes: ${{ inputs.timeout }}
+    continue-on-error: ${{ matrix.experimental }}
+    concurrency:
+      group: deploy-${{ github.ref }}
+      cancel-in-progress: true
+    environment:
+      name: production
+      url: https://example.com
+    container:
+      image: node:22
+      options: --cpus 2
+`))
+	require.NoError(t, err)
+	require.NotNil(t, def.Concurrency)
+	assert.Equal(t, "ci-${{ github.ref }}", def.Concurrency.Group)
+	assert.False(t, def.Concurrency.Cancels())
+
+	test := def.Jobs["test"]
+	assert.Equal(t, "github.event_name == 'push'", test.Condition())
+	timeout, ok := test.Timeout()
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Minute, timeout)
+	assert.Equal(t, "true", test.ContinueOnError)
+	assert.Equal(t, &Environment{Name: "staging"}, test.Environment)
+	assert.Equal(t, &Container{Image: "golang:1.24"}, test.Container)
+	assert.Equal(t, map[string]Container{
+		"postgres": {Image: "postgres:16"},
+		"redis":    {Image: "redis:7"},
+	}, test.Services)
+	assert.Nil(t, test.Concurrency)
+
+	deploy := def.Jobs["deploy"]
+	assert.Equal(t, "always()", deploy.Condition())
+	_, ok = deploy.Timeout()
+	assert.False(t, ok, "timeouts set with an expression are only known at runtime")
+	assert.Equal(t, "${{ matrix.experimental }}", deploy.ContinueOnError)
+	assert.Equal(t, &Concurrency{Group: "deploy-${{ github.ref }}", CancelInProgress: "true"}, deploy.Concurrency)
+	assert.True(t, deploy.Concurrency.Cancels())
+	assert.Equal(t, &Environment{Name: "production", URL: "https://example.com"}, deploy.Environment)
+	assert.Equal(t, &Container{Image: "node:22"}, deploy.Container)
+
+	timeout, ok = JobDef{}.Timeout()
+	require.True(t, ok)
+	assert.Equal(t, 6*time.Hour, timeout, "jobs without timeout-minutes should get GitHub's default")
+}
+
 func TestWorkflowDefGetJobID(t *testing.T) {
 	t.Parallel()
 
@@ -192,3 +274,163 @@ func TestWorkflowDefGetJobID(t *testing.T) {
 	_, ok = def.GetJobIDByName("unknown")
 	assert.False(t, ok)
 }
+
+func TestWorkflowDefExpanded(t *testing.T) {
+	t.Parallel()
+
+	def := &WorkflowDef{
+		Jobs: map[string]JobDef{
+			"build": {Name: "build"},
+			"e2e": {
+				Name:  "E2E",
+				Needs: Needs{"build"},
+				Uses:  "./.github/workflows/e2e.yml",
+				Calls: &WorkflowDef{
+					Jobs: map[string]JobDef{
+						"setup": {Name: "setup"},
+						"smoke": {Name: "smoke", Needs: Needs{"setup"}},
+						"soak":  {Name: "soak", Needs: Needs{"setup"}},
+					},
+				},
+			},
+			"deploy": {Name: "deploy", Needs: Needs{"e2e"}},
+		},
+	}
+
+	expanded := def.Expanded()
+	assert.Equal(t, map[string]JobDef{
+		"build":     {Name: "build"},
+		"e2e/setup": {Name: "E2E / setup", Needs: Needs{"build"}},
+		"e2e/smoke": {Name: "E2E / smoke", Needs: Needs{"e2e/setup"}},
+		"e2e/soak":  {Name: "E2E / soak", Needs: Needs{"e2e/setup"}},
+		"deploy":    {Name: "deploy", Needs: Needs{"e2e/smoke", "e2e/soak"}},
+	}, expanded.Jobs)
+	assert.Contains(t, def.Jobs, "e2e", "expanding should leave the workflow as it was")
+
+	id, ok := expanded.GetJobIDByName("E2E / smoke")
+	require.True(t, ok, "runtime names of called jobs should match")
+	assert.Equal(t, "e2e/smoke", id)
+
+	assert.Nil(t, (*WorkflowDef)(nil).Expanded())
+}
+
+func TestCalledWorkflowFile(t *testing.T) {
+	t.Parallel()
+
+	caller := workflowFile{owner: "acme", repo: "app", path: ".github/workflows/ci.yml", ref: "abc123"}
+	tests := []struct {
+		uses   string
+		want   workflowFile
+		wantOK bool
+	}{
+		{
+			uses:   "./.github/workflows/e2e.yml",
+			want:   workflowFile{owner: "acme", repo: "app", path: ".github/workflows/e2e.yml", ref: "abc123"},
+			wantOK: true,
+		},
+		{
+			uses:   "acme/shared/.github/workflows/lint.yaml@v1",
+			want:   workflowFile{owner: "acme", repo: "shared", path: ".github/workflows/lint.yaml", ref: "v1"},
+			wantOK: true,
+		},
+		{uses: "acme/shared/.github/workflows/lint.yml"},
+		{uses: "actions/checkout@v4"},
+		{uses: "./.github/actions/setup"},
+	}
+	for _, tt := range tests {
+		got, ok := caller.calledWorkflowFile(tt.uses)
+		assert.Equal(t, tt.wantOK, ok, tt.uses)
+		if tt.wantOK {
+			assert.Equal(t, tt.want, got, tt.uses)
+		}
+	}
+}
+
+func TestWorkflowDefDataReusableWorkflows(t *testing.T) {
+	t.Parallel()
+
+	log, _ := testhelpers.Setup(t)
+	files := map[string]string{
+		"/repos/acme/app/contents/.github/workflows/ci.yml": `
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  e2e:
+    needs: build
+    uses: ./.github/workflows/e2e.yml
+  lint:
+    uses: acme/shared/.github/workflows/lint.yml@v1
+  missing:
+    uses: ./.github/workflows/missing.yml
+`,
+		"/repos/acme/app/contents/.github/workflows/e2e.yml": `
+jobs:
+  setup:
+    runs-on: ubuntu-latest
+  nested:
+    needs: setup
+    uses: ./.github/workflows/loop.yml
+`,
+		// Calls itself, which is left unexpanded
+		"/repos/acme/app/contents/.github/workflows/loop.yml": `
+jobs:
+  again:
+    uses: ./.github/workflows/loop.yml
+`,
+		"/repos/acme/shared/contents/.github/workflows/lint.yml": `
+jobs:
+  golangci:
+    name: golangci-lint
+    runs-on: ubuntu-latest
+`,
+	}
+	var refs []string
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposContentsByOwnerByRepoByPath,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				refs = append(refs, r.URL.Query().Get("ref"))
+				content, ok := files[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write(mock.MustMarshal(&github.RepositoryContent{
+					Encoding: new("base64"),
+					Content:  new(base64.StdEncoding.EncodeToString([]byte(content))),
+				}))
+			}),
+		),
+	)
+	client, err := NewGitHubClient(log, "mock-token", mockedHTTPClient.Transport)
+	require.NoError(t, err)
+
+	def, version, err := workflowDefData(t.Context(), log, client, "acme", "app", &github.WorkflowRun{
+		Path:    new(".github/workflows/ci.yml"),
+		HeadSHA: new("abc123"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, def)
+	require.NotNil(t, version)
+	assert.Equal(t, ".github/workflows/ci.yml", version.Path)
+	assert.Equal(t, "abc123", version.Ref)
+	assert.Equal(t, files["/repos/acme/app/contents/.github/workflows/ci.yml"], version.Content)
+
+	e2e := def.Jobs["e2e"].Calls
+	require.NotNil(t, e2e, "local reusable workflows should be resolved")
+	assert.Contains(t, e2e.Jobs, "setup")
+	require.NotNil(t, def.Jobs["lint"].Calls, "reusable workflows of other repos should be resolved")
+	assert.Equal(t, "golangci-lint", def.Jobs["lint"].Calls.Jobs["golangci"].Name)
+	assert.Nil(t, def.Jobs["missing"].Calls, "missing reusable workflows should stay unexpanded")
+	loop := e2e.Jobs["nested"].Calls
+	require.NotNil(t, loop, "nested reusable workflows should be resolved")
+	assert.Nil(t, loop.Jobs["again"].Calls, "workflows calling themselves should stay unexpanded")
+
+	slices.Sort(refs)
+	assert.Equal(t, []string{"abc123", "abc123", "abc123", "abc123", "v1"}, refs,
+		"every workflow file should be fetched once, local ones at the run's SHA")
+
+	expanded := def.Expanded()
+	assert.Contains(t, expanded.Jobs, "lint/golangci")
+	assert.Equal(t, Needs{"build"}, expanded.Jobs["e2e/setup"].Needs)
+}
@@ -0,0 +1,126 @@
+package gather
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // git identifies blobs by their SHA-1
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// WorkflowDefsDataDir is the Store category holding the versions of the workflow files runs were started from,
+// keyed by git blob SHA so runs of an unchanged file share a version. Like SyncDataDir, it isn't listed by Entries.
+const WorkflowDefsDataDir = "workflow_defs"
+
+// WorkflowDefVersion is a workflow file as it was at a commit.
+type WorkflowDefVersion struct {
+	Path string `json:"path"`
+	// SHA is the git blob SHA of Content, which stays the same across commits that don't change the file.
+	SHA string `json:"sha"`
+	// Ref is the commit the version was first fetched at.
+	Ref     string `json:"ref"`
+	Content string `json:"content"`
+}
+
+func newWorkflowDefVersion(path, ref string, content []byte) *WorkflowDefVersion {
+	hash := sha1.New() //nolint:gosec // git identifies blobs by their SHA-1
+	_, _ = fmt.Fprintf(hash, "blob %d\x00", len(content))
+	_, _ = hash.Write(content)
+	return &WorkflowDefVersion{
+		Path:    path,
+		SHA:     hex.EncodeToString(hash.Sum(nil)),
+		Ref:     ref,
+		Content: string(content),
+	}
+}
+
+// saveWorkflowDefVersion stores version, unless the store already has it.
+func saveWorkflowDefVersion(store Store, owner, repo string, version *WorkflowDefVersion) error {
+	if version == nil || storeHas(store, owner, repo, WorkflowDefsDataDir, version.SHA) {
+		return nil
+	}
+	if err := store.Save(owner, repo, WorkflowDefsDataDir, version.SHA, version); err != nil {
+		return fmt.Errorf("failed to save workflow file version '%s': %w", version.SHA, err)
+	}
+	return nil
+}
+
+// WorkflowRunDefVersion returns the version of the workflow file a run was started from, whose SHA links it to
+// the run. Versions stored while gathering the run are loaded from the data dir. Runs gathered before versions were
+// stored have theirs fetched at the run's HeadSHA through the API, and only the version is stored: workflowRun is
+// left as it is, so callers sharing it don't race, and linking it is up to them.
+// Returns nil (no error) when the file isn't found, or isn't stored and there's no client to fetch it with.
+func WorkflowRunDefVersion(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *GitHubClient,
+	workflowRun *WorkflowRunData,
+	opts ...Option,
+) (*WorkflowDefVersion, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if workflowRun == nil {
+		return nil, nil
+	}
+
+	owner, repo := workflowRun.GetOwner(), workflowRun.GetRepo()
+	store, err := o.getStore()
+	if err != nil {
+		return nil, err
+	}
+	if workflowRun.WorkflowDefSHA != "" {
+		var version *WorkflowDefVersion
+		err := store.Load(owner, repo, WorkflowDefsDataDir, workflowRun.WorkflowDefSHA, &version)
+		if err == nil && version != nil {
+			return version, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to load workflow file version '%s': %w", workflowRun.WorkflowDefSHA, err)
+		}
+	}
+
+	file, ok := runWorkflowFile(owner, repo, workflowRun.WorkflowRun)
+	if client == nil || !ok {
+		return nil, nil
+	}
+	log.Debug().
+		Int64("workflow_run_id", workflowRun.GetID()).
+		Str("workflow_file", file.path).
+		Msg("Fetching workflow file version that wasn't stored with the run")
+	version, err := fetchWorkflowFile(ctx, client, file)
+	if err != nil || version == nil {
+		return nil, err
+	}
+	if err := saveWorkflowDefVersion(store, owner, repo, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// WorkflowDefVersionAt fetches the version of a workflow file at ref through the API, at the head of the repo's
+// default branch when ref is empty. Unlike the versions runs were started from, it isn't stored, as refs move.
+// Returns nil (no error) when the file isn't found, or there's no client to fetch it with.
+func WorkflowDefVersionAt(
+	ctx context.Context,
+	client *GitHubClient,
+	owner, repo, path, ref string,
+) (*WorkflowDefVersion, error) {
+	if client == nil {
+		return nil, nil
+	}
+	if ref == "" {
+		repoCtx, cancel := ghCtx(ctx)
+		repoData, _, err := client.Rest.Repositories.Get(repoCtx, owner, repo)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the default branch of %s/%s: %w", owner, repo, err)
+		}
+		ref = repoData.GetDefaultBranch()
+	}
+	return fetchWorkflowFile(ctx, client, workflowFile{owner: owner, repo: repo, path: path, ref: ref})
+}
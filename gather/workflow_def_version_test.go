@@ -0,0 +1,103 @@
+package gather
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func TestNewWorkflowDefVersion(t *testing.T) {
+	t.Parallel()
+
+	version := newWorkflowDefVersion(".github/workflows/ci.yml", "abc123", []byte("hello\n"))
+	// git hash-object of "hello\n"
+	assert.Equal(t, "ce013625030ba8dba906f756967f9e9ca394464a", version.SHA)
+	assert.Equal(t, "hello\n", version.Content)
+}
+
+func TestWorkflowRunDefVersion(t *testing.T) {
+	t.Parallel()
+
+	log, dataDir := testhelpers.Setup(t)
+	contents := map[string]string{
+		"old": "jobs:\n  build:\n    runs-on: ubuntu-latest\n",
+		"new": "jobs:\n  build:\n    runs-on: ubuntu-24.04\n",
+	}
+	var fetched []string
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposContentsByOwnerByRepoByPath,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ref := r.URL.Query().Get("ref")
+				fetched = append(fetched, ref)
+				content, ok := contents[ref]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write(mock.MustMarshal(&github.RepositoryContent{
+					Encoding: new("base64"),
+					Content:  new(base64.StdEncoding.EncodeToString([]byte(content))),
+				}))
+			}),
+		),
+	)
+	client, err := NewGitHubClient(log, "mock-token", mockedHTTPClient.Transport)
+	require.NoError(t, err)
+
+	run := func(id int64, headSHA string) *WorkflowRunData {
+		return &WorkflowRunData{WorkflowRun: &github.WorkflowRun{
+			ID:      new(id),
+			Path:    new(".github/workflows/ci.yml"),
+			HeadSHA: new(headSHA),
+			Repository: &github.Repository{
+				Name:  new("repo"),
+				Owner: &github.User{Login: new("owner")},
+			},
+		}}
+	}
+	opts := []Option{CustomDataFolder(dataDir)}
+
+	oldRun := run(1, "old")
+	version, err := WorkflowRunDefVersion(t.Context(), log, client, oldRun, opts...)
+	require.NoError(t, err)
+	require.NotNil(t, version)
+	assert.Equal(t, contents["old"], version.Content)
+	assert.Equal(t, "old", version.Ref)
+	assert.Empty(t, oldRun.WorkflowDefSHA, "the run should be left for the caller to link")
+	_, err = loadWorkflowRun(NewFileStore(dataDir), "owner", "repo", 1)
+	require.Error(t, err, "the run shouldn't be saved")
+
+	linked := run(1, "old")
+	linked.WorkflowDefSHA = version.SHA
+	offline, err := WorkflowRunDefVersion(t.Context(), log, nil, linked, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, version, offline, "stored versions should load without a client")
+	assert.Equal(t, []string{"old"}, fetched)
+
+	newVersion, err := WorkflowRunDefVersion(t.Context(), log, client, run(2, "new"), opts...)
+	require.NoError(t, err)
+	require.NotNil(t, newVersion)
+	assert.NotEqual(t, version.SHA, newVersion.SHA)
+
+	missing, err := WorkflowRunDefVersion(t.Context(), log, client, run(3, "deleted"), opts...)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	unknown, err := WorkflowRunDefVersion(t.Context(), log, nil, run(4, "old"), opts...)
+	require.NoError(t, err)
+	assert.Nil(t, unknown, "runs without a stored version can't be looked up without a client")
+
+	entries, err := NewFileStore(dataDir).Entries()
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotEqual(t, WorkflowDefsDataDir, entry.Category, "versions should not be listed")
+	}
+}
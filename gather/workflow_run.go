@@ -93,6 +93,8 @@ type JobData struct {
 	CostEstimate bool `json:"cost_estimate,omitempty"`
 	// CostGathered is true when cost data was gathered (billing API or log parsing), false if skipped
 	CostGathered bool `json:"cost_gathered,omitempty"`
+	// PricingVersion is the version of the pricing catalog Cost was priced with, empty for built-in rates
+	PricingVersion string `json:"pricing_version,omitempty"`
 	// Analysis is monitoring analysis data for the job run
 	Analysis *monitor.Analysis `json:"analysis,omitempty"`
 	// LogPath is the path to the downloaded raw log file for this job
@@ -139,6 +141,14 @@ func (j *JobData) GetCostGathered() bool {
 	return j.CostGathered
 }
 
+// GetPricingVersion returns the version of the pricing catalog the job's cost was priced with.
+func (j *JobData) GetPricingVersion() string {
+	if j == nil {
+		return ""
+	}
+	return j.PricingVersion
+}
+
 // GetAnalysis returns the monitoring analysis data for the job run.
 func (j *JobData) GetAnalysis() *monitor.Analysis {
 	if j == nil || j.Analysis == nil {
@@ -155,9 +165,11 @@ type WorkflowRunData struct {
 	Cost                     int64                    `json:"cost"`
 	CostEstimate             bool                     `json:"cost_estimate,omitempty"`
 	CostGathered             bool                     `json:"cost_gathered,omitempty"`
+	PricingVersion           string                   `json:"pricing_version,omitempty"`
 	RunCompletedAt           time.Time                `json:"completed_at"`
 	Usage                    *github.WorkflowRunUsage `json:"usage,omitempty"`
 	WorkflowDef              *WorkflowDef             `json:"workflow_def,omitempty"`
+	WorkflowDefSHA           string                   `json:"workflow_def_sha,omitempty"`
 	CorrespondingPRNum       int                      `json:"corresponding_pr_number,omitempty"`
 	CorrespondingPRCloseTime time.Time                `json:"corresponding_pr_close_time,omitzero"`
 	CorrespondingCommitSHA   string                   `json:"corresponding_commit_sha,omitempty"`
@@ -264,9 +276,11 @@ func tryLoadWorkflowRunFromCache(
 	owner, repo string,
 	workflowRunID int64,
 	opts *options,
-	cacheKey, targetFile string,
+	store Store,
+	cacheKey string,
 ) (*WorkflowRunData, bool) {
-	if opts.ForceUpdate || !cacheFileExists(targetFile) {
+	id := fmt.Sprint(workflowRunID)
+	if opts.ForceUpdate || !storeHas(store, owner, repo, WorkflowRunsDataDir, id) {
 		return nil, false
 	}
 	if !opts.SkipMemoryCache {
@@ -277,14 +291,13 @@ func tryLoadWorkflowRunFromCache(
 			}
 		}
 	}
-	data, loadErr := loadWorkflowRunFromDisk(targetFile)
+	data, loadErr := loadWorkflowRun(store, owner, repo, workflowRunID)
 	if loadErr != nil {
 		if !errors.Is(loadErr, os.ErrNotExist) {
 			log.Warn().
 				Err(loadErr).
-				Str("target_file", targetFile).
 				Msg("Corrupted local cache file encountered; re-fetching from GitHub")
-			_ = os.Remove(targetFile)
+			_ = store.Delete(owner, repo, WorkflowRunsDataDir, id)
 		}
 		return nil, false
 	}
@@ -314,6 +327,13 @@ func tryLoadWorkflowRunFromCache(
 			data.LogsDir = dlLogsDir
 		}
 	}
+	if opts.gatherCost && data.CostGathered && data.PricingVersion != opts.pricing.GetVersion() && client != nil {
+		log.Debug().
+			Str("cached_pricing_version", data.PricingVersion).
+			Str("pricing_version", opts.pricing.GetVersion()).
+			Msg("Cached workflow run was priced with another pricing catalog; refreshing from GitHub API")
+		return nil, false
+	}
 	if !opts.gatherCost || data.CostGathered || client == nil {
 		workflowRunCache.Store(cacheKey, data)
 		return data, true
@@ -335,8 +355,13 @@ func WorkflowRun(
 		opt(opts)
 	}
 
+	store, err := opts.getStore()
+	if err != nil {
+		return nil, "", err
+	}
+	targetFile := store.Location(owner, repo, WorkflowRunsDataDir, fmt.Sprint(workflowRunID))
+	// Monitoring artifacts are downloaded and analyzed here, whichever Store holds the run itself.
 	targetDir := filepath.Join(opts.DataDir, owner, repo, WorkflowRunsDataDir)
-	targetFile := filepath.Join(targetDir, fmt.Sprintf("%d.json", workflowRunID))
 
 	if err := ensureDataDir(targetDir, WorkflowRunsDataDir); err != nil {
 		return nil, "", fmt.Errorf("failed to make data dir '%s': %w", WorkflowRunsDataDir, err)
@@ -345,7 +370,7 @@ func WorkflowRun(
 	log = log.With().Str("target_file", targetFile).Int64("workflow_run_id", workflowRunID).Logger()
 	startTime := time.Now()
 
-	cacheKey := fmt.Sprintf("%s:cost=%t", targetFile, opts.gatherCost)
+	cacheKey := fmt.Sprintf("%s:cost=%t:pricing=%s", targetFile, opts.gatherCost, opts.pricing.GetVersion())
 
 	if cached, ok := tryLoadWorkflowRunFromCache(
 		ctx,
@@ -355,8 +380,8 @@ func WorkflowRun(
 		repo,
 		workflowRunID,
 		opts,
+		store,
 		cacheKey,
-		targetFile,
 	); ok {
 		log.Debug().
 			Str("duration", time.Since(startTime).String()).
@@ -374,8 +399,8 @@ func WorkflowRun(
 			repo,
 			workflowRunID,
 			opts,
+			store,
 			cacheKey,
-			targetFile,
 		); ok {
 			return cached, nil
 		}
@@ -393,13 +418,14 @@ func WorkflowRun(
 			repo,
 			workflowRunID,
 			opts,
+			store,
 			targetDir,
 		)
 		if fetchErr != nil {
 			return nil, fetchErr
 		}
 
-		if saveErr := saveWorkflowRunToDisk(workflowRunData, owner, repo, opts.DataDir, targetFile); saveErr != nil {
+		if saveErr := saveWorkflowRun(store, owner, repo, workflowRunID, workflowRunData); saveErr != nil {
 			return nil, fmt.Errorf("failed to save workflow run data for '%d': %w", workflowRunID, saveErr)
 		}
 
@@ -417,131 +443,46 @@ func WorkflowRun(
 	return val.(*WorkflowRunData), targetFile, nil
 }
 
-// loadWorkflowRunFromDisk loads a workflow run from local disk.
-func loadWorkflowRunFromDisk(targetFile string) (*WorkflowRunData, error) {
-	if !cacheFileExists(targetFile) {
-		return nil, os.ErrNotExist
+// loadWorkflowRun loads a workflow run from the store.
+func loadWorkflowRun(store Store, owner, repo string, workflowRunID int64) (*WorkflowRunData, error) {
+	var data *WorkflowRunData
+	if err := store.Load(owner, repo, WorkflowRunsDataDir, fmt.Sprint(workflowRunID), &data); err != nil {
+		return nil, err
 	}
-	return readJSONFile[*WorkflowRunData](targetFile)
+	return data, nil
 }
 
-// FindWorkflowRunIDForJob scans the data directory for the workflow run that contains
+// FindWorkflowRunIDForJob searches store for the workflow run that contains
 // the given job ID. Returns the workflow run ID and nil error if found.
-func FindWorkflowRunIDForJob(dataDir, owner, repo string, jobID int64) (int64, error) {
-	var targetWfID int64
-	var found bool
-
-	err := filepath.WalkDir(dataDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
-			return nil
-		}
-		dirName := filepath.Base(filepath.Dir(path))
-		if dirName != WorkflowRunsDataDir {
-			return nil
-		}
-		if owner != "" && repo != "" {
-			rel, relErr := filepath.Rel(dataDir, path)
-			if relErr == nil {
-				parts := strings.Split(rel, string(filepath.Separator))
-				if len(parts) >= 2 && (parts[0] != owner || parts[1] != repo) {
-					return nil
-				}
-			}
-		}
-		wfID, parseErr := strconv.ParseInt(strings.TrimSuffix(d.Name(), ".json"), 10, 64)
-		if parseErr != nil {
-			return nil
-		}
-		data, loadErr := loadWorkflowRunFromDisk(path)
-		if loadErr != nil {
-			return nil
-		}
-		for _, job := range data.Jobs {
-			if job.GetID() == jobID {
-				targetWfID = wfID
-				found = true
-				return filepath.SkipAll
-			}
-		}
-		return nil
-	})
-	if err != nil || !found {
+func FindWorkflowRunIDForJob(store Store, owner, repo string, jobID int64) (int64, error) {
+	entry, err := store.FindWorkflowRunForJob(owner, repo, jobID)
+	if err != nil {
 		return 0, fmt.Errorf("job %d not found in dataDir", jobID)
 	}
-
-	return targetWfID, nil
+	return strconv.ParseInt(entry.ID, 10, 64)
 }
 
-// FindOwnerRepoForJob scans the data directory for the workflow run containing jobID and returns its owner and repo.
-func FindOwnerRepoForJob(dataDir string, jobID int64) (owner, repo string, wfID int64, err error) {
-	var foundOwner, foundRepo string
-	var targetWfID int64
-	var found bool
-
-	walkErr := filepath.WalkDir(dataDir, func(path string, d os.DirEntry, walkErr error) error {
-		if walkErr != nil || d.IsDir() || filepath.Ext(path) != ".json" {
-			return nil
-		}
-		if filepath.Base(filepath.Dir(path)) != WorkflowRunsDataDir {
-			return nil
-		}
-		wfIDVal, parseErr := strconv.ParseInt(strings.TrimSuffix(d.Name(), ".json"), 10, 64)
-		if parseErr != nil {
-			return nil
-		}
-		data, loadErr := loadWorkflowRunFromDisk(path)
-		if loadErr != nil {
-			return nil
-		}
-		for _, job := range data.Jobs {
-			if job.GetID() == jobID {
-				targetWfID = wfIDVal
-				foundOwner = data.GetOwner()
-				foundRepo = data.GetRepo()
-				found = true
-				return filepath.SkipAll
-			}
-		}
-		return nil
-	})
-
-	if walkErr != nil || !found {
+// FindOwnerRepoForJob searches store for the workflow run containing jobID
+// and returns its owner and repo.
+func FindOwnerRepoForJob(store Store, jobID int64) (owner, repo string, wfID int64, err error) {
+	entry, err := store.FindWorkflowRunForJob("", "", jobID)
+	if err != nil {
 		return "", "", 0, fmt.Errorf("job %d not found in dataDir", jobID)
 	}
-
-	return foundOwner, foundRepo, targetWfID, nil
+	wfID, err = strconv.ParseInt(entry.ID, 10, 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid workflow run ID '%s' for job %d: %w", entry.ID, jobID, err)
+	}
+	return entry.Owner, entry.Repo, wfID, nil
 }
 
-// saveWorkflowRunToDisk saves a workflow run to local disk.
-func saveWorkflowRunToDisk(data *WorkflowRunData, owner, repo, dataDir, targetFile string) error {
-	if err := writeJSONFile(targetFile, data); err != nil {
+// saveWorkflowRun saves a workflow run to the store and records it and its jobs in the manifest.
+func saveWorkflowRun(store Store, owner, repo string, workflowRunID int64, data *WorkflowRunData) error {
+	if err := store.Save(owner, repo, WorkflowRunsDataDir, fmt.Sprint(workflowRunID), data); err != nil {
 		return err
 	}
-	state := data.GetConclusion()
-	if state == "" {
-		state = data.GetStatus()
-	}
-	_ = AppendManifestRecord(dataDir, owner, repo, ManifestRecord{
-		Type:      "workflow_run",
-		ID:        fmt.Sprint(data.GetID()),
-		Name:      data.GetName(),
-		State:     state,
-		Actor:     data.GetActor().GetLogin(),
-		CreatedAt: data.GetCreatedAt().Time,
-	})
-	for _, job := range data.Jobs {
-		jState := job.GetConclusion()
-		if jState == "" {
-			jState = job.GetStatus()
-		}
-		_ = AppendManifestRecord(dataDir, owner, repo, ManifestRecord{
-			Type:      "job_run",
-			ID:        fmt.Sprint(job.GetID()),
-			Name:      job.GetName(),
-			State:     jState,
-			Actor:     data.GetActor().GetLogin(),
-			CreatedAt: job.GetStartedAt().Time,
-		})
+	for _, rec := range workflowRunManifestRecords(data) {
+		_ = store.AppendManifestRecord(owner, repo, rec)
 	}
 	return nil
 }
@@ -554,6 +495,7 @@ func fetchWorkflowRunFromGitHub(
 	owner, repo string,
 	workflowRunID int64,
 	opts *options,
+	store Store,
 	targetDir string,
 ) (*WorkflowRunData, error) {
 	log.Debug().Msg("Fetching workflow run data from GitHub")
@@ -568,8 +510,8 @@ func fetchWorkflowRunFromGitHub(
 		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
-	targetFile := filepath.Join(targetDir, fmt.Sprintf("%d.json", workflowRunID))
-	if existingData, loadErr := loadWorkflowRunFromDisk(targetFile); loadErr == nil && existingData != nil {
+	existingData, loadErr := loadWorkflowRun(store, owner, repo, workflowRunID)
+	if loadErr == nil && existingData != nil {
 		if !existingData.IsInProgress() && workflowRun.GetStatus() == "completed" {
 			remoteUpdated := workflowRun.GetUpdatedAt().Time
 			localUpdated := existingData.GetUpdatedAt().Time
@@ -656,6 +598,7 @@ func fetchWorkflowRunFromGitHub(
 		workflowBillingData *github.WorkflowRunUsage
 		analyses            []*monitor.Analysis
 		workflowDef         *WorkflowDef
+		workflowDefVersion  *WorkflowDefVersion
 	)
 
 	egCtxInst, egCancel := ghCtx(parentCtx)
@@ -664,7 +607,7 @@ func fetchWorkflowRunFromGitHub(
 
 	eg.Go(func() error {
 		var defErr error
-		workflowDef, defErr = workflowDefData(egCtx, log, client, owner, repo, workflowRun)
+		workflowDef, workflowDefVersion, defErr = workflowDefData(egCtx, log, client, owner, repo, workflowRun)
 		return defErr
 	})
 
@@ -697,6 +640,11 @@ func fetchWorkflowRunFromGitHub(
 
 	data.Usage = workflowBillingData
 	data.WorkflowDef = workflowDef
+	if err := saveWorkflowDefVersion(store, owner, repo, workflowDefVersion); err != nil {
+		log.Warn().Err(err).Msg("Failed to store workflow file version; comparisons will fetch it again")
+	} else if workflowDefVersion != nil {
+		data.WorkflowDefSHA = workflowDefVersion.SHA
+	}
 	processJobs(
 		parentCtx,
 		log,
@@ -707,6 +655,7 @@ func fetchWorkflowRunFromGitHub(
 		workflowRunJobs,
 		workflowBillingData,
 		opts.gatherCost,
+		opts.pricing,
 		opts.DataDir,
 	)
 	processAnalyses(log, data, analyses)
@@ -818,11 +767,13 @@ func processJobs(
 	jobs []*github.WorkflowJob,
 	billingData *github.WorkflowRunUsage,
 	gatherCost bool,
+	pricing *PricingCatalog,
 	dataDir string,
 ) {
 	completed := data.GetStatus() == "completed"
 	if gatherCost && completed {
 		data.CostGathered = true
+		data.PricingVersion = pricing.GetVersion()
 	}
 	billingIndex := buildJobBillingIndex(billingData)
 
@@ -882,13 +833,15 @@ func processJobs(
 
 	// Pass 2: calculate billing/estimates and assemble jobs
 	for _, job := range jobs {
-		runner, cost, costEstimate := calculateJobCostAndRunner(
+		runner, cost, costEstimate, pricingVersion := calculateJobCostAndRunner(
 			log,
 			job,
+			owner,
 			completed,
 			gatherCost,
 			billingIndex,
 			&logResults,
+			pricing,
 		)
 
 		data.Cost += cost
@@ -899,28 +852,38 @@ func processJobs(
 			data.CostGathered = true
 		}
 		data.Jobs = append(data.Jobs, &JobData{
-			WorkflowJob:  job,
-			Runner:       runner,
-			Cost:         cost,
-			CostEstimate: costEstimate,
-			CostGathered: gatherCost && completed,
+			WorkflowJob:    job,
+			Runner:         runner,
+			Cost:           cost,
+			CostEstimate:   costEstimate,
+			CostGathered:   gatherCost && completed,
+			PricingVersion: pricingVersion,
 		})
 	}
 }
 
+// calculateJobCostAndRunner prices a job from GitHub billing, the pricing catalog's runner group and label rates,
+// its self-hosted runner classes, or runs-on logs and rates, in that order.
+// Costs priced from the catalog's rates get the owner's discount and the catalog's version. Runner class estimates
+// only get the version: they're what the runners cost to run, not a price a discount was negotiated on.
+// Costs priced from built-in rates get neither.
 func calculateJobCostAndRunner(
 	log zerolog.Logger,
 	job *github.WorkflowJob,
+	owner string,
 	completed, gatherCost bool,
 	billingIndex map[int64]jobBillingEntry,
 	logResults *sync.Map,
-) (runner string, cost int64, costEstimate bool) {
+	pricing *PricingCatalog,
+) (runner string, cost int64, costEstimate bool, pricingVersion string) {
+	var pricedFromCatalog, discountable bool
 	if completed && gatherCost {
 		var billingErr error
-		runner, cost, billingErr = calculateJobRunBilling(job.GetID(), billingIndex)
+		runner, cost, pricedFromCatalog, billingErr = calculateJobRunBilling(job, billingIndex, pricing)
 		if billingErr != nil {
 			log.Warn().Err(billingErr).Int64("job_id", job.GetID()).Msg("failed to calculate cost for job")
 		}
+		discountable = pricedFromCatalog
 	}
 
 	if runner == "" {
@@ -937,7 +900,17 @@ func calculateJobCostAndRunner(
 		duration := completedAt.Sub(startedAt)
 
 		if conclusion != "skipped" && duration > 0 {
-			if _, isRunsOn := parseRunsOnLabel(job.Labels); isRunsOn {
+			if rate, matched, ok := pricing.groupOrLabelRate(job); ok {
+				// Self-hosted runners are charged back per started minute, like GitHub bills its runners
+				cost = int64(math.Round(float64(billableMinutes(duration.Milliseconds())) * rate))
+				runner = matched
+				pricedFromCatalog, discountable = true, true
+			} else if class, ok := pricing.runnerClass(job); ok {
+				cost = class.cost(duration)
+				costEstimate = true
+				runner = class.Name
+				pricedFromCatalog = true
+			} else if _, isRunsOn := parseRunsOnLabel(job.Labels); isRunsOn {
 				if val, ok := logResults.Load(job.GetID()); ok {
 					res := val.(runsOnLogResult)
 					cost = res.cost
@@ -947,9 +920,10 @@ func calculateJobCostAndRunner(
 					} else if runsOnName := runsOnRunnerName(job.Labels); runsOnName != "" {
 						runner = runsOnName
 					}
-				} else if runsOnCost, isEstimate := calculateRunsOnCost(job.Labels, duration); runsOnCost > 0 {
+				} else if runsOnCost, isEstimate, fromCatalog := pricing.runsOnCost(job, duration); runsOnCost > 0 {
 					cost = runsOnCost
 					costEstimate = isEstimate
+					pricedFromCatalog, discountable = fromCatalog, fromCatalog
 					if runsOnName := runsOnRunnerName(job.Labels); runsOnName != "" {
 						runner = runsOnName
 					}
@@ -958,7 +932,13 @@ func calculateJobCostAndRunner(
 		}
 	}
 
-	return runner, cost, costEstimate
+	if discountable {
+		cost = pricing.discounted(owner, job.GetStartedAt().Time, cost)
+	}
+	if pricedFromCatalog {
+		pricingVersion = pricing.Version
+	}
+	return runner, cost, costEstimate, pricingVersion
 }
 
 // processAnalyses processes the analyses for a workflow run.
@@ -1082,8 +1062,8 @@ func billingData(
 
 // jobBillingEntry holds per-job billing computed once from workflow usage data.
 type jobBillingEntry struct {
-	runner string
-	cost   int64
+	runner  string
+	minutes int64
 }
 
 func buildJobBillingIndex(billingData *github.WorkflowRunUsage) map[int64]jobBillingEntry {
@@ -1092,30 +1072,30 @@ func buildJobBillingIndex(billingData *github.WorkflowRunUsage) map[int64]jobBil
 		return index
 	}
 	for runnerName, billData := range *billingData.GetBillable() {
-		rate, ok := rateByRunner[runnerName]
-		if !ok {
-			continue
-		}
 		for _, job := range billData.JobRuns {
 			index[int64(job.GetJobID())] = jobBillingEntry{
-				runner: runnerName,
-				cost:   billableMinutes(job.GetDurationMS()) * rate,
+				runner:  runnerName,
+				minutes: billableMinutes(job.GetDurationMS()),
 			}
 		}
 	}
 	return index
 }
 
-// calculateJobRunBilling calculates the cost of a job run based on the billing data
+// calculateJobRunBilling calculates the cost of a job run based on the billing data,
+// at the pricing catalog's rates when it has one for the job, which fromCatalog reports
 func calculateJobRunBilling(
-	jobID int64,
+	job *github.WorkflowJob,
 	billingIndex map[int64]jobBillingEntry,
-) (runner string, costInTenthsOfCents int64, err error) {
-	if entry, ok := billingIndex[jobID]; ok {
-		return entry.runner, entry.cost, nil
+	pricing *PricingCatalog,
+) (runner string, costInTenthsOfCents int64, fromCatalog bool, err error) {
+	if entry, ok := billingIndex[job.GetID()]; ok {
+		if rate, fromCatalog, ok := pricing.billedJobRate(job, entry.runner); ok {
+			return entry.runner, int64(math.Round(float64(entry.minutes) * rate)), fromCatalog, nil
+		}
 	}
-	// if we didn't find the job ID in billing data, it was free
-	return "Free", 0, nil
+	// if we didn't find the job ID in billing data, or have no rate for its runner, it was free
+	return "Free", 0, false, nil
 }
 
 func billableMinutes(durationMS int64) int64 {
@@ -715,6 +715,7 @@ func TestProcessJobs_RunsOnAlwaysFetchLogs(t *testing.T) {
 		[]*github.WorkflowJob{skippedJob, pricedJob},
 		nil,
 		true,
+		nil,
 		tempDir,
 	)
 
@@ -808,17 +809,17 @@ func TestBuildJobBillingIndex_KnownRunners(t *testing.T) {
 
 	index := buildJobBillingIndex(usage)
 
-	runner, cost, err := calculateJobRunBilling(1, index)
+	runner, cost, _, err := calculateJobRunBilling(&github.WorkflowJob{ID: new(int64(1))}, index, nil)
 	require.NoError(t, err)
 	require.Equal(t, "UBUNTU", runner)
 	require.Equal(t, int64(8), cost, "ubuntu cost should be 0.8 cents per minute")
 
-	runner, cost, err = calculateJobRunBilling(2, index)
+	runner, cost, _, err = calculateJobRunBilling(&github.WorkflowJob{ID: new(int64(2))}, index, nil)
 	require.NoError(t, err)
 	require.Equal(t, "MACOS", runner)
 	require.Positive(t, cost, "macOS job should have a non-zero cost")
 
-	runner, cost, err = calculateJobRunBilling(3, index)
+	runner, cost, _, err = calculateJobRunBilling(&github.WorkflowJob{ID: new(int64(3))}, index, nil)
 	require.NoError(t, err)
 	require.Equal(t, "WINDOWS", runner)
 	require.Positive(t, cost, "Windows job should have a non-zero cost")
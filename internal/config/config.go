@@ -4,6 +4,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -19,6 +20,9 @@ import (
 type Config struct {
 	LogLevel          string        `mapstructure:"log_level"`
 	GitHubToken       string        `mapstructure:"github_token"`
+	GitHubServerURL   string        `mapstructure:"github_server_url"`
+	GitHubAPIURL      string        `mapstructure:"github_api_url"`
+	GitHubGraphQLURL  string        `mapstructure:"github_graphql_url"`
 	Owner             string        `mapstructure:"owner"`
 	Repo              string        `mapstructure:"repo"`
 	CommitSHA         string        `mapstructure:"commit_sha"`
@@ -30,7 +34,11 @@ type Config struct {
 	From              time.Time     `mapstructure:"from"`
 	To                time.Time     `mapstructure:"to"`
 	ExcludeCosts      bool          `mapstructure:"exclude_costs"`
+	PricingCatalog    string        `mapstructure:"pricing_catalog"`
 	DataDir           string        `mapstructure:"data_dir"`
+	Store             string        `mapstructure:"store"`
+	Record            string        `mapstructure:"record"`
+	Replay            string        `mapstructure:"replay"`
 	ExcludeWorkflows  []string      `mapstructure:"exclude_workflows"`
 	IncludeWorkflows  []string      `mapstructure:"include_workflows"`
 	CPUProfile        string        `mapstructure:"cpu_profile"`
@@ -201,6 +209,54 @@ func (c *Config) ValidateWorkflowFilters() error {
 	return nil
 }
 
+// ValidateCassette returns an error if both record and replay are set.
+func (c *Config) ValidateCassette() error {
+	if c.Record != "" && c.Replay != "" {
+		return errors.New("record and replay are mutually exclusive")
+	}
+	return nil
+}
+
+// EnterpriseURLs returns the REST and GraphQL API URLs to use for a GitHub Enterprise Server instance.
+// Explicit API URLs take precedence; otherwise they are derived from GitHubServerURL
+// (e.g. https://ghes.example.com -> https://ghes.example.com/api/v3).
+// Both are empty when targeting github.com.
+func (c *Config) EnterpriseURLs() (apiURL, graphQLURL string) {
+	apiURL = strings.TrimSpace(c.GitHubAPIURL)
+	graphQLURL = strings.TrimSpace(c.GitHubGraphQLURL)
+	if apiURL == "" {
+		host := c.GitHubWebHost()
+		if host == "" {
+			return "", ""
+		}
+		server := strings.TrimSuffix(strings.TrimSpace(c.GitHubServerURL), "/")
+		apiURL = server + "/api/v3"
+		if graphQLURL == "" {
+			graphQLURL = server + "/api/graphql"
+		}
+	}
+	if u, err := url.Parse(apiURL); err == nil && strings.EqualFold(u.Hostname(), "api.github.com") {
+		return "", ""
+	}
+	return apiURL, graphQLURL
+}
+
+// GitHubWebHost returns the hostname of GitHubServerURL, or an empty string when it is unset or github.com.
+func (c *Config) GitHubWebHost() string {
+	if strings.TrimSpace(c.GitHubServerURL) == "" {
+		return ""
+	}
+	u, err := url.Parse(strings.TrimSpace(c.GitHubServerURL))
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "github.com" || host == "www.github.com" {
+		return ""
+	}
+	return host
+}
+
 var timeLayouts = []string{time.RFC3339, "2006-01-02"}
 
 func stringToTimeHookFunc() mapstructure.DecodeHookFuncType {
@@ -203,3 +203,75 @@ func TestValidateWorkflowFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_EnvEnterpriseURLs(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://ghes.example.com")
+	t.Setenv("GITHUB_API_URL", "https://ghes.example.com/api/v3")
+	t.Setenv("GITHUB_GRAPHQL_URL", "https://ghes.example.com/api/graphql")
+	cfg, err := Load()
+	require.NoError(t, err, "failed to load config")
+	assert.Equal(t, "https://ghes.example.com", cfg.GitHubServerURL)
+	assert.Equal(t, "https://ghes.example.com/api/v3", cfg.GitHubAPIURL)
+	assert.Equal(t, "https://ghes.example.com/api/graphql", cfg.GitHubGraphQLURL)
+}
+
+func TestEnterpriseURLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		cfg            Config
+		wantAPIURL     string
+		wantGraphQLURL string
+		wantWebHost    string
+	}{
+		{
+			name: "unset",
+			cfg:  Config{},
+		},
+		{
+			name: "github.com defaults from GitHub Actions",
+			cfg: Config{
+				GitHubServerURL:  "https://github.com",
+				GitHubAPIURL:     "https://api.github.com",
+				GitHubGraphQLURL: "https://api.github.com/graphql",
+			},
+		},
+		{
+			name:           "derived from server URL",
+			cfg:            Config{GitHubServerURL: "https://GHES.example.com/"},
+			wantAPIURL:     "https://GHES.example.com/api/v3",
+			wantGraphQLURL: "https://GHES.example.com/api/graphql",
+			wantWebHost:    "ghes.example.com",
+		},
+		{
+			name: "explicit API URLs",
+			cfg: Config{
+				GitHubServerURL:  "https://ghes.example.com",
+				GitHubAPIURL:     "https://api.ghes.example.com",
+				GitHubGraphQLURL: "https://api.ghes.example.com/graphql",
+			},
+			wantAPIURL:     "https://api.ghes.example.com",
+			wantGraphQLURL: "https://api.ghes.example.com/graphql",
+			wantWebHost:    "ghes.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			apiURL, graphQLURL := tt.cfg.EnterpriseURLs()
+			assert.Equal(t, tt.wantAPIURL, apiURL)
+			assert.Equal(t, tt.wantGraphQLURL, graphQLURL)
+			assert.Equal(t, tt.wantWebHost, tt.cfg.GitHubWebHost())
+		})
+	}
+}
+
+func TestValidateCassette(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, (&Config{Record: "cassette"}).ValidateCassette())
+	require.NoError(t, (&Config{Replay: "cassette"}).ValidateCassette())
+	require.ErrorContains(t, (&Config{Record: "a", Replay: "b"}).ValidateCassette(), "mutually exclusive")
+}
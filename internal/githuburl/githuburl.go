@@ -4,6 +4,7 @@ package githuburl
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -19,14 +20,16 @@ type Result struct {
 }
 
 // Parse parses a GitHub web URL for workflow run, job, commit, or PR into a Result struct.
-func Parse(rawURL string) (*Result, error) {
+// github.com URLs are always accepted; enterpriseHosts lists additional GitHub Enterprise
+// Server hostnames (e.g. "ghes.example.com") to accept.
+func Parse(rawURL string, enterpriseHosts ...string) (*Result, error) {
 	u, err := url.Parse(rawURL)
 	if err != nil || u.Scheme == "" || u.Host == "" {
 		return nil, fmt.Errorf("invalid GitHub URL %q: %w", rawURL, err)
 	}
 
 	hostname := strings.ToLower(u.Hostname())
-	if hostname != "github.com" && hostname != "www.github.com" {
+	if !isGitHubHost(hostname, enterpriseHosts) {
 		return nil, fmt.Errorf("unsupported GitHub URL host %q", u.Hostname())
 	}
 
@@ -86,3 +89,12 @@ func Parse(rawURL string) (*Result, error) {
 		return nil, fmt.Errorf("unsupported GitHub URL path %q", u.Path)
 	}
 }
+
+func isGitHubHost(hostname string, enterpriseHosts []string) bool {
+	if hostname == "github.com" || hostname == "www.github.com" {
+		return true
+	}
+	return slices.ContainsFunc(enterpriseHosts, func(h string) bool {
+		return h != "" && strings.EqualFold(h, hostname)
+	})
+}
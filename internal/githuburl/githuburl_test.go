@@ -117,3 +117,26 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEnterpriseHost(t *testing.T) {
+	t.Parallel()
+
+	const runURL = "https://ghes.example.com/kalverra/octometrics/actions/runs/42/job/7"
+
+	_, err := githuburl.Parse(runURL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported GitHub URL host")
+
+	got, err := githuburl.Parse(runURL, "GHES.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &githuburl.Result{
+		Owner:         "kalverra",
+		Repo:          "octometrics",
+		WorkflowRunID: 42,
+		JobID:         7,
+	}, got)
+
+	got, err = githuburl.Parse("https://github.com/kalverra/octometrics/pull/3", "ghes.example.com")
+	require.NoError(t, err, "github.com should still be accepted alongside enterprise hosts")
+	assert.Equal(t, 3, got.PullRequestNumber)
+}
@@ -19,6 +19,7 @@ type options struct {
 	disableConsoleLog bool
 	logLevelInput     string
 	logFileName       string
+	writers           []io.Writer
 }
 
 // Option configures the logger.
@@ -38,6 +39,13 @@ func WithLevel(logLevelInput string) Option {
 	}
 }
 
+// WithWriter also writes every log line to w, e.g. to follow log output live.
+func WithWriter(w io.Writer) Option {
+	return func(o *options) {
+		o.writers = append(o.writers, w)
+	}
+}
+
 // DisableConsoleLog disables console logging.
 func DisableConsoleLog() Option {
 	return func(o *options) {
@@ -81,7 +89,7 @@ func New(options ...Option) (zerolog.Logger, error) {
 		MaxAge:     30,
 	}
 
-	writers := []io.Writer{lumberLogger}
+	writers := append([]io.Writer{lumberLogger}, opts.writers...)
 	if !disableConsoleLog {
 		writers = append(writers, zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: TimeLayout})
 	}
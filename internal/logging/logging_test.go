@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"bytes"
 	"flag"
 	"os"
 	"testing"
@@ -72,3 +73,26 @@ func TestLogging(t *testing.T) {
 		"log file should contain warning log message",
 	)
 }
+
+func TestLoggingWithWriter(t *testing.T) {
+	t.Parallel()
+
+	logFile := "logging_writer_test.log"
+	var extra bytes.Buffer
+	logger, err := New(
+		WithFileName(logFile),
+		WithLevel("info"),
+		WithWriter(&extra),
+		DisableConsoleLog(),
+	)
+	require.NoError(t, err, "error creating logger")
+	t.Cleanup(func() {
+		require.NoError(t, os.Remove(logFile), "error removing log file")
+	})
+
+	logger.Info().Msg("Written to both")
+	require.Contains(t, extra.String(), "Written to both", "extra writer should receive log lines")
+	logFileData, err := os.ReadFile(logFile)
+	require.NoError(t, err, "error reading log file")
+	require.Contains(t, string(logFileData), "Written to both", "log file should still receive log lines")
+}
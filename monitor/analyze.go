@@ -25,6 +25,19 @@ type Analysis struct {
 	MemoryMeasurements []*MemoryMeasurement      `json:"memory_measurements"`
 	DiskMeasurements   []*DiskMeasurement        `json:"disk_measurements"`
 	IOMeasurements     []*IOMeasurement          `json:"io_measurements"`
+	// DiskIOMeasurements are per-device block I/O deltas since the previous observation.
+	DiskIOMeasurements []*DiskIOMeasurement `json:"disk_io_measurements"`
+	// PressureMeasurements are Linux pressure stall (PSI) readings for cpu, memory and io.
+	PressureMeasurements []*PressureMeasurement `json:"pressure_measurements"`
+	LoadMeasurements     []*LoadMeasurement     `json:"load_measurements"`
+	// CgroupMeasurements describe the cgroup the monitor ran in, e.g. a job container's limits rather than the node's.
+	CgroupMeasurements []*CgroupMeasurement `json:"cgroup_measurements"`
+	// ProcessMeasurements are the top processes at each observation, only recorded when process sampling is enabled.
+	ProcessMeasurements []*ProcessMeasurement `json:"process_measurements"`
+	// StepMarkers are step boundaries written by Mark while monitoring.
+	StepMarkers []*StepMarker `json:"step_markers"`
+	// Steps is the resource usage of each marked step, empty when no steps were marked.
+	Steps []*StepUsage `json:"steps"`
 }
 
 // SystemInfo contains system-level information about CPU, memory, disk, and GitHub Actions environment variables.
@@ -32,6 +45,7 @@ type SystemInfo struct {
 	CPU                  []*SystemCPUInfo      `json:"cpu"`
 	Memory               *SystemMemoryInfo     `json:"memory"`
 	Disk                 *SystemDiskInfo       `json:"disk"`
+	Cgroup               *SystemCgroupInfo     `json:"cgroup,omitempty"`
 	GitHubActionsEnvVars *githubActionsEnvVars `json:"github_actions_env_vars"`
 }
 
@@ -56,6 +70,16 @@ type SystemDiskInfo struct {
 	Total uint64 `json:"total"`
 }
 
+// SystemCgroupInfo details the cgroup the monitor ran in.
+type SystemCgroupInfo struct {
+	Version int    `json:"version"`
+	Path    string `json:"path"`
+	// CPULimit is the CPU quota in cores, 0 when unlimited.
+	CPULimit float64 `json:"cpu_limit"`
+	// MemoryMax is the memory limit in bytes, 0 when unlimited.
+	MemoryMax uint64 `json:"memory_max"`
+}
+
 // CPUMeasurement details information about the CPU usage on the system.
 type CPUMeasurement struct {
 	Time        time.Time `json:"time"`
@@ -87,6 +111,73 @@ type IOMeasurement struct {
 	PacketsRecv uint64    `json:"packets_recv"`
 }
 
+// DiskIOMeasurement details how much a block device read and wrote since the previous observation.
+type DiskIOMeasurement struct {
+	Time       time.Time `json:"time"`
+	Device     string    `json:"device"`
+	ReadBytes  uint64    `json:"read_bytes"`
+	WriteBytes uint64    `json:"write_bytes"`
+	ReadCount  uint64    `json:"read_count"`
+	WriteCount uint64    `json:"write_count"`
+}
+
+// PressureMeasurement details how long tasks stalled waiting on one resource since the previous observation.
+// "Some" is time at least one task was stalled, "Full" is time every non-idle task was stalled at once.
+type PressureMeasurement struct {
+	Time time.Time `json:"time"`
+	// Resource is cpu, memory or io.
+	Resource string `json:"resource"`
+	// SomePercent and FullPercent are the stalled share of the time since the previous observation.
+	SomePercent        float64 `json:"some_percent"`
+	FullPercent        float64 `json:"full_percent"`
+	SomeStalledSeconds float64 `json:"some_stalled_seconds"`
+	FullStalledSeconds float64 `json:"full_stalled_seconds"`
+	// SomeAvg10 and FullAvg10 are the kernel's own 10 second running averages, in percent.
+	SomeAvg10 float64 `json:"some_avg10"`
+	FullAvg10 float64 `json:"full_avg10"`
+}
+
+// LoadMeasurement details the system load averages.
+type LoadMeasurement struct {
+	Time   time.Time `json:"time"`
+	Load1  float64   `json:"load1"`
+	Load5  float64   `json:"load5"`
+	Load15 float64   `json:"load15"`
+}
+
+// CgroupMeasurement details the cgroup's usage since the previous observation.
+type CgroupMeasurement struct {
+	Time time.Time `json:"time"`
+	// CPUPercent is the CPU the cgroup used, where 100% is one fully busy core.
+	CPUPercent float64 `json:"cpu_percent"`
+	// CPULimit is the CPU quota in cores, 0 when unlimited.
+	CPULimit float64 `json:"cpu_limit"`
+	// Periods and ThrottledPeriods count CFS enforcement periods, and the ones where the quota ran out.
+	Periods          uint64  `json:"periods"`
+	ThrottledPeriods uint64  `json:"throttled_periods"`
+	ThrottledSeconds float64 `json:"throttled_seconds"`
+	MemoryCurrent    uint64  `json:"memory_current"`
+	// MemoryMax is the memory limit in bytes, 0 when unlimited.
+	MemoryMax uint64 `json:"memory_max"`
+	// OOMEvents counts times the cgroup hit its memory limit and the OOM killer ran, OOMKills the processes it killed.
+	OOMEvents uint64 `json:"oom_events"`
+	OOMKills  uint64 `json:"oom_kills"`
+}
+
+// ProcessMeasurement details the resource usage of a single process at one observation.
+type ProcessMeasurement struct {
+	Time time.Time `json:"time"`
+	PID  int32     `json:"pid"`
+	PPID int32     `json:"ppid"`
+	Name string    `json:"name"`
+	// Cmdline is the process's command line, truncated to keep the monitor file small.
+	Cmdline string `json:"cmdline"`
+	// CPUPercent is the CPU used since the previous observation, where 100% is one fully busy core.
+	CPUPercent float64 `json:"cpu_percent"`
+	// RSS is the resident set size in bytes.
+	RSS uint64 `json:"rss"`
+}
+
 // Analyze reads the monitor data from a file and processes each entry.
 func Analyze(log zerolog.Logger, dataFile string) (*Analysis, error) {
 	file, err := os.Open(filepath.Clean(dataFile))
@@ -104,11 +195,17 @@ func Analyze(log zerolog.Logger, dataFile string) (*Analysis, error) {
 		startTime    = time.Now()
 		linesScanned = 0
 		analysis     = &Analysis{
-			SystemInfo:         &SystemInfo{},
-			CPUMeasurements:    map[int][]*CPUMeasurement{},
-			MemoryMeasurements: []*MemoryMeasurement{},
-			DiskMeasurements:   []*DiskMeasurement{},
-			IOMeasurements:     []*IOMeasurement{},
+			SystemInfo:           &SystemInfo{},
+			CPUMeasurements:      map[int][]*CPUMeasurement{},
+			MemoryMeasurements:   []*MemoryMeasurement{},
+			DiskMeasurements:     []*DiskMeasurement{},
+			IOMeasurements:       []*IOMeasurement{},
+			DiskIOMeasurements:   []*DiskIOMeasurement{},
+			CgroupMeasurements:   []*CgroupMeasurement{},
+			PressureMeasurements: []*PressureMeasurement{},
+			LoadMeasurements:     []*LoadMeasurement{},
+			ProcessMeasurements:  []*ProcessMeasurement{},
+			StepMarkers:          []*StepMarker{},
 		}
 	)
 	for {
@@ -131,6 +228,7 @@ func Analyze(log zerolog.Logger, dataFile string) (*Analysis, error) {
 			return nil, fmt.Errorf("failed to process entry %d: %w", linesScanned, err)
 		}
 	}
+	analysis.Steps = analysis.AttributeSteps(analysis.MarkerStepWindows())
 	log.Info().
 		Str("Duration", time.Since(startTime).String()).
 		Int("Lines scanned", linesScanned).
@@ -165,6 +263,13 @@ func processEntry(analysis *Analysis, entry *monitorEntry) error {
 		analysis.SystemInfo.Disk = &SystemDiskInfo{
 			Total: entry.GetTotal(),
 		}
+	case CgroupInfoMsg:
+		analysis.SystemInfo.Cgroup = &SystemCgroupInfo{
+			Version:   entry.GetCgroupVersion(),
+			Path:      entry.GetCgroupPath(),
+			CPULimit:  entry.GetCPULimit(),
+			MemoryMax: entry.GetMemoryMax(),
+		}
 	case GitHubActionsEnvVarsMsg:
 		if entry.GitHubActionsEnvVars == nil {
 			return nil
@@ -202,6 +307,61 @@ func processEntry(analysis *Analysis, entry *monitorEntry) error {
 			PacketsSent: entry.GetPacketsSent(),
 			PacketsRecv: entry.GetPacketsRecv(),
 		})
+	case ObservedDiskIOMsg:
+		analysis.DiskIOMeasurements = append(analysis.DiskIOMeasurements, &DiskIOMeasurement{
+			Time:       entry.GetTime(),
+			Device:     entry.GetDevice(),
+			ReadBytes:  entry.GetReadBytes(),
+			WriteBytes: entry.GetWriteBytes(),
+			ReadCount:  entry.GetReadCount(),
+			WriteCount: entry.GetWriteCount(),
+		})
+	case ObservedPressureMsg:
+		analysis.PressureMeasurements = append(analysis.PressureMeasurements, &PressureMeasurement{
+			Time:               entry.GetTime(),
+			Resource:           entry.GetResource(),
+			SomePercent:        entry.GetSomePercent(),
+			FullPercent:        entry.GetFullPercent(),
+			SomeStalledSeconds: entry.GetSomeStalledSeconds(),
+			FullStalledSeconds: entry.GetFullStalledSeconds(),
+			SomeAvg10:          entry.GetSomeAvg10(),
+			FullAvg10:          entry.GetFullAvg10(),
+		})
+	case ObservedLoadMsg:
+		analysis.LoadMeasurements = append(analysis.LoadMeasurements, &LoadMeasurement{
+			Time:   entry.GetTime(),
+			Load1:  entry.GetLoad1(),
+			Load5:  entry.GetLoad5(),
+			Load15: entry.GetLoad15(),
+		})
+	case StepMarkerMsg:
+		analysis.StepMarkers = append(analysis.StepMarkers, &StepMarker{
+			Time: entry.GetTime(),
+			Name: entry.GetStep(),
+		})
+	case ObservedCgroupMsg:
+		analysis.CgroupMeasurements = append(analysis.CgroupMeasurements, &CgroupMeasurement{
+			Time:             entry.GetTime(),
+			CPUPercent:       entry.GetCPUPercent(),
+			CPULimit:         entry.GetCPULimit(),
+			Periods:          entry.GetNrPeriods(),
+			ThrottledPeriods: entry.GetNrThrottled(),
+			ThrottledSeconds: entry.GetThrottledSeconds(),
+			MemoryCurrent:    entry.GetMemoryCurrent(),
+			MemoryMax:        entry.GetMemoryMax(),
+			OOMEvents:        entry.GetOOMEvents(),
+			OOMKills:         entry.GetOOMKills(),
+		})
+	case ObservedProcMsg:
+		analysis.ProcessMeasurements = append(analysis.ProcessMeasurements, &ProcessMeasurement{
+			Time:       entry.GetTime(),
+			PID:        entry.GetPID(),
+			PPID:       entry.GetPPID(),
+			Name:       entry.GetName(),
+			Cmdline:    entry.GetCmdline(),
+			CPUPercent: entry.GetCPUPercent(),
+			RSS:        entry.GetRSS(),
+		})
 	}
 
 	return nil
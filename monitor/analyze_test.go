@@ -1,9 +1,12 @@
 package monitor
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kalverra/octometrics/internal/testhelpers"
@@ -28,3 +31,84 @@ func TestAnalyze(t *testing.T) {
 		require.NotEmpty(t, measurements, "measurements should not be empty")
 	}
 }
+
+func TestAnalyze_Processes(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	monitorLogFile := filepath.Join(testDir, "octometrics.monitor.jsonl")
+	lines := `{"level":"debug","pid":42,"ppid":1,"name":"go","cmdline":"go build ./...","cpu_percent":180.5,"rss":524288000,"time":"2026-03-10T16:10:23.587","message":"Observed Process Usage"}
+{"level":"debug","pid":7,"ppid":1,"name":"node","cmdline":"node server.js","cpu_percent":2,"rss":1048576,"time":"2026-03-10T16:10:23.587","message":"Observed Process Usage"}
+`
+	require.NoError(t, os.WriteFile(monitorLogFile, []byte(lines), 0o600))
+
+	analysis, err := Analyze(log, monitorLogFile)
+	require.NoError(t, err, "error analyzing monitor log")
+	require.Len(t, analysis.ProcessMeasurements, 2)
+
+	goProc := analysis.ProcessMeasurements[0]
+	assert.Equal(t, int32(42), goProc.PID)
+	assert.Equal(t, int32(1), goProc.PPID)
+	assert.Equal(t, "go", goProc.Name)
+	assert.Equal(t, "go build ./...", goProc.Cmdline)
+	assert.InDelta(t, 180.5, goProc.CPUPercent, 0.001)
+	assert.Equal(t, uint64(524288000), goProc.RSS)
+	assert.Equal(t, time.Date(2026, 3, 10, 16, 10, 23, 587000000, time.UTC), goProc.Time)
+}
+
+func TestAnalyze_DiskIO(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	monitorLogFile := filepath.Join(testDir, "octometrics.monitor.jsonl")
+	lines := `{"level":"debug","device":"nvme0n1","read_bytes":4096,"write_bytes":1048576,"read_count":1,"write_count":32,"time":"2026-03-10T16:10:23.587","message":"Observed Disk IO Usage"}
+{"level":"debug","used":1,"available":2,"used_percent":50,"time":"2026-03-10T16:10:23.587","message":"Observed Disk Usage"}
+`
+	require.NoError(t, os.WriteFile(monitorLogFile, []byte(lines), 0o600))
+
+	analysis, err := Analyze(log, monitorLogFile)
+	require.NoError(t, err, "error analyzing monitor log")
+	require.Len(t, analysis.DiskIOMeasurements, 1)
+	require.Len(t, analysis.DiskMeasurements, 1, "disk usage and disk IO should stay separate")
+
+	m := analysis.DiskIOMeasurements[0]
+	assert.Equal(t, "nvme0n1", m.Device)
+	assert.Equal(t, uint64(4096), m.ReadBytes)
+	assert.Equal(t, uint64(1048576), m.WriteBytes)
+	assert.Equal(t, uint64(1), m.ReadCount)
+	assert.Equal(t, uint64(32), m.WriteCount)
+}
+
+func TestAnalyze_PressureAndLoad(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	monitorLogFile := filepath.Join(testDir, "octometrics.monitor.jsonl")
+	lines := `{"level":"debug","resource":"cpu","some_avg10":30.5,"some_percent":42,"full_percent":0,"some_stalled_seconds":0.42,"time":"2026-03-10T16:10:23.587","message":"Observed Pressure Stall"}
+{"level":"debug","resource":"io","some_avg10":1,"full_avg10":0.5,"some_percent":2,"full_percent":1,"some_stalled_seconds":0.02,"full_stalled_seconds":0.01,"time":"2026-03-10T16:10:23.587","message":"Observed Pressure Stall"}
+{"level":"debug","load1":3.5,"load5":2.25,"load15":1.5,"time":"2026-03-10T16:10:23.587","message":"Observed Load Average"}
+`
+	require.NoError(t, os.WriteFile(monitorLogFile, []byte(lines), 0o600))
+
+	analysis, err := Analyze(log, monitorLogFile)
+	require.NoError(t, err, "error analyzing monitor log")
+	require.Len(t, analysis.PressureMeasurements, 2)
+	require.Len(t, analysis.LoadMeasurements, 1)
+
+	cpu := analysis.PressureMeasurements[0]
+	assert.Equal(t, "cpu", cpu.Resource)
+	assert.InDelta(t, 42.0, cpu.SomePercent, 0.001)
+	assert.InDelta(t, 0.42, cpu.SomeStalledSeconds, 0.001)
+	assert.InDelta(t, 30.5, cpu.SomeAvg10, 0.001)
+	assert.Zero(t, cpu.FullPercent)
+
+	io := analysis.PressureMeasurements[1]
+	assert.Equal(t, "io", io.Resource)
+	assert.InDelta(t, 1.0, io.FullPercent, 0.001)
+	assert.InDelta(t, 0.01, io.FullStalledSeconds, 0.001)
+
+	load := analysis.LoadMeasurements[0]
+	assert.InDelta(t, 3.5, load.Load1, 0.001)
+	assert.InDelta(t, 2.25, load.Load5, 0.001)
+	assert.InDelta(t, 1.5, load.Load15, 0.001)
+}
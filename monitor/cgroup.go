@@ -0,0 +1,340 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCgroupRoot is where cgroupfs is mounted on Linux.
+	defaultCgroupRoot = "/sys/fs/cgroup"
+	// procSelfCgroup lists the cgroups this process belongs to.
+	procSelfCgroup = "/proc/self/cgroup"
+	// cgroupV1UnlimitedMemory is the smallest memory.limit_in_bytes cgroup v1 reports for "no limit",
+	// the exact value depends on the page size.
+	cgroupV1UnlimitedMemory = 1 << 62
+)
+
+// cgroupReader reads resource usage of the cgroup this process runs in.
+// In a container, that's the container's limits rather than the whole node's.
+type cgroupReader struct {
+	version int
+	// path is the cgroup's path relative to the cgroupfs root, for logging.
+	path string
+	// cpuDir, cpuacctDir and memoryDir are the same directory for cgroup v2.
+	cpuDir     string
+	cpuacctDir string
+	memoryDir  string
+}
+
+// cgroupStats is a snapshot of a cgroup's cumulative counters and current limits.
+type cgroupStats struct {
+	CPUUsage         time.Duration
+	Periods          uint64
+	ThrottledPeriods uint64
+	ThrottledTime    time.Duration
+	// CPULimit is the CPU quota in cores, 0 when unlimited.
+	CPULimit      float64
+	MemoryCurrent uint64
+	// MemoryMax is the memory limit in bytes, 0 when unlimited.
+	MemoryMax uint64
+	OOMEvents uint64
+	OOMKills  uint64
+}
+
+// cgroupDelta is how a cgroup's usage changed between two observations.
+type cgroupDelta struct {
+	// CPUPercent is CPU used since the previous observation, where 100% is one fully busy core.
+	CPUPercent       float64
+	CPULimit         float64
+	Periods          uint64
+	ThrottledPeriods uint64
+	ThrottledSeconds float64
+	MemoryCurrent    uint64
+	MemoryMax        uint64
+	OOMEvents        uint64
+	OOMKills         uint64
+}
+
+// detectCgroup finds the cgroup this process belongs to under root, resolving its path from procCgroupFile.
+// It returns nil when root isn't a cgroup v1 or v2 mount, e.g. on macOS or Windows.
+func detectCgroup(root, procCgroupFile string) (*cgroupReader, error) {
+	memberships, err := readProcCgroup(procCgroupFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileExists(filepath.Join(root, "cgroup.controllers")) {
+		path := memberships[""]
+		dir := cgroupDir(root, path)
+		return &cgroupReader{version: 2, path: path, cpuDir: dir, cpuacctDir: dir, memoryDir: dir}, nil
+	}
+
+	reader := &cgroupReader{version: 1}
+	// Controllers are mounted on their own, or co-mounted like cpu,cpuacct
+	for _, controller := range []struct {
+		name   string
+		mounts []string
+		dir    *string
+	}{
+		{name: "cpu", mounts: []string{"cpu", "cpu,cpuacct", "cpuacct,cpu"}, dir: &reader.cpuDir},
+		{name: "cpuacct", mounts: []string{"cpuacct", "cpu,cpuacct", "cpuacct,cpu"}, dir: &reader.cpuacctDir},
+		{name: "memory", mounts: []string{"memory"}, dir: &reader.memoryDir},
+	} {
+		for _, mount := range controller.mounts {
+			base := filepath.Join(root, mount)
+			if !fileExists(base) {
+				continue
+			}
+			path := memberships[controller.name]
+			*controller.dir = cgroupDir(base, path)
+			if reader.path == "" {
+				reader.path = path
+			}
+			break
+		}
+	}
+	if reader.cpuDir == "" && reader.cpuacctDir == "" && reader.memoryDir == "" {
+		return nil, nil
+	}
+	return reader, nil
+}
+
+// readProcCgroup maps each controller to this process's cgroup path, with "" for the cgroup v2 hierarchy.
+// A missing file, like on non-Linux systems, returns no memberships.
+func readProcCgroup(file string) (map[string]string, error) {
+	memberships := map[string]string{}
+	content, err := os.ReadFile(filepath.Clean(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return memberships, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", file, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			memberships[""] = fields[2]
+			continue
+		}
+		for controller := range strings.SplitSeq(fields[1], ",") {
+			memberships[controller] = fields[2]
+		}
+	}
+	return memberships, nil
+}
+
+// cgroupDir is the cgroup's directory under base. Inside a container, cgroupfs is usually mounted at the
+// container's own cgroup while /proc/self/cgroup shows the host path, so fall back to base when it doesn't exist.
+func cgroupDir(base, path string) string {
+	if path != "" && path != "/" {
+		if dir := filepath.Join(base, path); fileExists(dir) {
+			return dir
+		}
+	}
+	return base
+}
+
+// read snapshots the cgroup's counters. Files a cgroup doesn't have, like limits on the root cgroup, read as unset.
+func (r *cgroupReader) read() (*cgroupStats, error) {
+	if r.version == 2 {
+		return r.readV2()
+	}
+	return r.readV1()
+}
+
+func (r *cgroupReader) readV2() (*cgroupStats, error) {
+	stats := &cgroupStats{}
+
+	cpuStat, err := readKeyValueFile(filepath.Join(r.cpuDir, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.CPUUsage = time.Duration(cpuStat["usage_usec"]) * time.Microsecond
+	stats.Periods = cpuStat["nr_periods"]
+	stats.ThrottledPeriods = cpuStat["nr_throttled"]
+	stats.ThrottledTime = time.Duration(cpuStat["throttled_usec"]) * time.Microsecond
+
+	// cpu.max is "$MAX $PERIOD", where $MAX is "max" when unlimited
+	cpuMax, err := readOptionalFile(filepath.Join(r.cpuDir, "cpu.max"))
+	if err != nil {
+		return nil, err
+	}
+	if fields := strings.Fields(cpuMax); len(fields) == 2 && fields[0] != "max" {
+		quota, quotaErr := strconv.ParseFloat(fields[0], 64)
+		period, periodErr := strconv.ParseFloat(fields[1], 64)
+		if quotaErr == nil && periodErr == nil && period > 0 {
+			stats.CPULimit = quota / period
+		}
+	}
+
+	if stats.MemoryCurrent, err = readUintFile(filepath.Join(r.memoryDir, "memory.current")); err != nil {
+		return nil, err
+	}
+	if stats.MemoryMax, err = readUintFile(filepath.Join(r.memoryDir, "memory.max")); err != nil {
+		return nil, err
+	}
+
+	events, err := readKeyValueFile(filepath.Join(r.memoryDir, "memory.events"))
+	if err != nil {
+		return nil, err
+	}
+	stats.OOMEvents = events["oom"]
+	stats.OOMKills = events["oom_kill"]
+	return stats, nil
+}
+
+func (r *cgroupReader) readV1() (*cgroupStats, error) {
+	stats := &cgroupStats{}
+	var err error
+
+	if r.cpuacctDir != "" {
+		usage, err := readUintFile(filepath.Join(r.cpuacctDir, "cpuacct.usage"))
+		if err != nil {
+			return nil, err
+		}
+		stats.CPUUsage = time.Duration(usage) //nolint:gosec // nanoseconds of CPU time won't overflow int64
+	}
+
+	if r.cpuDir != "" {
+		cpuStat, err := readKeyValueFile(filepath.Join(r.cpuDir, "cpu.stat"))
+		if err != nil {
+			return nil, err
+		}
+		stats.Periods = cpuStat["nr_periods"]
+		stats.ThrottledPeriods = cpuStat["nr_throttled"]
+		stats.ThrottledTime = time.Duration(cpuStat["throttled_time"]) //nolint:gosec // nanoseconds
+
+		// A quota of -1 means unlimited
+		quota, err := readOptionalFile(filepath.Join(r.cpuDir, "cpu.cfs_quota_us"))
+		if err != nil {
+			return nil, err
+		}
+		period, err := readUintFile(filepath.Join(r.cpuDir, "cpu.cfs_period_us"))
+		if err != nil {
+			return nil, err
+		}
+		if q, err := strconv.ParseFloat(quota, 64); err == nil && q > 0 && period > 0 {
+			stats.CPULimit = q / float64(period)
+		}
+	}
+
+	if r.memoryDir != "" {
+		if stats.MemoryCurrent, err = readUintFile(filepath.Join(r.memoryDir, "memory.usage_in_bytes")); err != nil {
+			return nil, err
+		}
+		if stats.MemoryMax, err = readUintFile(filepath.Join(r.memoryDir, "memory.limit_in_bytes")); err != nil {
+			return nil, err
+		}
+		if stats.MemoryMax >= cgroupV1UnlimitedMemory {
+			stats.MemoryMax = 0
+		}
+		// cgroup v1 has no OOM event counter, only kills on newer kernels
+		oomControl, err := readKeyValueFile(filepath.Join(r.memoryDir, "memory.oom_control"))
+		if err != nil {
+			return nil, err
+		}
+		stats.OOMKills = oomControl["oom_kill"]
+		stats.OOMEvents = stats.OOMKills
+	}
+	return stats, nil
+}
+
+// cgroupDeltaFromStats returns how the cgroup changed over elapsed, or nil without a previous snapshot.
+// Counters that went backwards, e.g. a recreated cgroup, report no change.
+func cgroupDeltaFromStats(prev, curr *cgroupStats, elapsed time.Duration) *cgroupDelta {
+	if prev == nil || curr == nil || elapsed <= 0 {
+		return nil
+	}
+	delta := &cgroupDelta{
+		CPULimit:         curr.CPULimit,
+		MemoryCurrent:    curr.MemoryCurrent,
+		MemoryMax:        curr.MemoryMax,
+		Periods:          counterDelta(prev.Periods, curr.Periods),
+		ThrottledPeriods: counterDelta(prev.ThrottledPeriods, curr.ThrottledPeriods),
+		OOMEvents:        counterDelta(prev.OOMEvents, curr.OOMEvents),
+		OOMKills:         counterDelta(prev.OOMKills, curr.OOMKills),
+	}
+	if curr.CPUUsage >= prev.CPUUsage {
+		delta.CPUPercent = 100 * (curr.CPUUsage - prev.CPUUsage).Seconds() / elapsed.Seconds()
+	}
+	if curr.ThrottledTime >= prev.ThrottledTime {
+		delta.ThrottledSeconds = (curr.ThrottledTime - prev.ThrottledTime).Seconds()
+	}
+	return delta
+}
+
+func counterDelta(prev, curr uint64) uint64 {
+	if curr < prev {
+		return 0
+	}
+	return curr - prev
+}
+
+// readKeyValueFile parses flat keyed files like cpu.stat and memory.events, "key value" per line.
+// A missing file reads as empty.
+func readKeyValueFile(file string) (map[string]uint64, error) {
+	content, err := readOptionalFile(file)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]uint64{}
+	for line := range strings.SplitSeq(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = value
+	}
+	return values, nil
+}
+
+// readUintFile reads a single number, treating a missing file or "max" as 0.
+func readUintFile(file string) (uint64, error) {
+	content, err := readOptionalFile(file)
+	if err != nil {
+		return 0, err
+	}
+	if content == "" || content == "max" {
+		return 0, nil
+	}
+	value, err := strconv.ParseUint(content, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse '%s': %w", file, err)
+	}
+	return value, nil
+}
+
+// readOptionalFile returns the trimmed file content, or "" when it doesn't exist.
+func readOptionalFile(file string) (string, error) {
+	content, err := os.ReadFile(filepath.Clean(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s': %w", file, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,260 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+var cgroupTestDataDir = filepath.Join(testDataDir, "cgroup")
+
+func TestDetectCgroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		root     string
+		procFile string
+		want     *cgroupReader
+	}{
+		{
+			name:     "v2 resolves own cgroup",
+			root:     filepath.Join(cgroupTestDataDir, "v2"),
+			procFile: filepath.Join(cgroupTestDataDir, "v2.proc_self_cgroup"),
+			want: &cgroupReader{
+				version:    2,
+				path:       "/job.slice",
+				cpuDir:     filepath.Join(cgroupTestDataDir, "v2", "job.slice"),
+				cpuacctDir: filepath.Join(cgroupTestDataDir, "v2", "job.slice"),
+				memoryDir:  filepath.Join(cgroupTestDataDir, "v2", "job.slice"),
+			},
+		},
+		{
+			name:     "v2 without proc file uses root",
+			root:     filepath.Join(cgroupTestDataDir, "v2"),
+			procFile: filepath.Join(cgroupTestDataDir, "missing"),
+			want: &cgroupReader{
+				version:    2,
+				cpuDir:     filepath.Join(cgroupTestDataDir, "v2"),
+				cpuacctDir: filepath.Join(cgroupTestDataDir, "v2"),
+				memoryDir:  filepath.Join(cgroupTestDataDir, "v2"),
+			},
+		},
+		{
+			name:     "v1 container falls back to mount root",
+			root:     filepath.Join(cgroupTestDataDir, "v1"),
+			procFile: filepath.Join(cgroupTestDataDir, "v1.proc_self_cgroup"),
+			want: &cgroupReader{
+				version:    1,
+				path:       "/docker/abc123",
+				cpuDir:     filepath.Join(cgroupTestDataDir, "v1", "cpu,cpuacct"),
+				cpuacctDir: filepath.Join(cgroupTestDataDir, "v1", "cpu,cpuacct"),
+				memoryDir:  filepath.Join(cgroupTestDataDir, "v1", "memory"),
+			},
+		},
+		{
+			name:     "no cgroupfs",
+			root:     filepath.Join(cgroupTestDataDir, "missing"),
+			procFile: filepath.Join(cgroupTestDataDir, "missing"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader, err := detectCgroup(tt.root, tt.procFile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reader)
+		})
+	}
+}
+
+func TestCgroupRead(t *testing.T) {
+	t.Parallel()
+
+	t.Run("v2", func(t *testing.T) {
+		t.Parallel()
+
+		reader, err := detectCgroup(
+			filepath.Join(cgroupTestDataDir, "v2"),
+			filepath.Join(cgroupTestDataDir, "v2.proc_self_cgroup"),
+		)
+		require.NoError(t, err)
+		stats, err := reader.read()
+		require.NoError(t, err)
+		assert.Equal(t, &cgroupStats{
+			CPUUsage:         120 * time.Second,
+			Periods:          400,
+			ThrottledPeriods: 100,
+			ThrottledTime:    12500 * time.Millisecond,
+			CPULimit:         2,
+			MemoryCurrent:    1932735283,
+			MemoryMax:        2147483648,
+			OOMEvents:        2,
+			OOMKills:         1,
+		}, stats)
+	})
+
+	t.Run("v2 root cgroup has no limits", func(t *testing.T) {
+		t.Parallel()
+
+		reader, err := detectCgroup(filepath.Join(cgroupTestDataDir, "v2"), filepath.Join(cgroupTestDataDir, "missing"))
+		require.NoError(t, err)
+		stats, err := reader.read()
+		require.NoError(t, err)
+		assert.Equal(t, 500*time.Second, stats.CPUUsage)
+		assert.Zero(t, stats.CPULimit)
+		assert.Zero(t, stats.MemoryMax)
+	})
+
+	t.Run("v1", func(t *testing.T) {
+		t.Parallel()
+
+		reader, err := detectCgroup(
+			filepath.Join(cgroupTestDataDir, "v1"),
+			filepath.Join(cgroupTestDataDir, "v1.proc_self_cgroup"),
+		)
+		require.NoError(t, err)
+		stats, err := reader.read()
+		require.NoError(t, err)
+		assert.Equal(t, &cgroupStats{
+			CPUUsage:         90 * time.Second,
+			Periods:          600,
+			ThrottledPeriods: 30,
+			ThrottledTime:    4500 * time.Millisecond,
+			CPULimit:         1.5,
+			MemoryCurrent:    536870912,
+			MemoryMax:        0, // 9223372036854771712 means unlimited
+			OOMEvents:        3,
+			OOMKills:         3,
+		}, stats)
+	})
+}
+
+func TestCgroupDeltaFromStats(t *testing.T) {
+	t.Parallel()
+
+	prev := &cgroupStats{
+		CPUUsage:         10 * time.Second,
+		Periods:          100,
+		ThrottledPeriods: 10,
+		ThrottledTime:    time.Second,
+		OOMKills:         1,
+	}
+	curr := &cgroupStats{
+		CPUUsage:         13 * time.Second,
+		Periods:          120,
+		ThrottledPeriods: 15,
+		ThrottledTime:    1500 * time.Millisecond,
+		CPULimit:         2,
+		MemoryCurrent:    100,
+		MemoryMax:        200,
+		OOMEvents:        1,
+		OOMKills:         2,
+	}
+
+	delta := cgroupDeltaFromStats(prev, curr, 2*time.Second)
+	require.NotNil(t, delta)
+	assert.InDelta(t, 150.0, delta.CPUPercent, 0.001, "3s of CPU over 2s is one and a half cores")
+	assert.InDelta(t, 0.5, delta.ThrottledSeconds, 0.001)
+	assert.Equal(t, &cgroupDelta{
+		CPUPercent:       delta.CPUPercent,
+		CPULimit:         2,
+		Periods:          20,
+		ThrottledPeriods: 5,
+		ThrottledSeconds: delta.ThrottledSeconds,
+		MemoryCurrent:    100,
+		MemoryMax:        200,
+		OOMEvents:        1,
+		OOMKills:         1,
+	}, delta)
+
+	assert.Nil(t, cgroupDeltaFromStats(nil, curr, time.Second), "first observation has nothing to compare to")
+	reset := cgroupDeltaFromStats(curr, prev, time.Second)
+	require.NotNil(t, reset)
+	assert.Zero(t, reset.CPUPercent, "counters going backwards should not report negative usage")
+	assert.Zero(t, reset.Periods)
+}
+
+func TestMonitorCgroup(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	log, testDir := testhelpers.Setup(t)
+	outputFile := filepath.Join(testDir, "monitor.json")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	err := Start(ctx,
+		DisableCPU(),
+		DisableMemory(),
+		DisableDisk(),
+		DisableDiskIO(),
+		DisableIO(),
+		WithCgroupRoot(filepath.Join(cgroupTestDataDir, "v1")),
+		WithObserveInterval(250*time.Millisecond),
+		WithOutputFile(outputFile),
+	)
+	require.NoError(t, err, "error while monitoring")
+
+	data, err := os.ReadFile(filepath.Clean(outputFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), CgroupInfoMsg)
+	assert.Contains(t, string(data), ObservedCgroupMsg)
+
+	analysis, err := Analyze(log, outputFile)
+	require.NoError(t, err)
+	require.NotNil(t, analysis.SystemInfo.Cgroup)
+	assert.Equal(t, 1, analysis.SystemInfo.Cgroup.Version)
+	assert.InDelta(t, 1.5, analysis.SystemInfo.Cgroup.CPULimit, 0.001)
+	require.NotEmpty(t, analysis.CgroupMeasurements)
+	assert.Equal(t, uint64(536870912), analysis.CgroupMeasurements[0].MemoryCurrent)
+	assert.Zero(t, analysis.CgroupMeasurements[0].CPUPercent, "static fake counters should show no usage")
+}
+
+func TestMonitorCgroupUnreadable(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, testDir := testhelpers.Setup(t)
+	// A cgroup v2 root whose counters exist but can't be read
+	cgroupRoot := filepath.Join(testDir, "cgroup")
+	require.NoError(t, os.MkdirAll(filepath.Join(cgroupRoot, "cpu.stat"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupRoot, "cgroup.controllers"), []byte("cpu memory\n"), 0o600))
+	outputFile := filepath.Join(testDir, "monitor.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	t.Cleanup(cancel)
+
+	err := Start(ctx,
+		DisableCPU(),
+		DisableMemory(),
+		DisableDisk(),
+		DisableDiskIO(),
+		DisableIO(),
+		DisablePressure(),
+		WithCgroupRoot(cgroupRoot),
+		WithObserveInterval(100*time.Millisecond),
+		WithOutputFile(outputFile),
+	)
+	require.NoError(t, err, "unreadable cgroup files should not stop the monitor")
+
+	data, err := os.ReadFile(filepath.Clean(outputFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "skipping cgroup monitoring")
+	assert.NotContains(t, string(data), ObservedCgroupMsg)
+	assert.Contains(t, string(data), ObservedLoadMsg)
+}
@@ -0,0 +1,247 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricsExporter keeps the latest observations for Prometheus to scrape. It follows the monitor's log output,
+// so it sees exactly what is written to the output file.
+type metricsExporter struct {
+	mu sync.Mutex
+
+	// labels are GitHub Actions details added to every series, so scrapes can be matched to workflow runs.
+	labels map[string]string
+
+	cpuUsedPercent  map[int]float64
+	memoryTotal     *uint64
+	memoryUsed      *uint64
+	memoryAvailable *uint64
+	diskTotal       *uint64
+	diskUsed        *uint64
+	diskAvailable   *uint64
+	diskUsedPercent *float64
+	// Network and disk I/O are logged as deltas, so they're summed into counters.
+	networkSentBytes uint64
+	networkRecvBytes uint64
+	networkObserved  bool
+	diskReadBytes    map[string]uint64
+	diskWrittenBytes map[string]uint64
+	load             *LoadMeasurement
+	lastObservation  time.Time
+}
+
+func newMetricsExporter() *metricsExporter {
+	return &metricsExporter{
+		labels:           map[string]string{},
+		cpuUsedPercent:   map[int]float64{},
+		diskReadBytes:    map[string]uint64{},
+		diskWrittenBytes: map[string]uint64{},
+	}
+}
+
+// Write records one monitor log line. Lines it can't parse are ignored, they must never stop monitoring.
+func (m *metricsExporter) Write(p []byte) (int, error) {
+	var entry *monitorEntry
+	if err := json.Unmarshal(p, &entry); err != nil || entry == nil {
+		return len(p), nil //nolint:nilerr // not every log line is an observation
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch entry.Message {
+	case MemSystemInfoMsg:
+		m.memoryTotal = entry.Total
+	case DiskSystemInfoMsg:
+		m.diskTotal = entry.Total
+	case GitHubActionsEnvVarsMsg:
+		m.setLabels(entry.GitHubActionsEnvVars)
+	case ObservedCPUMsg:
+		m.cpuUsedPercent[entry.GetNum()] = entry.GetUsedPercent()
+	case ObservedMemMsg:
+		m.memoryUsed, m.memoryAvailable = entry.Used, entry.Available
+	case ObservedDiskMsg:
+		m.diskUsed, m.diskAvailable, m.diskUsedPercent = entry.Used, entry.Available, entry.UsedPercent
+	case ObservedIOMsg:
+		m.networkSentBytes += entry.GetBytesSent()
+		m.networkRecvBytes += entry.GetBytesRecv()
+		m.networkObserved = true
+	case ObservedDiskIOMsg:
+		m.diskReadBytes[entry.GetDevice()] += entry.GetReadBytes()
+		m.diskWrittenBytes[entry.GetDevice()] += entry.GetWriteBytes()
+	case ObservedLoadMsg:
+		m.load = &LoadMeasurement{Load1: entry.GetLoad1(), Load5: entry.GetLoad5(), Load15: entry.GetLoad15()}
+	default:
+		return len(p), nil
+	}
+	if entry.Time.After(m.lastObservation) {
+		m.lastObservation = entry.Time.Time
+	}
+	return len(p), nil
+}
+
+func (m *metricsExporter) setLabels(envVars *githubActionsEnvVars) {
+	if envVars == nil {
+		return
+	}
+	for name, value := range map[string]string{
+		"repository":  envVars.Repository,
+		"workflow":    envVars.Workflow,
+		"job":         envVars.Job,
+		"job_name":    envVars.JobName,
+		"run_id":      strconv.FormatInt(envVars.RunID, 10),
+		"run_attempt": envVars.RunAttempt,
+		"runner_name": envVars.RunnerName,
+	} {
+		if value != "" && value != "0" {
+			m.labels[name] = value
+		}
+	}
+}
+
+// serveMetrics serves the exporter at /metrics on addr until the returned stop function is called.
+func serveMetrics(log zerolog.Logger, addr string, metrics *metricsExporter) (stop func(), err error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to listen on '%s': %w", ErrMetricsServer, addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Metrics server stopped")
+		}
+	}()
+	log.Info().Str("metrics_addr", listener.Addr().String()).Msg("Serving metrics")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to stop metrics server")
+		}
+	}, nil
+}
+
+// ServeHTTP writes the latest observations in the Prometheus text exposition format.
+func (m *metricsExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+	_ = m.writeMetrics(w)
+}
+
+func (m *metricsExporter) writeMetrics(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	cpus := slices.Sorted(maps.Keys(m.cpuUsedPercent))
+	if len(cpus) > 0 {
+		m.writeHeader(&b, "octometrics_cpu_used_percent", "gauge", "CPU usage of each core, in percent.")
+		for _, num := range cpus {
+			m.writeSample(&b, "octometrics_cpu_used_percent", m.cpuUsedPercent[num], "cpu", strconv.Itoa(num))
+		}
+	}
+	m.writeUint(&b, "octometrics_memory_total_bytes", "gauge", "Total system memory.", m.memoryTotal)
+	m.writeUint(&b, "octometrics_memory_used_bytes", "gauge", "Used system memory.", m.memoryUsed)
+	m.writeUint(&b, "octometrics_memory_available_bytes", "gauge", "Available system memory.", m.memoryAvailable)
+	m.writeUint(&b, "octometrics_disk_total_bytes", "gauge", "Total size of the monitored disk.", m.diskTotal)
+	m.writeUint(&b, "octometrics_disk_used_bytes", "gauge", "Used space on the monitored disk.", m.diskUsed)
+	m.writeUint(&b, "octometrics_disk_available_bytes", "gauge", "Free space on the monitored disk.", m.diskAvailable)
+	if m.diskUsedPercent != nil {
+		m.writeHeader(&b, "octometrics_disk_used_percent", "gauge", "Used space on the monitored disk, in percent.")
+		m.writeSample(&b, "octometrics_disk_used_percent", *m.diskUsedPercent)
+	}
+	if m.networkObserved {
+		m.writeHeader(&b, "octometrics_network_sent_bytes_total", "counter", "Bytes sent over the network.")
+		m.writeSample(&b, "octometrics_network_sent_bytes_total", float64(m.networkSentBytes))
+		m.writeHeader(&b, "octometrics_network_received_bytes_total", "counter", "Bytes received over the network.")
+		m.writeSample(&b, "octometrics_network_received_bytes_total", float64(m.networkRecvBytes))
+	}
+	m.writeDeviceCounter(&b, "octometrics_disk_read_bytes_total", "Bytes read from each disk.", m.diskReadBytes)
+	m.writeDeviceCounter(&b, "octometrics_disk_written_bytes_total", "Bytes written to each disk.", m.diskWrittenBytes)
+	if m.load != nil {
+		for _, l := range []struct {
+			name  string
+			value float64
+		}{
+			{name: "octometrics_load1", value: m.load.Load1},
+			{name: "octometrics_load5", value: m.load.Load5},
+			{name: "octometrics_load15", value: m.load.Load15},
+		} {
+			m.writeHeader(&b, l.name, "gauge", "System load average.")
+			m.writeSample(&b, l.name, l.value)
+		}
+	}
+	if !m.lastObservation.IsZero() {
+		m.writeHeader(&b, "octometrics_last_observation_timestamp_seconds", "gauge",
+			"Unix time of the latest observation.")
+		m.writeSample(&b, "octometrics_last_observation_timestamp_seconds",
+			float64(m.lastObservation.UnixMilli())/1000)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (m *metricsExporter) writeHeader(b *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func (m *metricsExporter) writeUint(b *strings.Builder, name, metricType, help string, value *uint64) {
+	if value == nil {
+		return
+	}
+	m.writeHeader(b, name, metricType, help)
+	m.writeSample(b, name, float64(*value))
+}
+
+func (m *metricsExporter) writeDeviceCounter(b *strings.Builder, name, help string, values map[string]uint64) {
+	if len(values) == 0 {
+		return
+	}
+	m.writeHeader(b, name, "counter", help)
+	for _, device := range slices.Sorted(maps.Keys(values)) {
+		m.writeSample(b, name, float64(values[device]), "device", device)
+	}
+}
+
+// writeSample writes one series with the GitHub Actions labels and any extra label name and value pairs.
+func (m *metricsExporter) writeSample(b *strings.Builder, name string, value float64, extraLabels ...string) {
+	labels := make([]string, 0, len(m.labels)+len(extraLabels)/2)
+	for i := 0; i+1 < len(extraLabels); i += 2 {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, extraLabels[i], labelValueEscaper.Replace(extraLabels[i+1])))
+	}
+	for _, label := range slices.Sorted(maps.Keys(m.labels)) {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, label, labelValueEscaper.Replace(m.labels[label])))
+	}
+	b.WriteString(name)
+	if len(labels) > 0 {
+		fmt.Fprintf(b, "{%s}", strings.Join(labels, ","))
+	}
+	fmt.Fprintf(b, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// labelValueEscaper escapes label values as the exposition format expects.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		`{"level":"info","total":8000,"time":"2026-03-10T16:10:22.000","message":"System Memory Info"}`,
+		`{"level":"debug","github_actions_env_vars":{"GITHUB_REPOSITORY":"kalverra/octometrics",` +
+			`"GITHUB_WORKFLOW":"CI \"main\"","GITHUB_JOB":"test","GITHUB_RUN_ID":12345},` +
+			`"time":"2026-03-10T16:10:22.000","message":"GitHub Actions Environment Variables"}`,
+		`{"level":"debug","num":1,"used_percent":75.5,"time":"2026-03-10T16:10:23.000",` +
+			`"message":"Observed CPU Usage"}`,
+		`{"level":"debug","num":0,"used_percent":25,"time":"2026-03-10T16:10:23.000",` +
+			`"message":"Observed CPU Usage"}`,
+		`{"level":"debug","used":6000,"available":2000,"time":"2026-03-10T16:10:23.000",` +
+			`"message":"Observed Memory Usage"}`,
+		`{"level":"debug","bytes_sent":100,"bytes_recv":200,"time":"2026-03-10T16:10:23.000",` +
+			`"message":"Observed IO Usage"}`,
+		`{"level":"debug","bytes_sent":50,"bytes_recv":25,"time":"2026-03-10T16:10:24.500",` +
+			`"message":"Observed IO Usage"}`,
+		`{"level":"debug","device":"sda","read_bytes":4096,"write_bytes":0,"time":"2026-03-10T16:10:24.500",` +
+			`"message":"Observed Disk IO Usage"}`,
+		`{"level":"debug","load1":1.5,"load5":1,"load15":0.5,"time":"2026-03-10T16:10:24.500",` +
+			`"message":"Observed Load Average"}`,
+		`not json`,
+	}
+	exporter := newMetricsExporter()
+	for _, line := range lines {
+		n, err := exporter.Write([]byte(line + "\n"))
+		require.NoError(t, err, "writes should never fail")
+		assert.Equal(t, len(line)+1, n)
+	}
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, metricsContentType, recorder.Header().Get("Content-Type"))
+
+	const labels = `repository="kalverra/octometrics",run_id="12345",workflow="CI \"main\""`
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE octometrics_cpu_used_percent gauge",
+		`octometrics_cpu_used_percent{cpu="0",job="test",` + labels + `} 25`,
+		`octometrics_cpu_used_percent{cpu="1",job="test",` + labels + `} 75.5`,
+		`octometrics_memory_total_bytes{job="test",` + labels + `} 8000`,
+		`octometrics_memory_used_bytes{job="test",` + labels + `} 6000`,
+		"# TYPE octometrics_network_sent_bytes_total counter",
+		`octometrics_network_sent_bytes_total{job="test",` + labels + `} 150`,
+		`octometrics_network_received_bytes_total{job="test",` + labels + `} 225`,
+		`octometrics_disk_read_bytes_total{device="sda",job="test",` + labels + `} 4096`,
+		`octometrics_load1{job="test",` + labels + `} 1.5`,
+		`octometrics_last_observation_timestamp_seconds{job="test",` + labels + `} 1.7731590245e+09`,
+	} {
+		assert.Contains(t, body, want)
+	}
+	assert.NotContains(t, body, "octometrics_disk_used_bytes", "unobserved metrics should be left out")
+	assert.Less(t, strings.Index(body, `cpu="0"`), strings.Index(body, `cpu="1"`), "series should be sorted")
+}
+
+func TestServeMetrics(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	log, testDir := testhelpers.Setup(t)
+
+	_, err := serveMetrics(log, "not an address", newMetricsExporter())
+	require.ErrorIs(t, err, ErrMetricsServer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	t.Cleanup(cancel)
+	err = Start(ctx,
+		DisableDisk(),
+		DisableDiskIO(),
+		DisableCgroup(),
+		DisablePressure(),
+		WithMetricsAddr("127.0.0.1:0"),
+		WithObserveInterval(100*time.Millisecond),
+		WithOutputFile(filepath.Join(testDir, "monitor.json")),
+	)
+	require.NoError(t, err, "error while monitoring with metrics enabled")
+}
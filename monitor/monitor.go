@@ -13,8 +13,10 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
 	"github.com/shirou/gopsutil/v4/mem"
 	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/kalverra/octometrics/internal/logging"
@@ -31,6 +33,8 @@ const (
 	MemSystemInfoMsg = "System Memory Info"
 	// DiskSystemInfoMsg is the log message for disk system info.
 	DiskSystemInfoMsg = "System Disk Info"
+	// CgroupInfoMsg is the log message for the cgroup the monitor runs in.
+	CgroupInfoMsg = "Cgroup Info"
 	// GitHubActionsEnvVarsMsg is the log message for GitHub Actions environment variables.
 	GitHubActionsEnvVarsMsg = "GitHub Actions Environment Variables"
 
@@ -44,6 +48,16 @@ const (
 	ObservedProcMsg = "Observed Process Usage"
 	// ObservedIOMsg is the log message for an IO usage observation.
 	ObservedIOMsg = "Observed IO Usage"
+	// ObservedCgroupMsg is the log message for a cgroup usage observation.
+	ObservedCgroupMsg = "Observed Cgroup Usage"
+	// StepMarkerMsg is the log message for a step boundary written by Mark.
+	StepMarkerMsg = "Step Marker"
+	// ObservedPressureMsg is the log message for a pressure stall (PSI) observation of one resource.
+	ObservedPressureMsg = "Observed Pressure Stall"
+	// ObservedLoadMsg is the log message for a load average observation.
+	ObservedLoadMsg = "Observed Load Average"
+	// ObservedDiskIOMsg is the log message for a block device I/O observation.
+	ObservedDiskIOMsg = "Observed Disk IO Usage"
 )
 
 // Sentinel errors for monitoring failures.
@@ -56,6 +70,18 @@ var (
 	ErrMonitorDisk = errors.New("error monitoring Disk")
 	// ErrMonitorIO indicates an IO monitoring failure.
 	ErrMonitorIO = errors.New("error monitoring IO")
+	// ErrMonitorDiskIO indicates a disk I/O monitoring failure.
+	ErrMonitorDiskIO = errors.New("error monitoring Disk IO")
+	// ErrMonitorCgroup indicates a cgroup monitoring failure.
+	ErrMonitorCgroup = errors.New("error monitoring Cgroup")
+	// ErrMonitorPressure indicates a pressure stall monitoring failure.
+	ErrMonitorPressure = errors.New("error monitoring Pressure")
+	// ErrMonitorLoad indicates a load average monitoring failure.
+	ErrMonitorLoad = errors.New("error monitoring Load")
+	// ErrMetricsServer indicates the Prometheus metrics endpoint could not be served.
+	ErrMetricsServer = errors.New("error serving metrics")
+	// ErrMonitorProcesses indicates a process monitoring failure.
+	ErrMonitorProcesses = errors.New("error monitoring Processes")
 )
 
 // Start begins monitoring system resources and writes the data to a file.
@@ -66,25 +92,73 @@ func Start(ctx context.Context, options ...Option) error {
 		opt(opts)
 	}
 
-	log, err := logging.New(
+	loggingOpts := []logging.Option{
 		logging.WithFileName(opts.OutputFile),
 		logging.WithLevel("trace"),
 		logging.DisableConsoleLog(),
-	)
+	}
+	var metrics *metricsExporter
+	if opts.MetricsAddr != "" {
+		metrics = newMetricsExporter()
+		loggingOpts = append(loggingOpts, logging.WithWriter(metrics))
+	}
+	log, err := logging.New(loggingOpts...)
 	if err != nil {
 		return fmt.Errorf("error creating logger: %w", err)
 	}
 
+	if metrics != nil {
+		stopMetrics, err := serveMetrics(log, opts.MetricsAddr, metrics)
+		if err != nil {
+			return err
+		}
+		defer stopMetrics()
+	}
+
 	interruptChan := make(chan os.Signal, 1)
 	signal.Notify(interruptChan, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(interruptChan)
 
+	if opts.MonitorCgroup {
+		opts.cgroup, err = detectCgroup(opts.CgroupRoot, procSelfCgroup)
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to detect cgroup, skipping cgroup monitoring")
+		}
+		opts.MonitorCgroup = opts.cgroup != nil
+	}
+
+	// Disk I/O counters come from /proc/diskstats on Linux, which some containers don't expose
+	if opts.MonitorDiskIO {
+		if _, err := opts.diskIOCounters(); err != nil {
+			log.Warn().Err(err).Msg("Unable to read disk I/O counters, skipping disk I/O monitoring")
+			opts.MonitorDiskIO = false
+		}
+	}
+
+	// PSI is Linux only, and needs a 4.20+ kernel
+	if opts.MonitorPressure && !fileExists(opts.pressureDir) {
+		opts.MonitorPressure = false
+	}
+	if opts.MonitorPressure {
+		if err := probePressure(opts.pressureDir); err != nil {
+			log.Warn().Err(err).Msg("Unable to read pressure stall information, skipping pressure monitoring")
+			opts.MonitorPressure = false
+		}
+	}
+
 	log.Info().
 		Str("output_file", opts.OutputFile).
+		Str("metrics_addr", opts.MetricsAddr).
 		Str("observe_interval", opts.ObserveInterval.String()).
 		Bool("monitor_cpu", opts.MonitorCPU).
 		Bool("monitor_memory", opts.MonitorMemory).
 		Bool("monitor_disk", opts.MonitorDisk).
+		Bool("monitor_disk_io", opts.MonitorDiskIO).
+		Bool("monitor_cgroup", opts.MonitorCgroup).
+		Bool("monitor_pressure", opts.MonitorPressure).
+		Bool("monitor_load", opts.MonitorLoad).
+		Bool("monitor_processes", opts.MonitorProcesses).
+		Int("top_processes", opts.TopProcesses).
 		Msg("Starting Monitoring")
 
 	if err := systemInfo(log, opts); err != nil {
@@ -155,6 +229,20 @@ func systemInfo(log zerolog.Logger, opts *options) error {
 		Uint64("total", diskStat.Total).
 		Msg(DiskSystemInfoMsg)
 
+	if opts.cgroup != nil {
+		if stats, err := opts.cgroup.read(); err != nil {
+			log.Warn().Err(err).Msg("Unable to read cgroup, skipping cgroup monitoring")
+			opts.cgroup, opts.MonitorCgroup = nil, false
+		} else {
+			log.Info().
+				Int("cgroup_version", opts.cgroup.version).
+				Str("cgroup_path", opts.cgroup.path).
+				Float64("cpu_limit", stats.CPULimit).
+				Uint64("memory_max", stats.MemoryMax).
+				Msg(CgroupInfoMsg)
+		}
+	}
+
 	envVars, err := collectGitHubActionsEnvVars()
 	if err != nil {
 		return fmt.Errorf("error collecting GitHub Actions environment variables: %w", err)
@@ -199,12 +287,55 @@ func spot(log zerolog.Logger, opts *options) error {
 		})
 	}
 
+	if opts.MonitorDiskIO {
+		eg.Go(func() error {
+			// Disk I/O is optional, so a failed read skips the observation instead of stopping the monitor
+			if err := spotDiskIO(log, opts); err != nil {
+				log.Warn().Err(err).Msg("Skipping disk I/O observation")
+			}
+			return nil
+		})
+	}
+
 	if opts.MonitorIO {
 		eg.Go(func() error {
 			return spotIO(log, opts)
 		})
 	}
 
+	if opts.MonitorCgroup {
+		eg.Go(func() error {
+			// Cgroup monitoring is optional, so a failed read turns it off instead of stopping the monitor
+			if err := spotCgroup(log, opts); err != nil {
+				log.Warn().Err(err).Msg("Unable to read cgroup, skipping cgroup monitoring")
+				opts.MonitorCgroup = false
+			}
+			return nil
+		})
+	}
+
+	if opts.MonitorPressure {
+		eg.Go(func() error {
+			// PSI is optional, so a failed read skips the observation instead of stopping the monitor
+			if err := spotPressure(log, opts); err != nil {
+				log.Warn().Err(err).Msg("Skipping pressure stall observation")
+			}
+			return nil
+		})
+	}
+
+	if opts.MonitorLoad {
+		eg.Go(func() error {
+			return spotLoad(log)
+		})
+	}
+
+	if opts.MonitorProcesses {
+		eg.Go(func() error {
+			return spotProcesses(log, opts)
+		})
+	}
+
 	if err := eg.Wait(); err != nil {
 		return fmt.Errorf("error while monitoring: %w", err)
 	}
@@ -269,6 +400,102 @@ func spotDisk(log zerolog.Logger, opts *options) error {
 	return nil
 }
 
+func spotDiskIO(log zerolog.Logger, opts *options) error {
+	ioStats, err := opts.diskIOCounters()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMonitorDiskIO, err)
+	}
+	ioStats = wholeDiskCounters(ioStats)
+
+	deltas := diskIODeltasFromCounters(opts.prevDiskIOStats, ioStats)
+	opts.prevDiskIOStats = ioStats
+
+	for _, stat := range deltas {
+		log.Debug().
+			Str("device", stat.Name).
+			Uint64("read_bytes", stat.ReadBytes).
+			Uint64("write_bytes", stat.WriteBytes).
+			Uint64("read_count", stat.ReadCount).
+			Uint64("write_count", stat.WriteCount).
+			Msg(ObservedDiskIOMsg)
+	}
+	return nil
+}
+
+func spotCgroup(log zerolog.Logger, opts *options) error {
+	stats, err := opts.cgroup.read()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMonitorCgroup, err)
+	}
+	sampledAt := time.Now()
+
+	delta := cgroupDeltaFromStats(opts.prevCgroupStats, stats, sampledAt.Sub(opts.prevCgroupAt))
+	opts.prevCgroupStats, opts.prevCgroupAt = stats, sampledAt
+	if delta == nil {
+		return nil
+	}
+
+	log.Debug().
+		Float64("cpu_percent", delta.CPUPercent).
+		Float64("cpu_limit", delta.CPULimit).
+		Uint64("nr_periods", delta.Periods).
+		Uint64("nr_throttled", delta.ThrottledPeriods).
+		Float64("throttled_seconds", delta.ThrottledSeconds).
+		Uint64("memory_current", delta.MemoryCurrent).
+		Uint64("memory_max", delta.MemoryMax).
+		Uint64("oom_events", delta.OOMEvents).
+		Uint64("oom_kills", delta.OOMKills).
+		Msg(ObservedCgroupMsg)
+	return nil
+}
+
+func spotPressure(log zerolog.Logger, opts *options) error {
+	var (
+		sampledAt = time.Now()
+		stats     = make(map[string]*pressureStat, len(pressureResources))
+		elapsed   = sampledAt.Sub(opts.prevPressureAt)
+	)
+	for _, resource := range pressureResources {
+		stat, err := readPressure(opts.pressureDir, resource)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrMonitorPressure, err)
+		}
+		stats[resource] = stat
+	}
+
+	prev := opts.prevPressure
+	opts.prevPressure, opts.prevPressureAt = stats, sampledAt
+	for _, resource := range pressureResources {
+		delta := pressureDeltaFromStats(resource, prev[resource], stats[resource], elapsed)
+		if delta == nil {
+			continue
+		}
+		log.Debug().
+			Str("resource", delta.Resource).
+			Float64("some_avg10", delta.SomeAvg10).
+			Float64("full_avg10", delta.FullAvg10).
+			Float64("some_percent", delta.SomePercent).
+			Float64("full_percent", delta.FullPercent).
+			Float64("some_stalled_seconds", delta.SomeStalled.Seconds()).
+			Float64("full_stalled_seconds", delta.FullStalled.Seconds()).
+			Msg(ObservedPressureMsg)
+	}
+	return nil
+}
+
+func spotLoad(log zerolog.Logger) error {
+	avg, err := load.Avg()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMonitorLoad, err)
+	}
+	log.Debug().
+		Float64("load1", avg.Load1).
+		Float64("load5", avg.Load5).
+		Float64("load15", avg.Load15).
+		Msg(ObservedLoadMsg)
+	return nil
+}
+
 func spotIO(log zerolog.Logger, opts *options) error {
 	ioStats, err := net.IOCounters(false)
 	if err != nil {
@@ -295,3 +522,56 @@ func spotIO(log zerolog.Logger, opts *options) error {
 	}
 	return nil
 }
+
+func spotProcesses(log zerolog.Logger, opts *options) error {
+	procs, err := process.Processes()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMonitorProcesses, err)
+	}
+	sampledAt := time.Now()
+
+	var (
+		samples  = make([]processSample, 0, len(procs))
+		cpuTimes = make(map[int32]float64, len(procs))
+		byPID    = make(map[int32]*process.Process, len(procs))
+	)
+	for _, proc := range procs {
+		// Processes exit or deny access between listing and reading them, skip those
+		times, err := proc.Times()
+		if err != nil {
+			continue
+		}
+		memInfo, err := proc.MemoryInfo()
+		if err != nil {
+			continue
+		}
+		cpuTime := times.User + times.System
+		cpuTimes[proc.Pid] = cpuTime
+		byPID[proc.Pid] = proc
+		samples = append(samples, processSample{PID: proc.Pid, CPUTime: cpuTime, RSS: memInfo.RSS})
+	}
+
+	prev, prevSampled := opts.prevProcCPUTimes, opts.prevProcSampled
+	opts.prevProcCPUTimes, opts.prevProcSampled = cpuTimes, sampledAt
+	if prev == nil {
+		return nil
+	}
+	processCPUPercents(prev, sampledAt.Sub(prevSampled), samples)
+
+	for _, sample := range topProcesses(samples, opts.TopProcesses) {
+		proc := byPID[sample.PID]
+		// Names and command lines are only read for the processes we log, they're comparatively expensive
+		name, _ := proc.Name()
+		cmdline, _ := proc.Cmdline()
+		ppid, _ := proc.Ppid()
+		log.Debug().
+			Int32("pid", sample.PID).
+			Int32("ppid", ppid).
+			Str("name", name).
+			Str("cmdline", truncateCmdline(cmdline, maxCmdlineLength)).
+			Float64("cpu_percent", sample.CPUPercent).
+			Uint64("rss", sample.RSS).
+			Msg(ObservedProcMsg)
+	}
+	return nil
+}
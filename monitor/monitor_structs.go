@@ -7,6 +7,7 @@ import (
 
 	"github.com/caarlos0/env/v11"
 	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/net"
 
 	"github.com/kalverra/octometrics/internal/logging"
@@ -50,6 +51,13 @@ func DisableDisk() Option {
 	}
 }
 
+// DisableDiskIO disables block device I/O monitoring
+func DisableDiskIO() Option {
+	return func(opts *options) {
+		opts.MonitorDiskIO = false
+	}
+}
+
 // DisableIO disables IO monitoring
 func DisableIO() Option {
 	return func(opts *options) {
@@ -64,16 +72,79 @@ func WithDiskPath(path string) Option {
 	}
 }
 
+// WithMetricsAddr serves the latest observations in the Prometheus text format on addr, e.g. ":9090",
+// at /metrics while monitoring.
+func WithMetricsAddr(addr string) Option {
+	return func(opts *options) {
+		opts.MetricsAddr = addr
+	}
+}
+
+// DisablePressure disables Linux pressure stall information (PSI) monitoring
+func DisablePressure() Option {
+	return func(opts *options) {
+		opts.MonitorPressure = false
+	}
+}
+
+// DisableLoad disables load average monitoring
+func DisableLoad() Option {
+	return func(opts *options) {
+		opts.MonitorLoad = false
+	}
+}
+
+// DisableCgroup disables cgroup monitoring, which is otherwise on whenever the monitor runs inside a cgroup
+func DisableCgroup() Option {
+	return func(opts *options) {
+		opts.MonitorCgroup = false
+	}
+}
+
+// WithCgroupRoot sets where cgroupfs is mounted, defaults to /sys/fs/cgroup.
+func WithCgroupRoot(path string) Option {
+	return func(opts *options) {
+		opts.CgroupRoot = path
+	}
+}
+
+// WithTopProcesses enables process sampling, recording the top n processes by CPU and by memory each interval.
+// Process sampling is off by default; n <= 0 leaves it disabled.
+func WithTopProcesses(n int) Option {
+	return func(opts *options) {
+		opts.MonitorProcesses = n > 0
+		opts.TopProcesses = n
+	}
+}
+
 type options struct {
-	OutputFile      string
-	ObserveInterval time.Duration
-	MonitorCPU      bool
-	MonitorMemory   bool
-	MonitorDisk     bool
-	MonitorIO       bool
-	DiskPath        string
-	prevCPUTimes    []cpu.TimesStat
-	prevIOStats     []net.IOCountersStat
+	OutputFile       string
+	MetricsAddr      string
+	ObserveInterval  time.Duration
+	MonitorCPU       bool
+	MonitorMemory    bool
+	MonitorDisk      bool
+	MonitorDiskIO    bool
+	MonitorIO        bool
+	MonitorCgroup    bool
+	MonitorPressure  bool
+	MonitorLoad      bool
+	MonitorProcesses bool
+	TopProcesses     int
+	DiskPath         string
+	CgroupRoot       string
+	prevCPUTimes     []cpu.TimesStat
+	prevIOStats      []net.IOCountersStat
+	prevDiskIOStats  map[string]disk.IOCountersStat
+	diskIOCounters   func(names ...string) (map[string]disk.IOCountersStat, error)
+	cgroup           *cgroupReader
+	prevCgroupStats  *cgroupStats
+	prevCgroupAt     time.Time
+	pressureDir      string
+	prevPressure     map[string]*pressureStat
+	prevPressureAt   time.Time
+	prevProcCPUTimes map[int32]float64
+	prevProcSampled  time.Time
 }
 
 func defaultOptions() *options {
@@ -83,8 +154,15 @@ func defaultOptions() *options {
 		MonitorCPU:      true,
 		MonitorMemory:   true,
 		MonitorDisk:     true,
+		MonitorDiskIO:   true,
 		MonitorIO:       true,
+		MonitorCgroup:   true,
+		MonitorPressure: true,
+		MonitorLoad:     true,
 		DiskPath:        defaultDiskPath(),
+		CgroupRoot:      defaultCgroupRoot,
+		pressureDir:     defaultPressureDir,
+		diskIOCounters:  disk.IOCounters,
 	}
 }
 
@@ -115,6 +193,50 @@ type monitorEntry struct {
 	PacketsSent *uint64 `json:"packets_sent,omitempty"`
 	PacketsRecv *uint64 `json:"packets_recv,omitempty"`
 
+	// Disk IO specific values
+	Device     *string `json:"device,omitempty"`
+	ReadBytes  *uint64 `json:"read_bytes,omitempty"`
+	WriteBytes *uint64 `json:"write_bytes,omitempty"`
+	ReadCount  *uint64 `json:"read_count,omitempty"`
+	WriteCount *uint64 `json:"write_count,omitempty"`
+
+	// Cgroup specific values
+	CgroupVersion    *int     `json:"cgroup_version,omitempty"`
+	CgroupPath       *string  `json:"cgroup_path,omitempty"`
+	CPULimit         *float64 `json:"cpu_limit,omitempty"`
+	NrPeriods        *uint64  `json:"nr_periods,omitempty"`
+	NrThrottled      *uint64  `json:"nr_throttled,omitempty"`
+	ThrottledSeconds *float64 `json:"throttled_seconds,omitempty"`
+	MemoryCurrent    *uint64  `json:"memory_current,omitempty"`
+	MemoryMax        *uint64  `json:"memory_max,omitempty"`
+	OOMEvents        *uint64  `json:"oom_events,omitempty"`
+	OOMKills         *uint64  `json:"oom_kills,omitempty"`
+
+	// Step marker specific values
+	Step *string `json:"step,omitempty"`
+
+	// Pressure stall specific values
+	Resource           *string  `json:"resource,omitempty"`
+	SomeAvg10          *float64 `json:"some_avg10,omitempty"`
+	FullAvg10          *float64 `json:"full_avg10,omitempty"`
+	SomePercent        *float64 `json:"some_percent,omitempty"`
+	FullPercent        *float64 `json:"full_percent,omitempty"`
+	SomeStalledSeconds *float64 `json:"some_stalled_seconds,omitempty"`
+	FullStalledSeconds *float64 `json:"full_stalled_seconds,omitempty"`
+
+	// Load average specific values
+	Load1  *float64 `json:"load1,omitempty"`
+	Load5  *float64 `json:"load5,omitempty"`
+	Load15 *float64 `json:"load15,omitempty"`
+
+	// Process specific values
+	PID        *int32   `json:"pid,omitempty"`
+	PPID       *int32   `json:"ppid,omitempty"`
+	Name       *string  `json:"name,omitempty"`
+	Cmdline    *string  `json:"cmdline,omitempty"`
+	CPUPercent *float64 `json:"cpu_percent,omitempty"`
+	RSS        *uint64  `json:"rss,omitempty"`
+
 	// GitHub Actions Environment Variables
 	// https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/store-information-in-variables#default-environment-variables
 	GitHubActionsEnvVars *githubActionsEnvVars `json:"github_actions_env_vars,omitempty"`
@@ -330,3 +452,227 @@ func (m *monitorEntry) GetPacketsRecv() uint64 {
 	}
 	return *m.PacketsRecv
 }
+
+func (m *monitorEntry) GetDevice() string {
+	if m == nil || m.Device == nil {
+		return ""
+	}
+	return *m.Device
+}
+
+func (m *monitorEntry) GetReadBytes() uint64 {
+	if m == nil || m.ReadBytes == nil {
+		return 0
+	}
+	return *m.ReadBytes
+}
+
+func (m *monitorEntry) GetWriteBytes() uint64 {
+	if m == nil || m.WriteBytes == nil {
+		return 0
+	}
+	return *m.WriteBytes
+}
+
+func (m *monitorEntry) GetReadCount() uint64 {
+	if m == nil || m.ReadCount == nil {
+		return 0
+	}
+	return *m.ReadCount
+}
+
+func (m *monitorEntry) GetWriteCount() uint64 {
+	if m == nil || m.WriteCount == nil {
+		return 0
+	}
+	return *m.WriteCount
+}
+
+func (m *monitorEntry) GetCgroupVersion() int {
+	if m == nil || m.CgroupVersion == nil {
+		return 0
+	}
+	return *m.CgroupVersion
+}
+
+func (m *monitorEntry) GetCgroupPath() string {
+	if m == nil || m.CgroupPath == nil {
+		return ""
+	}
+	return *m.CgroupPath
+}
+
+func (m *monitorEntry) GetCPULimit() float64 {
+	if m == nil || m.CPULimit == nil {
+		return 0
+	}
+	return *m.CPULimit
+}
+
+func (m *monitorEntry) GetNrPeriods() uint64 {
+	if m == nil || m.NrPeriods == nil {
+		return 0
+	}
+	return *m.NrPeriods
+}
+
+func (m *monitorEntry) GetNrThrottled() uint64 {
+	if m == nil || m.NrThrottled == nil {
+		return 0
+	}
+	return *m.NrThrottled
+}
+
+func (m *monitorEntry) GetThrottledSeconds() float64 {
+	if m == nil || m.ThrottledSeconds == nil {
+		return 0
+	}
+	return *m.ThrottledSeconds
+}
+
+func (m *monitorEntry) GetMemoryCurrent() uint64 {
+	if m == nil || m.MemoryCurrent == nil {
+		return 0
+	}
+	return *m.MemoryCurrent
+}
+
+func (m *monitorEntry) GetMemoryMax() uint64 {
+	if m == nil || m.MemoryMax == nil {
+		return 0
+	}
+	return *m.MemoryMax
+}
+
+func (m *monitorEntry) GetOOMEvents() uint64 {
+	if m == nil || m.OOMEvents == nil {
+		return 0
+	}
+	return *m.OOMEvents
+}
+
+func (m *monitorEntry) GetOOMKills() uint64 {
+	if m == nil || m.OOMKills == nil {
+		return 0
+	}
+	return *m.OOMKills
+}
+
+func (m *monitorEntry) GetStep() string {
+	if m == nil || m.Step == nil {
+		return ""
+	}
+	return *m.Step
+}
+
+func (m *monitorEntry) GetResource() string {
+	if m == nil || m.Resource == nil {
+		return ""
+	}
+	return *m.Resource
+}
+
+func (m *monitorEntry) GetSomeAvg10() float64 {
+	if m == nil || m.SomeAvg10 == nil {
+		return 0
+	}
+	return *m.SomeAvg10
+}
+
+func (m *monitorEntry) GetFullAvg10() float64 {
+	if m == nil || m.FullAvg10 == nil {
+		return 0
+	}
+	return *m.FullAvg10
+}
+
+func (m *monitorEntry) GetSomePercent() float64 {
+	if m == nil || m.SomePercent == nil {
+		return 0
+	}
+	return *m.SomePercent
+}
+
+func (m *monitorEntry) GetFullPercent() float64 {
+	if m == nil || m.FullPercent == nil {
+		return 0
+	}
+	return *m.FullPercent
+}
+
+func (m *monitorEntry) GetSomeStalledSeconds() float64 {
+	if m == nil || m.SomeStalledSeconds == nil {
+		return 0
+	}
+	return *m.SomeStalledSeconds
+}
+
+func (m *monitorEntry) GetFullStalledSeconds() float64 {
+	if m == nil || m.FullStalledSeconds == nil {
+		return 0
+	}
+	return *m.FullStalledSeconds
+}
+
+func (m *monitorEntry) GetLoad1() float64 {
+	if m == nil || m.Load1 == nil {
+		return 0
+	}
+	return *m.Load1
+}
+
+func (m *monitorEntry) GetLoad5() float64 {
+	if m == nil || m.Load5 == nil {
+		return 0
+	}
+	return *m.Load5
+}
+
+func (m *monitorEntry) GetLoad15() float64 {
+	if m == nil || m.Load15 == nil {
+		return 0
+	}
+	return *m.Load15
+}
+
+func (m *monitorEntry) GetPID() int32 {
+	if m == nil || m.PID == nil {
+		return 0
+	}
+	return *m.PID
+}
+
+func (m *monitorEntry) GetPPID() int32 {
+	if m == nil || m.PPID == nil {
+		return 0
+	}
+	return *m.PPID
+}
+
+func (m *monitorEntry) GetName() string {
+	if m == nil || m.Name == nil {
+		return ""
+	}
+	return *m.Name
+}
+
+func (m *monitorEntry) GetCmdline() string {
+	if m == nil || m.Cmdline == nil {
+		return ""
+	}
+	return *m.Cmdline
+}
+
+func (m *monitorEntry) GetCPUPercent() float64 {
+	if m == nil || m.CPUPercent == nil {
+		return 0
+	}
+	return *m.CPUPercent
+}
+
+func (m *monitorEntry) GetRSS() uint64 {
+	if m == nil || m.RSS == nil {
+		return 0
+	}
+	return *m.RSS
+}
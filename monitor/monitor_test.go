@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -44,6 +45,7 @@ func TestMonitorIntegration(t *testing.T) {
 			opts: []Option{
 				DisableMemory(),
 				DisableDisk(),
+				DisableDiskIO(),
 				DisableIO(),
 				WithObserveInterval(defaultObserverInterval),
 			},
@@ -54,6 +56,7 @@ func TestMonitorIntegration(t *testing.T) {
 			opts: []Option{
 				DisableCPU(),
 				DisableDisk(),
+				DisableDiskIO(),
 				DisableIO(),
 				WithObserveInterval(defaultObserverInterval),
 			},
@@ -64,6 +67,18 @@ func TestMonitorIntegration(t *testing.T) {
 			opts: []Option{
 				DisableCPU(),
 				DisableMemory(),
+				DisableDiskIO(),
+				DisableIO(),
+				WithObserveInterval(defaultObserverInterval),
+			},
+			monitorTime: time.Second,
+		},
+		{
+			name: "monitor only disk IO",
+			opts: []Option{
+				DisableCPU(),
+				DisableMemory(),
+				DisableDisk(),
 				DisableIO(),
 				WithObserveInterval(defaultObserverInterval),
 			},
@@ -75,6 +90,20 @@ func TestMonitorIntegration(t *testing.T) {
 				DisableCPU(),
 				DisableMemory(),
 				DisableDisk(),
+				DisableDiskIO(),
+				WithObserveInterval(defaultObserverInterval),
+			},
+			monitorTime: time.Second,
+		},
+		{
+			name: "monitor only processes",
+			opts: []Option{
+				DisableCPU(),
+				DisableMemory(),
+				DisableDisk(),
+				DisableDiskIO(),
+				DisableIO(),
+				WithTopProcesses(3),
 				WithObserveInterval(defaultObserverInterval),
 			},
 			monitorTime: time.Second,
@@ -144,11 +173,60 @@ func TestMonitorIntegration(t *testing.T) {
 				assert.NotContains(t, content, ObservedDiskMsg, "should not contain disk observations")
 			}
 
+			if opts.MonitorDiskIO {
+				assert.Contains(t, content, ObservedDiskIOMsg, "should contain disk IO observations")
+			} else {
+				assert.NotContains(t, content, ObservedDiskIOMsg, "should not contain disk IO observations")
+			}
+
 			if opts.MonitorIO {
 				assert.Contains(t, content, ObservedIOMsg, "should contain IO observations")
 			} else {
 				assert.NotContains(t, content, ObservedIOMsg, "should not contain IO observations")
 			}
+
+			if opts.MonitorProcesses {
+				assert.Contains(t, content, ObservedProcMsg, "should contain process observations")
+			} else {
+				assert.NotContains(t, content, ObservedProcMsg, "should not contain process observations")
+			}
 		})
 	}
 }
+
+func TestMonitorDiskIOUnreadable(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, testDir := testhelpers.Setup(t)
+	outputFile := filepath.Join(testDir, "monitor.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	t.Cleanup(cancel)
+
+	err := Start(ctx,
+		DisableCPU(),
+		DisableMemory(),
+		DisableDisk(),
+		DisableIO(),
+		DisableCgroup(),
+		DisablePressure(),
+		func(opts *options) {
+			// Like a container without /proc/diskstats
+			opts.diskIOCounters = func(...string) (map[string]disk.IOCountersStat, error) {
+				return nil, os.ErrNotExist
+			}
+		},
+		WithObserveInterval(100*time.Millisecond),
+		WithOutputFile(outputFile),
+	)
+	require.NoError(t, err, "unreadable disk I/O counters should not stop the monitor")
+
+	data, err := os.ReadFile(filepath.Clean(outputFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "skipping disk I/O monitoring")
+	assert.NotContains(t, string(data), ObservedDiskIOMsg)
+	assert.Contains(t, string(data), ObservedLoadMsg)
+}
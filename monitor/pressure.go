@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPressureDir is where Linux exposes pressure stall information (PSI).
+const defaultPressureDir = "/proc/pressure"
+
+// pressureResources are the PSI files sampled, in logging order.
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// pressureStat is one PSI file: how long tasks stalled waiting on a resource.
+// "some" is time at least one task was stalled, "full" is time every non-idle task was stalled at once.
+type pressureStat struct {
+	SomeAvg10 float64
+	FullAvg10 float64
+	SomeTotal time.Duration
+	FullTotal time.Duration
+}
+
+// pressureDelta is how long tasks stalled on a resource between two observations.
+type pressureDelta struct {
+	Resource    string
+	SomeAvg10   float64
+	FullAvg10   float64
+	SomeStalled time.Duration
+	FullStalled time.Duration
+	// SomePercent and FullPercent are the stalled share of the time between observations.
+	SomePercent float64
+	FullPercent float64
+}
+
+// readPressure reads the PSI file for resource in dir.
+func readPressure(dir, resource string) (*pressureStat, error) {
+	file := filepath.Join(dir, resource)
+	content, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", file, err)
+	}
+	stat, err := parsePressure(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", file, err)
+	}
+	return stat, nil
+}
+
+// probePressure reads every PSI file once. Kernels built with PSI but booted with psi=0 have the files,
+// but fail to read them.
+func probePressure(dir string) error {
+	for _, resource := range pressureResources {
+		if _, err := readPressure(dir, resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePressure parses PSI lines like "some avg10=1.97 avg60=2.75 avg300=2.24 total=103436687",
+// where total is cumulative stalled microseconds. Older kernels have no "full" line for cpu.
+func parsePressure(content string) (*pressureStat, error) {
+	stat := &pressureStat{}
+	for line := range strings.SplitSeq(strings.TrimSpace(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var (
+			avg10 *float64
+			total *time.Duration
+		)
+		switch fields[0] {
+		case "some":
+			avg10, total = &stat.SomeAvg10, &stat.SomeTotal
+		case "full":
+			avg10, total = &stat.FullAvg10, &stat.FullTotal
+		default:
+			return nil, fmt.Errorf("unexpected pressure line '%s'", line)
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("unexpected pressure field '%s'", field)
+			}
+			switch key {
+			case "avg10":
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse avg10 '%s': %w", value, err)
+				}
+				*avg10 = v
+			case "total":
+				v, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse total '%s': %w", value, err)
+				}
+				*total = time.Duration(v) * time.Microsecond
+			}
+		}
+	}
+	return stat, nil
+}
+
+// pressureDeltaFromStats returns how long tasks stalled over elapsed, or nil without a previous reading.
+func pressureDeltaFromStats(resource string, prev, curr *pressureStat, elapsed time.Duration) *pressureDelta {
+	if prev == nil || curr == nil || elapsed <= 0 {
+		return nil
+	}
+	delta := &pressureDelta{
+		Resource:  resource,
+		SomeAvg10: curr.SomeAvg10,
+		FullAvg10: curr.FullAvg10,
+	}
+	if curr.SomeTotal >= prev.SomeTotal {
+		delta.SomeStalled = curr.SomeTotal - prev.SomeTotal
+	}
+	if curr.FullTotal >= prev.FullTotal {
+		delta.FullStalled = curr.FullTotal - prev.FullTotal
+	}
+	// Timing jitter between reading the file and the clock can nudge a fully stalled interval past 100%
+	delta.SomePercent = min(100, 100*delta.SomeStalled.Seconds()/elapsed.Seconds())
+	delta.FullPercent = min(100, 100*delta.FullStalled.Seconds()/elapsed.Seconds())
+	return delta
+}
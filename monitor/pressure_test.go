@@ -0,0 +1,204 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+var pressureTestDataDir = filepath.Join(testDataDir, "pressure")
+
+func TestParsePressure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    *pressureStat
+		wantErr bool
+	}{
+		{
+			name: "some and full",
+			content: "some avg10=1.97 avg60=2.75 avg300=2.24 total=103436687\n" +
+				"full avg10=0.50 avg60=0.25 avg300=0.10 total=2000000\n",
+			want: &pressureStat{
+				SomeAvg10: 1.97,
+				FullAvg10: 0.5,
+				SomeTotal: 103436687 * time.Microsecond,
+				FullTotal: 2 * time.Second,
+			},
+		},
+		{
+			name:    "older kernels have no full cpu line",
+			content: "some avg10=0.00 avg60=0.00 avg300=0.00 total=1000\n",
+			want:    &pressureStat{SomeTotal: time.Millisecond},
+		},
+		{
+			name:    "unknown line",
+			content: "most avg10=0.00 total=1000\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad total",
+			content: "some avg10=0.00 total=lots\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stat, err := parsePressure(tt.content)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stat)
+		})
+	}
+}
+
+func TestReadPressure(t *testing.T) {
+	t.Parallel()
+
+	stat, err := readPressure(pressureTestDataDir, "io")
+	require.NoError(t, err)
+	assert.Equal(t, &pressureStat{
+		SomeAvg10: 3,
+		FullAvg10: 1,
+		SomeTotal: 1200 * time.Millisecond,
+		FullTotal: 600 * time.Millisecond,
+	}, stat)
+
+	_, err = readPressure(filepath.Join(pressureTestDataDir, "missing"), "io")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPressureDeltaFromStats(t *testing.T) {
+	t.Parallel()
+
+	prev := &pressureStat{SomeTotal: time.Second, FullTotal: time.Second}
+	curr := &pressureStat{
+		SomeAvg10: 25,
+		FullAvg10: 10,
+		SomeTotal: 1500 * time.Millisecond,
+		FullTotal: 1200 * time.Millisecond,
+	}
+
+	delta := pressureDeltaFromStats("cpu", prev, curr, 2*time.Second)
+	require.NotNil(t, delta)
+	assert.InDelta(t, 25.0, delta.SomePercent, 0.001, "500ms stalled over 2s is a quarter of the time")
+	assert.InDelta(t, 10.0, delta.FullPercent, 0.001)
+	assert.Equal(t, &pressureDelta{
+		Resource:    "cpu",
+		SomeAvg10:   25,
+		FullAvg10:   10,
+		SomeStalled: 500 * time.Millisecond,
+		FullStalled: 200 * time.Millisecond,
+		SomePercent: delta.SomePercent,
+		FullPercent: delta.FullPercent,
+	}, delta)
+
+	assert.Nil(t, pressureDeltaFromStats("cpu", nil, curr, time.Second), "first observation has nothing to compare to")
+
+	saturated := pressureDeltaFromStats("cpu", prev, curr, 100*time.Millisecond)
+	require.NotNil(t, saturated)
+	assert.InDelta(t, 100.0, saturated.SomePercent, 0.001, "stalls should never pass 100% of the time")
+
+	reset := pressureDeltaFromStats("cpu", curr, prev, time.Second)
+	require.NotNil(t, reset)
+	assert.Zero(t, reset.SomeStalled, "counters going backwards should not report negative stalls")
+}
+
+func TestMonitorPressure(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	log, testDir := testhelpers.Setup(t)
+	outputFile := filepath.Join(testDir, "monitor.json")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	err := Start(ctx,
+		DisableCPU(),
+		DisableMemory(),
+		DisableDisk(),
+		DisableDiskIO(),
+		DisableIO(),
+		DisableCgroup(),
+		func(opts *options) {
+			opts.pressureDir = pressureTestDataDir
+		},
+		WithObserveInterval(250*time.Millisecond),
+		WithOutputFile(outputFile),
+	)
+	require.NoError(t, err, "error while monitoring")
+
+	data, err := os.ReadFile(filepath.Clean(outputFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ObservedPressureMsg)
+	assert.Contains(t, string(data), ObservedLoadMsg)
+
+	analysis, err := Analyze(log, outputFile)
+	require.NoError(t, err)
+	require.NotEmpty(t, analysis.LoadMeasurements)
+	require.NotEmpty(t, analysis.PressureMeasurements)
+	resources := map[string]bool{}
+	for _, m := range analysis.PressureMeasurements {
+		resources[m.Resource] = true
+		assert.Zero(t, m.SomePercent, "static fake counters should show no stalls")
+	}
+	assert.Equal(t, map[string]bool{"cpu": true, "memory": true, "io": true}, resources)
+	assert.InDelta(t, 12.5, analysis.PressureMeasurements[0].SomeAvg10, 0.001)
+}
+
+func TestMonitorPressureUnreadable(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	_, testDir := testhelpers.Setup(t)
+	// Like a kernel booted with psi=0, the PSI files exist but can't be read
+	pressureDir := filepath.Join(testDir, "pressure")
+	for _, resource := range pressureResources {
+		require.NoError(t, os.MkdirAll(filepath.Join(pressureDir, resource), 0o750))
+	}
+	outputFile := filepath.Join(testDir, "monitor.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	t.Cleanup(cancel)
+
+	err := Start(ctx,
+		DisableCPU(),
+		DisableMemory(),
+		DisableDisk(),
+		DisableDiskIO(),
+		DisableIO(),
+		DisableCgroup(),
+		func(opts *options) {
+			opts.pressureDir = pressureDir
+		},
+		WithObserveInterval(100*time.Millisecond),
+		WithOutputFile(outputFile),
+	)
+	require.NoError(t, err, "unreadable PSI files should not stop the monitor")
+
+	data, err := os.ReadFile(filepath.Clean(outputFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "skipping pressure monitoring")
+	assert.NotContains(t, string(data), ObservedPressureMsg)
+	assert.Contains(t, string(data), ObservedLoadMsg)
+}
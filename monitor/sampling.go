@@ -1,10 +1,16 @@
 package monitor
 
 import (
+	"cmp"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/net"
 )
 
@@ -64,3 +70,141 @@ func ioDeltasFromCounters(prev, curr []net.IOCountersStat) ([]net.IOCountersStat
 	}
 	return deltas, nil
 }
+
+// virtualDiskPrefixes are in-memory or file-backed block devices whose I/O isn't real disk traffic.
+var virtualDiskPrefixes = []string{"loop", "ram", "zram"}
+
+// wholeDiskCounters drops partitions and virtual devices, so summing the remaining devices doesn't count
+// the same I/O twice (Linux reports sda and sda1, nvme0n1 and nvme0n1p1 separately).
+func wholeDiskCounters(stats map[string]disk.IOCountersStat) map[string]disk.IOCountersStat {
+	whole := make(map[string]disk.IOCountersStat, len(stats))
+	for name, stat := range stats {
+		if slices.ContainsFunc(virtualDiskPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(name, prefix)
+		}) {
+			continue
+		}
+		if isPartition(name, stats) {
+			continue
+		}
+		whole[name] = stat
+	}
+	return whole
+}
+
+// isPartition reports whether name is a partition of another device in stats, e.g. sda1 of sda or nvme0n1p2 of nvme0n1.
+func isPartition(name string, stats map[string]disk.IOCountersStat) bool {
+	for parent := range stats {
+		suffix, ok := strings.CutPrefix(name, parent)
+		if !ok || suffix == "" {
+			continue
+		}
+		// Devices whose names end in a digit number their partitions with a "p", e.g. nvme0n1p1 and mmcblk0p1
+		if parent[len(parent)-1] >= '0' && parent[len(parent)-1] <= '9' {
+			suffix, ok = strings.CutPrefix(suffix, "p")
+			if !ok {
+				continue
+			}
+		}
+		if suffix != "" && strings.Trim(suffix, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// diskIODeltasFromCounters returns how much each device read and wrote since the previous observation, sorted by name.
+// Devices without a previous reading, or whose counters went backwards, are skipped until the next observation.
+func diskIODeltasFromCounters(prev, curr map[string]disk.IOCountersStat) []disk.IOCountersStat {
+	if len(prev) == 0 {
+		return nil
+	}
+
+	deltas := make([]disk.IOCountersStat, 0, len(curr))
+	for name, c := range curr {
+		p, ok := prev[name]
+		if !ok || c.ReadBytes < p.ReadBytes || c.WriteBytes < p.WriteBytes ||
+			c.ReadCount < p.ReadCount || c.WriteCount < p.WriteCount {
+			continue
+		}
+		deltas = append(deltas, disk.IOCountersStat{
+			Name:       name,
+			ReadBytes:  c.ReadBytes - p.ReadBytes,
+			WriteBytes: c.WriteBytes - p.WriteBytes,
+			ReadCount:  c.ReadCount - p.ReadCount,
+			WriteCount: c.WriteCount - p.WriteCount,
+		})
+	}
+	slices.SortFunc(deltas, func(a, b disk.IOCountersStat) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return deltas
+}
+
+// maxCmdlineLength caps recorded process command lines so huge argument lists don't bloat the monitor file.
+const maxCmdlineLength = 256
+
+// processSample is one process's resource usage at a single observation.
+type processSample struct {
+	PID        int32
+	CPUTime    float64 // user + system seconds since the process started
+	CPUPercent float64
+	RSS        uint64
+}
+
+// processCPUPercents fills in each sample's CPUPercent from the CPU time it used since the previous observation.
+// 100% is one fully busy core, so busy multi-threaded processes can exceed 100%.
+// Processes without a previous reading (new, or a reused PID) report 0% until the next observation.
+func processCPUPercents(prev map[int32]float64, elapsed time.Duration, samples []processSample) {
+	if elapsed <= 0 {
+		return
+	}
+	for i := range samples {
+		prevTime, ok := prev[samples[i].PID]
+		if !ok || samples[i].CPUTime < prevTime {
+			continue
+		}
+		samples[i].CPUPercent = 100 * (samples[i].CPUTime - prevTime) / elapsed.Seconds()
+	}
+}
+
+// topProcesses returns the top n samples by CPU and the top n by RSS, without duplicates, ordered by CPU usage.
+func topProcesses(samples []processSample, n int) []processSample {
+	if n <= 0 || len(samples) == 0 {
+		return nil
+	}
+
+	byCPU := slices.Clone(samples)
+	slices.SortStableFunc(byCPU, func(a, b processSample) int {
+		return cmp.Or(cmp.Compare(b.CPUPercent, a.CPUPercent), cmp.Compare(b.RSS, a.RSS))
+	})
+	byRSS := slices.Clone(samples)
+	slices.SortStableFunc(byRSS, func(a, b processSample) int {
+		return cmp.Or(cmp.Compare(b.RSS, a.RSS), cmp.Compare(b.CPUPercent, a.CPUPercent))
+	})
+
+	var (
+		top  = make([]processSample, 0, 2*n)
+		seen = make(map[int32]struct{}, 2*n)
+	)
+	for _, sample := range slices.Concat(byCPU[:min(n, len(byCPU))], byRSS[:min(n, len(byRSS))]) {
+		if _, ok := seen[sample.PID]; ok {
+			continue
+		}
+		seen[sample.PID] = struct{}{}
+		top = append(top, sample)
+	}
+	slices.SortStableFunc(top, func(a, b processSample) int {
+		return cmp.Or(cmp.Compare(b.CPUPercent, a.CPUPercent), cmp.Compare(b.RSS, a.RSS))
+	})
+	return top
+}
+
+// truncateCmdline shortens cmdline to at most limit runes, marking the cut with an ellipsis.
+func truncateCmdline(cmdline string, limit int) string {
+	if utf8.RuneCountInString(cmdline) <= limit {
+		return cmdline
+	}
+	runes := []rune(cmdline)
+	return string(runes[:limit-1]) + "…"
+}
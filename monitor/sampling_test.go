@@ -2,8 +2,10 @@ package monitor
 
 import (
 	"testing"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/net"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -81,3 +83,93 @@ func TestIODeltasFromCounters_emptyPrev(t *testing.T) {
 	require.NoError(t, err)
 	assert.Nil(t, deltas)
 }
+
+func TestProcessCPUPercents(t *testing.T) {
+	t.Parallel()
+
+	prev := map[int32]float64{1: 10, 2: 5, 3: 50}
+	samples := []processSample{
+		{PID: 1, CPUTime: 12},  // 2s of CPU over 2s, one busy core
+		{PID: 2, CPUTime: 5.5}, // 0.5s over 2s
+		{PID: 3, CPUTime: 1},   // PID reused by a new process
+		{PID: 4, CPUTime: 3},   // not seen before
+	}
+
+	processCPUPercents(prev, 2*time.Second, samples)
+	assert.InDelta(t, 100.0, samples[0].CPUPercent, 0.01)
+	assert.InDelta(t, 25.0, samples[1].CPUPercent, 0.01)
+	assert.Zero(t, samples[2].CPUPercent)
+	assert.Zero(t, samples[3].CPUPercent)
+}
+
+func TestTopProcesses(t *testing.T) {
+	t.Parallel()
+
+	samples := []processSample{
+		{PID: 1, CPUPercent: 5, RSS: 900},
+		{PID: 2, CPUPercent: 90, RSS: 100},
+		{PID: 3, CPUPercent: 50, RSS: 50},
+		{PID: 4, CPUPercent: 1, RSS: 10},
+		{PID: 5, CPUPercent: 0, RSS: 800},
+	}
+
+	top := topProcesses(samples, 2)
+	pids := make([]int32, len(top))
+	for i, sample := range top {
+		pids[i] = sample.PID
+	}
+	assert.Equal(t, []int32{2, 3, 1, 5}, pids, "should keep the top 2 by CPU and top 2 by RSS, ordered by CPU")
+
+	top = topProcesses(samples, 10)
+	assert.Len(t, top, len(samples), "asking for more than exist should return each process once")
+	assert.Nil(t, topProcesses(samples, 0))
+}
+
+func TestTruncateCmdline(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "go test ./...", truncateCmdline("go test ./...", 20))
+	assert.Equal(t, "go te…", truncateCmdline("go test ./...", 6))
+	assert.Equal(t, "héllo", truncateCmdline("héllo", 5), "limit should count runes, not bytes")
+}
+
+func TestWholeDiskCounters(t *testing.T) {
+	t.Parallel()
+
+	stats := map[string]disk.IOCountersStat{}
+	names := []string{"sda", "sda1", "sda2", "sdaa", "nvme0n1", "nvme0n1p1", "nvme0n10", "loop0", "zram0", "vda"}
+	for _, name := range names {
+		stats[name] = disk.IOCountersStat{Name: name}
+	}
+
+	var whole []string
+	for name := range wholeDiskCounters(stats) {
+		whole = append(whole, name)
+	}
+	assert.ElementsMatch(t, []string{"sda", "sdaa", "nvme0n1", "nvme0n10", "vda"}, whole)
+}
+
+func TestDiskIODeltasFromCounters(t *testing.T) {
+	t.Parallel()
+
+	prev := map[string]disk.IOCountersStat{
+		"sda":  {Name: "sda", ReadBytes: 1000, WriteBytes: 2000, ReadCount: 10, WriteCount: 20},
+		"vda":  {Name: "vda", ReadBytes: 5000, WriteBytes: 5000, ReadCount: 50, WriteCount: 50},
+		"gone": {Name: "gone"},
+	}
+	curr := map[string]disk.IOCountersStat{
+		"vda": {Name: "vda", ReadBytes: 100, WriteBytes: 100, ReadCount: 1, WriteCount: 1}, // counters reset
+		"sda": {Name: "sda", ReadBytes: 1500, WriteBytes: 2600, ReadCount: 15, WriteCount: 26},
+		"new": {Name: "new", ReadBytes: 10},
+	}
+
+	deltas := diskIODeltasFromCounters(prev, curr)
+	require.Len(t, deltas, 1)
+	assert.Equal(t, "sda", deltas[0].Name)
+	assert.Equal(t, uint64(500), deltas[0].ReadBytes)
+	assert.Equal(t, uint64(600), deltas[0].WriteBytes)
+	assert.Equal(t, uint64(5), deltas[0].ReadCount)
+	assert.Equal(t, uint64(6), deltas[0].WriteCount)
+
+	assert.Nil(t, diskIODeltasFromCounters(nil, curr))
+}
@@ -0,0 +1,213 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kalverra/octometrics/internal/logging"
+)
+
+// StepMarker is a step boundary written by Mark. A step runs until the next marker or the end of monitoring.
+type StepMarker struct {
+	Time time.Time `json:"time"`
+	Name string    `json:"name"`
+}
+
+// StepWindow is a named span of the monitored job, from a step marker or the runner's own step timing.
+type StepWindow struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// StepUsage is the resource usage observed during one step.
+type StepUsage struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// PeakCPUPercent and AvgCPUPercent are averaged across all cores, like the CPU summary.
+	PeakCPUPercent float64 `json:"peak_cpu_percent"`
+	AvgCPUPercent  float64 `json:"avg_cpu_percent"`
+	PeakMemoryUsed uint64  `json:"peak_memory_used"`
+	BytesSent      uint64  `json:"bytes_sent"`
+	BytesRecv      uint64  `json:"bytes_recv"`
+	// Observations is how many CPU, memory or network observations fell inside the step.
+	Observations int `json:"observations"`
+}
+
+// Duration is how long the step ran.
+func (s *StepUsage) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Mark appends a step marker to a running monitor's output file, so Analyze can attribute usage to the step.
+// The output file is set with WithOutputFile, other options are ignored.
+func Mark(name string, options ...Option) (err error) {
+	if name == "" {
+		return errors.New("step name is required")
+	}
+	opts := defaultOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	// Start truncates the output file, so a marker written before it would be lost
+	if !fileExists(opts.OutputFile) {
+		return fmt.Errorf("monitor output file '%s' not found, is 'octometrics monitor' running?", opts.OutputFile)
+	}
+	file, err := os.OpenFile(filepath.Clean(opts.OutputFile), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open monitor output file '%s': %w", opts.OutputFile, err)
+	}
+
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close monitor output file '%s': %w", opts.OutputFile, closeErr)
+		}
+	}()
+
+	line, err := json.Marshal(struct {
+		Level   string `json:"level"`
+		Step    string `json:"step"`
+		Time    string `json:"time"`
+		Message string `json:"message"`
+	}{
+		Level:   zerolog.InfoLevel.String(),
+		Step:    name,
+		Time:    time.Now().Format(logging.TimeLayout),
+		Message: StepMarkerMsg,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode step marker: %w", err)
+	}
+	// O_APPEND keeps this single write from interleaving with the monitor's own lines
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write step marker to '%s': %w", opts.OutputFile, err)
+	}
+	return nil
+}
+
+// MarkerStepWindows turns step markers into windows, each ending at the next marker.
+// The last step ends at the last observation.
+func (a *Analysis) MarkerStepWindows() []*StepWindow {
+	if a == nil || len(a.StepMarkers) == 0 {
+		return nil
+	}
+	markers := slices.Clone(a.StepMarkers)
+	slices.SortStableFunc(markers, func(x, y *StepMarker) int {
+		return x.Time.Compare(y.Time)
+	})
+
+	end := a.lastObservation()
+	windows := make([]*StepWindow, 0, len(markers))
+	for i, marker := range markers {
+		window := &StepWindow{Name: marker.Name, Start: marker.Time, End: end}
+		if i+1 < len(markers) {
+			window.End = markers[i+1].Time
+		}
+		if window.End.Before(window.Start) {
+			window.End = window.Start
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// AttributeSteps splits CPU, memory and network observations into the given steps.
+// Observations are attributed to the step they were taken in, [Start, End).
+func (a *Analysis) AttributeSteps(windows []*StepWindow) []*StepUsage {
+	if a == nil || len(windows) == 0 {
+		return nil
+	}
+
+	cpuByTime := map[time.Time][]float64{}
+	for _, measurements := range a.CPUMeasurements {
+		for _, m := range measurements {
+			cpuByTime[m.Time] = append(cpuByTime[m.Time], m.UsedPercent)
+		}
+	}
+
+	usages := make([]*StepUsage, 0, len(windows))
+	for _, window := range windows {
+		usage := &StepUsage{Name: window.Name, Start: window.Start, End: window.End}
+		var (
+			cpuSum     float64
+			cpuSamples int
+		)
+		for t, percents := range cpuByTime {
+			if !inWindow(window, t) {
+				continue
+			}
+			var sum float64
+			for _, p := range percents {
+				sum += p
+			}
+			avg := sum / float64(len(percents))
+			usage.PeakCPUPercent = max(usage.PeakCPUPercent, avg)
+			cpuSum += avg
+			cpuSamples++
+		}
+		if cpuSamples > 0 {
+			usage.AvgCPUPercent = cpuSum / float64(cpuSamples)
+		}
+
+		var memSamples int
+		for _, m := range a.MemoryMeasurements {
+			if inWindow(window, m.Time) {
+				usage.PeakMemoryUsed = max(usage.PeakMemoryUsed, m.Used)
+				memSamples++
+			}
+		}
+
+		var ioSamples int
+		for _, m := range a.IOMeasurements {
+			if inWindow(window, m.Time) {
+				usage.BytesSent += m.BytesSent
+				usage.BytesRecv += m.BytesRecv
+				ioSamples++
+			}
+		}
+		usage.Observations = max(cpuSamples, memSamples, ioSamples)
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+func inWindow(window *StepWindow, t time.Time) bool {
+	return !t.Before(window.Start) && t.Before(window.End)
+}
+
+// lastObservation is the time of the latest CPU, memory or network observation.
+func (a *Analysis) lastObservation() time.Time {
+	var last time.Time
+	for _, measurements := range a.CPUMeasurements {
+		for _, m := range measurements {
+			last = latest(last, m.Time)
+		}
+	}
+	for _, m := range a.MemoryMeasurements {
+		last = latest(last, m.Time)
+	}
+	for _, m := range a.IOMeasurements {
+		last = latest(last, m.Time)
+	}
+	// The last step includes the final observation
+	if !last.IsZero() {
+		last = last.Add(time.Nanosecond)
+	}
+	return last
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func stepsAnalysis() *Analysis {
+	base := time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC)
+	return &Analysis{
+		CPUMeasurements: map[int][]*CPUMeasurement{
+			0: {
+				{Time: base, Num: 0, UsedPercent: 10},
+				{Time: base.Add(time.Second), Num: 0, UsedPercent: 90},
+				{Time: base.Add(2 * time.Second), Num: 0, UsedPercent: 50},
+			},
+			1: {
+				{Time: base, Num: 1, UsedPercent: 30},
+				{Time: base.Add(time.Second), Num: 1, UsedPercent: 70},
+				{Time: base.Add(2 * time.Second), Num: 1, UsedPercent: 50},
+			},
+		},
+		MemoryMeasurements: []*MemoryMeasurement{
+			{Time: base, Used: 100},
+			{Time: base.Add(time.Second), Used: 300},
+			{Time: base.Add(2 * time.Second), Used: 200},
+		},
+		IOMeasurements: []*IOMeasurement{
+			{Time: base, BytesSent: 1, BytesRecv: 2},
+			{Time: base.Add(time.Second), BytesSent: 10, BytesRecv: 20},
+			{Time: base.Add(2 * time.Second), BytesSent: 100, BytesRecv: 200},
+		},
+		StepMarkers: []*StepMarker{
+			{Time: base.Add(time.Second), Name: "Run tests"},
+			{Time: base, Name: "Build"},
+		},
+	}
+}
+
+func TestMarkerStepWindows(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC)
+	windows := stepsAnalysis().MarkerStepWindows()
+	require.Len(t, windows, 2)
+	assert.Equal(t, &StepWindow{Name: "Build", Start: base, End: base.Add(time.Second)}, windows[0],
+		"markers should be sorted and end at the next marker")
+	assert.Equal(t, "Run tests", windows[1].Name)
+	assert.True(t, windows[1].End.After(base.Add(2*time.Second)), "last step should include the final observation")
+
+	assert.Nil(t, (&Analysis{}).MarkerStepWindows())
+}
+
+func TestAttributeSteps(t *testing.T) {
+	t.Parallel()
+
+	analysis := stepsAnalysis()
+	usages := analysis.AttributeSteps(analysis.MarkerStepWindows())
+	require.Len(t, usages, 2)
+
+	build := usages[0]
+	assert.Equal(t, "Build", build.Name)
+	assert.Equal(t, time.Second, build.Duration())
+	assert.InDelta(t, 20.0, build.PeakCPUPercent, 0.001)
+	assert.InDelta(t, 20.0, build.AvgCPUPercent, 0.001)
+	assert.Equal(t, uint64(100), build.PeakMemoryUsed)
+	assert.Equal(t, uint64(1), build.BytesSent)
+	assert.Equal(t, uint64(2), build.BytesRecv)
+	assert.Equal(t, 1, build.Observations)
+
+	tests := usages[1]
+	assert.Equal(t, "Run tests", tests.Name)
+	assert.InDelta(t, 80.0, tests.PeakCPUPercent, 0.001)
+	assert.InDelta(t, 65.0, tests.AvgCPUPercent, 0.001)
+	assert.Equal(t, uint64(300), tests.PeakMemoryUsed)
+	assert.Equal(t, uint64(110), tests.BytesSent)
+	assert.Equal(t, uint64(220), tests.BytesRecv)
+	assert.Equal(t, 2, tests.Observations)
+
+	assert.Nil(t, analysis.AttributeSteps(nil))
+}
+
+func TestMark(t *testing.T) {
+	t.Parallel()
+
+	log, testDir := testhelpers.Setup(t)
+	outputFile := filepath.Join(testDir, "octometrics.monitor.jsonl")
+
+	err := Mark("Build", WithOutputFile(outputFile))
+	require.Error(t, err, "marking without a running monitor should fail")
+	require.NoFileExists(t, outputFile, "marking should not create the output file")
+	require.Error(t, Mark("", WithOutputFile(outputFile)), "step name should be required")
+
+	lines := `{"level":"debug","num":0,"used_percent":50,"time":"2026-03-10T16:10:23.587","message":"Observed CPU Usage"}
+`
+	require.NoError(t, os.WriteFile(outputFile, []byte(lines), 0o600))
+	require.NoError(t, Mark("Build", WithOutputFile(outputFile)))
+	require.NoError(t, Mark("Run tests", WithOutputFile(outputFile)))
+
+	analysis, err := Analyze(log, outputFile)
+	require.NoError(t, err, "error analyzing marked monitor log")
+	require.Len(t, analysis.StepMarkers, 2)
+	assert.Equal(t, "Build", analysis.StepMarkers[0].Name)
+	assert.Equal(t, "Run tests", analysis.StepMarkers[1].Name)
+	assert.False(t, analysis.StepMarkers[0].Time.IsZero())
+	require.Len(t, analysis.Steps, 2)
+}
@@ -50,9 +50,8 @@ func TestGenerateAllObserveData_IgnoresNonJSONAndSkipsExisting(t *testing.T) {
 		log,
 		nil,
 		[]string{"html"},
-		dataDir,
+		gather.NewFileStore(dataDir),
 		WithCustomOutputDir(outputDir),
-		WithGatherOptions(gather.CustomDataFolder(dataDir)),
 	)
 	require.NoError(t, err, "generateAllObserveData should ignore non-JSON files without error")
 
@@ -73,9 +72,8 @@ func TestGenerateAllObserveData_IgnoresNonJSONAndSkipsExisting(t *testing.T) {
 		log,
 		nil,
 		[]string{"html"},
-		dataDir,
+		gather.NewFileStore(dataDir),
 		WithCustomOutputDir(outputDir),
-		WithGatherOptions(gather.CustomDataFolder(dataDir)),
 	)
 	require.NoError(t, err)
 
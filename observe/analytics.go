@@ -84,7 +84,8 @@ func CalculateCriticalPath(jobs []*gather.JobData, def *gather.WorkflowDef) *Cri
 		return nil
 	}
 
-	needsMap := buildNeedsMap(def)
+	// Runtime jobs of reusable workflows are named "caller / callee", like the jobs of the expanded workflow
+	needsMap := buildNeedsMap(def.Expanded())
 	nodes, criticalNodes, nearCriticalNodes := analyzeJobNodes(jobs, needsMap, runEnd)
 	if len(nodes) == 0 {
 		return nil
@@ -165,20 +166,26 @@ func buildNeedsMap(def *gather.WorkflowDef) map[string][]string {
 		if nameTrim == "" {
 			nameTrim = keyTrim
 		}
-		rawNeeds := make([]string, len(jobDef.Needs))
-		for i, n := range jobDef.Needs {
-			rawNeeds[i] = strings.TrimSpace(n)
-		}
-
-		needsMap[nameTrim] = rawNeeds
-		needsMap[keyTrim] = rawNeeds
-
-		for i, needKey := range jobDef.Needs {
+		// Needs are keyed by job name, and a matrix job is only done once all of its legs are
+		needs := make([]string, 0, len(jobDef.Needs))
+		for _, needKey := range jobDef.Needs {
 			needKeyTrim := strings.TrimSpace(needKey)
-			if targetDef, ok := def.Jobs[needKeyTrim]; ok && targetDef.Name != "" {
-				needsMap[nameTrim][i] = strings.TrimSpace(targetDef.Name)
+			targetDef, ok := def.Jobs[needKeyTrim]
+			switch {
+			case ok && len(targetDef.MatrixLegNames()) > 0:
+				needs = append(needs, targetDef.MatrixLegNames()...)
+			case ok && targetDef.Name != "":
+				needs = append(needs, strings.TrimSpace(targetDef.Name))
+			default:
+				needs = append(needs, needKeyTrim)
 			}
 		}
+
+		needsMap[nameTrim] = needs
+		needsMap[keyTrim] = needs
+		for _, leg := range jobDef.MatrixLegNames() {
+			needsMap[leg] = needs
+		}
 	}
 	return needsMap
 }
@@ -235,6 +242,10 @@ func jobDur(j *gather.JobData) time.Duration {
 
 func resolveExplicitNeeds(jNameTrim string, needsMap map[string][]string, jobMap map[string]*gather.JobData) string {
 	needs, ok := needsMap[jNameTrim]
+	if base, isLeg := matrixJobName(jNameTrim); !ok && isLeg {
+		// A leg of a matrix only known at runtime shares the needs of its job
+		needs, ok = needsMap[base]
+	}
 	if !ok {
 		for name, nList := range needsMap {
 			nameTrim := strings.TrimSpace(name)
@@ -253,9 +264,10 @@ func resolveExplicitNeeds(jNameTrim string, needsMap map[string][]string, jobMap
 	var latestDepEnd time.Time
 	var bestDepJob *gather.JobData
 	for _, needName := range needs {
-		needNameTrim := strings.TrimSpace(needName)
-		depJob, foundName := findJobInMap(needNameTrim, jobMap)
-		if depJob != nil && !depJob.GetCompletedAt().IsZero() {
+		for foundName, depJob := range neededJobs(strings.TrimSpace(needName), jobMap) {
+			if depJob == nil || depJob.GetCompletedAt().IsZero() {
+				continue
+			}
 			depEnd := depJob.GetCompletedAt().Time
 			if latestDepEnd.IsZero() || depEnd.After(latestDepEnd) ||
 				(depEnd.Equal(latestDepEnd) && bestDepJob != nil && jobDur(depJob) > jobDur(bestDepJob)) ||
@@ -269,6 +281,34 @@ func resolveExplicitNeeds(jNameTrim string, needsMap map[string][]string, jobMap
 	return blockingNeed
 }
 
+// neededJobs finds the runtime jobs of a need. A need on a matrix job whose legs are only known at runtime
+// is every leg of it, like "e2e (1)" and "e2e (2)" for "e2e".
+func neededJobs(needName string, jobMap map[string]*gather.JobData) map[string]*gather.JobData {
+	if depJob, exists := jobMap[needName]; exists {
+		return map[string]*gather.JobData{needName: depJob}
+	}
+	legs := make(map[string]*gather.JobData)
+	for name, depJob := range jobMap {
+		if base, isLeg := matrixJobName(strings.TrimSpace(name)); isLeg && base == needName {
+			legs[name] = depJob
+		}
+	}
+	if len(legs) > 0 {
+		return legs
+	}
+	depJob, foundName := findJobInMap(needName, jobMap)
+	return map[string]*gather.JobData{foundName: depJob}
+}
+
+// matrixJobName strips the matrix values from the name of a matrix leg like "test (ubuntu-latest, 1.22)".
+func matrixJobName(name string) (string, bool) {
+	i := strings.LastIndex(name, " (")
+	if i <= 0 || !strings.HasSuffix(name, ")") {
+		return name, false
+	}
+	return name[:i], true
+}
+
 func resolveFallbackNeeds(jNameTrim string, start time.Time, jobMap map[string]*gather.JobData) string {
 	var blockingNeed string
 	var latestDepEnd time.Time
@@ -374,6 +374,112 @@ func TestCalculateCriticalPath_MatrixAndNeedsMapping(t *testing.T) {
 	assert.GreaterOrEqual(t, len(cp.CriticalNodes), 5, "critical path should trace full chain, not just 1-2 nodes")
 }
 
+func TestCalculateCriticalPath_ReusableWorkflow(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 8, 11, 20, 0, 0, 0, time.UTC)
+
+	job := func(id int64, name string, start, end time.Duration) *gather.JobData {
+		return &gather.JobData{
+			WorkflowJob: &github.WorkflowJob{
+				ID:          new(id),
+				Name:        new(name),
+				Status:      new("completed"),
+				Conclusion:  new("success"),
+				StartedAt:   &github.Timestamp{Time: now.Add(start)},
+				CompletedAt: &github.Timestamp{Time: now.Add(end)},
+			},
+		}
+	}
+	jobs := []*gather.JobData{
+		job(1, "build", 0, time.Minute),
+		job(2, "E2E / setup", time.Minute, 2*time.Minute),
+		job(3, "E2E / smoke", 2*time.Minute, 10*time.Minute),
+		job(4, "E2E / soak", 2*time.Minute, 4*time.Minute),
+		job(5, "deploy", 10*time.Minute, 11*time.Minute),
+	}
+	def := &gather.WorkflowDef{
+		Jobs: map[string]gather.JobDef{
+			"build": {Name: "build"},
+			"e2e": {
+				Name:  "E2E",
+				Needs: gather.Needs{"build"},
+				Uses:  "./.github/workflows/e2e.yml",
+				Calls: &gather.WorkflowDef{
+					Jobs: map[string]gather.JobDef{
+						"setup": {Name: "setup"},
+						"smoke": {Name: "smoke", Needs: gather.Needs{"setup"}},
+						"soak":  {Name: "soak", Needs: gather.Needs{"setup"}},
+					},
+				},
+			},
+			"deploy": {Name: "deploy", Needs: gather.Needs{"e2e"}},
+		},
+	}
+
+	cp := CalculateCriticalPath(jobs, def)
+	require.NotNil(t, cp)
+	names := make([]string, 0, len(cp.CriticalNodes))
+	for _, n := range cp.CriticalNodes {
+		names = append(names, n.JobName)
+	}
+	assert.Equal(t, []string{"build", "E2E / setup", "E2E / smoke", "deploy"}, names)
+	assert.Equal(t, "E2E / smoke", cp.CriticalNodes[3].BlockingNeed,
+		"needs on the calling job should resolve to the called jobs")
+}
+
+func TestCalculateCriticalPath_MatrixLegs(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 8, 11, 20, 0, 0, 0, time.UTC)
+
+	job := func(id int64, name string, start, end time.Duration) *gather.JobData {
+		return &gather.JobData{
+			WorkflowJob: &github.WorkflowJob{
+				ID:          new(id),
+				Name:        new(name),
+				Status:      new("completed"),
+				Conclusion:  new("success"),
+				StartedAt:   &github.Timestamp{Time: now.Add(start)},
+				CompletedAt: &github.Timestamp{Time: now.Add(end)},
+			},
+		}
+	}
+	jobs := []*gather.JobData{
+		job(1, "build", 0, time.Minute),
+		job(2, "test (ubuntu)", time.Minute, 3*time.Minute),
+		job(3, "test (windows)", time.Minute, 6*time.Minute),
+		job(4, "shards (1)", 6*time.Minute, 8*time.Minute),
+		job(5, "shards (2)", 6*time.Minute, 12*time.Minute),
+		job(6, "deploy", 12*time.Minute, 13*time.Minute),
+	}
+	def := &gather.WorkflowDef{
+		Jobs: map[string]gather.JobDef{
+			"build": {Name: "build"},
+			"test": {
+				Name:  "test",
+				Needs: gather.Needs{"build"},
+				Strategy: &gather.Strategy{Matrix: &gather.Matrix{
+					Dimensions: []gather.MatrixDimension{{Name: "os", Values: []any{"ubuntu", "windows"}}},
+				}},
+			},
+			"shards": {
+				Name:     "shards",
+				Needs:    gather.Needs{"test"},
+				Strategy: &gather.Strategy{Matrix: &gather.Matrix{Expression: "${{ fromJSON(needs.test.outputs) }}"}},
+			},
+			"deploy": {Name: "deploy", Needs: gather.Needs{"shards"}},
+		},
+	}
+
+	cp := CalculateCriticalPath(jobs, def)
+	require.NotNil(t, cp)
+	names := make([]string, 0, len(cp.CriticalNodes))
+	for _, n := range cp.CriticalNodes {
+		names = append(names, n.JobName)
+	}
+	assert.Equal(t, []string{"build", "test (windows)", "shards (2)", "deploy"}, names,
+		"needs on a matrix job should wait on its slowest leg")
+}
+
 func TestCalculateCriticalPath_DeterministicTieBreak(t *testing.T) {
 	t.Parallel()
 	now := time.Date(2026, 8, 11, 20, 0, 0, 0, time.UTC)
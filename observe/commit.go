@@ -154,7 +154,7 @@ func buildCommitTimelineData(
 				if startTime.After(workflowRun.CorrespondingPRCloseTime) {
 					postTimelineItems = append(postTimelineItems, PostTimelineItem{
 						Name: workflowRun.GetName(),
-						Link: workflowRun.GetHTMLURL(),
+						Link: gitHubRunLink(workflowRun),
 						Time: startTime,
 					})
 					continue
@@ -3,6 +3,8 @@ package observe
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -19,7 +21,8 @@ import (
 
 const comparisonsOutputDir = "comparisons"
 
-// Comparison holds two observations of the same type and their computed diff.
+// Comparison holds two observations of the same type and their computed diff,
+// or more than two lined up against a baseline.
 type Comparison struct {
 	Left        *Observation
 	Right       *Observation
@@ -28,12 +31,24 @@ type Comparison struct {
 	CompareType string // "workflow_run" or "commit"
 	Summary     ComparisonSummary
 
+	// Observations holds every observation of a comparison of more than two, baseline first.
+	// Left is the baseline and Right the first observation compared to it.
+	Observations []*Observation
+	// Columns summarize each of Observations against the baseline.
+	Columns []ComparisonColumn
+	// Matrix lines up each event's items across Observations.
+	Matrix []EventMatrix
+
 	// EventPairs groups left/right timelines by event for side-by-side rendering.
 	// Populated during Render() after process() normalizes the timelines.
 	EventPairs []EventPair
 
 	// MonitoringPairs pairs corresponding charts by title from Left and Right observations.
 	MonitoringPairs []MonitoringPair
+
+	// WorkflowDrifts are how the workflow files changed between the compared workflow runs, or the runs of the
+	// compared commits, with each change listed once. Empty when none did.
+	WorkflowDrifts []*WorkflowDrift
 }
 
 // MonitoringPair holds related charts from the left and right observations.
@@ -105,7 +120,9 @@ type ComparisonItem struct {
 	LeftDuration    time.Duration
 	RightDuration   time.Duration
 	DurationDelta   time.Duration // Right - Left; positive means right is slower
-	LeftConclusion  string        // Gantt status: "", "crit", "done", "active"
+	LeftCost        int64
+	RightCost       int64
+	LeftConclusion  string // Gantt status: "", "crit", "done", "active"
 	RightConclusion string
 	StatusChanged   bool
 	LeftRunner      string
@@ -121,6 +138,53 @@ type ComparisonOnlyItem struct {
 	Runner     string
 }
 
+// ComparisonColumn is one observation of a multi-way comparison, measured against the baseline.
+type ComparisonColumn struct {
+	ID         string
+	Label      string
+	Name       string
+	GitHubLink string
+	State      string
+	StartedAt  time.Time
+	Baseline   bool
+	Total      MatrixCell
+}
+
+// EventMatrix lines up one event's items across every observation of a multi-way comparison.
+type EventMatrix struct {
+	Event     string
+	IsTypical bool
+	// Totals is the wall-clock duration and cost of the event in each observation.
+	Totals []MatrixCell
+	Rows   []MatrixRow
+	// StackedGantt has one section per observation, aligned to the same start time.
+	StackedGantt *CompareGanttData
+}
+
+// MatrixRow is one item matched by name across every observation, in the same order as the columns.
+type MatrixRow struct {
+	Name  string
+	Cells []MatrixCell
+	// Spread is the difference between the slowest and fastest observation of the item.
+	Spread time.Duration
+}
+
+// MatrixCell is an item or event total in one observation, with its deltas against the baseline.
+// DurationDelta and CostDelta are only meaningful when both this cell and the baseline's are Present.
+type MatrixCell struct {
+	Present              bool
+	ID                   string
+	Link                 string
+	Duration             time.Duration
+	DurationDelta        time.Duration
+	DurationDeltaPercent string
+	Cost                 int64
+	CostDelta            int64
+	Conclusion           string
+	StatusChanged        bool
+	Runner               string
+}
+
 // ComparisonSummary holds aggregate comparison metrics.
 type ComparisonSummary struct {
 	LeftDuration   time.Duration
@@ -132,7 +196,8 @@ type ComparisonSummary struct {
 	RightStartedAt time.Time
 }
 
-// CompareWorkflowRuns builds a comparison between two workflow runs.
+// CompareWorkflowRuns builds a comparison between two workflow runs, including how their workflow file changed
+// between the commits they were started from.
 func CompareWorkflowRuns(
 	ctx context.Context,
 	log zerolog.Logger,
@@ -148,15 +213,28 @@ func CompareWorkflowRuns(
 	reporter := options.getReporter()
 	reporter.Start(fmt.Sprintf("Building comparison (workflow run %d vs %d)", leftID, rightID))
 
-	left, err := WorkflowRun(ctx, log, client, owner, repo, leftID, opts...)
+	leftRun, _, err := gather.WorkflowRun(ctx, log, client, owner, repo, leftID, options.gatherOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build left observation (run %d): %w", leftID, err)
 	}
-	right, err := WorkflowRun(ctx, log, client, owner, repo, rightID, opts...)
+	left, err := workflowRunObservation(leftRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build left observation (run %d): %w", leftID, err)
+	}
+	rightRun, _, err := gather.WorkflowRun(ctx, log, client, owner, repo, rightID, options.gatherOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build right observation (run %d): %w", rightID, err)
+	}
+	right, err := workflowRunObservation(rightRun)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build right observation (run %d): %w", rightID, err)
 	}
-	return buildComparison(left, right, owner, repo, "workflow_run"), nil
+
+	comparison := buildComparison(left, right, owner, repo, "workflow_run")
+	comparison.WorkflowDrifts = workflowRunPairDrifts(
+		ctx, log, client, [][2]*gather.WorkflowRunData{{leftRun, rightRun}}, options,
+	)
+	return comparison, nil
 }
 
 // CompareCommits builds a comparison between two commits.
@@ -191,7 +269,95 @@ func CompareCommits(
 	if err != nil {
 		return nil, fmt.Errorf("failed to build right observation (commit %s): %w", rightSHA, err)
 	}
-	return buildComparison(left, right, owner, repo, "commit"), nil
+	comparison := buildComparison(left, right, owner, repo, "commit")
+	comparison.WorkflowDrifts = commitWorkflowDrifts(ctx, log, client, comparison, options)
+	return comparison, nil
+}
+
+// CompareManyWorkflowRuns lines up workflow runs against a baseline run, e.g. to bisect a CI slowdown.
+// With a single other run it's the same as CompareWorkflowRuns.
+func CompareManyWorkflowRuns(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	owner, repo string,
+	baselineID int64,
+	otherIDs []int64,
+	opts ...Option,
+) (*Comparison, error) {
+	if len(otherIDs) == 0 {
+		return nil, errors.New("at least one workflow run is needed to compare against the baseline")
+	}
+	if len(otherIDs) == 1 {
+		return CompareWorkflowRuns(ctx, log, client, owner, repo, baselineID, otherIDs[0], opts...)
+	}
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	options.getReporter().Start(
+		fmt.Sprintf("Building comparison (workflow run %d vs %d others)", baselineID, len(otherIDs)),
+	)
+
+	var (
+		observations = make([]*Observation, 0, len(otherIDs)+1)
+		runs         = make([]*gather.WorkflowRunData, 0, len(otherIDs)+1)
+	)
+	for _, id := range append([]int64{baselineID}, otherIDs...) {
+		run, _, err := gather.WorkflowRun(ctx, log, client, owner, repo, id, options.gatherOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build observation (run %d): %w", id, err)
+		}
+		obs, err := workflowRunObservation(run)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build observation (run %d): %w", id, err)
+		}
+		observations = append(observations, obs)
+		runs = append(runs, run)
+	}
+	comparison := buildMultiComparison(observations, owner, repo, "workflow_run")
+	pairs := make([][2]*gather.WorkflowRunData, 0, len(otherIDs))
+	for _, run := range runs[1:] {
+		pairs = append(pairs, [2]*gather.WorkflowRunData{runs[0], run})
+	}
+	comparison.WorkflowDrifts = workflowRunPairDrifts(ctx, log, client, pairs, options)
+	return comparison, nil
+}
+
+// CompareManyCommits lines up commits against a baseline commit, e.g. to bisect a CI slowdown.
+// With a single other commit it's the same as CompareCommits.
+func CompareManyCommits(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	owner, repo string,
+	baselineSHA string,
+	otherSHAs []string,
+	opts ...Option,
+) (*Comparison, error) {
+	if len(otherSHAs) == 0 {
+		return nil, errors.New("at least one commit is needed to compare against the baseline")
+	}
+	if len(otherSHAs) == 1 {
+		return CompareCommits(ctx, log, client, owner, repo, baselineSHA, otherSHAs[0], opts...)
+	}
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	options.getReporter().Start(
+		fmt.Sprintf("Building comparison (commit %s vs %d others)", shortSHA(baselineSHA), len(otherSHAs)),
+	)
+
+	observations := make([]*Observation, 0, len(otherSHAs)+1)
+	for _, sha := range append([]string{baselineSHA}, otherSHAs...) {
+		obs, err := Commit(ctx, log, client, owner, repo, sha, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build observation (commit %s): %w", sha, err)
+		}
+		observations = append(observations, obs)
+	}
+	return buildMultiComparison(observations, owner, repo, "commit"), nil
 }
 
 // CompareJobRuns builds a comparison between two job runs.
@@ -306,25 +472,121 @@ func buildComparison(left, right *Observation, owner, repo, compareType string)
 	return c
 }
 
-// matchItems computes matched, only-left, and only-right items for two sets of timeline items.
-func matchItems(leftItems, rightItems []TimelineItem) ([]ComparisonItem, []ComparisonOnlyItem, []ComparisonOnlyItem) {
-	leftCounts := make(map[string]int)
-	leftByKey := make(map[itemMatchKey]TimelineItem, len(leftItems))
-	for _, item := range leftItems {
-		name := normalizeCompareName(item.Name)
-		key := itemMatchKey{name: name, index: leftCounts[name]}
-		leftCounts[name]++
-		leftByKey[key] = item
+// buildMultiComparison lines up more than two observations against the first one, the baseline.
+// Like buildComparison, it must be called before process() is invoked on any observation's timelines.
+func buildMultiComparison(observations []*Observation, owner, repo, compareType string) *Comparison {
+	baseline := observations[0]
+	c := &Comparison{
+		Left:         baseline,
+		Right:        observations[1],
+		Owner:        owner,
+		Repo:         repo,
+		CompareType:  compareType,
+		Observations: observations,
 	}
 
-	rightCounts := make(map[string]int)
-	rightByKey := make(map[itemMatchKey]TimelineItem, len(rightItems))
-	for _, item := range rightItems {
-		name := normalizeCompareName(item.Name)
-		key := itemMatchKey{name: name, index: rightCounts[name]}
-		rightCounts[name]++
-		rightByKey[key] = item
+	baselineDur := wallClockDuration(baseline.TimelineData)
+	for i, obs := range observations {
+		duration := wallClockDuration(obs.TimelineData)
+		label := "#" + obs.ID
+		if obs.DataType == "commit" {
+			label = shortSHA(obs.ID)
+		}
+		c.Columns = append(c.Columns, ComparisonColumn{
+			ID:         obs.ID,
+			Label:      label,
+			Name:       obs.Name,
+			GitHubLink: obs.GitHubLink,
+			State:      obs.State,
+			StartedAt:  earliestStartTime(obs.TimelineData),
+			Baseline:   i == 0,
+			Total: MatrixCell{
+				Present:              true,
+				ID:                   obs.ID,
+				Duration:             duration,
+				DurationDelta:        duration - baselineDur,
+				DurationDeltaPercent: formatPercentDelta(int64(duration-baselineDur), int64(baselineDur)),
+				Cost:                 obs.Cost,
+				CostDelta:            obs.Cost - baseline.Cost,
+			},
+		})
+	}
+	c.Summary = ComparisonSummary{
+		LeftDuration:   baselineDur,
+		RightDuration:  c.Columns[1].Total.Duration,
+		DurationDelta:  c.Columns[1].Total.DurationDelta,
+		LeftCost:       baseline.Cost,
+		RightCost:      observations[1].Cost,
+		LeftStartedAt:  c.Columns[0].StartedAt,
+		RightStartedAt: c.Columns[1].StartedAt,
+	}
+
+	// Events in the order they first appear, typical events first
+	var events []string
+	byEvent := map[string][]*Timeline{}
+	for i, obs := range observations {
+		for _, td := range obs.TimelineData {
+			if _, ok := byEvent[td.Event]; !ok {
+				events = append(events, td.Event)
+				byEvent[td.Event] = make([]*Timeline, len(observations))
+			}
+			byEvent[td.Event][i] = td
+		}
+	}
+	for _, event := range events {
+		c.Matrix = append(c.Matrix, buildEventMatrix(event, byEvent[event], c.Columns))
 	}
+	sort.SliceStable(c.Matrix, func(i, j int) bool {
+		return c.Matrix[i].IsTypical && !c.Matrix[j].IsTypical
+	})
+	return c
+}
+
+// buildEventMatrix lines up one event's timelines, one per column. Observations without the event have a nil timeline.
+func buildEventMatrix(event string, timelines []*Timeline, columns []ComparisonColumn) EventMatrix {
+	matrix := EventMatrix{Event: event, IsTypical: typicalEvents[event]}
+
+	itemSets := make([][]TimelineItem, len(timelines))
+	sides := make([]ganttSide, 0, len(timelines))
+	for i, td := range timelines {
+		total := MatrixCell{}
+		if td != nil {
+			itemSets[i] = td.Items
+			total = MatrixCell{
+				Present:  true,
+				Duration: wallClockDuration([]*Timeline{td}),
+				Cost:     sumItemCosts(td.Items),
+			}
+			sides = append(sides, ganttSide{label: columns[i].Label, timeline: td, idPrefix: fmt.Sprintf("c%d-", i)})
+		}
+		matrix.Totals = append(matrix.Totals, total)
+	}
+	baseline := matrix.Totals[0]
+	for i := range matrix.Totals {
+		setCellDeltas(&matrix.Totals[i], baseline)
+	}
+
+	matrix.Rows = matchItemsAcross(itemSets)
+	matrix.StackedGantt = buildStackedGantt(sides)
+	return matrix
+}
+
+// setCellDeltas fills in cell's deltas against the baseline's cell, when both are present.
+func setCellDeltas(cell *MatrixCell, baseline MatrixCell) {
+	if !cell.Present || !baseline.Present {
+		cell.DurationDeltaPercent = "N/A"
+		return
+	}
+	cell.DurationDelta = cell.Duration - baseline.Duration
+	cell.DurationDeltaPercent = formatPercentDelta(int64(cell.DurationDelta), int64(baseline.Duration))
+	cell.CostDelta = cell.Cost - baseline.Cost
+	cell.StatusChanged = cell.Conclusion != baseline.Conclusion
+}
+
+// matchItems computes matched, only-left, and only-right items for two sets of timeline items.
+func matchItems(leftItems, rightItems []TimelineItem) ([]ComparisonItem, []ComparisonOnlyItem, []ComparisonOnlyItem) {
+	leftByKey, _ := keyItems(leftItems)
+	rightByKey, _ := keyItems(rightItems)
 
 	var matched []ComparisonItem
 	seen := make(map[itemMatchKey]bool)
@@ -338,6 +600,8 @@ func matchItems(leftItems, rightItems []TimelineItem) ([]ComparisonItem, []Compa
 				LeftDuration:    li.Duration,
 				RightDuration:   ri.Duration,
 				DurationDelta:   delta,
+				LeftCost:        li.Cost,
+				RightCost:       ri.Cost,
 				LeftConclusion:  li.Conclusion,
 				RightConclusion: ri.Conclusion,
 				StatusChanged:   li.Conclusion != ri.Conclusion,
@@ -386,6 +650,76 @@ type itemMatchKey struct {
 	index int
 }
 
+// keyItems keys items by their normalized name and how many items before them had the same name,
+// so items with repeated names are matched in order. keys lists the keys in the order of items.
+func keyItems(items []TimelineItem) (byKey map[itemMatchKey]TimelineItem, keys []itemMatchKey) {
+	counts := make(map[string]int)
+	byKey = make(map[itemMatchKey]TimelineItem, len(items))
+	for _, item := range items {
+		name := normalizeCompareName(item.Name)
+		key := itemMatchKey{name: name, index: counts[name]}
+		counts[name]++
+		byKey[key] = item
+		keys = append(keys, key)
+	}
+	return byKey, keys
+}
+
+// matchItemsAcross matches items by name across any number of item sets, the first being the baseline.
+// Each row has a cell per set, items missing from a set have an empty cell.
+// Rows are sorted by how much the item's duration varies across sets, largest first.
+func matchItemsAcross(itemSets [][]TimelineItem) []MatrixRow {
+	byKeys := make([]map[itemMatchKey]TimelineItem, len(itemSets))
+	var order []itemMatchKey
+	seen := make(map[itemMatchKey]bool)
+	for i, items := range itemSets {
+		var keys []itemMatchKey
+		byKeys[i], keys = keyItems(items)
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				order = append(order, key)
+			}
+		}
+	}
+
+	rows := make([]MatrixRow, 0, len(order))
+	for _, key := range order {
+		row := MatrixRow{Name: key.name, Cells: make([]MatrixCell, len(itemSets))}
+		var fastest, slowest time.Duration
+		present := 0
+		for i, byKey := range byKeys {
+			item, ok := byKey[key]
+			if !ok {
+				continue
+			}
+			row.Cells[i] = MatrixCell{
+				Present:    true,
+				ID:         item.ID,
+				Link:       item.Link,
+				Duration:   item.Duration,
+				Cost:       item.Cost,
+				Conclusion: item.Conclusion,
+				Runner:     item.Runner,
+			}
+			if present == 0 || item.Duration < fastest {
+				fastest = item.Duration
+			}
+			if present == 0 || item.Duration > slowest {
+				slowest = item.Duration
+			}
+			present++
+		}
+		for i := range row.Cells {
+			setCellDeltas(&row.Cells[i], row.Cells[0])
+		}
+		row.Spread = slowest - fastest
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Spread > rows[j].Spread })
+	return rows
+}
+
 func normalizeCompareName(name string) string {
 	for _, suffix := range []string{" (in progress)", " (cancelled)"} {
 		name = strings.TrimSuffix(name, suffix)
@@ -461,25 +795,40 @@ func absDur(d time.Duration) time.Duration {
 // compareGanttEpoch is a fixed calendar anchor so both sides share one Mermaid time axis.
 var compareGanttEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
 
+// ganttSide is one section of a comparison Gantt chart.
+type ganttSide struct {
+	label    string
+	timeline *Timeline
+	idPrefix string
+}
+
 // buildCompareGantt builds a single Gantt chart with one section per non-empty side.
 // Each side is shifted so its Timeline start aligns with compareGanttEpoch, making
 // parallel structure and relative timing easy to compare across runs.
 func buildCompareGantt(left, right *Timeline) *CompareGanttData {
+	return buildStackedGantt([]ganttSide{
+		{label: "Before", timeline: left, idPrefix: "cl-"},
+		{label: "After", timeline: right, idPrefix: "cr-"},
+	})
+}
+
+// buildStackedGantt builds a single Gantt chart with one section per non-empty side, see buildCompareGantt.
+func buildStackedGantt(sides []ganttSide) *CompareGanttData {
 	var sections []CompareGanttSection
 	maxEnd := compareGanttEpoch
 
-	appendSide := func(label string, side *Timeline, idPrefix string) {
-		if side == nil || len(side.Items) == 0 {
-			return
+	for _, side := range sides {
+		if side.timeline == nil || len(side.timeline.Items) == 0 {
+			continue
 		}
-		origin := side.StartTime
-		tasks := make([]CompareGanttTask, 0, len(side.Items))
-		for _, it := range side.Items {
+		origin := side.timeline.StartTime
+		tasks := make([]CompareGanttTask, 0, len(side.timeline.Items))
+		for _, it := range side.timeline.Items {
 			off := it.StartTime.Sub(origin)
 			ns := compareGanttEpoch.Add(off)
 			tasks = append(tasks, CompareGanttTask{
 				Name:       it.Name,
-				ID:         idPrefix + it.ID,
+				ID:         side.idPrefix + it.ID,
 				StartTime:  ns,
 				Duration:   it.Duration,
 				Conclusion: it.Conclusion,
@@ -491,12 +840,9 @@ func buildCompareGantt(left, right *Timeline) *CompareGanttData {
 				maxEnd = end
 			}
 		}
-		sections = append(sections, CompareGanttSection{Label: label, Tasks: tasks})
+		sections = append(sections, CompareGanttSection{Label: side.label, Tasks: tasks})
 	}
 
-	appendSide("Before", left, "cl-")
-	appendSide("After", right, "cr-")
-
 	if len(sections) == 0 {
 		return nil
 	}
@@ -727,7 +1073,11 @@ func ensureCompareObservationLinks(
 ) error {
 	// Render standalone observation pages for parent items so that "only in left/right"
 	// links and standard Gantt links still work.
-	for _, obs := range []*Observation{c.Left, c.Right} {
+	observations := c.Observations
+	if len(observations) == 0 {
+		observations = []*Observation{c.Left, c.Right}
+	}
+	for _, obs := range observations {
 		if obs == nil {
 			continue
 		}
@@ -840,13 +1190,27 @@ func renderWorkflowRunAndJobs(
 	return nil
 }
 
-// Render writes the comparison to a file in the given format ("html" or "md") and returns
+// IsMultiway reports whether more than two observations are lined up against a baseline.
+func (c *Comparison) IsMultiway() bool {
+	return len(c.Observations) > 2
+}
+
+// fileName is the comparison's file name without extension, the IDs of what's compared joined by "_vs_".
+func (c *Comparison) fileName() string {
+	if !c.IsMultiway() {
+		return fmt.Sprintf("%s_vs_%s", c.Left.ID, c.Right.ID)
+	}
+	ids := make([]string, 0, len(c.Observations))
+	for _, obs := range c.Observations {
+		ids = append(ids, obs.ID)
+	}
+	return strings.Join(ids, "_vs_")
+}
+
+// Render writes the comparison to a file in the given format ("html", "md" or "json") and returns
 // the output file path. For HTML the path is a URL-style path suitable for the browser;
-// for markdown it is a filesystem path.
+// for markdown and JSON it is a filesystem path.
 func (c *Comparison) Render(log zerolog.Logger, outputType string, opts ...Option) (string, error) {
-	if len(c.EventPairs) == 0 {
-		c.EventPairs = buildEventPairs(c.Left.TimelineData, c.Right.TimelineData, c.Owner, c.Repo, c.CompareType)
-	}
 
 	observeOpts := defaultOptions()
 	for _, opt := range opts {
@@ -857,23 +1221,16 @@ func (c *Comparison) Render(log zerolog.Logger, outputType string, opts ...Optio
 	if observeOpts.outputDir != OutputDir && observeOpts.outputDir != "" {
 		baseDir = observeOpts.outputDir
 	}
-	if outputType == "md" {
+	if outputType != "html" {
 		baseDir = markdownOutputDir
 	}
-	fileName := fmt.Sprintf("%s_vs_%s.%s", c.Left.ID, c.Right.ID, outputType)
+	fileName := fmt.Sprintf("%s.%s", c.fileName(), outputType)
 
 	targetFile := filepath.Join(baseDir, c.Owner, c.Repo, comparisonsOutputDir, fileName)
 
-	var buf bytes.Buffer
-	if outputType == "md" {
-		if err := mdTemplate.ExecuteTemplate(&buf, "compare_md", c); err != nil {
-			return "", fmt.Errorf("failed to render comparison %s: %w", outputType, err)
-		}
-		buf = cleanMarkdown(buf)
-	} else {
-		if err := htmlTemplate.ExecuteTemplate(&buf, "compare_html", c); err != nil {
-			return "", fmt.Errorf("failed to render comparison %s: %w", outputType, err)
-		}
+	content, err := c.RenderString(log, outputType)
+	if err != nil {
+		return "", err
 	}
 
 	//nolint:gosec // internal target path
@@ -881,7 +1238,7 @@ func (c *Comparison) Render(log zerolog.Logger, outputType string, opts ...Optio
 		return "", fmt.Errorf("failed to create comparison directory: %w", err)
 	}
 	//nolint:gosec // internal target path
-	if err := os.WriteFile(targetFile, buf.Bytes(), 0o600); err != nil {
+	if err := os.WriteFile(targetFile, []byte(content), 0o600); err != nil {
 		return "", fmt.Errorf("failed to write comparison file: %w", err)
 	}
 
@@ -893,21 +1250,34 @@ func (c *Comparison) Render(log zerolog.Logger, outputType string, opts ...Optio
 	return targetFile, nil
 }
 
-// RenderString renders the comparison to a string in the given format ("html" or "md").
+// RenderString renders the comparison to a string in the given format ("html", "md" or "json").
+// Comparisons of more than two observations render as a matrix against the baseline.
 func (c *Comparison) RenderString(_ zerolog.Logger, outputType string) (string, error) {
-	if len(c.EventPairs) == 0 {
+	if len(c.EventPairs) == 0 && !c.IsMultiway() {
 		c.EventPairs = buildEventPairs(c.Left.TimelineData, c.Right.TimelineData, c.Owner, c.Repo, c.CompareType)
 	}
+	templateName := "compare"
+	if c.IsMultiway() {
+		templateName = "compare_many"
+	}
 
 	var buf bytes.Buffer
-	if outputType == "md" {
-		if err := mdTemplate.ExecuteTemplate(&buf, "compare_md", c); err != nil {
+	switch outputType {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(c); err != nil {
+			return "", fmt.Errorf("failed to encode comparison to JSON: %w", err)
+		}
+		return buf.String(), nil
+	case "md":
+		if err := mdTemplate.ExecuteTemplate(&buf, templateName+"_md", c); err != nil {
 			return "", fmt.Errorf("failed to render comparison %s: %w", outputType, err)
 		}
 		res := cleanMarkdown(buf)
 		return res.String(), nil
 	}
-	if err := htmlTemplate.ExecuteTemplate(&buf, "compare_html", c); err != nil {
+	if err := htmlTemplate.ExecuteTemplate(&buf, templateName+"_html", c); err != nil {
 		return "", fmt.Errorf("failed to render comparison %s: %w", outputType, err)
 	}
 	return buf.String(), nil
@@ -0,0 +1,456 @@
+package observe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// Verdicts of a RunSetItem.
+const (
+	VerdictFaster   = "faster"
+	VerdictSlower   = "slower"
+	VerdictNoChange = "no significant change"
+	VerdictTooFew   = "too few runs"
+)
+
+// significanceLevel is the p-value under which a difference between run sets is considered real.
+const significanceLevel = 0.05
+
+// RunSet selects workflow runs for one side of a RunSetComparison.
+type RunSet struct {
+	// Branch is the head branch of the runs.
+	Branch string `json:"branch"`
+	// Event limits the set to runs triggered by this event, all events when empty.
+	Event string `json:"event,omitempty"`
+	// Limit keeps only the most recent runs of each workflow, all runs when 0.
+	Limit int `json:"limit,omitempty"`
+}
+
+func (s RunSet) String() string {
+	desc := s.Branch
+	if s.Event != "" {
+		desc += " (" + s.Event + ")"
+	}
+	if s.Limit > 0 {
+		desc = fmt.Sprintf("last %d runs of %s", s.Limit, desc)
+	}
+	return desc
+}
+
+// RunSetComparison compares the durations of a baseline set of workflow runs against a candidate set,
+// testing each workflow and job for a significant difference instead of trusting a single pair of runs.
+type RunSetComparison struct {
+	Owner     string            `json:"owner"`
+	Repo      string            `json:"repo"`
+	Baseline  RunSet            `json:"baseline"`
+	Candidate RunSet            `json:"candidate"`
+	Workflows []*RunSetWorkflow `json:"workflows"`
+	// OnlyBaseline and OnlyCandidate are workflows that only ran in one of the sets.
+	OnlyBaseline  []string `json:"only_baseline,omitempty"`
+	OnlyCandidate []string `json:"only_candidate,omitempty"`
+}
+
+// RunSetWorkflow compares one workflow, and each of its jobs, across the two sets.
+type RunSetWorkflow struct {
+	Name string `json:"name"`
+	// Run compares the duration of the whole workflow run.
+	Run           *RunSetItem   `json:"run"`
+	Jobs          []*RunSetItem `json:"jobs"`
+	OnlyBaseline  []string      `json:"only_baseline,omitempty"`
+	OnlyCandidate []string      `json:"only_candidate,omitempty"`
+}
+
+// RunSetItem compares the durations of a workflow or job in the baseline and candidate sets.
+type RunSetItem struct {
+	Name      string        `json:"name"`
+	Baseline  DurationStats `json:"baseline"`
+	Candidate DurationStats `json:"candidate"`
+	// MedianDelta and P90Delta are candidate - baseline, positive means the candidate is slower.
+	MedianDelta        time.Duration `json:"median_delta"`
+	MedianDeltaPercent string        `json:"median_delta_percent"`
+	P90Delta           time.Duration `json:"p90_delta"`
+	// PValue is the two-sided Mann-Whitney U test p-value, only set when Tested.
+	PValue  float64 `json:"p_value"`
+	Tested  bool    `json:"tested"`
+	Verdict string  `json:"verdict"`
+}
+
+// PValueText is the p-value for tables, or "-" when there were too few runs to test.
+func (i *RunSetItem) PValueText() string {
+	if !i.Tested {
+		return "-"
+	}
+	if i.PValue < 0.001 {
+		return "<0.001"
+	}
+	return fmt.Sprintf("%.3f", i.PValue)
+}
+
+// DurationStats describes the spread of a set of durations.
+type DurationStats struct {
+	Runs   int           `json:"runs"`
+	Median time.Duration `json:"median"`
+	P90    time.Duration `json:"p90"`
+	Mean   time.Duration `json:"mean"`
+	StdDev time.Duration `json:"std_dev"`
+}
+
+// LoadRunSetComparison compares baseline and candidate sets of the workflow runs of owner/repo cached in store.
+func LoadRunSetComparison(
+	store gather.Store,
+	owner, repo string,
+	baseline, candidate RunSet,
+	opts ...Option,
+) (*RunSetComparison, error) {
+	runs, err := gather.StoredWorkflowRuns(store, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return CompareRunSets(owner, repo, runs, baseline, candidate, opts...)
+}
+
+// CompareRunSets compares the successful runs in the baseline and candidate sets, matching workflows and jobs by
+// name. Failed and cancelled runs and jobs are left out, since they'd skew durations.
+func CompareRunSets(
+	owner, repo string,
+	runs []*gather.WorkflowRunData,
+	baseline, candidate RunSet,
+	opts ...Option,
+) (*RunSetComparison, error) {
+	if baseline.Branch == "" || candidate.Branch == "" {
+		return nil, errors.New("both a baseline and a candidate branch are required")
+	}
+	if baseline == candidate {
+		return nil, fmt.Errorf("baseline and candidate select the same runs: %s", baseline)
+	}
+	observeOpts := defaultOptions()
+	for _, opt := range opts {
+		opt(observeOpts)
+	}
+
+	baselineRuns := selectRunSet(runs, baseline, observeOpts)
+	candidateRuns := selectRunSet(runs, candidate, observeOpts)
+
+	comparison := &RunSetComparison{
+		Owner:     owner,
+		Repo:      repo,
+		Baseline:  baseline,
+		Candidate: candidate,
+		Workflows: []*RunSetWorkflow{},
+	}
+	for _, name := range sortedKeys(baselineRuns) {
+		if _, ok := candidateRuns[name]; !ok {
+			comparison.OnlyBaseline = append(comparison.OnlyBaseline, name)
+			continue
+		}
+		comparison.Workflows = append(comparison.Workflows,
+			compareWorkflowRunSets(name, baselineRuns[name], candidateRuns[name]))
+	}
+	for _, name := range sortedKeys(candidateRuns) {
+		if _, ok := baselineRuns[name]; !ok {
+			comparison.OnlyCandidate = append(comparison.OnlyCandidate, name)
+		}
+	}
+	return comparison, nil
+}
+
+// selectRunSet returns the successful runs in set, grouped by workflow name, newest first.
+func selectRunSet(
+	runs []*gather.WorkflowRunData,
+	set RunSet,
+	observeOpts *options,
+) map[string][]*gather.WorkflowRunData {
+	byWorkflow := map[string][]*gather.WorkflowRunData{}
+	for _, run := range runs {
+		if run == nil || run.WorkflowRun == nil || run.GetConclusion() != "success" ||
+			run.GetHeadBranch() != set.Branch || (set.Event != "" && run.GetEvent() != set.Event) ||
+			!shouldIncludeWorkflow(run.GetName(), observeOpts) {
+			continue
+		}
+		if started, _ := runTimes(run); started.IsZero() {
+			continue
+		}
+		byWorkflow[run.GetName()] = append(byWorkflow[run.GetName()], run)
+	}
+	for name, workflowRuns := range byWorkflow {
+		slices.SortFunc(workflowRuns, func(a, b *gather.WorkflowRunData) int {
+			aStarted, _ := runTimes(a)
+			bStarted, _ := runTimes(b)
+			return bStarted.Compare(aStarted)
+		})
+		if set.Limit > 0 && len(workflowRuns) > set.Limit {
+			byWorkflow[name] = workflowRuns[:set.Limit]
+		}
+	}
+	return byWorkflow
+}
+
+func compareWorkflowRunSets(name string, baseline, candidate []*gather.WorkflowRunData) *RunSetWorkflow {
+	baselineJobs, baselineDurations := runSetDurations(baseline)
+	candidateJobs, candidateDurations := runSetDurations(candidate)
+
+	workflow := &RunSetWorkflow{
+		Name: name,
+		Run:  compareDurationSets(name, baselineDurations, candidateDurations),
+		Jobs: []*RunSetItem{},
+	}
+	for _, job := range sortedKeys(baselineJobs) {
+		if _, ok := candidateJobs[job]; !ok {
+			workflow.OnlyBaseline = append(workflow.OnlyBaseline, job)
+			continue
+		}
+		workflow.Jobs = append(workflow.Jobs, compareDurationSets(job, baselineJobs[job], candidateJobs[job]))
+	}
+	for _, job := range sortedKeys(candidateJobs) {
+		if _, ok := baselineJobs[job]; !ok {
+			workflow.OnlyCandidate = append(workflow.OnlyCandidate, job)
+		}
+	}
+	return workflow
+}
+
+// runSetDurations returns the durations of each successful job by name, and of the runs themselves.
+func runSetDurations(runs []*gather.WorkflowRunData) (jobs map[string][]time.Duration, durations []time.Duration) {
+	jobs = map[string][]time.Duration{}
+	for _, run := range runs {
+		started, completed := runTimes(run)
+		durations = append(durations, max(completed.Sub(started), 0))
+		for _, job := range run.GetJobs() {
+			if job == nil || job.WorkflowJob == nil || job.GetConclusion() != "success" ||
+				job.StartedAt == nil || job.CompletedAt == nil {
+				continue
+			}
+			jobs[job.GetName()] = append(jobs[job.GetName()], max(job.CompletedAt.Sub(job.StartedAt.Time), 0))
+		}
+	}
+	return jobs, durations
+}
+
+func compareDurationSets(name string, baseline, candidate []time.Duration) *RunSetItem {
+	item := &RunSetItem{
+		Name:      name,
+		Baseline:  durationStats(baseline),
+		Candidate: durationStats(candidate),
+		Verdict:   VerdictTooFew,
+	}
+	item.MedianDelta = item.Candidate.Median - item.Baseline.Median
+	item.P90Delta = item.Candidate.P90 - item.Baseline.P90
+	item.MedianDeltaPercent = formatPercentDelta(int64(item.MedianDelta), int64(item.Baseline.Median))
+
+	if minMannWhitneyPValue(len(baseline), len(candidate)) >= significanceLevel {
+		return item
+	}
+	item.Tested = true
+	item.PValue = mannWhitneyU(durationsToFloats(baseline), durationsToFloats(candidate))
+	switch {
+	case item.PValue >= significanceLevel || item.MedianDelta == 0:
+		item.Verdict = VerdictNoChange
+	case item.MedianDelta < 0:
+		item.Verdict = VerdictFaster
+	default:
+		item.Verdict = VerdictSlower
+	}
+	return item
+}
+
+func durationStats(durations []time.Duration) DurationStats {
+	stats := DurationStats{
+		Runs:   len(durations),
+		Median: percentileDuration(durations, 50),
+		P90:    percentileDuration(durations, 90),
+	}
+	if len(durations) == 0 {
+		return stats
+	}
+	values := durationsToFloats(durations)
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	stats.Mean = time.Duration(mean).Round(time.Second)
+	if len(values) > 1 {
+		stats.StdDev = time.Duration(math.Sqrt(squares / float64(len(values)-1))).Round(time.Second)
+	}
+	return stats
+}
+
+func durationsToFloats(durations []time.Duration) []float64 {
+	values := make([]float64, 0, len(durations))
+	for _, d := range durations {
+		values = append(values, float64(d))
+	}
+	return values
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test that a and b come from the same
+// distribution. It uses the normal approximation with tie and continuity corrections, which holds up for the
+// handful of runs CI comparisons usually have and doesn't assume durations are normally distributed.
+func mannWhitneyU(a, b []float64) float64 {
+	type sample struct {
+		value float64
+		fromA bool
+	}
+	samples := make([]sample, 0, len(a)+len(b))
+	for _, v := range a {
+		samples = append(samples, sample{value: v, fromA: true})
+	}
+	for _, v := range b {
+		samples = append(samples, sample{value: v})
+	}
+	slices.SortFunc(samples, func(x, y sample) int {
+		switch {
+		case x.value < y.value:
+			return -1
+		case x.value > y.value:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// Tied values share the average of the ranks they span
+	var rankSumA, tieCorrection float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].value == samples[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].fromA {
+				rankSumA += rank
+			}
+		}
+		ties := float64(j - i)
+		tieCorrection += ties*ties*ties - ties
+		i = j
+	}
+
+	n1 := float64(len(a))
+	return mannWhitneyPValue(rankSumA-n1*(n1+1)/2, len(a), len(b), tieCorrection)
+}
+
+// minMannWhitneyPValue is the smallest p-value mannWhitneyU can return for sets of n1 and n2 values, reached when
+// the sets don't overlap at all. When it isn't under significanceLevel, no result could be significant, so the
+// sets are too small to test. That depends on both sizes: 4 runs against 4 can be, but so can 3 against 10.
+func minMannWhitneyPValue(n1, n2 int) float64 {
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	return mannWhitneyPValue(0, n1, n2, 0)
+}
+
+// mannWhitneyPValue is the two-sided p-value of the U statistic of a set of n1 values against n2 others,
+// using the normal approximation. tieCorrection is the sum of t³-t over each group of t tied values.
+func mannWhitneyPValue(u float64, n1Count, n2Count int, tieCorrection float64) float64 {
+	n1, n2 := float64(n1Count), float64(n2Count)
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieCorrection/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := max(math.Abs(u-mean)-0.5, 0) / math.Sqrt(variance)
+	return math.Erfc(z / math.Sqrt2)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileName is where the comparison is written in the comparisons output dir.
+func (c *RunSetComparison) fileName(outputType string) string {
+	name := fmt.Sprintf("%s_vs_%s", c.Baseline.Branch, c.Candidate.Branch)
+	return strings.Trim(unsafeFileChars.ReplaceAllString(name, "-"), "-") + "." + outputType
+}
+
+// Render writes the comparison to a file in the given format ("html" or "md") and returns
+// the output file path. For HTML the path is a URL-style path suitable for the browser;
+// for markdown it is a filesystem path.
+func (c *RunSetComparison) Render(log zerolog.Logger, outputType string, opts ...Option) (string, error) {
+	observeOpts := defaultOptions()
+	for _, opt := range opts {
+		opt(observeOpts)
+	}
+
+	baseDir := activeHTMLOutputDir
+	if observeOpts.outputDir != OutputDir && observeOpts.outputDir != "" {
+		baseDir = observeOpts.outputDir
+	}
+	if outputType == "md" {
+		baseDir = markdownOutputDir
+	}
+	fileName := c.fileName(outputType)
+	targetFile := filepath.Join(baseDir, c.Owner, c.Repo, comparisonsOutputDir, fileName)
+
+	rendered, err := c.RenderString(outputType)
+	if err != nil {
+		return "", err
+	}
+	//nolint:gosec // internal target path
+	if err := os.MkdirAll(filepath.Dir(targetFile), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create comparison directory: %w", err)
+	}
+	//nolint:gosec // internal target path
+	if err := os.WriteFile(targetFile, []byte(rendered), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write comparison file: %w", err)
+	}
+
+	log.Info().Str("file", targetFile).Str("format", outputType).Msg("Rendered run set comparison")
+
+	if outputType == "html" {
+		return path.Join("/", c.Owner, c.Repo, comparisonsOutputDir, fileName), nil
+	}
+	return targetFile, nil
+}
+
+// RenderString renders the comparison as "html", "md" or "json".
+func (c *RunSetComparison) RenderString(outputType string) (string, error) {
+	var buf bytes.Buffer
+	switch outputType {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(c); err != nil {
+			return "", fmt.Errorf("failed to encode run set comparison to JSON: %w", err)
+		}
+		return buf.String(), nil
+	case "md":
+		if err := mdTemplate.ExecuteTemplate(&buf, "compare_sets_md", c); err != nil {
+			return "", fmt.Errorf("failed to render run set comparison %s: %w", outputType, err)
+		}
+		res := cleanMarkdown(buf)
+		return res.String(), nil
+	default:
+		if err := htmlTemplate.ExecuteTemplate(&buf, "compare_sets_html", c); err != nil {
+			return "", fmt.Errorf("failed to render run set comparison %s: %w", outputType, err)
+		}
+		return buf.String(), nil
+	}
+}
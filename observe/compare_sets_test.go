@@ -0,0 +1,169 @@
+package observe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+func runSetRuns() []*gather.WorkflowRunData {
+	var runs []*gather.WorkflowRunData
+	for i, minutes := range []int{10, 11, 12, 10, 11, 12} {
+		started := trendWeek.Add(time.Duration(i) * time.Hour)
+		duration := time.Duration(minutes) * time.Minute
+		runs = append(runs, trendRun(int64(i+1), "CI", started, duration, "success", "main", "push"))
+	}
+	for i, minutes := range []int{6, 7, 6, 7, 6} {
+		started := trendWeek.AddDate(0, 0, 1).Add(time.Duration(i) * time.Hour)
+		duration := time.Duration(minutes) * time.Minute
+		runs = append(runs, trendRun(int64(i+10), "CI", started, duration, "success", "feat/x", "pull_request"))
+	}
+	return append(runs,
+		trendRun(20, "CI", trendWeek.AddDate(0, 0, 2), time.Hour, "failure", "main", "push"),
+		trendRun(21, "Lint", trendWeek.AddDate(0, 0, 2), time.Minute, "success", "feat/x", "pull_request"),
+	)
+}
+
+func TestCompareRunSets(t *testing.T) {
+	t.Parallel()
+
+	comparison, err := CompareRunSets("kalverra", "octometrics", runSetRuns(),
+		RunSet{Branch: "main"}, RunSet{Branch: "feat/x"})
+	require.NoError(t, err)
+	require.Len(t, comparison.Workflows, 1)
+	assert.Equal(t, []string{"Lint"}, comparison.OnlyCandidate)
+	assert.Empty(t, comparison.OnlyBaseline)
+
+	ci := comparison.Workflows[0]
+	run := ci.Run
+	assert.Equal(t, 6, run.Baseline.Runs, "failed runs should be left out")
+	assert.Equal(t, 5, run.Candidate.Runs)
+	assert.Equal(t, 11*time.Minute, run.Baseline.Median)
+	assert.Equal(t, 12*time.Minute, run.Baseline.P90)
+	assert.Equal(t, 6*time.Minute, run.Candidate.Median)
+	assert.Equal(t, -5*time.Minute, run.MedianDelta)
+	assert.Equal(t, "-45.5%", run.MedianDeltaPercent)
+	assert.Equal(t, 11*time.Minute, run.Baseline.Mean)
+	assert.Equal(t, 54*time.Second, run.Baseline.StdDev)
+	assert.True(t, run.Tested)
+	assert.Less(t, run.PValue, significanceLevel)
+	assert.Equal(t, VerdictFaster, run.Verdict)
+
+	require.Len(t, ci.Jobs, 1)
+	assert.Equal(t, "test", ci.Jobs[0].Name)
+	assert.Equal(t, 10*time.Minute, ci.Jobs[0].Baseline.Median, "job durations shouldn't include queue time")
+	assert.Equal(t, VerdictFaster, ci.Jobs[0].Verdict)
+
+	reversed, err := CompareRunSets("kalverra", "octometrics", runSetRuns(),
+		RunSet{Branch: "feat/x"}, RunSet{Branch: "main"})
+	require.NoError(t, err)
+	assert.Equal(t, VerdictSlower, reversed.Workflows[0].Run.Verdict)
+}
+
+func TestCompareRunSetsSelection(t *testing.T) {
+	t.Parallel()
+
+	comparison, err := CompareRunSets("kalverra", "octometrics", runSetRuns(),
+		RunSet{Branch: "main", Limit: 3}, RunSet{Branch: "feat/x", Limit: 2})
+	require.NoError(t, err)
+	run := comparison.Workflows[0].Run
+	assert.Equal(t, 3, run.Baseline.Runs, "only the most recent baseline runs should be compared")
+	assert.Equal(t, 11*time.Minute, run.Baseline.Median, "the most recent runs took 10, 11 and 12 minutes")
+	assert.False(t, run.Tested, "too few runs shouldn't be tested")
+	assert.Equal(t, VerdictTooFew, run.Verdict)
+	assert.Equal(t, "-", run.PValueText())
+
+	comparison, err = CompareRunSets("kalverra", "octometrics", runSetRuns(),
+		RunSet{Branch: "main", Event: "pull_request"}, RunSet{Branch: "feat/x"})
+	require.NoError(t, err)
+	assert.Empty(t, comparison.Workflows, "no main runs were triggered by pull requests")
+	assert.Equal(t, []string{"CI", "Lint"}, comparison.OnlyCandidate)
+
+	_, err = CompareRunSets("kalverra", "octometrics", nil, RunSet{Branch: "main"}, RunSet{})
+	require.Error(t, err)
+	_, err = CompareRunSets("kalverra", "octometrics", nil, RunSet{Branch: "main"}, RunSet{Branch: "main"})
+	require.Error(t, err)
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0.0122, mannWhitneyU([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}), 0.0005)
+	assert.InDelta(t, 0.0122, mannWhitneyU([]float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}), 0.0005,
+		"the test should be two-sided")
+	assert.InDelta(t, 1.0, mannWhitneyU([]float64{1, 2, 3, 4}, []float64{1, 2, 3, 4}), 0.0001)
+	assert.InDelta(t, 1.0, mannWhitneyU([]float64{5, 5, 5, 5}, []float64{5, 5, 5, 5}), 0.0001,
+		"all ties should never be significant")
+	assert.Greater(t, mannWhitneyU([]float64{1, 3, 5, 7}, []float64{2, 4, 6, 8}), significanceLevel)
+}
+
+func TestMinMannWhitneyPValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n1, n2   int
+		testable bool
+	}{
+		{n1: 0, n2: 10},
+		{n1: 1, n2: 100},
+		{n1: 3, n2: 3},
+		{n1: 3, n2: 2},
+		{n1: 4, n2: 4, testable: true},
+		{n1: 3, n2: 10, testable: true},
+		{n1: 10, n2: 3, testable: true},
+	}
+	for _, tc := range tests {
+		minP := minMannWhitneyPValue(tc.n1, tc.n2)
+		assert.Equal(t, tc.testable, minP < significanceLevel, "%d vs %d runs, smallest p-value %f", tc.n1, tc.n2, minP)
+	}
+
+	separated := mannWhitneyU([]float64{1, 2, 3}, []float64{4, 5, 6, 7, 8, 9, 10, 11, 12, 13})
+	assert.InDelta(t, minMannWhitneyPValue(3, 10), separated, 0.0001,
+		"separated sets should reach the smallest p-value")
+	assert.Less(t, separated, significanceLevel)
+
+	item := compareDurationSets("CI",
+		[]time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute},
+		[]time.Duration{10, 11, 12, 13, 14, 15, 16, 17, 18, 19})
+	assert.True(t, item.Tested, "3 runs against 10 can be significant, so they should be tested")
+}
+
+func TestRunSetComparisonRender(t *testing.T) {
+	t.Parallel()
+
+	log, _ := testhelpers.Setup(t)
+	outputDir := t.TempDir()
+	comparison, err := CompareRunSets("kalverra", "octometrics", runSetRuns(),
+		RunSet{Branch: "main", Limit: 20}, RunSet{Branch: "feat/x"})
+	require.NoError(t, err)
+
+	md, err := comparison.RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "# Comparison: main vs feat/x")
+	assert.Contains(t, md, "last 20 runs of main")
+	assert.Contains(t, md, "| CI | 6 | 5 | 11m0s | 6m0s | -5m0s | -45.5% |")
+	assert.Contains(t, md, "Workflows only in candidate: Lint")
+
+	out, err := comparison.RenderString("json")
+	require.NoError(t, err)
+	var decoded RunSetComparison
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, comparison.Workflows[0].Run, decoded.Workflows[0].Run)
+
+	pagePath, err := comparison.Render(log, "html", WithCustomOutputDir(outputDir))
+	require.NoError(t, err)
+	assert.Equal(t, "/kalverra/octometrics/comparisons/main_vs_feat-x.html", pagePath)
+	//nolint:gosec // test file read
+	content, err := os.ReadFile(filepath.Join(outputDir, pagePath))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Mann-Whitney")
+	assert.Contains(t, string(content), `class="ct-duration delta-faster"`)
+}
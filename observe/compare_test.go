@@ -1,6 +1,7 @@
 package observe
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -346,3 +347,105 @@ func TestBuildEventPairs_unmatchedEvents(t *testing.T) {
 	assert.Equal(t, int64(200), pairs[1].RightCost)
 	assert.Equal(t, int64(200), pairs[1].CostDelta)
 }
+
+func multiwayObservation(id string, build, test time.Duration, testConclusion string) *Observation {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	items := []TimelineItem{
+		{ID: id + "1", Name: "build", StartTime: now, Duration: build, Cost: 100},
+	}
+	if test > 0 {
+		items = append(items, TimelineItem{
+			ID:         id + "2",
+			Name:       "test",
+			StartTime:  now.Add(build),
+			Duration:   test,
+			Conclusion: testConclusion,
+			Cost:       200,
+		})
+	}
+	return &Observation{
+		ID:           id,
+		Name:         "run-" + id,
+		Owner:        "owner",
+		Repo:         "repo",
+		DataType:     "workflow_run",
+		Cost:         300,
+		TimelineData: []*Timeline{{Event: "push", StartTime: now, Items: items}},
+	}
+}
+
+func TestBuildMultiComparison(t *testing.T) {
+	t.Parallel()
+
+	comp := buildMultiComparison([]*Observation{
+		multiwayObservation("1", time.Minute, 2*time.Minute, ""),
+		multiwayObservation("2", time.Minute, 5*time.Minute, "crit"),
+		multiwayObservation("3", 2*time.Minute, 0, ""),
+	}, "owner", "repo", "workflow_run")
+	require.True(t, comp.IsMultiway())
+	assert.Equal(t, "1", comp.Left.ID, "the first observation should be the baseline")
+
+	require.Len(t, comp.Columns, 3)
+	assert.True(t, comp.Columns[0].Baseline)
+	assert.Equal(t, "#2", comp.Columns[1].Label)
+	assert.Equal(t, 3*time.Minute, comp.Columns[1].Total.DurationDelta)
+	assert.Equal(t, "+100.0%", comp.Columns[1].Total.DurationDeltaPercent)
+	assert.Equal(t, -time.Minute, comp.Columns[2].Total.DurationDelta)
+
+	require.Len(t, comp.Matrix, 1)
+	matrix := comp.Matrix[0]
+	assert.Equal(t, "push", matrix.Event)
+	require.Len(t, matrix.Rows, 2)
+
+	test := matrix.Rows[0]
+	assert.Equal(t, "test", test.Name, "the item that varies the most should come first")
+	assert.Equal(t, 3*time.Minute, test.Spread)
+	assert.Equal(t, 3*time.Minute, test.Cells[1].DurationDelta)
+	assert.True(t, test.Cells[1].StatusChanged)
+	assert.False(t, test.Cells[2].Present, "run 3 has no test job")
+	assert.Equal(t, "N/A", test.Cells[2].DurationDeltaPercent)
+
+	build := matrix.Rows[1]
+	assert.Equal(t, time.Minute, build.Spread)
+	assert.Equal(t, "+100.0%", build.Cells[2].DurationDeltaPercent)
+
+	require.NotNil(t, matrix.StackedGantt)
+	require.Len(t, matrix.StackedGantt.Sections, 3)
+	assert.Equal(t, "#3", matrix.StackedGantt.Sections[2].Label)
+	assert.Equal(t, "c2-31", matrix.StackedGantt.Sections[2].Tasks[0].ID)
+}
+
+//nolint:paralleltest
+func TestMultiComparisonRender(t *testing.T) {
+	log, tempDir := testhelpers.Setup(t)
+
+	comp := buildMultiComparison([]*Observation{
+		multiwayObservation("1", time.Minute, 2*time.Minute, ""),
+		multiwayObservation("2", time.Minute, 5*time.Minute, "crit"),
+		multiwayObservation("3", 2*time.Minute, 0, ""),
+	}, "owner", "repo", "workflow_run")
+
+	md, err := comp.RenderString(log, "md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "# Comparison: 3 workflow runs against #1")
+	assert.Contains(t, md, "| test | 2m0s | 5m0s (+3m0s) failure | - | 3m0s |")
+	assert.Contains(t, md, "section #2")
+
+	out, err := comp.RenderString(log, "json")
+	require.NoError(t, err)
+	var decoded Comparison
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, comp.Matrix[0].Rows, decoded.Matrix[0].Rows)
+
+	setActiveHTMLOutputDir(tempDir)
+	renderedRelPath, err := comp.Render(log, "html")
+	require.NoError(t, err)
+	assert.Equal(t, "/owner/repo/comparisons/1_vs_2_vs_3.html", renderedRelPath)
+	//nolint:gosec // test file read
+	content, err := os.ReadFile(filepath.Join(tempDir, renderedRelPath))
+	require.NoError(t, err)
+	htmlStr := string(content)
+	assert.Contains(t, htmlStr, "Stacked timeline")
+	assert.Contains(t, htmlStr, "section #3")
+	assert.Contains(t, htmlStr, "status-changed")
+}
@@ -1,72 +1,374 @@
 package observe
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
-	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/kalverra/octometrics/gather"
 )
 
-// CheckWorkflowDrift checks git log for changes to .github/workflows between baseSHA and HEAD.
-func CheckWorkflowDrift(repoDir, baseSHA string) []string {
-	if baseSHA == "" {
-		return nil
+const (
+	// diffContext is how many unchanged lines surround each change in a workflow diff.
+	diffContext = 3
+	// maxDiffCells bounds the lines of two versions multiplied that are diffed line by line. Beyond it, the changed
+	// part of the file is shown as removed and added as a whole.
+	maxDiffCells = 4_000_000
+)
+
+// WorkflowDrift is how the workflow file changed between two compared runs.
+type WorkflowDrift struct {
+	// Name is the name of the workflow.
+	Name string
+	// LeftPath and RightPath are the workflow file of each run, which only differ when it was renamed.
+	LeftPath  string
+	RightPath string
+	// LeftRef and RightRef are the commits the runs were started from.
+	LeftRef  string
+	RightRef string
+	// LeftSHA and RightSHA are the git blob SHAs of each version of the file.
+	LeftSHA  string
+	RightSHA string
+	// Diff is a unified diff from the left run's version of the file to the right's, and Lines the same diff
+	// line by line.
+	Diff    string
+	Lines   []DiffLine
+	Added   int
+	Removed int
+}
+
+// DiffLine is a line of a unified diff.
+type DiffLine struct {
+	Kind string // "header", "hunk", "add", "del" or "context"
+	Text string
+}
+
+// workflowRunDrift diffs the versions of the workflow file two runs were started from, fetching them through the
+// API when they weren't stored while gathering the runs. Returns nil when the file didn't change or either version
+// can't be found.
+func workflowRunDrift(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	left, right *gather.WorkflowRunData,
+	opts *options,
+) *WorkflowDrift {
+	versions := make([]*gather.WorkflowDefVersion, 0, 2)
+	for _, run := range []*gather.WorkflowRunData{left, right} {
+		version, err := gather.WorkflowRunDefVersion(ctx, log, client, run, opts.gatherOptions...)
+		if err != nil {
+			log.Warn().Err(err).Int64("workflow_run_id", run.GetID()).Msg("Failed to get workflow file version")
+		}
+		if version == nil {
+			return nil
+		}
+		versions = append(versions, version)
+	}
+	drift := workflowDrift(versions[0], versions[1])
+	if drift != nil {
+		drift.Name = right.GetName()
 	}
-	//nolint:gosec // execution of git command for SHA verification
-	verifyCmd := exec.Command("git", "cat-file", "-e", fmt.Sprintf("%s^{commit}", baseSHA))
-	if repoDir != "" {
-		verifyCmd.Dir = repoDir
-	}
-	if err := verifyCmd.Run(); err != nil {
-		return []string{
-			fmt.Sprintf(
-				"⚠ Could not resolve run SHA %s in local git repository; skipping workflow drift check.",
-				baseSHA,
-			),
-		}
-	}
-
-	//nolint:gosec // execution of git command for workflow drift detection
-	cmd := exec.Command(
-		"git",
-		"log",
-		"--name-only",
-		"--oneline",
-		fmt.Sprintf("%s..HEAD", baseSHA),
-		"--",
-		".github/workflows/",
+	return drift
+}
+
+// workflowRunPairDrifts diffs the workflow files of pairs of runs, each a left and a right run, leaving out files
+// that didn't change and changes already listed, as when several runs were started from the same versions.
+func workflowRunPairDrifts(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	pairs [][2]*gather.WorkflowRunData,
+	opts *options,
+) []*WorkflowDrift {
+	var (
+		drifts []*WorkflowDrift
+		seen   = make(map[string]struct{})
 	)
-	if repoDir != "" {
-		cmd.Dir = repoDir
+	for _, pair := range pairs {
+		drift := workflowRunDrift(ctx, log, client, pair[0], pair[1], opts)
+		if drift == nil {
+			continue
+		}
+		key := drift.LeftPath + "@" + drift.LeftSHA + ".." + drift.RightPath + "@" + drift.RightSHA
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		drifts = append(drifts, drift)
 	}
-	out, err := cmd.Output()
-	if err != nil || len(out) == 0 {
-		return nil
+	return drifts
+}
+
+// commitWorkflowDrifts diffs the workflow files of the runs a commit comparison matched up. Runs that can't be
+// loaded are logged and left out, so a comparison never fails over its drift.
+func commitWorkflowDrifts(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	c *Comparison,
+	opts *options,
+) []*WorkflowDrift {
+	var pairs [][2]*gather.WorkflowRunData
+	for _, eventPair := range c.EventPairs {
+		for _, item := range eventPair.Items {
+			leftID, errL := strconv.ParseInt(item.LeftID, 10, 64)
+			rightID, errR := strconv.ParseInt(item.RightID, 10, 64)
+			if errL != nil || errR != nil {
+				continue
+			}
+			var pair [2]*gather.WorkflowRunData
+			for i, id := range []int64{leftID, rightID} {
+				run, _, err := gather.WorkflowRun(ctx, log, client, c.Owner, c.Repo, id, opts.gatherOptions...)
+				if err != nil {
+					log.Warn().
+						Err(err).
+						Int64("workflow_run_id", id).
+						Msg("Failed to load workflow run to diff its workflow file")
+					break
+				}
+				pair[i] = run
+			}
+			if pair[0] != nil && pair[1] != nil {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return workflowRunPairDrifts(ctx, log, client, pairs, opts)
+}
+
+// CommitWorkflowDrift diffs the workflow file each of a commit's runs was started from against the same file at the
+// head of the repo's default branch, through the API. It flags observations of runs whose workflows have changed
+// since, so they can't be taken for how CI runs today.
+func CommitWorkflowDrift(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	owner, repo string,
+	commitSHA string,
+	opts ...Option,
+) ([]*WorkflowDrift, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	commit, err := gather.Commit(ctx, log, client, owner, repo, commitSHA, options.gatherOptions...)
+	if err != nil {
+		return nil, err
+	}
+	runs := commit.WorkflowRuns
+	if len(runs) == 0 {
+		for _, workflowRunID := range commit.WorkflowRunIDs {
+			run, _, err := gather.WorkflowRun(ctx, log, client, owner, repo, workflowRunID, options.gatherOptions...)
+			if err != nil {
+				return nil, err
+			}
+			runs = append(runs, run)
+		}
 	}
 
-	counts := make(map[string]int)
-	lines := strings.SplitSeq(string(out), "\n")
-	for line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, ".github/workflows/") {
-			counts[line]++
+	var (
+		drifts []*WorkflowDrift
+		heads  = make(map[string]*gather.WorkflowDefVersion)
+		seen   = make(map[string]struct{})
+	)
+	for _, run := range runs {
+		version, err := gather.WorkflowRunDefVersion(ctx, log, client, run, options.gatherOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if version == nil {
+			continue
+		}
+		if _, ok := seen[version.Path+"@"+version.SHA]; ok {
+			continue
+		}
+		seen[version.Path+"@"+version.SHA] = struct{}{}
+
+		head, ok := heads[version.Path]
+		if !ok {
+			head, err = gather.WorkflowDefVersionAt(ctx, client, owner, repo, version.Path, "")
+			if err != nil {
+				return nil, err
+			}
+			heads[version.Path] = head
+		}
+		if drift := workflowDrift(version, head); drift != nil {
+			drift.Name = run.GetName()
+			drifts = append(drifts, drift)
 		}
 	}
+	return drifts, nil
+}
 
-	if len(counts) == 0 {
+// workflowDrift diffs two versions of a workflow file, returning nil when they're the same.
+func workflowDrift(left, right *gather.WorkflowDefVersion) *WorkflowDrift {
+	if left == nil || right == nil || (left.Content == right.Content && left.Path == right.Path) {
 		return nil
 	}
+	drift := &WorkflowDrift{
+		LeftPath:  left.Path,
+		RightPath: right.Path,
+		LeftRef:   left.Ref,
+		RightRef:  right.Ref,
+		LeftSHA:   left.SHA,
+		RightSHA:  right.SHA,
+	}
+	drift.Lines, drift.Added, drift.Removed = unifiedDiff("a/"+left.Path, "b/"+right.Path, left.Content,
+		right.Content)
+	var b strings.Builder
+	for _, line := range drift.Lines {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	drift.Diff = b.String()
+	return drift
+}
+
+// diffOp is a line kept (' '), removed ('-') or added ('+') going from one text to another.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff diffs two texts line by line, returning the lines of a unified diff and how many lines were added
+// and removed.
+func unifiedDiff(fromName, toName, from, to string) ([]DiffLine, int, int) {
+	ops := diffOps(splitLines(from), splitLines(to))
+
+	var (
+		lines          []DiffLine
+		added, removed int
+	)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// A hunk runs from diffContext lines before a change to diffContext lines after the last change that
+		// follows it closely enough for their context to touch.
+		start, last := max(i-diffContext, 0), i
+		for j := i; j < len(ops) && j-last <= 2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			}
+		}
+		end := min(last+diffContext+1, len(ops))
+
+		if lines == nil {
+			lines = []DiffLine{{Kind: "header", Text: "--- " + fromName}, {Kind: "header", Text: "+++ " + toName}}
+		}
+		fromStart, toStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				fromStart++
+			}
+			if op.kind != '-' {
+				toStart++
+			}
+		}
+		var fromCount, toCount int
+		hunk := make([]DiffLine, 0, end-start)
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case '-':
+				fromCount++
+				removed++
+				hunk = append(hunk, DiffLine{Kind: "del", Text: "-" + op.text})
+			case '+':
+				toCount++
+				added++
+				hunk = append(hunk, DiffLine{Kind: "add", Text: "+" + op.text})
+			default:
+				fromCount++
+				toCount++
+				hunk = append(hunk, DiffLine{Kind: "context", Text: " " + op.text})
+			}
+		}
+		// Empty ranges start at the line before them
+		if fromCount == 0 {
+			fromStart--
+		}
+		if toCount == 0 {
+			toStart--
+		}
+		lines = append(lines, DiffLine{
+			Kind: "hunk",
+			Text: fmt.Sprintf("@@ -%d,%d +%d,%d @@", fromStart, fromCount, toStart, toCount),
+		})
+		lines = append(lines, hunk...)
+		i = end
+	}
+	return lines, added, removed
+}
+
+// diffOps finds the fewest lines to remove from a and add to make b, from their longest common subsequence.
+func diffOps(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
 
-	var files []string
-	for f := range counts {
-		files = append(files, f)
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{kind: ' ', text: line})
+	}
+	from, to := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(from)*len(to) > maxDiffCells {
+		for _, line := range from {
+			ops = append(ops, diffOp{kind: '-', text: line})
+		}
+		for _, line := range to {
+			ops = append(ops, diffOp{kind: '+', text: line})
+		}
+	} else {
+		// common[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+		common := make([][]int32, len(from)+1)
+		for i := range common {
+			common[i] = make([]int32, len(to)+1)
+		}
+		for i := len(from) - 1; i >= 0; i-- {
+			for j := len(to) - 1; j >= 0; j-- {
+				if from[i] == to[j] {
+					common[i][j] = common[i+1][j+1] + 1
+				} else {
+					common[i][j] = max(common[i+1][j], common[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(from) || j < len(to) {
+			switch {
+			case i < len(from) && j < len(to) && from[i] == to[j]:
+				ops = append(ops, diffOp{kind: ' ', text: from[i]})
+				i++
+				j++
+			case j == len(to) || (i < len(from) && common[i+1][j] >= common[i][j+1]):
+				ops = append(ops, diffOp{kind: '-', text: from[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{kind: '+', text: to[j]})
+				j++
+			}
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{kind: ' ', text: line})
 	}
-	sort.Strings(files)
+	return ops
+}
 
-	var warnings []string
-	for _, f := range files {
-		c := counts[f]
-		warnings = append(warnings, fmt.Sprintf("⚠ %s changed in %d commit(s) since this run.", f, c))
+// splitLines splits text into lines, without a trailing empty line for a final newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
 	}
-	return warnings
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
 }
@@ -1,84 +1,210 @@
 package observe
 
 import (
-	"os"
-	"os/exec"
-	"path/filepath"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/google/go-github/v89/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/internal/testhelpers"
 )
 
-func TestCheckWorkflowDrift(t *testing.T) {
+func TestUnifiedDiff(t *testing.T) {
 	t.Parallel()
 
-	tmpDir := t.TempDir()
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nl\nm\n"
+	lines, added, removed := unifiedDiff("a/ci.yml", "b/ci.yml", from, to)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 2, removed)
+
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	assert.Equal(t, []string{
+		"--- a/ci.yml",
+		"+++ b/ci.yml",
+		"@@ -1,5 +1,5 @@",
+		" a",
+		"-b",
+		"+B",
+		" c",
+		" d",
+		" e",
+		"@@ -8,5 +8,5 @@",
+		" h",
+		" i",
+		" j",
+		"-k",
+		" l",
+		"+m",
+	}, texts, "changes more than twice the context apart should get their own hunks")
+
+	lines, added, removed = unifiedDiff("a/ci.yml", "b/ci.yml", "", "a\n")
+	assert.Equal(t, 1, added)
+	assert.Zero(t, removed)
+	assert.Equal(t, DiffLine{Kind: "hunk", Text: "@@ -0,0 +1,1 @@"}, lines[2])
+
+	lines, _, _ = unifiedDiff("a/ci.yml", "b/ci.yml", "a\n", "a\n")
+	assert.Empty(t, lines)
+}
+
+// driftVersions are two versions of a workflow file, the new one being what the default branch has now.
+var driftVersions = map[string]*gather.WorkflowDefVersion{
+	"old": {
+		Path:    ".github/workflows/ci.yml",
+		SHA:     "1111111111111111111111111111111111111111",
+		Ref:     "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		Content: "jobs:\n  build:\n    runs-on: ubuntu-latest\n",
+	},
+	"new": {
+		Path:    ".github/workflows/ci.yml",
+		SHA:     "2222222222222222222222222222222222222222",
+		Ref:     "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		Content: "jobs:\n  build:\n    runs-on: ubuntu-24.04\n",
+	},
+}
+
+// saveDriftRuns stores run 1, started from the old version of the workflow file at commit aaaaaaa,
+// and runs 2 and 3, started from the new version at commit bbbbbbb.
+func saveDriftRuns(t *testing.T, store gather.Store) {
+	t.Helper()
 
-	runCmd := func(args ...string) {
-		//nolint:gosec // git commands in unit test
-		cmd := exec.Command("git", args...)
-		cmd.Dir = tmpDir
-		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
-		out, err := cmd.CombinedOutput()
-		require.NoError(t, err, "git %v failed: %s", args, string(out))
+	started := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	runVersions := map[int64]*gather.WorkflowDefVersion{
+		1: driftVersions["old"],
+		2: driftVersions["new"],
+		3: driftVersions["new"],
+	}
+	commitRuns := make(map[string][]int64)
+	for id, version := range runVersions {
+		require.NoError(t, store.Save("owner", "repo", gather.WorkflowDefsDataDir, version.SHA, version))
+		runStarted := started.Add(time.Duration(id) * time.Hour)
+		run := &gather.WorkflowRunData{
+			WorkflowRun: &github.WorkflowRun{
+				ID:           new(id),
+				Name:         new("CI"),
+				Path:         new(version.Path),
+				HeadSHA:      new(version.Ref),
+				Event:        new("push"),
+				Status:       new("completed"),
+				Conclusion:   new("success"),
+				CreatedAt:    &github.Timestamp{Time: runStarted},
+				RunStartedAt: &github.Timestamp{Time: runStarted},
+				UpdatedAt:    &github.Timestamp{Time: runStarted.Add(10 * time.Minute)},
+				Repository: &github.Repository{
+					Name:  new("repo"),
+					Owner: &github.User{Login: new("owner")},
+				},
+			},
+			WorkflowDefSHA: version.SHA,
+		}
+		require.NoError(t, store.Save("owner", "repo", gather.WorkflowRunsDataDir, fmt.Sprint(id), run))
+		commitRuns[version.Ref] = append(commitRuns[version.Ref], id)
+	}
+	for sha, runIDs := range commitRuns {
+		slices.Sort(runIDs)
+		commit := &gather.CommitData{
+			RepositoryCommit: &github.RepositoryCommit{SHA: new(sha)},
+			Owner:            "owner",
+			Repo:             "repo",
+			WorkflowRunIDs:   runIDs[:1],
+			Conclusion:       "success",
+		}
+		require.NoError(t, store.Save("owner", "repo", gather.CommitsDataDir, sha, commit))
 	}
+}
+
+func TestCompareWorkflowRuns_WorkflowDrift(t *testing.T) {
+	t.Parallel()
 
-	runCmd("init")
-	runCmd("config", "user.name", "Test User")
-	runCmd("config", "user.email", "test@example.com")
-	runCmd("config", "commit.gpgsign", "false")
+	log, dataDir := testhelpers.Setup(t)
+	saveDriftRuns(t, gather.NewFileStore(dataDir))
+	opts := []Option{WithGatherOptions(gather.CustomDataFolder(dataDir))}
 
-	wfDir := filepath.Join(tmpDir, ".github", "workflows")
-	require.NoError(t, os.MkdirAll(wfDir, 0o700))
+	comparison, err := CompareWorkflowRuns(t.Context(), log, nil, "owner", "repo", 1, 2, opts...)
+	require.NoError(t, err)
+	require.Len(t, comparison.WorkflowDrifts, 1, "runs of different workflow file versions should be flagged")
+	drift := comparison.WorkflowDrifts[0]
+	assert.Equal(t, "CI", drift.Name)
+	assert.Equal(t, 1, drift.Added)
+	assert.Equal(t, 1, drift.Removed)
+	assert.Contains(t, drift.Diff, "-    runs-on: ubuntu-latest\n+    runs-on: ubuntu-24.04\n")
+
+	md, err := comparison.RenderString(log, "md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "## Workflow changed between these runs")
+	assert.Contains(t, md, "`.github/workflows/ci.yml` at aaaaaaa → `.github/workflows/ci.yml` at bbbbbbb (+1 −1)")
+	assert.Contains(t, md, "```diff\n--- a/.github/workflows/ci.yml\n")
 
-	wfFile := filepath.Join(wfDir, "test.yaml")
-	require.NoError(t, os.WriteFile(wfFile, []byte("name: test\n"), 0o600))
-	runCmd("add", ".")
-	runCmd("commit", "--no-gpg-sign", "-m", "Initial commit")
+	html, err := comparison.RenderString(log, "html")
+	require.NoError(t, err)
+	assert.Contains(t, html, "changed between these runs")
+	assert.Contains(t, html, `<span class="diff-add">&#43;    runs-on: ubuntu-24.04</span>`)
 
-	//nolint:gosec // git rev-parse in unit test
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = tmpDir
-	out, err := cmd.Output()
+	comparison, err = CompareWorkflowRuns(t.Context(), log, nil, "owner", "repo", 2, 3, opts...)
 	require.NoError(t, err)
-	baseSHA := string(out)[:40]
+	assert.Empty(t, comparison.WorkflowDrifts, "runs of the same workflow file version shouldn't be flagged")
 
-	// Make another commit modifying the workflow
-	require.NoError(t, os.WriteFile(wfFile, []byte("name: test\n# update\n"), 0o600))
-	runCmd("add", ".")
-	runCmd("commit", "--no-gpg-sign", "-m", "Update workflow")
+	comparison, err = CompareManyWorkflowRuns(t.Context(), log, nil, "owner", "repo", 1, []int64{2, 3}, opts...)
+	require.NoError(t, err)
+	assert.Len(t, comparison.WorkflowDrifts, 1, "runs started from the same version should share their drift")
+	md, err = comparison.RenderString(log, "md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "## Workflow changed between these runs")
 
-	warnings := CheckWorkflowDrift(tmpDir, baseSHA)
-	require.Len(t, warnings, 1)
-	assert.Contains(t, warnings[0], ".github/workflows/test.yaml changed in 1 commit(s) since this run")
+	comparison, err = CompareCommits(t.Context(), log, nil, "owner", "repo",
+		driftVersions["old"].Ref, driftVersions["new"].Ref, opts...)
+	require.NoError(t, err)
+	require.Len(t, comparison.WorkflowDrifts, 1, "the matched runs of compared commits should be diffed")
+	assert.Equal(t, driftVersions["new"].SHA, comparison.WorkflowDrifts[0].RightSHA)
 }
 
-func TestCheckWorkflowDrift_UnresolvableSHA(t *testing.T) {
+func TestCommitWorkflowDrift(t *testing.T) {
 	t.Parallel()
 
-	tmpDir := t.TempDir()
+	log, dataDir := testhelpers.Setup(t)
+	saveDriftRuns(t, gather.NewFileStore(dataDir))
+	var refs []string
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(mock.MustMarshal(&github.Repository{DefaultBranch: new("main")}))
+			}),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposContentsByOwnerByRepoByPath,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				refs = append(refs, r.URL.Query().Get("ref"))
+				_, _ = w.Write(mock.MustMarshal(&github.RepositoryContent{
+					Encoding: new("base64"),
+					Content:  new(base64.StdEncoding.EncodeToString([]byte(driftVersions["new"].Content))),
+				}))
+			}),
+		),
+	)
+	client, err := gather.NewGitHubClient(log, "mock-token", mockedHTTPClient.Transport)
+	require.NoError(t, err)
+	opts := []Option{WithGatherOptions(gather.CustomDataFolder(dataDir), gather.WithoutCost())}
 
-	runCmd := func(args ...string) {
-		//nolint:gosec // git commands in unit test
-		cmd := exec.Command("git", args...)
-		cmd.Dir = tmpDir
-		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
-		out, err := cmd.CombinedOutput()
-		require.NoError(t, err, "git %v failed: %s", args, string(out))
-	}
+	drifts, err := CommitWorkflowDrift(t.Context(), log, client, "owner", "repo", driftVersions["old"].Ref, opts...)
+	require.NoError(t, err)
+	require.Len(t, drifts, 1, "the workflow changed on the default branch since the old commit")
+	assert.Equal(t, "main", drifts[0].RightRef)
+	assert.Equal(t, 1, drifts[0].Added)
+	assert.Equal(t, []string{"main"}, refs, "only the default branch's version should be fetched")
 
-	runCmd("init")
-	runCmd("config", "user.name", "Test User")
-	runCmd("config", "user.email", "test@example.com")
-	runCmd("config", "commit.gpgsign", "false")
-
-	fakeSHA := "1234567890abcdef1234567890abcdef12345678"
-	warnings := CheckWorkflowDrift(tmpDir, fakeSHA)
-	require.Len(t, warnings, 1)
-	assert.Contains(
-		t,
-		warnings[0],
-		"Could not resolve run SHA 1234567890abcdef1234567890abcdef12345678 in local git repository",
-	)
+	drifts, err = CommitWorkflowDrift(t.Context(), log, client, "owner", "repo", driftVersions["new"].Ref, opts...)
+	require.NoError(t, err)
+	assert.Empty(t, drifts, "the default branch has the new commit's version")
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kalverra/octometrics/gather"
 	"github.com/kalverra/octometrics/internal/testhelpers"
 )
 
@@ -96,7 +97,7 @@ func TestHandlerServesExportJS(t *testing.T) {
 
 	log, tempDir := testhelpers.Setup(t)
 
-	handler := NewOnDemandHandler(log, nil, tempDir, tempDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(tempDir), tempDir)
 
 	req := httptest.NewRequest("GET", "/export-png.js", nil)
 	rr := httptest.NewRecorder()
@@ -0,0 +1,279 @@
+package observe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// FlakyJobs are the jobs of a repo whose conclusion flipped between failure and success on the same commit,
+// either across attempts of a run or across runs of the same workflow on identical SHAs.
+type FlakyJobs struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"`
+	// Commits is how many commits had finished jobs to analyze.
+	Commits int         `json:"commits"`
+	Jobs    []*FlakyJob `json:"jobs"`
+}
+
+// FlakyJob is a job that both failed and passed on at least one commit.
+type FlakyJob struct {
+	Workflow string `json:"workflow"`
+	Name     string `json:"name"`
+	// Commits is how many commits the job finished on, FlakyCommits how many of them it both failed and passed on.
+	Commits      int `json:"commits"`
+	FlakyCommits int `json:"flaky_commits"`
+	// Failures is how many times the job failed on a commit it also passed on.
+	Failures int `json:"failures"`
+	// Score is the share of commits the job flaked on, in percent.
+	Score    float64            `json:"score"`
+	LastSeen time.Time          `json:"last_seen"`
+	Steps    []*FlakyStep       `json:"steps"`
+	Flips    []*FlakyOccurrence `json:"flips"`
+}
+
+// FlakyStep is a step that failed in a flaky job's failed attempts and passed in its successful ones.
+type FlakyStep struct {
+	Name     string `json:"name"`
+	Failures int    `json:"failures"`
+}
+
+// FlakyOccurrence is one commit a job both failed and passed on.
+type FlakyOccurrence struct {
+	SHA    string `json:"sha"`
+	Branch string `json:"branch,omitempty"`
+	// Failures and Successes count the attempts of the job on this commit.
+	Failures  int `json:"failures"`
+	Successes int `json:"successes"`
+	// Rerun is true when the job flipped between attempts of the same workflow run.
+	Rerun        bool      `json:"rerun"`
+	WorkflowRuns []int64   `json:"workflow_run_ids"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// flakyExecution is one attempt of a job on a commit.
+type flakyExecution struct {
+	runID    int64
+	branch   string
+	failed   bool
+	finished time.Time
+	steps    map[string]bool
+}
+
+// LoadFlakyJobs finds flaky jobs of owner/repo in the workflow runs cached in store.
+func LoadFlakyJobs(store gather.Store, owner, repo string, opts ...Option) (*FlakyJobs, error) {
+	runs, err := gather.StoredWorkflowRuns(store, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return BuildFlakyJobs(owner, repo, runs, opts...), nil
+}
+
+// BuildFlakyJobs finds jobs that failed and passed on the same commit. Each run's jobs include those of every
+// attempt, so re-runs are compared along with other runs of the same workflow on the same SHA.
+// Jobs that were cancelled or skipped don't count either way.
+func BuildFlakyJobs(owner, repo string, runs []*gather.WorkflowRunData, opts ...Option) *FlakyJobs {
+	observeOpts := defaultOptions()
+	for _, opt := range opts {
+		opt(observeOpts)
+	}
+
+	type jobKey struct{ workflow, name string }
+	var (
+		commits    = map[string]bool{}
+		executions = map[jobKey]map[string][]*flakyExecution{}
+	)
+	for _, run := range runs {
+		if run == nil || run.WorkflowRun == nil || run.GetHeadSHA() == "" ||
+			!shouldIncludeWorkflow(run.GetName(), observeOpts) {
+			continue
+		}
+		sha := run.GetHeadSHA()
+		for _, job := range run.GetJobs() {
+			execution := flakyJobExecution(run, job)
+			if execution == nil {
+				continue
+			}
+			key := jobKey{workflow: run.GetName(), name: job.GetName()}
+			if executions[key] == nil {
+				executions[key] = map[string][]*flakyExecution{}
+			}
+			executions[key][sha] = append(executions[key][sha], execution)
+			commits[sha] = true
+		}
+	}
+
+	flaky := &FlakyJobs{Owner: owner, Repo: repo, Commits: len(commits), Jobs: []*FlakyJob{}}
+	for key, bySHA := range executions {
+		job := &FlakyJob{Workflow: key.workflow, Name: key.name, Commits: len(bySHA)}
+		stepFailures := map[string]int{}
+		for sha, execs := range bySHA {
+			flip := flakyFlip(sha, execs)
+			if flip == nil {
+				continue
+			}
+			job.FlakyCommits++
+			job.Failures += flip.Failures
+			if flip.LastSeen.After(job.LastSeen) {
+				job.LastSeen = flip.LastSeen
+			}
+			job.Flips = append(job.Flips, flip)
+			for name, n := range flakyStepFailures(execs) {
+				stepFailures[name] += n
+			}
+		}
+		if job.FlakyCommits == 0 {
+			continue
+		}
+		job.Score = float64(job.FlakyCommits) / float64(job.Commits) * 100
+		slices.SortFunc(job.Flips, func(a, b *FlakyOccurrence) int {
+			return b.LastSeen.Compare(a.LastSeen)
+		})
+		for name, failures := range stepFailures {
+			job.Steps = append(job.Steps, &FlakyStep{Name: name, Failures: failures})
+		}
+		slices.SortFunc(job.Steps, func(a, b *FlakyStep) int {
+			if a.Failures != b.Failures {
+				return b.Failures - a.Failures
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+		flaky.Jobs = append(flaky.Jobs, job)
+	}
+
+	// Jobs that flake most often first, then the ones that flake on the largest share of commits
+	slices.SortFunc(flaky.Jobs, func(a, b *FlakyJob) int {
+		if a.FlakyCommits != b.FlakyCommits {
+			return b.FlakyCommits - a.FlakyCommits
+		}
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		if a.Workflow != b.Workflow {
+			return strings.Compare(a.Workflow, b.Workflow)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return flaky
+}
+
+func flakyJobExecution(run *gather.WorkflowRunData, job *gather.JobData) *flakyExecution {
+	if job == nil || job.WorkflowJob == nil {
+		return nil
+	}
+	conclusion := job.GetConclusion()
+	failed := trendFailed(conclusion)
+	if !failed && conclusion != "success" {
+		return nil
+	}
+	execution := &flakyExecution{
+		runID:    run.GetID(),
+		branch:   run.GetHeadBranch(),
+		failed:   failed,
+		finished: job.GetCompletedAt().Time,
+		steps:    map[string]bool{},
+	}
+	for _, step := range job.Steps {
+		switch conclusion := step.GetConclusion(); {
+		case trendFailed(conclusion):
+			execution.steps[step.GetName()] = true
+		case conclusion == "success":
+			execution.steps[step.GetName()] = false
+		}
+	}
+	return execution
+}
+
+// flakyFlip summarizes the executions of a job on one commit, or returns nil if the job didn't both fail and pass.
+func flakyFlip(sha string, execs []*flakyExecution) *FlakyOccurrence {
+	flip := &FlakyOccurrence{SHA: sha}
+	failedRuns, passedRuns := map[int64]bool{}, map[int64]bool{}
+	for _, e := range execs {
+		if e.failed {
+			flip.Failures++
+			failedRuns[e.runID] = true
+		} else {
+			flip.Successes++
+			passedRuns[e.runID] = true
+		}
+		if !slices.Contains(flip.WorkflowRuns, e.runID) {
+			flip.WorkflowRuns = append(flip.WorkflowRuns, e.runID)
+		}
+		if e.finished.After(flip.LastSeen) {
+			flip.LastSeen = e.finished
+		}
+		if flip.Branch == "" {
+			flip.Branch = e.branch
+		}
+	}
+	if flip.Failures == 0 || flip.Successes == 0 {
+		return nil
+	}
+	for runID := range failedRuns {
+		if passedRuns[runID] {
+			flip.Rerun = true
+		}
+	}
+	slices.Sort(flip.WorkflowRuns)
+	return flip
+}
+
+// flakyStepFailures counts how often each step failed on a commit where the same step also passed.
+func flakyStepFailures(execs []*flakyExecution) map[string]int {
+	passed := map[string]bool{}
+	for _, e := range execs {
+		if e.failed {
+			continue
+		}
+		for name, failed := range e.steps {
+			if !failed {
+				passed[name] = true
+			}
+		}
+	}
+	failures := map[string]int{}
+	for _, e := range execs {
+		if !e.failed {
+			continue
+		}
+		for name, failed := range e.steps {
+			if failed && passed[name] {
+				failures[name]++
+			}
+		}
+	}
+	return failures
+}
+
+// RenderString renders the flaky jobs as "html", "md" or "json".
+func (f *FlakyJobs) RenderString(outputType string) (string, error) {
+	var buf bytes.Buffer
+	switch outputType {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f); err != nil {
+			return "", fmt.Errorf("failed to encode flaky jobs to JSON: %w", err)
+		}
+		return buf.String(), nil
+	case "md":
+		if err := mdTemplate.ExecuteTemplate(&buf, "flaky_md", f); err != nil {
+			return "", fmt.Errorf("failed to render flaky jobs %s: %w", outputType, err)
+		}
+		res := cleanMarkdown(buf)
+		return res.String(), nil
+	default:
+		if err := htmlTemplate.ExecuteTemplate(&buf, "flaky_html", f); err != nil {
+			return "", fmt.Errorf("failed to render flaky jobs %s: %w", outputType, err)
+		}
+		return buf.String(), nil
+	}
+}
@@ -0,0 +1,157 @@
+package observe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+var flakyStart = time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+
+func flakyJob(name string, attempt int64, conclusion string, finished time.Duration) *gather.JobData {
+	stepConclusion := conclusion
+	if conclusion == "cancelled" {
+		stepConclusion = "skipped"
+	}
+	return &gather.JobData{
+		WorkflowJob: &github.WorkflowJob{
+			Name:        new(name),
+			RunAttempt:  new(attempt),
+			Conclusion:  new(conclusion),
+			CompletedAt: &github.Timestamp{Time: flakyStart.Add(finished)},
+			Steps: []*github.TaskStep{
+				{Name: new("Set up job"), Conclusion: new("success")},
+				{Name: new("Run tests"), Conclusion: new(stepConclusion)},
+			},
+		},
+	}
+}
+
+func flakyRun(id int64, sha, event string, jobs ...*gather.JobData) *gather.WorkflowRunData {
+	return &gather.WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:         new(id),
+			Name:       new("CI"),
+			HeadSHA:    new(sha),
+			HeadBranch: new("main"),
+			Event:      new(event),
+		},
+		Jobs: jobs,
+	}
+}
+
+func flakyRuns() []*gather.WorkflowRunData {
+	return []*gather.WorkflowRunData{
+		// Re-run of a failed job passes
+		flakyRun(1, "aaa", "push",
+			flakyJob("test", 1, "failure", time.Minute),
+			flakyJob("test", 2, "success", time.Hour),
+			flakyJob("lint", 1, "success", time.Minute),
+		),
+		flakyRun(2, "bbb", "push",
+			flakyJob("test", 1, "success", 2*time.Hour),
+			flakyJob("lint", 1, "cancelled", 2*time.Hour),
+		),
+		// Same SHA passes in the merge queue after failing on push
+		flakyRun(3, "ccc", "push", flakyJob("test", 1, "failure", 3*time.Hour)),
+		flakyRun(4, "ccc", "merge_group", flakyJob("test", 1, "success", 4*time.Hour)),
+		// Consistent failures aren't flaky
+		flakyRun(5, "ddd", "push", flakyJob("lint", 1, "failure", 5*time.Hour)),
+		flakyRun(6, "ddd", "merge_group", flakyJob("lint", 1, "failure", 5*time.Hour)),
+	}
+}
+
+func TestBuildFlakyJobs(t *testing.T) {
+	t.Parallel()
+
+	flaky := BuildFlakyJobs("kalverra", "octometrics", flakyRuns())
+	assert.Equal(t, 4, flaky.Commits)
+	require.Len(t, flaky.Jobs, 1, "only jobs that both failed and passed on a commit should be flaky")
+
+	job := flaky.Jobs[0]
+	assert.Equal(t, "CI", job.Workflow)
+	assert.Equal(t, "test", job.Name)
+	assert.Equal(t, 3, job.Commits)
+	assert.Equal(t, 2, job.FlakyCommits)
+	assert.Equal(t, 2, job.Failures)
+	assert.InDelta(t, 66.67, job.Score, 0.01)
+	assert.Equal(t, flakyStart.Add(4*time.Hour), job.LastSeen)
+	assert.Equal(t, []*FlakyStep{{Name: "Run tests", Failures: 2}}, job.Steps)
+
+	require.Len(t, job.Flips, 2)
+	assert.Equal(t, &FlakyOccurrence{
+		SHA:          "ccc",
+		Branch:       "main",
+		Failures:     1,
+		Successes:    1,
+		WorkflowRuns: []int64{3, 4},
+		LastSeen:     flakyStart.Add(4 * time.Hour),
+	}, job.Flips[0], "most recent flips should come first")
+	assert.Equal(t, "aaa", job.Flips[1].SHA)
+	assert.True(t, job.Flips[1].Rerun, "flips between attempts of one run should be marked as re-runs")
+	assert.Equal(t, []int64{1}, job.Flips[1].WorkflowRuns)
+}
+
+func TestBuildFlakyJobsExcludedWorkflow(t *testing.T) {
+	t.Parallel()
+
+	flaky := BuildFlakyJobs("kalverra", "octometrics", flakyRuns(), ExcludeWorkflows([]string{"CI"}))
+	assert.Zero(t, flaky.Commits)
+	assert.Empty(t, flaky.Jobs)
+}
+
+func TestFlakyJobsRenderString(t *testing.T) {
+	t.Parallel()
+
+	flaky := BuildFlakyJobs("kalverra", "octometrics", flakyRuns())
+
+	md, err := flaky.RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "| CI | test | 67% | 2 / 3 | 2 | Run tests (2) |")
+
+	out, err := flaky.RenderString("json")
+	require.NoError(t, err)
+	var decoded FlakyJobs
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, flaky.Jobs[0].Flips, decoded.Jobs[0].Flips)
+
+	html, err := flaky.RenderString("html")
+	require.NoError(t, err)
+	assert.Contains(t, html, `href="/kalverra/octometrics/workflow_runs/3.html"`)
+	assert.Contains(t, html, `href="/kalverra/octometrics/commits/ccc.html"`)
+
+	empty, err := BuildFlakyJobs("kalverra", "octometrics", nil).RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, empty, "No flaky jobs found")
+}
+
+func TestHandler_FlakyPage(t *testing.T) {
+	t.Parallel()
+
+	log, dataDir := testhelpers.Setup(t)
+	store := gather.NewFileStore(dataDir)
+	for _, run := range flakyRuns() {
+		id := strconv.FormatInt(run.GetID(), 10)
+		require.NoError(t, store.Save("kalverra", "octometrics", gather.WorkflowRunsDataDir, id, run))
+	}
+	handler := NewOnDemandHandler(log, nil, store, t.TempDir())
+
+	req := httptest.NewRequest("GET", "/kalverra/octometrics/flaky", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "Flaky jobs")
+	assert.Contains(t, body, "Run tests (2)")
+}
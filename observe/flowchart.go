@@ -29,27 +29,19 @@ func buildFlowChart(def *gather.WorkflowDef, jobs []*gather.JobData) string {
 		jobConclusions[name] = job.GetConclusion()
 	}
 
-	// Sort job IDs for deterministic output
-	jobIDs := make([]string, 0, len(def.Jobs))
-	for id := range def.Jobs {
-		jobIDs = append(jobIDs, id)
-	}
-	sort.Strings(jobIDs)
-
 	var b strings.Builder
 	b.WriteString("flowchart TD\n")
 
-	// Emit nodes
-	for _, id := range jobIDs {
-		job := def.Jobs[id]
-		displayName := job.Name
-		if displayName == "" {
-			displayName = id
-		}
-		sanitized := sanitizeMermaidID(id)
-		sanizedName := sanitizeMermaidName(displayName)
-		fmt.Fprintf(&b, "    %s[\"%s\"]\n", sanitized, sanizedName)
+	// Emit nodes, with the jobs of called reusable workflows nested in subgraphs
+	writeFlowChartJobs(&b, def, "", "    ")
+
+	// Edges follow the expanded workflow, so needs on a reusable workflow job point at its called jobs
+	expanded := def.Expanded()
+	jobIDs := make([]string, 0, len(expanded.Jobs))
+	for id := range expanded.Jobs {
+		jobIDs = append(jobIDs, id)
 	}
+	sort.Strings(jobIDs)
 
 	// Helper for recording edges, resolving job names to canonical IDs
 	type edge struct {
@@ -59,10 +51,10 @@ func buildFlowChart(def *gather.WorkflowDef, jobs []*gather.JobData) string {
 	seenEdges := make(map[edge]bool)
 
 	addEdge := func(from, to string) {
-		if targetID, found := def.GetJobIDByName(from); found {
+		if targetID, found := expanded.GetJobIDByName(from); found {
 			from = targetID
 		}
-		if targetID, found := def.GetJobIDByName(to); found {
+		if targetID, found := expanded.GetJobIDByName(to); found {
 			to = targetID
 		}
 		e := edge{from: sanitizeMermaidID(from), to: sanitizeMermaidID(to)}
@@ -73,13 +65,14 @@ func buildFlowChart(def *gather.WorkflowDef, jobs []*gather.JobData) string {
 	}
 
 	for _, id := range jobIDs {
-		job := def.Jobs[id]
+		job := expanded.Jobs[id]
 		for _, need := range job.Needs {
 			addEdge(need, id)
 		}
 	}
 
 	// Process extra runtime jobs (e.g. reusable workflow child jobs like "parent / child")
+	// of reusable workflows that couldn't be expanded
 	extraNodes := make(map[string]string)
 	for _, job := range jobs {
 		if job == nil || job.WorkflowJob == nil {
@@ -95,6 +88,9 @@ func buildFlowChart(def *gather.WorkflowDef, jobs []*gather.JobData) string {
 			if targetID, found := def.GetJobIDByName(parent); found {
 				parentID = targetID
 			}
+			if def.Jobs[parentID].Calls != nil && len(def.Jobs[parentID].Calls.Jobs) > 0 {
+				continue
+			}
 
 			childSanitized := sanitizeMermaidID(child)
 			if _, exists := def.Jobs[child]; !exists {
@@ -134,6 +130,52 @@ func buildFlowChart(def *gather.WorkflowDef, jobs []*gather.JobData) string {
 	return result
 }
 
+// writeFlowChartJobs writes a node for each of def's jobs, and a subgraph of the called jobs for each job that
+// calls a reusable workflow. Node IDs are prefixed like the job IDs of WorkflowDef.Expanded.
+func writeFlowChartJobs(b *strings.Builder, def *gather.WorkflowDef, prefix, indent string) {
+	jobIDs := make([]string, 0, len(def.Jobs))
+	for id := range def.Jobs {
+		jobIDs = append(jobIDs, id)
+	}
+	sort.Strings(jobIDs)
+
+	for _, id := range jobIDs {
+		job := def.Jobs[id]
+		displayName := job.Name
+		if displayName == "" {
+			displayName = id
+		}
+		sanitized := sanitizeMermaidID(prefix + id)
+		sanitizedName := sanitizeMermaidName(displayName)
+		if job.Calls != nil && len(job.Calls.Jobs) > 0 {
+			fmt.Fprintf(b, "%ssubgraph %s [\"%s\"]\n", indent, sanitized, sanitizedName)
+			writeFlowChartJobs(b, job.Calls, prefix+id+"/", indent+"    ")
+			fmt.Fprintf(b, "%send\n", indent)
+			continue
+		}
+		if job.IsMatrix() {
+			// Matrix legs are grouped into one node
+			fmt.Fprintf(b, "%s%s[[\"%s\"]]\n", indent, sanitized, sanitizeMermaidName(matrixNodeName(job)))
+			continue
+		}
+		fmt.Fprintf(b, "%s%s[\"%s\"]\n", indent, sanitized, sanitizedName)
+	}
+}
+
+// matrixNodeName labels the flowchart node of a matrix job with how many legs it runs,
+// or just as a matrix when its legs are only known at runtime.
+func matrixNodeName(job gather.JobDef) string {
+	legs := job.MatrixLegNames()
+	switch {
+	case legs == nil:
+		return job.Name + " (matrix)"
+	case len(legs) == 1:
+		return job.Name + " (1 leg)"
+	default:
+		return fmt.Sprintf("%s (%d legs)", job.Name, len(legs))
+	}
+}
+
 // sanitizeMermaidID sanitizes a string for use as a Mermaid node ID.
 func sanitizeMermaidID(s string) string {
 	s = strings.TrimSpace(s)
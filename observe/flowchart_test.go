@@ -190,6 +190,43 @@ func TestBuildFlowChart_ChildAndMatrixJobs(t *testing.T) {
 	assert.Contains(t, chart, "build_chainlink -->")
 }
 
+func TestBuildFlowChart_GroupedMatrixJobs(t *testing.T) {
+	t.Parallel()
+
+	def := &gather.WorkflowDef{
+		Jobs: map[string]gather.JobDef{
+			"build": {Name: "build"},
+			"test": {
+				Name:  "test",
+				Needs: gather.Needs{"build"},
+				Strategy: &gather.Strategy{Matrix: &gather.Matrix{
+					Dimensions: []gather.MatrixDimension{
+						{Name: "os", Values: []any{"ubuntu-latest", "windows-latest"}},
+						{Name: "go", Values: []any{"1.22", "1.23"}},
+					},
+				}},
+			},
+			"e2e": {
+				Name:     "e2e",
+				Needs:    gather.Needs{"test"},
+				Strategy: &gather.Strategy{Matrix: &gather.Matrix{Expression: "${{ fromJSON(inputs.matrix) }}"}},
+			},
+		},
+	}
+	jobs := []*gather.JobData{
+		{WorkflowJob: &github.WorkflowJob{ID: new(int64(1)), Name: new("test (ubuntu-latest, 1.22)")}},
+		{WorkflowJob: &github.WorkflowJob{ID: new(int64(2)), Name: new("test (windows-latest, 1.23)")}},
+		{WorkflowJob: &github.WorkflowJob{ID: new(int64(3)), Name: new("e2e (smoke)")}},
+	}
+
+	assert.Equal(t, `flowchart TD
+    build["build"]
+    e2e[["e2e (matrix)"]]
+    test[["test (4 legs)"]]
+    build --> test
+    test --> e2e`, buildFlowChart(def, jobs), "matrix legs should be grouped into one node per job")
+}
+
 func TestBuildFlowChart_SanitizationAndParentMatching(t *testing.T) {
 	t.Parallel()
 
@@ -229,3 +266,40 @@ func TestBuildFlowChart_SanitizationAndParentMatching(t *testing.T) {
 	// Edge for needs matching must map Run_Core_CRE_E2E_Regression_Tests to run_core_cre_e2e_regression_tests
 	assert.Contains(t, chart, "run_core_cre_e2e_regression_tests --> test_job")
 }
+
+func TestBuildFlowChart_ExpandedReusableWorkflow(t *testing.T) {
+	t.Parallel()
+
+	def := &gather.WorkflowDef{
+		Jobs: map[string]gather.JobDef{
+			"build": {Name: "build"},
+			"e2e": {
+				Name:  "E2E",
+				Needs: gather.Needs{"build"},
+				Uses:  "./.github/workflows/e2e.yml",
+				Calls: &gather.WorkflowDef{
+					Jobs: map[string]gather.JobDef{
+						"setup": {Name: "setup"},
+						"smoke": {Name: "smoke", Needs: gather.Needs{"setup"}},
+					},
+				},
+			},
+			"deploy": {Name: "deploy", Needs: gather.Needs{"e2e"}},
+		},
+	}
+	jobs := []*gather.JobData{
+		{WorkflowJob: &github.WorkflowJob{ID: new(int64(1)), Name: new("E2E / setup")}},
+		{WorkflowJob: &github.WorkflowJob{ID: new(int64(2)), Name: new("E2E / smoke")}},
+	}
+
+	assert.Equal(t, `flowchart TD
+    build["build"]
+    deploy["deploy"]
+    subgraph e2e ["E2E"]
+        e2e_setup["setup"]
+        e2e_smoke["smoke"]
+    end
+    build --> e2e_setup
+    e2e_setup --> e2e_smoke
+    e2e_smoke --> deploy`, buildFlowChart(def, jobs), "runtime jobs of expanded workflows shouldn't add extra nodes")
+}
@@ -0,0 +1,335 @@
+package observe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// GateLimits are the largest increases over the baseline that still pass the gate. Zero leaves a limit unset.
+// When both the absolute and percent limit of a metric are set, both have to be exceeded to breach it,
+// so a short job doesn't fail the gate over a few seconds.
+type GateLimits struct {
+	Duration        time.Duration `yaml:"duration" json:"duration,omitempty"`
+	DurationPercent float64       `yaml:"duration_percent" json:"duration_percent,omitempty"`
+	// Cost is in USD.
+	Cost        float64 `yaml:"cost" json:"cost,omitempty"`
+	CostPercent float64 `yaml:"cost_percent" json:"cost_percent,omitempty"`
+}
+
+// GateThresholds are the limits every workflow and job is checked against, overridden per workflow and job.
+//
+//	duration_percent: 20
+//	workflows:
+//	  CI:
+//	    duration: 5m
+//	    jobs:
+//	      test:
+//	        duration_percent: 10
+type GateThresholds struct {
+	GateLimits `yaml:",inline"`
+
+	Workflows map[string]WorkflowGateThresholds `yaml:"workflows"`
+}
+
+// WorkflowGateThresholds override the default limits for a workflow and its jobs.
+type WorkflowGateThresholds struct {
+	GateLimits `yaml:",inline"`
+
+	Jobs map[string]GateLimits `yaml:"jobs"`
+}
+
+// GateCheck is one metric of a workflow or job checked against its limits.
+type GateCheck struct {
+	Workflow string `json:"workflow"`
+	// Job is empty when the whole workflow is checked.
+	Job          string `json:"job,omitempty"`
+	Metric       string `json:"metric"` // "duration" or "cost"
+	Baseline     string `json:"baseline"`
+	Candidate    string `json:"candidate"`
+	Delta        string `json:"delta"`
+	DeltaPercent string `json:"delta_percent"`
+	Limit        string `json:"limit"`
+	Breached     bool   `json:"breached"`
+}
+
+// GateResult is the verdict of checking a candidate against a baseline.
+type GateResult struct {
+	Owner     string       `json:"owner"`
+	Repo      string       `json:"repo"`
+	Baseline  *Observation `json:"-"`
+	Candidate *Observation `json:"-"`
+	Checks    []*GateCheck `json:"checks"`
+	Breaches  int          `json:"breaches"`
+}
+
+// Passed reports whether no limit was breached.
+func (g *GateResult) Passed() bool {
+	return g.Breaches == 0
+}
+
+// BreachedChecks returns the checks that breached their limits.
+func (g *GateResult) BreachedChecks() []*GateCheck {
+	var breached []*GateCheck
+	for _, check := range g.Checks {
+		if check.Breached {
+			breached = append(breached, check)
+		}
+	}
+	return breached
+}
+
+// LoadGateThresholds reads gate thresholds from a YAML file.
+func LoadGateThresholds(path string) (*GateThresholds, error) {
+	//nolint:gosec // user specified thresholds file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gate thresholds %q: %w", path, err)
+	}
+	thresholds := &GateThresholds{}
+	if err := yaml.Unmarshal(data, thresholds); err != nil {
+		return nil, fmt.Errorf("failed to parse gate thresholds %q: %w", path, err)
+	}
+	return thresholds, nil
+}
+
+// merge returns l with the limits set in override replacing its own.
+func (l GateLimits) merge(override GateLimits) GateLimits {
+	if override.Duration != 0 {
+		l.Duration = override.Duration
+	}
+	if override.DurationPercent != 0 {
+		l.DurationPercent = override.DurationPercent
+	}
+	if override.Cost != 0 {
+		l.Cost = override.Cost
+	}
+	if override.CostPercent != 0 {
+		l.CostPercent = override.CostPercent
+	}
+	return l
+}
+
+// isSet reports whether any of the limits are set.
+func (l GateLimits) isSet() bool {
+	return l != GateLimits{}
+}
+
+// HasLimits reports whether any limit is set, by default or for a workflow or job.
+// Without one, nothing can be breached and the gate passes whatever the comparison shows.
+func (t *GateThresholds) HasLimits() bool {
+	if t == nil {
+		return false
+	}
+	if t.GateLimits.isSet() {
+		return true
+	}
+	for _, workflow := range t.Workflows {
+		if workflow.GateLimits.isSet() {
+			return true
+		}
+		for _, job := range workflow.Jobs {
+			if job.isSet() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *GateThresholds) workflowLimits(workflow string) GateLimits {
+	return t.GateLimits.merge(t.Workflows[workflow].GateLimits)
+}
+
+func (t *GateThresholds) jobLimits(workflow, job string) GateLimits {
+	return t.workflowLimits(workflow).merge(t.Workflows[workflow].Jobs[job])
+}
+
+// Gate checks a comparison of a baseline (Left) and a candidate (Right) against thresholds.
+// Commit comparisons hold workflow runs, so their jobs are checked through comparisons of each matched pair of runs.
+func Gate(
+	ctx context.Context,
+	log zerolog.Logger,
+	client *gather.GitHubClient,
+	c *Comparison,
+	thresholds *GateThresholds,
+	opts ...Option,
+) (*GateResult, error) {
+	runComparisons := make(map[string]*Comparison)
+	if c.CompareType == "commit" {
+		for _, pair := range c.EventPairs {
+			for _, item := range pair.Items {
+				baselineID, errB := strconv.ParseInt(item.LeftID, 10, 64)
+				candidateID, errC := strconv.ParseInt(item.RightID, 10, 64)
+				if errB != nil || errC != nil {
+					continue
+				}
+				runComparison, err := CompareWorkflowRuns(
+					ctx,
+					log,
+					client,
+					c.Owner,
+					c.Repo,
+					baselineID,
+					candidateID,
+					opts...,
+				)
+				if err != nil {
+					return nil, fmt.Errorf("failed to compare workflow run %s: %w", item.Name, err)
+				}
+				runComparisons[item.RightID] = runComparison
+			}
+		}
+	}
+	return GateComparison(c, thresholds, runComparisons), nil
+}
+
+// GateComparison checks a comparison against thresholds. For commit comparisons, runComparisons holds the
+// comparisons of matched workflow runs keyed by the candidate run's ID, whose jobs are checked as well.
+// Items only in the baseline or the candidate have nothing to be checked against and are skipped.
+func GateComparison(c *Comparison, thresholds *GateThresholds, runComparisons map[string]*Comparison) *GateResult {
+	if thresholds == nil {
+		thresholds = &GateThresholds{}
+	}
+	result := &GateResult{Owner: c.Owner, Repo: c.Repo, Baseline: c.Left, Candidate: c.Right}
+
+	switch c.CompareType {
+	case "commit":
+		for _, pair := range c.EventPairs {
+			for _, item := range pair.Items {
+				limits := thresholds.workflowLimits(item.Name)
+				result.add(gateChecks(item.Name, "", limits,
+					item.LeftDuration, item.RightDuration, item.LeftCost, item.RightCost)...)
+				if runComparison, ok := runComparisons[item.RightID]; ok {
+					result.addJobs(item.Name, runComparison, thresholds)
+				}
+			}
+		}
+	default:
+		workflow := c.Right.Name
+		result.add(gateChecks(workflow, "", thresholds.workflowLimits(workflow),
+			c.Summary.LeftDuration, c.Summary.RightDuration, c.Summary.LeftCost, c.Summary.RightCost)...)
+		result.addJobs(workflow, c, thresholds)
+	}
+	return result
+}
+
+// addJobs checks the jobs of a workflow run comparison.
+func (g *GateResult) addJobs(workflow string, c *Comparison, thresholds *GateThresholds) {
+	for _, pair := range c.EventPairs {
+		for _, item := range pair.Items {
+			g.add(gateChecks(workflow, item.Name, thresholds.jobLimits(workflow, item.Name),
+				item.LeftDuration, item.RightDuration, item.LeftCost, item.RightCost)...)
+		}
+	}
+}
+
+func (g *GateResult) add(checks ...*GateCheck) {
+	for _, check := range checks {
+		if check.Breached {
+			g.Breaches++
+		}
+		g.Checks = append(g.Checks, check)
+	}
+}
+
+// gateChecks checks the duration and cost of a workflow or job, skipping metrics without limits.
+// Costs are in tenths of a cent.
+func gateChecks(
+	workflow, job string,
+	limits GateLimits,
+	baselineDuration, candidateDuration time.Duration,
+	baselineCost, candidateCost int64,
+) []*GateCheck {
+	var checks []*GateCheck
+	if limits.Duration > 0 || limits.DurationPercent > 0 {
+		delta := candidateDuration - baselineDuration
+		checks = append(checks, &GateCheck{
+			Workflow:     workflow,
+			Job:          job,
+			Metric:       "duration",
+			Baseline:     baselineDuration.String(),
+			Candidate:    candidateDuration.String(),
+			Delta:        formatDelta(delta),
+			DeltaPercent: formatPercentDelta(int64(delta), int64(baselineDuration)),
+			Limit:        gateLimitText("+"+limits.Duration.String(), limits.Duration > 0, limits.DurationPercent),
+			Breached: exceedsGateLimits(
+				float64(delta), float64(baselineDuration), float64(limits.Duration), limits.DurationPercent,
+			),
+		})
+	}
+	if limits.Cost > 0 || limits.CostPercent > 0 {
+		delta := candidateCost - baselineCost
+		checks = append(checks, &GateCheck{
+			Workflow:     workflow,
+			Job:          job,
+			Metric:       "cost",
+			Baseline:     fmt.Sprintf("$%.2f", float64(baselineCost)/1000),
+			Candidate:    fmt.Sprintf("$%.2f", float64(candidateCost)/1000),
+			Delta:        formatCostDelta(delta),
+			DeltaPercent: formatPercentDelta(delta, baselineCost),
+			Limit:        gateLimitText(fmt.Sprintf("+$%.2f", limits.Cost), limits.Cost > 0, limits.CostPercent),
+			Breached: exceedsGateLimits(
+				float64(delta)/1000, float64(baselineCost)/1000, limits.Cost, limits.CostPercent,
+			),
+		})
+	}
+	return checks
+}
+
+// exceedsGateLimits reports whether an increase of delta over base exceeds every limit that is set.
+func exceedsGateLimits(delta, base, limit, percentLimit float64) bool {
+	if delta <= 0 {
+		return false
+	}
+	if limit > 0 && delta <= limit {
+		return false
+	}
+	if percentLimit > 0 && base > 0 && delta/base*100 <= percentLimit {
+		return false
+	}
+	return true
+}
+
+func gateLimitText(absolute string, hasAbsolute bool, percent float64) string {
+	var parts []string
+	if hasAbsolute {
+		parts = append(parts, absolute)
+	}
+	if percent > 0 {
+		parts = append(parts, fmt.Sprintf("+%s%%", strconv.FormatFloat(percent, 'f', -1, 64)))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// RenderString renders the gate verdict as "md" or "json".
+func (g *GateResult) RenderString(outputType string) (string, error) {
+	var buf bytes.Buffer
+	switch outputType {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(g); err != nil {
+			return "", fmt.Errorf("failed to encode gate result to JSON: %w", err)
+		}
+		return buf.String(), nil
+	case "md":
+		if err := mdTemplate.ExecuteTemplate(&buf, "gate_md", g); err != nil {
+			return "", fmt.Errorf("failed to render gate result %s: %w", outputType, err)
+		}
+		res := cleanMarkdown(buf)
+		return res.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported gate result format %q, must be 'md' or 'json'", outputType)
+	}
+}
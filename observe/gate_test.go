@@ -0,0 +1,207 @@
+package observe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gateObservation(id, dataType string, items ...TimelineItem) *Observation {
+	var cost int64
+	for _, item := range items {
+		cost += item.Cost
+	}
+	return &Observation{
+		ID:           id,
+		Name:         "CI",
+		Owner:        "owner",
+		Repo:         "repo",
+		DataType:     dataType,
+		Cost:         cost,
+		GitHubLink:   "https://github.com/owner/repo/actions/runs/" + id,
+		TimelineData: []*Timeline{{Event: "push", Items: items}},
+	}
+}
+
+func gateItem(id, name string, started, duration time.Duration, cost int64) TimelineItem {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return TimelineItem{ID: id, Name: name, StartTime: start.Add(started), Duration: duration, Cost: cost}
+}
+
+func TestGateComparison(t *testing.T) {
+	t.Parallel()
+
+	baseline := gateObservation("1", "workflow_run",
+		gateItem("11", "build", 0, 2*time.Minute, 100),
+		gateItem("12", "test", 2*time.Minute, 10*time.Minute, 1000),
+	)
+	candidate := gateObservation("2", "workflow_run",
+		gateItem("21", "build", 0, 2*time.Minute+30*time.Second, 100),
+		gateItem("22", "test", 150*time.Second, 14*time.Minute, 1400),
+		gateItem("23", "lint", 0, time.Minute, 50),
+	)
+	comparison := buildComparison(baseline, candidate, "owner", "repo", "workflow_run")
+
+	thresholds := &GateThresholds{
+		GateLimits: GateLimits{Duration: time.Minute, DurationPercent: 20},
+		Workflows: map[string]WorkflowGateThresholds{
+			"CI": {Jobs: map[string]GateLimits{"test": {CostPercent: 50}}},
+		},
+	}
+	result := GateComparison(comparison, thresholds, nil)
+	assert.False(t, result.Passed())
+	assert.Equal(t, 2, result.Breaches)
+
+	breached := result.BreachedChecks()
+	require.Len(t, breached, 2)
+	assert.Equal(t, &GateCheck{
+		Workflow:     "CI",
+		Metric:       "duration",
+		Baseline:     "12m0s",
+		Candidate:    "16m30s",
+		Delta:        "+4m30s",
+		DeltaPercent: "+37.5%",
+		Limit:        "+1m0s and +20%",
+		Breached:     true,
+	}, breached[0])
+	assert.Equal(t, "test", breached[1].Job)
+	assert.Equal(t, "duration", breached[1].Metric)
+
+	var build, testCost *GateCheck
+	for _, check := range result.Checks {
+		switch {
+		case check.Job == "build":
+			build = check
+		case check.Job == "test" && check.Metric == "cost":
+			testCost = check
+		}
+	}
+	require.NotNil(t, build)
+	assert.False(t, build.Breached, "+25% but only 30s slower shouldn't breach both limits")
+	require.NotNil(t, testCost)
+	assert.False(t, testCost.Breached, "a 40% cost increase is within the job's limit")
+
+	assert.True(t, GateComparison(comparison, nil, nil).Passed(), "nothing should breach without thresholds")
+}
+
+func TestGateComparisonCommits(t *testing.T) {
+	t.Parallel()
+
+	baseline := gateObservation("aaa", "commit", gateItem("1", "CI", 0, 10*time.Minute, 1000))
+	candidate := gateObservation("bbb", "commit", gateItem("2", "CI", 0, 11*time.Minute, 3000))
+	comparison := buildComparison(baseline, candidate, "owner", "repo", "commit")
+
+	runComparison := buildComparison(
+		gateObservation("1", "workflow_run", gateItem("11", "test", 0, 10*time.Minute, 1000)),
+		gateObservation("2", "workflow_run", gateItem("21", "test", 0, 11*time.Minute, 3000)),
+		"owner", "repo", "workflow_run",
+	)
+	thresholds := &GateThresholds{GateLimits: GateLimits{Cost: 0.01}}
+	result := GateComparison(comparison, thresholds, map[string]*Comparison{"2": runComparison})
+	require.Len(t, result.Checks, 2, "both the workflow run and its job should be checked")
+	assert.Empty(t, result.Checks[0].Job)
+	assert.Equal(t, "test", result.Checks[1].Job)
+	assert.Equal(t, "+$2.00", result.Checks[1].Delta)
+	assert.Equal(t, 2, result.Breaches)
+}
+
+func TestExceedsGateLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                        string
+		delta, base, limit, percent float64
+		want                        bool
+	}{
+		{name: "faster", delta: -10, base: 100, limit: 5, want: false},
+		{name: "over absolute", delta: 10, base: 100, limit: 5, want: true},
+		{name: "at absolute", delta: 5, base: 100, limit: 5, want: false},
+		{name: "over percent", delta: 10, base: 100, percent: 5, want: true},
+		{name: "over absolute under percent", delta: 10, base: 100, limit: 5, percent: 20, want: false},
+		{name: "over both", delta: 30, base: 100, limit: 5, percent: 20, want: true},
+		{name: "new cost", delta: 10, base: 0, percent: 20, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, exceedsGateLimits(tt.delta, tt.base, tt.limit, tt.percent))
+		})
+	}
+}
+
+func TestLoadGateThresholds(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "gate.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+duration_percent: 20
+cost: 0.5
+workflows:
+  CI:
+    duration: 5m
+    jobs:
+      test:
+        duration_percent: 10
+`), 0o600))
+
+	thresholds, err := LoadGateThresholds(path)
+	require.NoError(t, err)
+	assert.Equal(t, GateLimits{DurationPercent: 20, Cost: 0.5}, thresholds.workflowLimits("Lint"))
+	assert.Equal(t,
+		GateLimits{Duration: 5 * time.Minute, DurationPercent: 20, Cost: 0.5}, thresholds.workflowLimits("CI"))
+	assert.Equal(t,
+		GateLimits{Duration: 5 * time.Minute, DurationPercent: 10, Cost: 0.5}, thresholds.jobLimits("CI", "test"))
+
+	assert.True(t, thresholds.HasLimits())
+
+	_, err = LoadGateThresholds(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestGateThresholdsHasLimits(t *testing.T) {
+	t.Parallel()
+
+	var unset *GateThresholds
+	assert.False(t, unset.HasLimits())
+	assert.False(t, (&GateThresholds{}).HasLimits())
+	assert.False(t, (&GateThresholds{Workflows: map[string]WorkflowGateThresholds{
+		"CI": {Jobs: map[string]GateLimits{"test": {}}},
+	}}).HasLimits(), "workflows and jobs without limits don't count")
+	assert.True(t, (&GateThresholds{GateLimits: GateLimits{CostPercent: 5}}).HasLimits())
+	assert.True(t, (&GateThresholds{Workflows: map[string]WorkflowGateThresholds{
+		"CI": {Jobs: map[string]GateLimits{"test": {Duration: time.Minute}}},
+	}}).HasLimits(), "a limit on a single job should count")
+}
+
+func TestGateResultRenderString(t *testing.T) {
+	t.Parallel()
+
+	comparison := buildComparison(
+		gateObservation("1", "workflow_run", gateItem("11", "test", 0, 10*time.Minute, 1000)),
+		gateObservation("2", "workflow_run", gateItem("21", "test", 0, 15*time.Minute, 1000)),
+		"owner", "repo", "workflow_run",
+	)
+	result := GateComparison(comparison, &GateThresholds{GateLimits: GateLimits{DurationPercent: 10}}, nil)
+
+	md, err := result.RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "❌ CI performance gate failed")
+	assert.Contains(t, md, "[CI #2](https://github.com/owner/repo/actions/runs/2) against the baseline [CI #1]")
+	assert.Contains(t, md, "| CI | test | duration | 10m0s | 15m0s | +5m0s | +50.0% | +10% |")
+
+	passed, err := GateComparison(comparison, nil, nil).RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, passed, "✅ CI performance gate passed")
+	assert.Contains(t, passed, "No thresholds apply")
+
+	out, err := result.RenderString("json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"breaches": 2`)
+
+	_, err = result.RenderString("html")
+	require.Error(t, err)
+}
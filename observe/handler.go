@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 
@@ -25,7 +26,7 @@ type gatherJob struct {
 type OnDemandHandler struct {
 	log       zerolog.Logger
 	client    *gather.GitHubClient
-	dataDir   string
+	store     gather.Store
 	outputDir string
 	opts      []Option
 	uiState   *uistate.State
@@ -38,11 +39,11 @@ type OnDemandHandler struct {
 func NewOnDemandHandler(
 	log zerolog.Logger,
 	client *gather.GitHubClient,
-	dataDir string,
+	store gather.Store,
 	outputDir string,
 	opts ...Option,
 ) *OnDemandHandler {
-	st, err := uistate.Load(dataDir)
+	st, err := uistate.Load(store.DataDir())
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to load ui state, starting clean")
 		st, _ = uistate.Load("")
@@ -60,7 +61,7 @@ func NewOnDemandHandler(
 	h := &OnDemandHandler{
 		log:       log,
 		client:    client,
-		dataDir:   dataDir,
+		store:     store,
 		outputDir: outputDir,
 		opts:      handlerOpts,
 		uiState:   st,
@@ -81,7 +82,10 @@ func NewOnDemandHandler(
 	mux.HandleFunc("GET /export-png.js", h.handleStatic)
 	mux.HandleFunc("GET /search.js", h.handleStatic)
 	mux.HandleFunc("GET /tables.js", h.handleStatic)
+	mux.HandleFunc("GET /orgs/{org}", h.handleOrg)
 	mux.HandleFunc("GET /{owner}/{repo}", h.handleRepo)
+	mux.HandleFunc("GET /{owner}/{repo}/trends", h.handleTrends)
+	mux.HandleFunc("GET /{owner}/{repo}/flaky", h.handleFlaky)
 	mux.HandleFunc("GET /{owner}/{repo}/index.html", func(w http.ResponseWriter, r *http.Request) {
 		owner := r.PathValue("owner")
 		repo := r.PathValue("repo")
@@ -136,7 +140,6 @@ func (h *OnDemandHandler) handleEntity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	targetOutFile := filepath.Clean(filepath.Join(h.outputDir, owner, repo, category, filename))
-	sourceJSONFile := h.sourceJSONPath(owner, repo, category, id)
 
 	// Check disk cache hit
 	jobKey := fmt.Sprintf("%s/%s/%s/%s", owner, repo, category, id)
@@ -152,8 +155,8 @@ func (h *OnDemandHandler) handleEntity(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		if !stale {
-			if srcStat, sErr := os.Stat(sourceJSONFile); sErr == nil {
-				if outStat.ModTime().After(srcStat.ModTime()) {
+			if srcModTime, sErr := h.sourceModTime(owner, repo, category, id); sErr == nil {
+				if outStat.ModTime().After(srcModTime) {
 					h.jobsMu.Lock()
 					delete(h.jobs, jobKey)
 					h.jobsMu.Unlock()
@@ -222,16 +225,17 @@ func (h *OnDemandHandler) handleEntity(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *OnDemandHandler) sourceJSONPath(owner, repo, category, id string) string {
+// sourceModTime returns when the gathered data behind an observation was last saved to the store.
+func (h *OnDemandHandler) sourceModTime(owner, repo, category, id string) (time.Time, error) {
 	switch category {
 	case "workflow_runs", "job_runs":
-		return filepath.Join(h.dataDir, owner, repo, gather.WorkflowRunsDataDir, id+".json")
+		return h.store.ModTime(owner, repo, gather.WorkflowRunsDataDir, id)
 	case "commits":
-		return filepath.Join(h.dataDir, owner, repo, gather.CommitsDataDir, id+".json")
+		return h.store.ModTime(owner, repo, gather.CommitsDataDir, id)
 	case "pull_requests":
-		return filepath.Join(h.dataDir, owner, repo, gather.PullRequestsDataDir, id+".json")
+		return h.store.ModTime(owner, repo, gather.PullRequestsDataDir, id)
 	default:
-		return filepath.Join(h.dataDir, owner, repo, category, id+".json")
+		return h.store.ModTime(owner, repo, category, id)
 	}
 }
 
@@ -241,7 +245,7 @@ func (h *OnDemandHandler) renderEntity(ctx context.Context, owner, repo, categor
 	allOpts = append(allOpts, h.opts...)
 	allOpts = append(allOpts,
 		WithGatherOptions(
-			gather.CustomDataFolder(h.dataDir),
+			gather.WithStore(h.store),
 			gather.WithProgressReporter(&gather.NoopProgressReporter{}),
 		),
 		WithProgressReporter(&gather.NoopProgressReporter{}),
@@ -255,7 +259,7 @@ func (h *OnDemandHandler) renderEntity(ctx context.Context, owner, repo, categor
 			if err != nil {
 				return fmt.Errorf("invalid job run ID '%s': %w", id, err)
 			}
-			workflowRunID, err = gather.FindWorkflowRunIDForJob(h.dataDir, owner, repo, jobID)
+			workflowRunID, err = gather.FindWorkflowRunIDForJob(h.store, owner, repo, jobID)
 			if err != nil {
 				return fmt.Errorf("failed to find parent workflow run for job '%s': %w", id, err)
 			}
@@ -273,7 +277,7 @@ func (h *OnDemandHandler) renderEntity(ctx context.Context, owner, repo, categor
 			owner,
 			repo,
 			workflowRunID,
-			gather.CustomDataFolder(h.dataDir),
+			gather.WithStore(h.store),
 			gather.SkipMemoryCache(),
 		)
 		if err != nil {
@@ -333,8 +337,8 @@ func (h *OnDemandHandler) renderEntity(ctx context.Context, owner, repo, categor
 		if errL == nil && errR == nil {
 			comp, err = CompareWorkflowRuns(ctx, h.log, h.client, owner, repo, leftNum, rightNum, allOpts...)
 			if err != nil {
-				leftWfID, errWfL := gather.FindWorkflowRunIDForJob(h.dataDir, owner, repo, leftNum)
-				rightWfID, errWfR := gather.FindWorkflowRunIDForJob(h.dataDir, owner, repo, rightNum)
+				leftWfID, errWfL := gather.FindWorkflowRunIDForJob(h.store, owner, repo, leftNum)
+				rightWfID, errWfR := gather.FindWorkflowRunIDForJob(h.store, owner, repo, rightNum)
 				if errWfL == nil && errWfR == nil {
 					comp, err = CompareJobRuns(
 						ctx,
@@ -0,0 +1,178 @@
+package observe
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// timeoutWarningPercent is how much of its timeout a job can use before it's flagged as close to timing out.
+const timeoutWarningPercent = 80
+
+// containerSteps are the steps GitHub adds to jobs with a container or services to start and stop them.
+var containerSteps = []string{"Initialize containers", "Stop containers"}
+
+// statusCheckFunction matches the status check functions that stop GitHub from implicitly adding success()
+// to a condition.
+var statusCheckFunction = regexp.MustCompile(`\b(always|success|failure|cancelled)\(\s*\)`)
+
+// JobDefinition is what the workflow definition says about a job run, along with findings from comparing the run
+// against it.
+type JobDefinition struct {
+	// ID is the job's key in the workflow, like "build", or "e2e/smoke" for a job of a called reusable workflow.
+	ID string `json:"id"`
+	// Condition is the job's if condition.
+	Condition string `json:"condition,omitempty"`
+	// Timeout is how long the job may run, zero when timeout-minutes is an expression.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// TimeoutUsed is how much of Timeout the run took, in percent.
+	TimeoutUsed     float64 `json:"timeout_used,omitempty"`
+	ContinueOnError string  `json:"continue_on_error,omitempty"`
+	Environment     string  `json:"environment,omitempty"`
+	// ConcurrencyGroup is the job's concurrency group, or the workflow's when the job doesn't set one.
+	ConcurrencyGroup string `json:"concurrency_group,omitempty"`
+	CancelInProgress bool   `json:"cancel_in_progress,omitempty"`
+	// Container is the image the job's steps run in, and Services the service containers it starts,
+	// as "name (image)".
+	Container string   `json:"container,omitempty"`
+	Services  []string `json:"services,omitempty"`
+	// ContainerStartup is how long the job spent starting and stopping its container and services, and
+	// ContainerCost that time's share of the job's cost, in tenths of a cent.
+	ContainerStartup      time.Duration `json:"container_startup,omitempty"`
+	ContainerCost         int64         `json:"container_cost,omitempty"`
+	ContainerCostEstimate bool          `json:"container_cost_estimate,omitempty"`
+	// Findings explain how the run relates to its definition, like why it was skipped or how close it came to
+	// timing out.
+	Findings []string `json:"findings,omitempty"`
+}
+
+// buildJobDefinition finds the definition of a job run in the workflow, including jobs of called reusable
+// workflows and matrix legs. Returns nil when the workflow or the job's definition isn't known.
+func buildJobDefinition(def *gather.WorkflowDef, job *gather.JobData) *JobDefinition {
+	expanded := def.Expanded()
+	if expanded == nil || job == nil || job.WorkflowJob == nil {
+		return nil
+	}
+	id, ok := expanded.GetJobIDByName(job.GetName())
+	if !ok {
+		return nil
+	}
+	jobDef := expanded.Jobs[id]
+
+	definition := &JobDefinition{
+		ID:              id,
+		Condition:       jobDef.Condition(),
+		ContinueOnError: strings.TrimSpace(jobDef.ContinueOnError),
+	}
+	if jobDef.Environment != nil {
+		definition.Environment = jobDef.Environment.Name
+	}
+	concurrency := jobDef.Concurrency
+	if concurrency == nil {
+		concurrency = expanded.Concurrency
+	}
+	if concurrency != nil {
+		definition.ConcurrencyGroup = concurrency.Group
+		definition.CancelInProgress = concurrency.Cancels()
+	}
+	if jobDef.Container != nil {
+		definition.Container = jobDef.Container.Image
+	}
+	for name, service := range jobDef.Services {
+		definition.Services = append(definition.Services, fmt.Sprintf("%s (%s)", name, service.Image))
+	}
+	slices.Sort(definition.Services)
+
+	var duration time.Duration
+	if !job.GetStartedAt().IsZero() && !job.GetCompletedAt().IsZero() {
+		duration = max(job.GetCompletedAt().Sub(job.GetStartedAt().Time), 0)
+	}
+	if timeout, known := jobDef.Timeout(); known {
+		definition.Timeout = timeout
+		definition.TimeoutUsed = float64(duration) / float64(timeout) * 100
+	}
+	definition.attributeContainerCost(job, duration)
+	definition.Findings = jobDefinitionFindings(definition, jobDef, job, duration)
+	return definition
+}
+
+// attributeContainerCost sums up the container steps of a job run and gives them their share of its cost.
+func (d *JobDefinition) attributeContainerCost(job *gather.JobData, duration time.Duration) {
+	for _, step := range job.Steps {
+		if step == nil || !slices.Contains(containerSteps, step.GetName()) ||
+			step.GetStartedAt().IsZero() || step.GetCompletedAt().IsZero() {
+			continue
+		}
+		d.ContainerStartup += max(step.GetCompletedAt().Sub(step.GetStartedAt().Time), 0)
+	}
+	if d.ContainerStartup > 0 && duration > 0 && job.GetCostGathered() {
+		d.ContainerCost = int64(float64(job.GetCost()) * min(float64(d.ContainerStartup)/float64(duration), 1))
+		d.ContainerCostEstimate = true
+	}
+}
+
+func jobDefinitionFindings(
+	definition *JobDefinition,
+	jobDef gather.JobDef,
+	job *gather.JobData,
+	duration time.Duration,
+) []string {
+	var findings []string
+	// GitHub stops a job at its timeout, give or take the time it took to cancel it
+	timedOut := definition.Timeout > 0 && job.GetConclusion() != "success" && duration >= definition.Timeout*99/100
+	switch {
+	case timedOut:
+		findings = append(findings, fmt.Sprintf("Ran for %s, hitting its %s timeout", duration.Round(time.Second),
+			definition.Timeout))
+	case definition.TimeoutUsed >= timeoutWarningPercent:
+		findings = append(findings, fmt.Sprintf("Ran for %s, %.0f%% of its %s timeout", duration.Round(time.Second),
+			definition.TimeoutUsed, definition.Timeout))
+	}
+
+	switch job.GetConclusion() {
+	case "skipped":
+		if reason := skippedReason(definition.Condition, len(jobDef.Needs) > 0); reason != "" {
+			findings = append(findings, reason)
+		}
+	case "cancelled":
+		if !timedOut && definition.CancelInProgress {
+			findings = append(findings, fmt.Sprintf(
+				"Cancelled, possibly by a newer run in concurrency group %q, which cancels runs in progress",
+				definition.ConcurrencyGroup,
+			))
+		}
+	case "failure":
+		if definition.ContinueOnError != "" && definition.ContinueOnError != "false" {
+			findings = append(findings, "Failed, but continue-on-error let the workflow run carry on")
+		}
+	}
+
+	if definition.ContainerStartup > 0 {
+		finding := fmt.Sprintf("Spent %s starting and stopping its containers",
+			definition.ContainerStartup.Round(time.Second))
+		if job.GetCostGathered() {
+			finding += fmt.Sprintf(", about $%.2f of its cost", float64(definition.ContainerCost)/1000)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// skippedReason explains why GitHub skipped a job. Conditions without a status check function like always()
+// only run once every needed job succeeded.
+func skippedReason(condition string, hasNeeds bool) string {
+	switch {
+	case condition != "" && hasNeeds && !statusCheckFunction.MatchString(condition):
+		return fmt.Sprintf("Skipped because its condition %q was false, or a job it needs didn't succeed", condition)
+	case condition != "":
+		return fmt.Sprintf("Skipped because its condition %q was false", condition)
+	case hasNeeds:
+		return "Skipped because a job it needs didn't succeed"
+	default:
+		return ""
+	}
+}
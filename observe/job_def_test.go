@@ -0,0 +1,188 @@
+package observe
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+func jobDefinitionTestDef() *gather.WorkflowDef {
+	return &gather.WorkflowDef{
+		Concurrency: &gather.Concurrency{Group: "ci-${{ github.ref }}", CancelInProgress: "true"},
+		Jobs: map[string]gather.JobDef{
+			"build": {Name: "build", TimeoutMinutes: "10"},
+			"test": {
+				Name:            "test",
+				Needs:           gather.Needs{"build"},
+				ContinueOnError: "true",
+				Container:       &gather.Container{Image: "golang:1.24"},
+				Services:        map[string]gather.Container{"postgres": {Image: "postgres:16"}},
+			},
+			"deploy": {
+				Name:        "deploy",
+				Needs:       gather.Needs{"test"},
+				If:          "${{ github.ref == 'refs/heads/main' }}",
+				Environment: &gather.Environment{Name: "production"},
+				Concurrency: &gather.Concurrency{Group: "deploy"},
+			},
+			"notify": {Name: "notify", Needs: gather.Needs{"deploy"}, If: "always() && github.event_name == 'push'"},
+		},
+	}
+}
+
+func TestBuildJobDefinition(t *testing.T) {
+	t.Parallel()
+
+	step := func(name string, start, end time.Duration) *github.TaskStep {
+		return &github.TaskStep{
+			Name:        new(name),
+			Conclusion:  new("success"),
+			StartedAt:   &github.Timestamp{Time: testStartTime.Add(start)},
+			CompletedAt: &github.Timestamp{Time: testStartTime.Add(end)},
+		}
+	}
+	job := func(name, conclusion string, duration time.Duration, steps ...*github.TaskStep) *gather.JobData {
+		return &gather.JobData{
+			WorkflowJob: &github.WorkflowJob{
+				ID:          new(int64(1)),
+				Name:        new(name),
+				Status:      new("completed"),
+				Conclusion:  new(conclusion),
+				StartedAt:   &github.Timestamp{Time: testStartTime},
+				CompletedAt: &github.Timestamp{Time: testStartTime.Add(duration)},
+				Steps:       steps,
+			},
+			Cost:         100,
+			CostGathered: true,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		job          *gather.JobData
+		wantFindings []string
+		check        func(t *testing.T, definition *JobDefinition)
+	}{
+		{
+			name:         "close to its timeout",
+			job:          job("build", "success", 9*time.Minute),
+			wantFindings: []string{"Ran for 9m0s, 90% of its 10m0s timeout"},
+			check: func(t *testing.T, definition *JobDefinition) {
+				assert.Equal(t, 10*time.Minute, definition.Timeout)
+				assert.InDelta(t, 90, definition.TimeoutUsed, 0.01)
+				assert.Equal(t, "ci-${{ github.ref }}", definition.ConcurrencyGroup, "jobs should fall back to the "+
+					"workflow's concurrency group")
+			},
+		},
+		{
+			name:         "timed out",
+			job:          job("build", "cancelled", 10*time.Minute),
+			wantFindings: []string{"Ran for 10m0s, hitting its 10m0s timeout"},
+		},
+		{
+			name: "cancelled by its concurrency group",
+			job:  job("build", "cancelled", time.Minute),
+			wantFindings: []string{
+				`Cancelled, possibly by a newer run in concurrency group "ci-${{ github.ref }}", ` +
+					"which cancels runs in progress",
+			},
+		},
+		{
+			name: "container startup and continue-on-error",
+			job: job("test", "failure", 10*time.Minute,
+				step("Set up job", 0, time.Minute),
+				step("Initialize containers", time.Minute, 3*time.Minute),
+				step("go test", 3*time.Minute, 9*time.Minute),
+				step("Stop containers", 9*time.Minute, 10*time.Minute),
+			),
+			wantFindings: []string{
+				"Failed, but continue-on-error let the workflow run carry on",
+				"Spent 3m0s starting and stopping its containers, about $0.03 of its cost",
+			},
+			check: func(t *testing.T, definition *JobDefinition) {
+				assert.Equal(t, "golang:1.24", definition.Container)
+				assert.Equal(t, []string{"postgres (postgres:16)"}, definition.Services)
+				assert.Equal(t, 3*time.Minute, definition.ContainerStartup)
+				assert.Equal(t, int64(30), definition.ContainerCost)
+			},
+		},
+		{
+			name: "skipped by its condition or needs",
+			job:  job("deploy", "skipped", 0),
+			wantFindings: []string{
+				`Skipped because its condition "github.ref == 'refs/heads/main'" was false, or a job it needs didn't ` +
+					"succeed",
+			},
+			check: func(t *testing.T, definition *JobDefinition) {
+				assert.Equal(t, "production", definition.Environment)
+				assert.Equal(t, "deploy", definition.ConcurrencyGroup)
+				assert.False(t, definition.CancelInProgress)
+			},
+		},
+		{
+			name:         "skipped by a condition with a status check",
+			job:          job("notify", "skipped", 0),
+			wantFindings: []string{`Skipped because its condition "always() && github.event_name == 'push'" was false`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			definition := buildJobDefinition(jobDefinitionTestDef(), tt.job)
+			require.NotNil(t, definition)
+			assert.Equal(t, tt.job.GetName(), definition.ID)
+			assert.Equal(t, tt.wantFindings, definition.Findings)
+			if tt.check != nil {
+				tt.check(t, definition)
+			}
+		})
+	}
+
+	assert.Nil(t, buildJobDefinition(nil, job("build", "success", time.Minute)))
+	assert.Nil(t, buildJobDefinition(jobDefinitionTestDef(), job("unknown", "success", time.Minute)))
+}
+
+func TestJobRunObservations_JobDefinition(t *testing.T) {
+	t.Parallel()
+
+	run := &gather.WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:    new(int64(42)),
+			Name:  new("CI"),
+			Event: new("push"),
+			Repository: &github.Repository{
+				Name:  new("repo"),
+				Owner: &github.User{Login: new("owner")},
+			},
+		},
+		Jobs: []*gather.JobData{{WorkflowJob: &github.WorkflowJob{
+			ID:         new(int64(7)),
+			Name:       new("deploy"),
+			Status:     new("completed"),
+			Conclusion: new("skipped"),
+		}}},
+		WorkflowDef: jobDefinitionTestDef(),
+	}
+
+	observations, err := jobRunObservations(run)
+	require.NoError(t, err)
+	require.Len(t, observations, 1)
+	require.NotNil(t, observations[0].JobDefinition)
+
+	var md bytes.Buffer
+	require.NoError(t, mdTemplate.ExecuteTemplate(&md, "observation_md", observations[0]))
+	assert.Contains(t, md.String(), "| **If** | `github.ref == 'refs/heads/main'` |")
+	assert.Contains(t, md.String(), "| **Environment** | production |")
+	assert.Contains(t, md.String(), "> **Definition:** Skipped because its condition")
+
+	var html bytes.Buffer
+	require.NoError(t, htmlTemplate.ExecuteTemplate(&html, "observation_html", observations[0]))
+	assert.Contains(t, html.String(), `<span class="badge-label">Environment</span> production`)
+}
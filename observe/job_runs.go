@@ -57,7 +57,7 @@ func jobRunObservations(workflowRun *gather.WorkflowRunData) ([]*Observation, er
 			}
 			jobRunTemplateData.Event = workflowRun.GetEvent()
 			winStart, winEnd, _ := jobMonitoringTimeWindow(job)
-			jobRunMonitoringData, err := monitoring(job.Analysis, winStart, winEnd)
+			jobRunMonitoringData, err := monitoring(job.Analysis, job.Steps, winStart, winEnd)
 			if err != nil {
 				return fmt.Errorf("failed to build monitoring data for job '%d': %w", job.GetID(), err)
 			}
@@ -70,7 +70,7 @@ func jobRunObservations(workflowRun *gather.WorkflowRunData) ([]*Observation, er
 			observationsChan <- &Observation{
 				ID:             fmt.Sprint(job.GetID()),
 				Name:           job.GetName(),
-				GitHubLink:     job.GetHTMLURL(),
+				GitHubLink:     gitHubJobLink(workflowRun, job),
 				TimelineData:   []*Timeline{jobRunTemplateData},
 				Owner:          owner,
 				Repo:           repo,
@@ -82,6 +82,7 @@ func jobRunObservations(workflowRun *gather.WorkflowRunData) ([]*Observation, er
 				Cost:           job.GetCost(),
 				CostEstimate:   job.GetCostEstimate(),
 				CostGathered:   job.GetCostGathered(),
+				JobDefinition:  buildJobDefinition(workflowRun.GetWorkflowDef(), job),
 			}
 			return nil
 		})
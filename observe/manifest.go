@@ -12,16 +12,16 @@ import (
 type ManifestRecord = gather.ManifestRecord
 
 // AppendManifestRecord delegates to gather.AppendManifestRecord.
-func AppendManifestRecord(dataDir, owner, repo string, rec ManifestRecord) error {
-	return gather.AppendManifestRecord(dataDir, owner, repo, rec)
+func AppendManifestRecord(store gather.Store, owner, repo string, rec ManifestRecord) error {
+	return gather.AppendManifestRecord(store, owner, repo, rec)
 }
 
 // LoadManifest delegates to gather.LoadManifest.
-func LoadManifest(dataDir, owner, repo string) ([]ManifestRecord, error) {
-	return gather.LoadManifest(dataDir, owner, repo)
+func LoadManifest(store gather.Store, owner, repo string) ([]ManifestRecord, error) {
+	return gather.LoadManifest(store, owner, repo)
 }
 
 // RebuildManifest delegates to gather.RebuildManifest.
-func RebuildManifest(ctx context.Context, log zerolog.Logger, dataDir string) error {
-	return gather.RebuildManifest(ctx, log, dataDir)
+func RebuildManifest(ctx context.Context, log zerolog.Logger, store gather.Store) error {
+	return gather.RebuildManifest(ctx, log, store)
 }
@@ -0,0 +1,159 @@
+package observe
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// MatrixJob shows how the legs of a matrix job in a workflow run compare, so a single slow or expensive leg stands
+// out from the rest.
+type MatrixJob struct {
+	// Name is the job's name in the workflow, without matrix values.
+	Name        string `json:"name"`
+	FailFast    bool   `json:"fail_fast"`
+	MaxParallel int    `json:"max_parallel,omitempty"`
+	// Legs are the legs that ran, slowest first.
+	Legs []*MatrixLeg `json:"legs"`
+	// Expected is how many legs the workflow expands the matrix to, zero when it's only known at runtime.
+	Expected int `json:"expected,omitempty"`
+	// NotRun are the expected legs without a job in the run, like legs fail-fast cancelled before they started.
+	NotRun []string `json:"not_run,omitempty"`
+
+	MinDuration    time.Duration `json:"min_duration"`
+	MedianDuration time.Duration `json:"median_duration"`
+	MaxDuration    time.Duration `json:"max_duration"`
+	// Spread is how much longer the slowest leg took than the fastest.
+	Spread time.Duration `json:"spread"`
+
+	// Cost, MinCost and MaxCost are in tenths of a cent, only of legs where cost data was gathered.
+	Cost         int64 `json:"cost,omitempty"`
+	MinCost      int64 `json:"min_cost,omitempty"`
+	MaxCost      int64 `json:"max_cost,omitempty"`
+	CostEstimate bool  `json:"cost_estimate,omitempty"`
+	CostGathered bool  `json:"cost_gathered,omitempty"`
+}
+
+// MatrixLeg is a leg of a matrix job in a workflow run.
+type MatrixLeg struct {
+	Name         string        `json:"name"`
+	JobID        int64         `json:"job_id"`
+	Conclusion   string        `json:"conclusion,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	Cost         int64         `json:"cost,omitempty"`
+	CostEstimate bool          `json:"cost_estimate,omitempty"`
+	CostGathered bool          `json:"cost_gathered,omitempty"`
+}
+
+// Slowest returns the leg that took the longest.
+func (m *MatrixJob) Slowest() *MatrixLeg {
+	if m == nil || len(m.Legs) == 0 {
+		return nil
+	}
+	return m.Legs[0]
+}
+
+// BuildMatrixJobs matches the jobs of a workflow run to the matrix jobs of its definition, including those of
+// called reusable workflows, and summarizes how their legs compare. Only the latest attempt of a leg counts,
+// and matrix jobs without a leg that started are left out. Returns nil without a definition.
+func BuildMatrixJobs(def *gather.WorkflowDef, jobs []*gather.JobData) []*MatrixJob {
+	expanded := def.Expanded()
+	if expanded == nil {
+		return nil
+	}
+
+	// Latest attempt of every job that ran, by name
+	latest := make(map[string]*gather.JobData, len(jobs))
+	for _, job := range jobs {
+		if job == nil || job.WorkflowJob == nil || job.GetStartedAt().IsZero() || job.GetConclusion() == "skipped" {
+			continue
+		}
+		if existing, ok := latest[job.GetName()]; !ok || job.GetRunAttempt() > existing.GetRunAttempt() {
+			latest[job.GetName()] = job
+		}
+	}
+
+	var matrices []*MatrixJob
+	for _, jobDef := range expanded.Jobs {
+		if !jobDef.IsMatrix() {
+			continue
+		}
+		legNames := jobDef.MatrixLegNames()
+		matrix := &MatrixJob{
+			Name:        jobDef.Name,
+			FailFast:    jobDef.Strategy.GetFailFast(),
+			MaxParallel: jobDef.Strategy.MaxParallel,
+			Expected:    len(legNames),
+		}
+		for name, job := range latest {
+			if jobDef.IsMatrixLeg(name) {
+				matrix.Legs = append(matrix.Legs, matrixLeg(job))
+			}
+		}
+		if len(matrix.Legs) == 0 {
+			continue
+		}
+		for _, name := range legNames {
+			if _, ran := latest[name]; !ran {
+				matrix.NotRun = append(matrix.NotRun, name)
+			}
+		}
+		summarizeMatrixLegs(matrix)
+		matrices = append(matrices, matrix)
+	}
+	slices.SortFunc(matrices, func(a, b *MatrixJob) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return matrices
+}
+
+func matrixLeg(job *gather.JobData) *MatrixLeg {
+	leg := &MatrixLeg{
+		Name:       job.GetName(),
+		JobID:      job.GetID(),
+		Conclusion: job.GetConclusion(),
+	}
+	if !job.GetCompletedAt().IsZero() {
+		leg.Duration = max(job.GetCompletedAt().Sub(job.GetStartedAt().Time), 0)
+	}
+	if job.GetCostGathered() {
+		leg.Cost = job.GetCost()
+		leg.CostEstimate = job.GetCostEstimate()
+		leg.CostGathered = true
+	}
+	return leg
+}
+
+// summarizeMatrixLegs sorts the legs slowest first and fills in their duration and cost spread.
+func summarizeMatrixLegs(matrix *MatrixJob) {
+	slices.SortFunc(matrix.Legs, func(a, b *MatrixLeg) int {
+		return cmp.Or(cmp.Compare(b.Duration, a.Duration), strings.Compare(a.Name, b.Name))
+	})
+
+	legs := matrix.Legs
+	matrix.MaxDuration = legs[0].Duration
+	matrix.MinDuration = legs[len(legs)-1].Duration
+	matrix.MedianDuration = legs[len(legs)/2].Duration
+	if len(legs)%2 == 0 {
+		matrix.MedianDuration = (legs[len(legs)/2-1].Duration + legs[len(legs)/2].Duration) / 2
+	}
+	matrix.Spread = matrix.MaxDuration - matrix.MinDuration
+
+	for _, leg := range legs {
+		if !leg.CostGathered {
+			continue
+		}
+		if !matrix.CostGathered || leg.Cost < matrix.MinCost {
+			matrix.MinCost = leg.Cost
+		}
+		if !matrix.CostGathered || leg.Cost > matrix.MaxCost {
+			matrix.MaxCost = leg.Cost
+		}
+		matrix.Cost += leg.Cost
+		matrix.CostEstimate = matrix.CostEstimate || leg.CostEstimate
+		matrix.CostGathered = true
+	}
+}
@@ -0,0 +1,128 @@
+package observe
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+func matrixTestRun() *gather.WorkflowRunData {
+	job := func(id int64, name, conclusion string, attempt int64, duration time.Duration, cost int64) *gather.JobData {
+		return &gather.JobData{
+			WorkflowJob: &github.WorkflowJob{
+				ID:          new(id),
+				Name:        new(name),
+				Status:      new("completed"),
+				Conclusion:  new(conclusion),
+				RunAttempt:  new(attempt),
+				StartedAt:   &github.Timestamp{Time: testStartTime},
+				CompletedAt: &github.Timestamp{Time: testStartTime.Add(duration)},
+			},
+			Cost:         cost,
+			CostGathered: true,
+		}
+	}
+	return &gather.WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:           new(int64(42)),
+			Name:         new("CI"),
+			Event:        new("push"),
+			RunStartedAt: &github.Timestamp{Time: testStartTime},
+			Repository: &github.Repository{
+				Name:  new("repo"),
+				Owner: &github.User{Login: new("owner")},
+			},
+		},
+		Jobs: []*gather.JobData{
+			job(1, "build", "success", 1, time.Minute, 8),
+			job(2, "test (ubuntu-latest, 1.22)", "success", 1, 2*time.Minute, 16),
+			job(3, "test (ubuntu-latest, 1.23)", "failure", 1, 3*time.Minute, 24),
+			job(4, "test (ubuntu-latest, 1.23)", "success", 2, 9*time.Minute, 72),
+			job(5, "shards (1)", "success", 1, 4*time.Minute, 32),
+			job(6, "shards (2)", "success", 1, 6*time.Minute, 48),
+		},
+		WorkflowDef: &gather.WorkflowDef{
+			Jobs: map[string]gather.JobDef{
+				"build": {Name: "build"},
+				"test": {
+					Name:  "test",
+					Needs: gather.Needs{"build"},
+					Strategy: &gather.Strategy{
+						FailFast:    new(false),
+						MaxParallel: 2,
+						Matrix: &gather.Matrix{Dimensions: []gather.MatrixDimension{
+							{Name: "os", Values: []any{"ubuntu-latest", "windows-latest"}},
+							{Name: "go", Values: []any{"1.22", "1.23"}},
+						}},
+					},
+				},
+				"shards": {
+					Name:     "shards",
+					Needs:    gather.Needs{"build"},
+					Strategy: &gather.Strategy{Matrix: &gather.Matrix{Expression: "${{ fromJSON(inputs.shards) }}"}},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildMatrixJobs(t *testing.T) {
+	t.Parallel()
+
+	run := matrixTestRun()
+	matrices := BuildMatrixJobs(run.GetWorkflowDef(), run.GetJobs())
+	require.Len(t, matrices, 2)
+
+	shards, test := matrices[0], matrices[1]
+	assert.Equal(t, "shards", shards.Name)
+	assert.True(t, shards.FailFast)
+	assert.Zero(t, shards.Expected, "legs of a runtime matrix aren't known ahead of time")
+	assert.Empty(t, shards.NotRun)
+	assert.Equal(t, 5*time.Minute, shards.MedianDuration)
+	assert.Equal(t, 2*time.Minute, shards.Spread)
+
+	assert.Equal(t, "test", test.Name)
+	assert.False(t, test.FailFast)
+	assert.Equal(t, 2, test.MaxParallel)
+	assert.Equal(t, 4, test.Expected)
+	require.Len(t, test.Legs, 2, "only the latest attempt of a leg should count")
+	assert.Equal(t, []string{"test (windows-latest, 1.22)", "test (windows-latest, 1.23)"}, test.NotRun)
+	assert.Equal(t, "test (ubuntu-latest, 1.23)", test.Slowest().Name)
+	assert.Equal(t, int64(4), test.Slowest().JobID)
+	assert.Equal(t, 2*time.Minute, test.MinDuration)
+	assert.Equal(t, 9*time.Minute, test.MaxDuration)
+	assert.Equal(t, 7*time.Minute, test.Spread)
+	assert.True(t, test.CostGathered)
+	assert.Equal(t, int64(88), test.Cost)
+	assert.Equal(t, int64(16), test.MinCost)
+	assert.Equal(t, int64(72), test.MaxCost)
+
+	assert.Nil(t, BuildMatrixJobs(nil, run.GetJobs()))
+}
+
+func TestWorkflowRunObservation_Matrices(t *testing.T) {
+	t.Parallel()
+
+	obs, err := workflowRunObservation(matrixTestRun())
+	require.NoError(t, err)
+	require.Len(t, obs.Matrices, 2)
+	require.Len(t, obs.TimelineData, 1)
+
+	var md bytes.Buffer
+	require.NoError(t, mdTemplate.ExecuteTemplate(&md, "timeline_md", obs.TimelineData[0]))
+	assert.Contains(t, md.String(), "### Matrix Legs")
+	assert.Contains(t, md.String(), "#### test (2 of 4 legs)")
+	assert.Contains(t, md.String(), "Slowest leg: `test (ubuntu-latest, 1.23)`")
+	assert.Contains(t, md.String(), "Not run: `test (windows-latest, 1.22)`, `test (windows-latest, 1.23)`")
+
+	var html bytes.Buffer
+	require.NoError(t, htmlTemplate.ExecuteTemplate(&html, "timeline_html", obs.TimelineData[0]))
+	assert.Contains(t, html.String(), "Matrix legs (2 matrix jobs)")
+	assert.Contains(t, html.String(), `href="/owner/repo/job_runs/4.html"`)
+}
@@ -3,6 +3,8 @@ package observe
 import (
 	"time"
 
+	"github.com/google/go-github/v89/github"
+
 	"github.com/kalverra/octometrics/monitor"
 	"github.com/kalverra/octometrics/report"
 )
@@ -10,9 +12,17 @@ import (
 // Monitoring contains performance monitoring charts.
 type Monitoring struct {
 	Charts []report.MonitoringChart
+	// TopProcesses are the heaviest processes, only present when the monitor sampled processes.
+	TopProcesses []*report.ProcessSummary
+	// Steps is the resource usage of each job step, from step markers or the job's step timing.
+	Steps []*report.StepResourceUsage
 }
 
-func monitoring(analysis *monitor.Analysis, windowStart, windowEnd time.Time) (*Monitoring, error) {
+func monitoring(
+	analysis *monitor.Analysis,
+	steps []*github.TaskStep,
+	windowStart, windowEnd time.Time,
+) (*Monitoring, error) {
 	if analysis == nil {
 		return nil, nil
 	}
@@ -26,5 +36,9 @@ func monitoring(analysis *monitor.Analysis, windowStart, windowEnd time.Time) (*
 	if len(charts) == 0 {
 		return nil, nil
 	}
-	return &Monitoring{Charts: charts}, nil
+	return &Monitoring{
+		Charts:       charts,
+		TopProcesses: report.TopProcesses(analysis, report.DefaultTopProcesses),
+		Steps:        report.StepResources(analysis, steps),
+	}, nil
 }
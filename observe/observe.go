@@ -28,7 +28,6 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/kalverra/octometrics/gather"
-	"github.com/kalverra/octometrics/internal/config"
 )
 
 //go:embed templates/*.html templates/*.md templates/*.css templates/*.js
@@ -379,6 +378,13 @@ func WithGatherOptions(opts ...gather.Option) Option {
 	}
 }
 
+// withStore adds store to the gather options, keeping the others.
+func withStore(store gather.Store) Option {
+	return func(o *options) {
+		o.gatherOptions = append(slices.Clip(o.gatherOptions), gather.WithStore(store))
+	}
+}
+
 // ExcludeWorkflows sets workflow display names to omit from observations.
 func ExcludeWorkflows(names []string) Option {
 	return func(o *options) {
@@ -446,6 +452,10 @@ type Observation struct {
 	CriticalPath    *CriticalPathInfo  `json:"critical_path,omitempty"`
 	StepSummaries   []StepSummary      `json:"step_summaries,omitempty"`
 	SlowestJobSteps []JobStepBreakdown `json:"slowest_job_steps,omitempty"`
+	Matrices        []*MatrixJob       `json:"matrices,omitempty"`
+
+	// JobDefinition is what the workflow definition says about a job run
+	JobDefinition *JobDefinition `json:"job_definition,omitempty"`
 }
 
 // Render writes the observation to a file in the specified output format (html, md, or json).
@@ -583,7 +593,8 @@ func Interactive(
 	ctx context.Context,
 	log zerolog.Logger,
 	client *gather.GitHubClient,
-	initialPath, dataDir string,
+	initialPath string,
+	store gather.Store,
 	opts ...Option,
 ) error {
 	startTime := time.Now()
@@ -600,7 +611,7 @@ func Interactive(
 		return fmt.Errorf("failed to write static assets: %w", err)
 	}
 
-	handler := NewOnDemandHandler(log, client, dataDir, activeHTMLOutputDir, opts...)
+	handler := NewOnDemandHandler(log, client, store, activeHTMLOutputDir, opts...)
 
 	if initialPath != "" {
 		parts := strings.Split(strings.Trim(initialPath, "/"), "/")
@@ -644,8 +655,8 @@ func printObserveURLs(w io.Writer, serverURL, initialPath, markdownOutputDir str
 
 // ServeHTML starts a local HTTP server for the HTML output directory using OnDemandHandler
 // and opens the browser to the specified initial path.
-func ServeHTML(log zerolog.Logger, initialPath string) error {
-	handler := NewOnDemandHandler(log, nil, config.DefaultDataDir(), activeHTMLOutputDir)
+func ServeHTML(log zerolog.Logger, store gather.Store, initialPath string) error {
+	handler := NewOnDemandHandler(log, nil, store, activeHTMLOutputDir)
 	return ServeHTMLWithHandler(context.Background(), log, initialPath, handler)
 }
 
@@ -759,7 +770,7 @@ func All(
 	log zerolog.Logger,
 	client *gather.GitHubClient,
 	outputTypes []string,
-	dataDir string,
+	store gather.Store,
 	opts ...Option,
 ) error {
 	options := defaultOptions()
@@ -770,7 +781,7 @@ func All(
 	reporter := options.getReporter()
 	reporter.Start("Building observations")
 
-	err := generateAllObserveData(ctx, log, client, outputTypes, dataDir, opts...)
+	err := generateAllObserveData(ctx, log, client, outputTypes, store, opts...)
 	if err != nil {
 		reporter.Stop("")
 		return err
@@ -785,9 +796,10 @@ func generateAllObserveData(
 	log zerolog.Logger,
 	client *gather.GitHubClient,
 	outputTypes []string,
-	dataDir string,
+	store gather.Store,
 	opts ...Option,
 ) error {
+	opts = append(slices.Clip(opts), withStore(store))
 	observeOpts := defaultOptions()
 	for _, opt := range opts {
 		opt(observeOpts)
@@ -806,40 +818,17 @@ func generateAllObserveData(
 		filesSkipped int
 	)
 
-	err := filepath.WalkDir(dataDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		if d.Name() == ".DS_Store" {
-			//nolint:gosec // I don't care
-			if err := os.Remove(path); err != nil {
-				return fmt.Errorf("failed to remove .DS_Store file: %w", err)
-			}
-			return nil
-		}
-
-		if filepath.Ext(path) != ".json" {
-			log.Debug().Str("path", path).Msg("Skipping non-JSON file")
-			return nil
-		}
+	entries, err := store.Entries()
+	if err != nil {
+		return fmt.Errorf("failed to list gathered data: %w", err)
+	}
 
-		relPath, relErr := filepath.Rel(dataDir, path)
-		if relErr != nil {
-			return fmt.Errorf("failed to compute relative path for %s: %w", path, relErr)
-		}
-		pathComponents := strings.Split(relPath, string(filepath.Separator))
-		if len(pathComponents) != 4 {
-			log.Debug().Str("path", path).Msg("Skipping path not matching owner/repo/category/file.json")
-			return nil
-		}
+	for _, entry := range entries {
 		var (
-			owner    = pathComponents[0]
-			repo     = pathComponents[1]
-			dataCat  = pathComponents[2]
-			dataName = strings.TrimSuffix(pathComponents[3], ".json")
+			owner    = entry.Owner
+			repo     = entry.Repo
+			dataCat  = entry.Category
+			dataName = entry.ID
 		)
 
 		loadStart := time.Now()
@@ -887,7 +876,7 @@ func generateAllObserveData(
 					fmt.Sprintf("%s.%s", obs.ID, outputType),
 				)
 				if stat, statErr := os.Stat(targetPath); statErr == nil {
-					if srcStat, sErr := os.Stat(path); sErr == nil && stat.ModTime().After(srcStat.ModTime()) {
+					if stat.ModTime().After(entry.UpdatedAt) {
 						filesSkipped++
 						continue
 					}
@@ -901,11 +890,6 @@ func generateAllObserveData(
 				}
 			}
 		}
-
-		return nil
-	})
-	if err != nil {
-		return err
 	}
 
 	log.Debug().
@@ -0,0 +1,224 @@
+package observe
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// orgTopJobs is how many of the most expensive jobs an org report lists.
+const orgTopJobs = 20
+
+// unknownRunner groups jobs whose runner type wasn't gathered.
+const unknownRunner = "unknown"
+
+// OrgReport rolls up the cost and duration of an org's workflow runs by repository, workflow and runner type.
+// Durations are the time jobs spent running, which is what runners are billed for.
+type OrgReport struct {
+	Org string `json:"org"`
+	// Event limits the report to runs triggered by this event, all events when empty.
+	Event string `json:"event,omitempty"`
+	// From and To limit the report to runs started in [From, To), unbounded when zero.
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+	Runs int       `json:"runs"`
+	Jobs int       `json:"jobs"`
+	// Duration is the total time jobs ran for.
+	Duration time.Duration `json:"duration"`
+	// Cost is the total cost in tenths of a cent, only of jobs where cost data was gathered.
+	Cost int64 `json:"cost"`
+	// CostEstimate is true when any of Cost is estimated rather than billed.
+	CostEstimate bool `json:"cost_estimate,omitempty"`
+	// Repos, Workflows and Runners are sorted by cost, most expensive first.
+	Repos     []*OrgRollup `json:"repos"`
+	Workflows []*OrgRollup `json:"workflows"`
+	Runners   []*OrgRollup `json:"runners"`
+	// TopJobs are the most expensive jobs, matched across runs by repository, workflow and name.
+	TopJobs []*OrgRollup `json:"top_jobs"`
+}
+
+// OrgRollup is the cost and duration of a repository, workflow, runner type or job of an org.
+type OrgRollup struct {
+	// Repo and Workflow are set for workflows and jobs, Repo alone for repositories.
+	Repo     string `json:"repo,omitempty"`
+	Workflow string `json:"workflow,omitempty"`
+	// Name is the repository, workflow, runner type or job name.
+	Name     string        `json:"name"`
+	Runs     int           `json:"runs"`
+	Jobs     int           `json:"jobs"`
+	Duration time.Duration `json:"duration"`
+	Cost     int64         `json:"cost"`
+	// CostShare is the rollup's share of the org's cost, in percent.
+	CostShare float64 `json:"cost_share"`
+}
+
+// LoadOrgReport builds the org report of org from the workflow runs of all its repositories cached in store.
+func LoadOrgReport(
+	store gather.Store,
+	org string,
+	from, to time.Time,
+	event string,
+	opts ...Option,
+) (*OrgReport, error) {
+	runs, err := gather.StoredWorkflowRuns(store, org, "")
+	if err != nil {
+		return nil, err
+	}
+	return BuildOrgReport(org, runs, from, to, event, opts...)
+}
+
+// BuildOrgReport rolls up completed workflow runs started in [from, to). Runs still in progress are left out,
+// along with workflows excluded with ExcludeWorkflows or IncludeWorkflows.
+func BuildOrgReport(
+	org string,
+	runs []*gather.WorkflowRunData,
+	from, to time.Time,
+	event string,
+	opts ...Option,
+) (*OrgReport, error) {
+	if event == "all" {
+		event = ""
+	}
+	if err := validateOrgRange(from, to); err != nil {
+		return nil, err
+	}
+	observeOpts := defaultOptions()
+	for _, opt := range opts {
+		opt(observeOpts)
+	}
+
+	report := &OrgReport{Org: org, Event: event, From: from, To: to}
+	var (
+		repos     = map[string]*OrgRollup{}
+		workflows = map[[2]string]*OrgRollup{}
+		runners   = map[string]*OrgRollup{}
+		jobs      = map[[3]string]*OrgRollup{}
+	)
+	for _, run := range runs {
+		if run == nil || run.WorkflowRun == nil || run.GetStatus() != "completed" ||
+			!shouldIncludeWorkflow(run.GetName(), observeOpts) {
+			continue
+		}
+		if event != "" && run.GetEvent() != event {
+			continue
+		}
+		started, _ := runTimes(run)
+		if started.IsZero() || (!from.IsZero() && started.Before(from)) || (!to.IsZero() && !started.Before(to)) {
+			continue
+		}
+
+		repoName, workflowName := run.GetRepo(), run.GetName()
+		repo := orgRollup(repos, repoName, &OrgRollup{Name: repoName})
+		workflow := orgRollup(workflows, [2]string{repoName, workflowName},
+			&OrgRollup{Repo: repoName, Name: workflowName})
+		report.Runs++
+		repo.Runs++
+		workflow.Runs++
+
+		for _, job := range run.GetJobs() {
+			if job == nil || job.WorkflowJob == nil || job.StartedAt == nil || job.CompletedAt == nil {
+				continue
+			}
+			duration := max(job.GetCompletedAt().Sub(job.GetStartedAt().Time), 0)
+			var cost int64
+			if job.GetCostGathered() {
+				cost = job.GetCost()
+				report.CostEstimate = report.CostEstimate || job.GetCostEstimate()
+			}
+			runnerName := job.GetRunner()
+			if runnerName == "" {
+				runnerName = unknownRunner
+			}
+			runner := orgRollup(runners, runnerName, &OrgRollup{Name: runnerName})
+			top := orgRollup(jobs, [3]string{repoName, workflowName, job.GetName()},
+				&OrgRollup{Repo: repoName, Workflow: workflowName, Name: job.GetName()})
+			top.Runs++
+			for _, rollup := range []*OrgRollup{repo, workflow, runner, top} {
+				rollup.Jobs++
+				rollup.Duration += duration
+				rollup.Cost += cost
+			}
+			report.Jobs++
+			report.Duration += duration
+			report.Cost += cost
+		}
+	}
+
+	report.Repos = sortedOrgRollups(repos, report.Cost)
+	report.Workflows = sortedOrgRollups(workflows, report.Cost)
+	report.Runners = sortedOrgRollups(runners, report.Cost)
+	report.TopJobs = sortedOrgRollups(jobs, report.Cost)
+	if len(report.TopJobs) > orgTopJobs {
+		report.TopJobs = report.TopJobs[:orgTopJobs]
+	}
+	return report, nil
+}
+
+func validateOrgRange(from, to time.Time) error {
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return fmt.Errorf("org report range end %s must be after its start %s",
+			to.Format(time.DateOnly), from.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// orgRollup returns the rollup for key, adding rollup to rollups if there isn't one yet.
+func orgRollup[K comparable](rollups map[K]*OrgRollup, key K, rollup *OrgRollup) *OrgRollup {
+	if existing, ok := rollups[key]; ok {
+		return existing
+	}
+	rollups[key] = rollup
+	return rollup
+}
+
+// sortedOrgRollups sorts rollups by cost, then duration, and fills in their share of the total cost.
+func sortedOrgRollups[K comparable](rollups map[K]*OrgRollup, total int64) []*OrgRollup {
+	sorted := make([]*OrgRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		if total > 0 {
+			rollup.CostShare = float64(rollup.Cost) / float64(total) * 100
+		}
+		sorted = append(sorted, rollup)
+	}
+	slices.SortFunc(sorted, func(a, b *OrgRollup) int {
+		return cmp.Or(
+			cmp.Compare(b.Cost, a.Cost),
+			cmp.Compare(b.Duration, a.Duration),
+			strings.Compare(a.Repo, b.Repo),
+			strings.Compare(a.Workflow, b.Workflow),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+	return sorted
+}
+
+// RenderString renders the org report as "html", "md" or "json".
+func (r *OrgReport) RenderString(outputType string) (string, error) {
+	var buf bytes.Buffer
+	switch outputType {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return "", fmt.Errorf("failed to encode org report to JSON: %w", err)
+		}
+		return buf.String(), nil
+	case "md":
+		if err := mdTemplate.ExecuteTemplate(&buf, "org_md", r); err != nil {
+			return "", fmt.Errorf("failed to render org report %s: %w", outputType, err)
+		}
+		res := cleanMarkdown(buf)
+		return res.String(), nil
+	default:
+		if err := htmlTemplate.ExecuteTemplate(&buf, "org_html", r); err != nil {
+			return "", fmt.Errorf("failed to render org report %s: %w", outputType, err)
+		}
+		return buf.String(), nil
+	}
+}
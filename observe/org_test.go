@@ -0,0 +1,186 @@
+package observe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+var orgStart = time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+
+func orgJob(name, runner string, duration time.Duration, cost int64) *gather.JobData {
+	return &gather.JobData{
+		WorkflowJob: &github.WorkflowJob{
+			Name:        new(name),
+			Conclusion:  new("success"),
+			StartedAt:   &github.Timestamp{Time: orgStart},
+			CompletedAt: &github.Timestamp{Time: orgStart.Add(duration)},
+		},
+		Runner:       runner,
+		Cost:         cost,
+		CostGathered: runner != "",
+	}
+}
+
+func orgRun(
+	id int64,
+	repo, workflow, event string,
+	started time.Time,
+	jobs ...*gather.JobData,
+) *gather.WorkflowRunData {
+	return &gather.WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:           new(id),
+			Name:         new(workflow),
+			Event:        new(event),
+			Status:       new("completed"),
+			Conclusion:   new("success"),
+			RunStartedAt: &github.Timestamp{Time: started},
+			Repository:   &github.Repository{Name: new(repo), Owner: &github.User{Login: new("acme")}},
+		},
+		Jobs: jobs,
+	}
+}
+
+func orgRuns() []*gather.WorkflowRunData {
+	inProgress := orgRun(6, "api", "CI", "push", orgStart, orgJob("test", "UBUNTU", time.Hour, 480))
+	inProgress.Status = new("in_progress")
+	return []*gather.WorkflowRunData{
+		orgRun(1, "api", "CI", "push", orgStart,
+			orgJob("test", "UBUNTU_4_CORE", 10*time.Minute, 160),
+			orgJob("lint", "UBUNTU", 2*time.Minute, 16),
+		),
+		orgRun(2, "api", "CI", "pull_request", orgStart.Add(time.Hour),
+			orgJob("test", "UBUNTU_4_CORE", 20*time.Minute, 320),
+		),
+		orgRun(3, "web", "Deploy", "push", orgStart, orgJob("deploy", "UBUNTU", 3*time.Minute, 24)),
+		// Cost wasn't gathered, so only its duration counts
+		orgRun(4, "web", "CI", "push", orgStart, orgJob("build", "", 5*time.Minute, 0)),
+		// Outside the range
+		orgRun(5, "web", "CI", "push", orgStart.AddDate(0, -1, 0), orgJob("build", "UBUNTU", time.Hour, 480)),
+		inProgress,
+	}
+}
+
+func TestBuildOrgReport(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	report, err := BuildOrgReport("acme", orgRuns(), from, from.AddDate(0, 1, 0), "all")
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Runs)
+	assert.Equal(t, 5, report.Jobs)
+	assert.Equal(t, 40*time.Minute, report.Duration)
+	assert.Equal(t, int64(520), report.Cost)
+	assert.Empty(t, report.Event)
+
+	require.Len(t, report.Repos, 2)
+	assert.Equal(t, &OrgRollup{
+		Name:      "api",
+		Runs:      2,
+		Jobs:      3,
+		Duration:  32 * time.Minute,
+		Cost:      496,
+		CostShare: float64(496) / 520 * 100,
+	}, report.Repos[0], "most expensive repos should come first")
+	assert.Equal(t, "web", report.Repos[1].Name)
+
+	require.Len(t, report.Workflows, 3)
+	assert.Equal(t, "api", report.Workflows[0].Repo)
+	assert.Equal(t, "CI", report.Workflows[0].Name)
+	assert.Equal(t, "Deploy", report.Workflows[1].Name)
+	assert.Equal(t, "web", report.Workflows[2].Repo)
+	assert.Equal(t, 5*time.Minute, report.Workflows[2].Duration)
+
+	require.Len(t, report.Runners, 3)
+	assert.Equal(t, "UBUNTU_4_CORE", report.Runners[0].Name)
+	assert.Equal(t, int64(480), report.Runners[0].Cost)
+	assert.Equal(t, "UBUNTU", report.Runners[1].Name)
+	assert.Equal(t, unknownRunner, report.Runners[2].Name, "jobs without a runner type should be grouped together")
+
+	require.Len(t, report.TopJobs, 4)
+	assert.Equal(t, &OrgRollup{
+		Repo:      "api",
+		Workflow:  "CI",
+		Name:      "test",
+		Runs:      2,
+		Jobs:      2,
+		Duration:  30 * time.Minute,
+		Cost:      480,
+		CostShare: float64(480) / 520 * 100,
+	}, report.TopJobs[0], "jobs should be matched across runs")
+
+	pushes, err := BuildOrgReport("acme", orgRuns(), from, time.Time{}, "push", ExcludeWorkflows([]string{"Deploy"}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, pushes.Runs)
+	assert.Equal(t, int64(176), pushes.Cost)
+
+	_, err = BuildOrgReport("acme", orgRuns(), from, from, "")
+	require.Error(t, err, "an empty range should be rejected")
+}
+
+func TestOrgReportRenderString(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	report, err := BuildOrgReport("acme", orgRuns(), from, from.AddDate(0, 1, 0), "")
+	require.NoError(t, err)
+
+	md, err := report.RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "Runs started from 2025-06-01 before 2025-07-01.")
+	assert.Contains(t, md, "| api | $0.50 | 95.4% | 32m 0s | 2 | 3 |")
+	assert.Contains(t, md, "| api | CI | test | $0.48 | 92.3% | 30m 0s | 2 |")
+
+	out, err := report.RenderString("json")
+	require.NoError(t, err)
+	var decoded OrgReport
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, report.Runners, decoded.Runners)
+
+	html, err := report.RenderString("html")
+	require.NoError(t, err)
+	assert.Contains(t, html, `href="/acme/api"`)
+
+	empty, err := BuildOrgReport("acme", nil, time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	emptyMD, err := empty.RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, emptyMD, "No completed runs found")
+}
+
+func TestHandler_OrgPage(t *testing.T) {
+	t.Parallel()
+
+	log, dataDir := testhelpers.Setup(t)
+	store := gather.NewFileStore(dataDir)
+	for _, run := range orgRuns() {
+		id := strconv.FormatInt(run.GetID(), 10)
+		require.NoError(t, store.Save("acme", run.GetRepo(), gather.WorkflowRunsDataDir, id, run))
+	}
+	handler := NewOnDemandHandler(log, nil, store, t.TempDir())
+
+	req := httptest.NewRequest("GET", "/orgs/acme?from=2025-06-01&to=2025-07-01", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "acme Actions usage")
+	assert.Contains(t, body, "4 completed runs of 2 repositories")
+
+	req = httptest.NewRequest("GET", "/orgs/acme?from=2025-07-01&to=2025-06-01", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
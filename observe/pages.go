@@ -4,10 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kalverra/octometrics/gather"
 	"github.com/kalverra/octometrics/internal/uistate"
@@ -185,6 +184,105 @@ func (h *OnDemandHandler) handleRepo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *OnDemandHandler) handleTrends(w http.ResponseWriter, r *http.Request) {
+	owner := r.PathValue("owner")
+	repo := r.PathValue("repo")
+
+	query := r.URL.Query()
+	filter := TrendFilter{
+		Branch:   strings.TrimSpace(query.Get("branch")),
+		Event:    query.Get("event"),
+		Interval: query.Get("interval"),
+	}
+	for param, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(param); value != "" {
+			parsed, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				msg := fmt.Sprintf("invalid %s date '%s', expected YYYY-MM-DD", param, value)
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+	if err := filter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trends, err := LoadTrends(h.store, owner, repo, filter, h.opts...)
+	if err != nil {
+		h.log.Error().Err(err).Str("owner", owner).Str("repo", repo).Msg("failed to load trends")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	page, err := trends.RenderString("html")
+	if err != nil {
+		h.log.Error().Err(err).Msg("failed to render trends page")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(page))
+}
+
+func (h *OnDemandHandler) handleFlaky(w http.ResponseWriter, r *http.Request) {
+	owner := r.PathValue("owner")
+	repo := r.PathValue("repo")
+
+	flaky, err := LoadFlakyJobs(h.store, owner, repo, h.opts...)
+	if err != nil {
+		h.log.Error().Err(err).Str("owner", owner).Str("repo", repo).Msg("failed to load flaky jobs")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	page, err := flaky.RenderString("html")
+	if err != nil {
+		h.log.Error().Err(err).Msg("failed to render flaky jobs page")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(page))
+}
+
+func (h *OnDemandHandler) handleOrg(w http.ResponseWriter, r *http.Request) {
+	org := r.PathValue("org")
+
+	query := r.URL.Query()
+	var from, to time.Time
+	for param, t := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := query.Get(param); value != "" {
+			parsed, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				msg := fmt.Sprintf("invalid %s date '%s', expected YYYY-MM-DD", param, value)
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+	if err := validateOrgRange(from, to); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := LoadOrgReport(h.store, org, from, to, query.Get("event"), h.opts...)
+	if err != nil {
+		h.log.Error().Err(err).Str("org", org).Msg("failed to load org report")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	page, err := report.RenderString("html")
+	if err != nil {
+		h.log.Error().Err(err).Msg("failed to render org report page")
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(page))
+}
+
 func (h *OnDemandHandler) populateWorkflowsTab(
 	ctx context.Context,
 	vm *repoViewModel,
@@ -211,7 +309,7 @@ func (h *OnDemandHandler) populateWorkflowsTab(
 			vm.Runs = runs
 		}
 	} else {
-		records, _ := LoadManifest(h.dataDir, owner, repo)
+		records, _ := h.store.LoadManifest(owner, repo)
 		for _, rec := range records {
 			if rec.Type == "workflow_run" {
 				id, _ := strconv.ParseInt(rec.ID, 10, 64)
@@ -247,7 +345,7 @@ func (h *OnDemandHandler) populateCommitsTab(
 			vm.Commits = commits
 		}
 	} else {
-		records, _ := LoadManifest(h.dataDir, owner, repo)
+		records, _ := h.store.LoadManifest(owner, repo)
 		for _, rec := range records {
 			if rec.Type == "commit" {
 				isMQ := strings.Contains(rec.Name, "gh-readonly-queue") ||
@@ -303,7 +401,7 @@ func (h *OnDemandHandler) populatePullsTab(
 			vm.PRs = prs
 		}
 	} else {
-		records, _ := LoadManifest(h.dataDir, owner, repo)
+		records, _ := h.store.LoadManifest(owner, repo)
 		for _, rec := range records {
 			if rec.Type == "pull_request" {
 				num, _ := strconv.Atoi(rec.ID)
@@ -397,7 +495,7 @@ func (h *OnDemandHandler) handleFavorites(w http.ResponseWriter, r *http.Request
 
 func (h *OnDemandHandler) loadDownloadedMap(owner, repo string) map[string]bool {
 	m := make(map[string]bool)
-	records, err := LoadManifest(h.dataDir, owner, repo)
+	records, err := h.store.LoadManifest(owner, repo)
 	if err != nil {
 		return m
 	}
@@ -411,45 +509,35 @@ func (h *OnDemandHandler) searchLocalManifest(query string) []LocalMatch {
 	q := strings.ToLower(query)
 	var results []LocalMatch
 
-	entries, err := os.ReadDir(h.dataDir)
+	repos, err := h.store.Repos()
 	if err != nil {
 		return nil
 	}
 
-	for _, ownerEntry := range entries {
-		if !ownerEntry.IsDir() {
+	for _, repoKey := range repos {
+		owner, repo, ok := strings.Cut(repoKey, "/")
+		if !ok {
 			continue
 		}
-		owner := ownerEntry.Name()
-		repos, rErr := os.ReadDir(filepath.Join(h.dataDir, owner))
-		if rErr != nil {
+		records, mErr := h.store.LoadManifest(owner, repo)
+		if mErr != nil {
 			continue
 		}
-		for _, repoEntry := range repos {
-			if !repoEntry.IsDir() {
-				continue
-			}
-			repo := repoEntry.Name()
-			records, mErr := LoadManifest(h.dataDir, owner, repo)
-			if mErr != nil {
-				continue
-			}
-			for _, rec := range records {
-				if strings.Contains(strings.ToLower(rec.Name), q) ||
-					strings.Contains(strings.ToLower(rec.ID), q) ||
-					strings.Contains(strings.ToLower(rec.Actor), q) ||
-					strings.Contains(strings.ToLower(owner), q) ||
-					strings.Contains(strings.ToLower(repo), q) {
-					results = append(results, LocalMatch{
-						Type:  rec.Type,
-						ID:    rec.ID,
-						Name:  rec.Name,
-						State: rec.State,
-						Actor: rec.Actor,
-						Owner: owner,
-						Repo:  repo,
-					})
-				}
+		for _, rec := range records {
+			if strings.Contains(strings.ToLower(rec.Name), q) ||
+				strings.Contains(strings.ToLower(rec.ID), q) ||
+				strings.Contains(strings.ToLower(rec.Actor), q) ||
+				strings.Contains(strings.ToLower(owner), q) ||
+				strings.Contains(strings.ToLower(repo), q) {
+				results = append(results, LocalMatch{
+					Type:  rec.Type,
+					ID:    rec.ID,
+					Name:  rec.Name,
+					State: rec.State,
+					Actor: rec.Actor,
+					Owner: owner,
+					Repo:  repo,
+				})
 			}
 		}
 	}
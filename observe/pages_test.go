@@ -19,7 +19,7 @@ func TestHandler_Home(t *testing.T) {
 	log, dataDir := testhelpers.Setup(t)
 	outputDir := t.TempDir()
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	req := httptest.NewRequest("GET", "/", nil)
 	rec := httptest.NewRecorder()
@@ -40,7 +40,7 @@ func TestHandler_Search(t *testing.T) {
 	log, dataDir := testhelpers.Setup(t)
 	outputDir := t.TempDir()
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	// Full search page
 	req := httptest.NewRequest("GET", "/search?q=test", nil)
@@ -68,7 +68,7 @@ func TestHandler_RepoPage(t *testing.T) {
 	log, dataDir := testhelpers.Setup(t)
 	outputDir := t.TempDir()
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	req := httptest.NewRequest("GET", "/kalverra/octometrics", nil)
 	rec := httptest.NewRecorder()
@@ -89,14 +89,14 @@ func TestHandler_RepoPage_CommitsTab(t *testing.T) {
 	log, dataDir := testhelpers.Setup(t)
 	outputDir := t.TempDir()
 
-	_ = gather.AppendManifestRecord(dataDir, "kalverra", "octometrics", gather.ManifestRecord{
+	_ = gather.AppendManifestRecord(gather.NewFileStore(dataDir), "kalverra", "octometrics", gather.ManifestRecord{
 		Type:  "commit",
 		ID:    "abc123456789",
 		Name:  "Merge branch 'main' into gh-readonly-queue/main/pr-1",
 		Actor: "kalverra",
 	})
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	req := httptest.NewRequest("GET", "/kalverra/octometrics?tab=commits", nil)
 	rec := httptest.NewRecorder()
@@ -116,26 +116,26 @@ func TestHandler_RepoPage_SearchFilter(t *testing.T) {
 	log, dataDir := testhelpers.Setup(t)
 	outputDir := t.TempDir()
 
-	_ = gather.AppendManifestRecord(dataDir, "kalverra", "octometrics", gather.ManifestRecord{
+	_ = gather.AppendManifestRecord(gather.NewFileStore(dataDir), "kalverra", "octometrics", gather.ManifestRecord{
 		Type:  "commit",
 		ID:    "abc123456789",
 		Name:  "Fix critical bug",
 		Actor: "kalverra",
 	})
-	_ = gather.AppendManifestRecord(dataDir, "kalverra", "octometrics", gather.ManifestRecord{
+	_ = gather.AppendManifestRecord(gather.NewFileStore(dataDir), "kalverra", "octometrics", gather.ManifestRecord{
 		Type:  "commit",
 		ID:    "def987654321",
 		Name:  "Update docs",
 		Actor: "octocat",
 	})
-	_ = gather.AppendManifestRecord(dataDir, "kalverra", "octometrics", gather.ManifestRecord{
+	_ = gather.AppendManifestRecord(gather.NewFileStore(dataDir), "kalverra", "octometrics", gather.ManifestRecord{
 		Type:  "workflow_run",
 		ID:    "12345",
 		Name:  "CI Build",
 		State: "success",
 		Actor: "kalverra",
 	})
-	_ = gather.AppendManifestRecord(dataDir, "kalverra", "octometrics", gather.ManifestRecord{
+	_ = gather.AppendManifestRecord(gather.NewFileStore(dataDir), "kalverra", "octometrics", gather.ManifestRecord{
 		Type:  "pull_request",
 		ID:    "42",
 		Name:  "Add feature X",
@@ -143,7 +143,7 @@ func TestHandler_RepoPage_SearchFilter(t *testing.T) {
 		Actor: "kalverra",
 	})
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	// Filter commits by SHA/query
 	reqCommit := httptest.NewRequest("GET", "/kalverra/octometrics?tab=commits&q=abc1234", nil)
@@ -177,7 +177,7 @@ func TestHandler_FavoriteToggle(t *testing.T) {
 	log, dataDir := testhelpers.Setup(t)
 	outputDir := t.TempDir()
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	req := httptest.NewRequest("POST", "/favorites", nil)
 	req.Form = map[string][]string{
@@ -200,7 +200,7 @@ func TestHandler_PendingInterstitial(t *testing.T) {
 	outputDir := t.TempDir()
 
 	// Handler with nil client so rendering entity fails or stays pending
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	req := httptest.NewRequest("GET", "/owner/repo/workflow_runs/999.html", nil)
 	rec := httptest.NewRecorder()
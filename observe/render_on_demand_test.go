@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kalverra/octometrics/gather"
 	"github.com/kalverra/octometrics/internal/testhelpers"
 )
 
@@ -29,10 +30,10 @@ func TestManifestRecord(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	err := AppendManifestRecord(tempDir, "owner", "repo", rec)
+	err := AppendManifestRecord(gather.NewFileStore(tempDir), "owner", "repo", rec)
 	require.NoError(t, err)
 
-	records, err := LoadManifest(tempDir, "owner", "repo")
+	records, err := LoadManifest(gather.NewFileStore(tempDir), "owner", "repo")
 	require.NoError(t, err)
 	require.Len(t, records, 1)
 	assert.Equal(t, "workflow_run", records[0].Type)
@@ -63,7 +64,7 @@ func TestRenderOnDemandHandler(t *testing.T) {
 	}`)
 	require.NoError(t, os.WriteFile(jsonPath, sampleJSON, 0o600))
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	// Request 1: Cold hit returns 202 Pending and triggers background job
 	req := httptest.NewRequest("GET", "/owner/repo/workflow_runs/555.html", nil)
@@ -136,7 +137,7 @@ func TestRenderOnDemandHandler_JobRun(t *testing.T) {
 	}`)
 	require.NoError(t, os.WriteFile(jsonPath, sampleJSON, 0o600))
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	// First request -> 202 Pending
 	req := httptest.NewRequest("GET", "/owner/repo/job_runs/777.html", nil)
@@ -173,7 +174,7 @@ func TestRenderOnDemandHandler_Comparison(t *testing.T) {
 	require.NoError(t, os.MkdirAll(filepath.Dir(compPath), 0o750))
 	require.NoError(t, os.WriteFile(compPath, []byte("<html>Left vs Right</html>"), 0o600))
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	req := httptest.NewRequest("GET", "/owner/repo/comparisons/111_vs_222.html", nil)
 	rr := httptest.NewRecorder()
@@ -211,7 +212,7 @@ func TestRenderOnDemandHandler_Comparison_OnDemand(t *testing.T) {
 		"jobs": []
 	}`), 0o600))
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	// First request -> 202 Pending
 	req := httptest.NewRequest("GET", "/owner/repo/comparisons/111_vs_222.html", nil)
@@ -256,7 +257,7 @@ func TestRenderOnDemandHandler_StaleHTMLRefreshed(t *testing.T) {
 		"jobs": []
 	}`)
 
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir)
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir)
 
 	// First request -> 202, wait -> 200
 	req := httptest.NewRequest("GET", "/owner/repo/workflow_runs/555.html", nil)
@@ -287,9 +288,10 @@ func TestRenderOnDemandHandler_StaleHTMLRefreshed(t *testing.T) {
 		"actor": {"login": "user"},
 		"jobs": []
 	}`)
+	// Keep the source a bit in the past so the re-rendered HTML's coarse mtime is reliably newer
 	now := time.Now()
 	require.NoError(t, os.Chtimes(outPath, now.Add(-1*time.Hour), now.Add(-1*time.Hour)))
-	require.NoError(t, os.Chtimes(jsonPath, now, now))
+	require.NoError(t, os.Chtimes(jsonPath, now.Add(-1*time.Minute), now.Add(-1*time.Minute)))
 
 	// Second request detects stale HTML -> 202, triggers background job
 	req2 := httptest.NewRequest("GET", "/owner/repo/workflow_runs/555.html", nil)
@@ -330,7 +332,7 @@ func TestOnDemandHandler_SuppressesProgressReporter(t *testing.T) {
 	}`), 0o600))
 
 	rec := &recordingReporter{}
-	handler := NewOnDemandHandler(log, nil, dataDir, outputDir, WithProgressReporter(rec))
+	handler := NewOnDemandHandler(log, nil, gather.NewFileStore(dataDir), outputDir, WithProgressReporter(rec))
 
 	commitDir := filepath.Join(dataDir, "owner", "repo", "commits")
 	require.NoError(t, os.MkdirAll(commitDir, 0o750))
@@ -31,6 +31,7 @@ type Timeline struct {
 	CriticalPath    *CriticalPathInfo  `json:"critical_path,omitempty"`
 	StepSummaries   []StepSummary      `json:"step_summaries,omitempty"`
 	SlowestJobSteps []JobStepBreakdown `json:"slowest_job_steps,omitempty"`
+	Matrices        []*MatrixJob       `json:"matrices,omitempty"`
 
 	// Cost data for this timeline/event
 	Cost         int64 `json:"cost,omitempty"`
@@ -360,7 +360,8 @@ func TestInteractive_StopsProgressReporter(t *testing.T) {
 	cancel()
 
 	log := zerolog.Nop()
-	_ = Interactive(ctx, log, nil, "/", t.TempDir(), WithProgressReporter(rec), WithNoOpen(true), WithPort(0))
+	store := gather.NewFileStore(t.TempDir())
+	_ = Interactive(ctx, log, nil, "/", store, WithProgressReporter(rec), WithNoOpen(true), WithPort(0))
 
 	assert.NotEmpty(t, rec.stops, "Interactive must call reporter.Stop before starting server")
 }
@@ -0,0 +1,442 @@
+package observe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kalverra/octometrics/gather"
+)
+
+// Trend intervals group runs by the UTC day or week (starting Monday) they started in.
+const (
+	TrendIntervalDay  = "day"
+	TrendIntervalWeek = "week"
+)
+
+// TrendFilter selects which cached workflow runs go into Trends and how they're grouped.
+type TrendFilter struct {
+	// Branch limits trends to runs of this head branch, all branches when empty.
+	Branch string `json:"branch,omitempty"`
+	// Event limits trends to runs triggered by this event, all events when empty or "all".
+	Event string `json:"event,omitempty"`
+	// Interval is TrendIntervalDay or TrendIntervalWeek, defaults to TrendIntervalWeek.
+	Interval string `json:"interval"`
+	// From and To limit trends to runs started in [From, To), unbounded when zero.
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+// Validate checks the filter and fills in the default interval.
+func (f *TrendFilter) Validate() error {
+	if f.Interval == "" {
+		f.Interval = TrendIntervalWeek
+	}
+	if f.Interval != TrendIntervalDay && f.Interval != TrendIntervalWeek {
+		return fmt.Errorf("invalid trend interval '%s', expected '%s' or '%s'",
+			f.Interval, TrendIntervalDay, TrendIntervalWeek)
+	}
+	if f.Event == "all" {
+		f.Event = ""
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.To.After(f.From) {
+		return fmt.Errorf("trend range end %s must be after its start %s",
+			f.To.Format(time.DateOnly), f.From.Format(time.DateOnly))
+	}
+	return nil
+}
+
+func (f *TrendFilter) matches(run *gather.WorkflowRunData, started time.Time) bool {
+	if f.Branch != "" && run.GetHeadBranch() != f.Branch {
+		return false
+	}
+	if f.Event != "" && run.GetEvent() != f.Event {
+		return false
+	}
+	if !f.From.IsZero() && started.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !started.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// bucket is the start of the UTC day or week t falls in.
+func (f *TrendFilter) bucket(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if f.Interval == TrendIntervalDay {
+		return day
+	}
+	// Weeks start on Monday
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// trendEvents are the events offered as filters on the trends page.
+var trendEvents = []string{"push", "pull_request", "merge_group", "schedule", "workflow_dispatch"}
+
+// Trends are time series of each workflow's and job's duration, queue time, cost and failure rate,
+// built from locally cached workflow runs.
+type Trends struct {
+	Owner     string           `json:"owner"`
+	Repo      string           `json:"repo"`
+	Filter    TrendFilter      `json:"filter"`
+	Workflows []*WorkflowTrend `json:"workflows"`
+}
+
+// EventOptions are the events the trends page offers as filters, including the current one.
+func (t *Trends) EventOptions() []string {
+	if t.Filter.Event == "" || slices.Contains(trendEvents, t.Filter.Event) {
+		return trendEvents
+	}
+	return append(slices.Clone(trendEvents), t.Filter.Event)
+}
+
+// WorkflowTrend is the trend of one workflow, and of each of its jobs.
+type WorkflowTrend struct {
+	Name   string        `json:"name"`
+	Runs   int           `json:"runs"`
+	Points []*TrendPoint `json:"points"`
+	Jobs   []*JobTrend   `json:"jobs"`
+}
+
+// JobTrend is the trend of one job, matched across runs by name.
+type JobTrend struct {
+	Name   string        `json:"name"`
+	Runs   int           `json:"runs"`
+	Points []*TrendPoint `json:"points"`
+}
+
+// TrendPoint summarizes the runs that started in one day or week.
+// Runs that were cancelled, skipped or are still in progress are left out.
+type TrendPoint struct {
+	Start       time.Time     `json:"start"`
+	Runs        int           `json:"runs"`
+	DurationP50 time.Duration `json:"duration_p50"`
+	DurationP90 time.Duration `json:"duration_p90"`
+	// QueueP50 is the median time jobs waited for a runner.
+	QueueP50 time.Duration `json:"queue_p50"`
+	// Cost is the total cost of the runs in tenths of a cent, only of runs where cost data was gathered.
+	Cost     int64 `json:"cost"`
+	Failures int   `json:"failures"`
+	// FailureRate is the share of runs that failed or timed out, in percent.
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// Label is the point's day or week for chart axes and tables.
+func (p *TrendPoint) Label() string {
+	return p.Start.Format("Jan 2")
+}
+
+// trendSample is one run or job counted into a TrendPoint.
+type trendSample struct {
+	bucket   time.Time
+	duration time.Duration
+	queues   []time.Duration
+	cost     int64
+	failed   bool
+}
+
+// LoadTrends builds trends for owner/repo from the workflow runs cached in store.
+func LoadTrends(store gather.Store, owner, repo string, filter TrendFilter, opts ...Option) (*Trends, error) {
+	runs, err := gather.StoredWorkflowRuns(store, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTrends(owner, repo, runs, filter, opts...)
+}
+
+// BuildTrends builds trends from workflow runs. Workflows are grouped by name, jobs by workflow and job name.
+// Workflows excluded with ExcludeWorkflows or IncludeWorkflows are left out.
+func BuildTrends(
+	owner, repo string,
+	runs []*gather.WorkflowRunData,
+	filter TrendFilter,
+	opts ...Option,
+) (*Trends, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	observeOpts := defaultOptions()
+	for _, opt := range opts {
+		opt(observeOpts)
+	}
+
+	var (
+		workflowNames []string
+		runSamples    = map[string][]*trendSample{}
+		jobNames      = map[string][]string{}
+		jobSamples    = map[string]map[string][]*trendSample{}
+	)
+	for _, run := range runs {
+		if run == nil || run.WorkflowRun == nil || !trendConclusion(run.GetConclusion()) {
+			continue
+		}
+		name := run.GetName()
+		if !shouldIncludeWorkflow(name, observeOpts) {
+			continue
+		}
+		started, completed := runTimes(run)
+		if started.IsZero() || !filter.matches(run, started) {
+			continue
+		}
+
+		bucket := filter.bucket(started)
+		sample := &trendSample{
+			bucket:   bucket,
+			duration: max(completed.Sub(started), 0),
+			failed:   trendFailed(run.GetConclusion()),
+		}
+		if run.GetCostGathered() {
+			sample.cost = run.GetCost()
+		}
+		if _, ok := runSamples[name]; !ok {
+			workflowNames = append(workflowNames, name)
+			jobSamples[name] = map[string][]*trendSample{}
+		}
+
+		for _, job := range run.GetJobs() {
+			jobSample := jobTrendSample(job, bucket)
+			if jobSample == nil {
+				continue
+			}
+			sample.queues = append(sample.queues, jobSample.queues...)
+			jobName := job.GetName()
+			if _, ok := jobSamples[name][jobName]; !ok {
+				jobNames[name] = append(jobNames[name], jobName)
+			}
+			jobSamples[name][jobName] = append(jobSamples[name][jobName], jobSample)
+		}
+		runSamples[name] = append(runSamples[name], sample)
+	}
+
+	trends := &Trends{Owner: owner, Repo: repo, Filter: filter, Workflows: []*WorkflowTrend{}}
+	slices.Sort(workflowNames)
+	for _, name := range workflowNames {
+		workflow := &WorkflowTrend{
+			Name:   name,
+			Runs:   len(runSamples[name]),
+			Points: trendPoints(runSamples[name]),
+			Jobs:   []*JobTrend{},
+		}
+		slices.Sort(jobNames[name])
+		for _, jobName := range jobNames[name] {
+			samples := jobSamples[name][jobName]
+			workflow.Jobs = append(workflow.Jobs, &JobTrend{
+				Name:   jobName,
+				Runs:   len(samples),
+				Points: trendPoints(samples),
+			})
+		}
+		trends.Workflows = append(trends.Workflows, workflow)
+	}
+	return trends, nil
+}
+
+// runTimes is when a run started and completed, falling back to when it was created and last updated.
+func runTimes(run *gather.WorkflowRunData) (started, completed time.Time) {
+	started = run.GetRunStartedAt().Time
+	if started.IsZero() {
+		started = run.GetCreatedAt().Time
+	}
+	completed = run.GetRunCompletedAt()
+	if completed.IsZero() {
+		completed = run.GetUpdatedAt().Time
+	}
+	return started, completed
+}
+
+func jobTrendSample(job *gather.JobData, bucket time.Time) *trendSample {
+	if job == nil || job.WorkflowJob == nil || job.StartedAt == nil || job.CompletedAt == nil ||
+		!trendConclusion(job.GetConclusion()) {
+		return nil
+	}
+	sample := &trendSample{
+		bucket:   bucket,
+		duration: max(job.CompletedAt.Sub(job.StartedAt.Time), 0),
+		failed:   trendFailed(job.GetConclusion()),
+	}
+	if job.CreatedAt != nil && job.StartedAt.After(job.CreatedAt.Time) {
+		sample.queues = []time.Duration{job.StartedAt.Sub(job.CreatedAt.Time)}
+	}
+	if job.CostGathered {
+		sample.cost = job.Cost
+	}
+	return sample
+}
+
+// trendConclusion reports whether a run or job with this conclusion counts toward trends.
+// Cancelled runs are usually superseded by a newer push, so they'd skew durations and failure rates.
+func trendConclusion(conclusion string) bool {
+	switch conclusion {
+	case "", "cancelled", "skipped", "neutral", "stale":
+		return false
+	default:
+		return true
+	}
+}
+
+func trendFailed(conclusion string) bool {
+	switch conclusion {
+	case "failure", "timed_out", "startup_failure":
+		return true
+	default:
+		return false
+	}
+}
+
+// trendPoints summarizes samples into one point per bucket, oldest first.
+func trendPoints(samples []*trendSample) []*TrendPoint {
+	byBucket := map[time.Time][]*trendSample{}
+	for _, s := range samples {
+		byBucket[s.bucket] = append(byBucket[s.bucket], s)
+	}
+	buckets := make([]time.Time, 0, len(byBucket))
+	for bucket := range byBucket {
+		buckets = append(buckets, bucket)
+	}
+	slices.SortFunc(buckets, time.Time.Compare)
+
+	points := make([]*TrendPoint, 0, len(buckets))
+	for _, bucket := range buckets {
+		var (
+			point     = &TrendPoint{Start: bucket, Runs: len(byBucket[bucket])}
+			durations = make([]time.Duration, 0, len(byBucket[bucket]))
+			queues    []time.Duration
+		)
+		for _, s := range byBucket[bucket] {
+			durations = append(durations, s.duration)
+			queues = append(queues, s.queues...)
+			point.Cost += s.cost
+			if s.failed {
+				point.Failures++
+			}
+		}
+		point.DurationP50 = percentileDuration(durations, 50)
+		point.DurationP90 = percentileDuration(durations, 90)
+		point.QueueP50 = percentileDuration(queues, 50)
+		point.FailureRate = float64(point.Failures) / float64(point.Runs) * 100
+		points = append(points, point)
+	}
+	return points
+}
+
+// percentileDuration returns the nearest-rank percentile p (0-100] of durations.
+func percentileDuration(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// TrendChart is one Mermaid xychart-beta diagram of a workflow's trend, for HTML embedding.
+type TrendChart struct {
+	Title   string
+	Diagram string
+}
+
+// Charts returns line charts of the workflow's duration, queue time, cost and failure rate.
+// A workflow that only ran in a single day or week has no trend to chart.
+func (w *WorkflowTrend) Charts() []TrendChart {
+	if len(w.Points) < 2 {
+		return nil
+	}
+	var (
+		labels   = make([]string, 0, len(w.Points))
+		p50, p90 = make([]float64, 0, len(w.Points)), make([]float64, 0, len(w.Points))
+		queue    = make([]float64, 0, len(w.Points))
+		cost     = make([]float64, 0, len(w.Points))
+		failures = make([]float64, 0, len(w.Points))
+		hasCost  bool
+	)
+	for _, p := range w.Points {
+		labels = append(labels, p.Label())
+		p50 = append(p50, p.DurationP50.Minutes())
+		p90 = append(p90, p.DurationP90.Minutes())
+		queue = append(queue, p.QueueP50.Minutes())
+		cost = append(cost, float64(p.Cost)/1000)
+		failures = append(failures, p.FailureRate)
+		hasCost = hasCost || p.Cost > 0
+	}
+
+	charts := []TrendChart{
+		{Title: "Duration (p50 and p90)", Diagram: trendChartDiagram("Duration", "Minutes", labels, p50, p90)},
+		{Title: "Queue time (p50)", Diagram: trendChartDiagram("Queue time", "Minutes", labels, queue)},
+	}
+	if hasCost {
+		charts = append(charts, TrendChart{Title: "Cost", Diagram: trendChartDiagram("Cost", "USD", labels, cost)})
+	}
+	return append(charts, TrendChart{
+		Title:   "Failure rate",
+		Diagram: trendChartDiagram("Failure rate", "Percent", labels, failures),
+	})
+}
+
+func trendChartDiagram(title, yLabel string, labels []string, series ...[]float64) string {
+	var maxValue float64
+	for _, values := range series {
+		for _, v := range values {
+			maxValue = max(maxValue, v)
+		}
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	quoted := make([]string, 0, len(labels))
+	for _, label := range labels {
+		quoted = append(quoted, fmt.Sprintf("%q", label))
+	}
+
+	var b strings.Builder
+	b.WriteString("xychart-beta\n")
+	fmt.Fprintf(&b, "    title %q\n", title)
+	fmt.Fprintf(&b, "    x-axis [%s]\n", strings.Join(quoted, ", "))
+	fmt.Fprintf(&b, "    y-axis %q 0 --> %s\n", yLabel, trendValue(maxValue*1.1))
+	for _, values := range series {
+		formatted := make([]string, 0, len(values))
+		for _, v := range values {
+			formatted = append(formatted, trendValue(v))
+		}
+		fmt.Fprintf(&b, "    line [%s]\n", strings.Join(formatted, ", "))
+	}
+	return b.String()
+}
+
+func trendValue(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// RenderString renders the trends as "html", "md" or "json".
+func (t *Trends) RenderString(outputType string) (string, error) {
+	var buf bytes.Buffer
+	switch outputType {
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(t); err != nil {
+			return "", fmt.Errorf("failed to encode trends to JSON: %w", err)
+		}
+		return buf.String(), nil
+	case "md":
+		if err := mdTemplate.ExecuteTemplate(&buf, "trends_md", t); err != nil {
+			return "", fmt.Errorf("failed to render trends %s: %w", outputType, err)
+		}
+		res := cleanMarkdown(buf)
+		return res.String(), nil
+	default:
+		if err := htmlTemplate.ExecuteTemplate(&buf, "trends_html", t); err != nil {
+			return "", fmt.Errorf("failed to render trends %s: %w", outputType, err)
+		}
+		return buf.String(), nil
+	}
+}
@@ -0,0 +1,249 @@
+package observe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/gather"
+	"github.com/kalverra/octometrics/internal/testhelpers"
+)
+
+// trendWeek is a Monday, the start of the first week of trend test runs.
+var trendWeek = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func trendRun(
+	id int64,
+	name string,
+	started time.Time,
+	duration time.Duration,
+	conclusion, branch, event string,
+) *gather.WorkflowRunData {
+	queue := time.Minute
+	return &gather.WorkflowRunData{
+		WorkflowRun: &github.WorkflowRun{
+			ID:           new(id),
+			Name:         new(name),
+			Conclusion:   new(conclusion),
+			HeadBranch:   new(branch),
+			Event:        new(event),
+			RunStartedAt: &github.Timestamp{Time: started},
+		},
+		RunCompletedAt: started.Add(duration),
+		Cost:           10,
+		CostGathered:   true,
+		Jobs: []*gather.JobData{
+			{
+				WorkflowJob: &github.WorkflowJob{
+					ID:          new(id * 10),
+					Name:        new("test"),
+					Conclusion:  new(conclusion),
+					CreatedAt:   &github.Timestamp{Time: started},
+					StartedAt:   &github.Timestamp{Time: started.Add(queue)},
+					CompletedAt: &github.Timestamp{Time: started.Add(duration)},
+				},
+				Cost:         10,
+				CostGathered: true,
+			},
+		},
+	}
+}
+
+func trendRuns() []*gather.WorkflowRunData {
+	return []*gather.WorkflowRunData{
+		trendRun(1, "CI", trendWeek.Add(10*time.Hour), 10*time.Minute, "success", "main", "push"),
+		trendRun(2, "CI", trendWeek.AddDate(0, 0, 2), 20*time.Minute, "failure", "main", "push"),
+		trendRun(3, "CI", trendWeek.AddDate(0, 0, 8), 30*time.Minute, "success", "feature", "pull_request"),
+		trendRun(4, "CI", trendWeek.AddDate(0, 0, 9), time.Minute, "cancelled", "main", "push"),
+		trendRun(5, "Lint", trendWeek.AddDate(0, 0, 1), 5*time.Minute, "success", "main", "push"),
+	}
+}
+
+func TestBuildTrends(t *testing.T) {
+	t.Parallel()
+
+	trends, err := BuildTrends("kalverra", "octometrics", trendRuns(), TrendFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, TrendIntervalWeek, trends.Filter.Interval, "interval should default to weeks")
+	require.Len(t, trends.Workflows, 2)
+
+	ci := trends.Workflows[0]
+	assert.Equal(t, "CI", ci.Name)
+	assert.Equal(t, 3, ci.Runs, "cancelled runs should be left out")
+	require.Len(t, ci.Points, 2)
+
+	first := ci.Points[0]
+	assert.Equal(t, trendWeek, first.Start)
+	assert.Equal(t, "Jun 2", first.Label())
+	assert.Equal(t, 2, first.Runs)
+	assert.Equal(t, 10*time.Minute, first.DurationP50)
+	assert.Equal(t, 20*time.Minute, first.DurationP90)
+	assert.Equal(t, time.Minute, first.QueueP50)
+	assert.Equal(t, int64(20), first.Cost)
+	assert.Equal(t, 1, first.Failures)
+	assert.InDelta(t, 50.0, first.FailureRate, 0.001)
+
+	second := ci.Points[1]
+	assert.Equal(t, trendWeek.AddDate(0, 0, 7), second.Start, "weeks should start on Monday")
+	assert.Equal(t, 30*time.Minute, second.DurationP50)
+	assert.Zero(t, second.FailureRate)
+
+	require.Len(t, ci.Jobs, 1)
+	job := ci.Jobs[0]
+	assert.Equal(t, "test", job.Name)
+	assert.Equal(t, 3, job.Runs)
+	require.Len(t, job.Points, 2)
+	assert.Equal(t, 9*time.Minute, job.Points[0].DurationP50, "job durations shouldn't include queue time")
+
+	assert.Equal(t, "Lint", trends.Workflows[1].Name)
+}
+
+func TestBuildTrendsFilters(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		filter     TrendFilter
+		opts       []Option
+		wantCIRuns []int
+		workflows  int
+	}{
+		{name: "days", filter: TrendFilter{Interval: TrendIntervalDay}, wantCIRuns: []int{1, 1, 1}, workflows: 2},
+		{name: "branch", filter: TrendFilter{Branch: "main"}, wantCIRuns: []int{2}, workflows: 2},
+		{name: "event", filter: TrendFilter{Event: "pull_request"}, wantCIRuns: []int{1}, workflows: 1},
+		{name: "all events", filter: TrendFilter{Event: "all"}, wantCIRuns: []int{2, 1}, workflows: 2},
+		{
+			name:       "range",
+			filter:     TrendFilter{From: trendWeek.AddDate(0, 0, 2), To: trendWeek.AddDate(0, 0, 14)},
+			wantCIRuns: []int{1, 1},
+			workflows:  1,
+		},
+		{
+			name:       "excluded workflow",
+			opts:       []Option{ExcludeWorkflows([]string{"Lint"})},
+			wantCIRuns: []int{2, 1},
+			workflows:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			trends, err := BuildTrends("kalverra", "octometrics", trendRuns(), tc.filter, tc.opts...)
+			require.NoError(t, err)
+			require.Len(t, trends.Workflows, tc.workflows)
+			ci := trends.Workflows[0]
+			require.Equal(t, "CI", ci.Name)
+			var runs []int
+			for _, p := range ci.Points {
+				runs = append(runs, p.Runs)
+			}
+			assert.Equal(t, tc.wantCIRuns, runs)
+		})
+	}
+}
+
+func TestTrendFilterValidate(t *testing.T) {
+	t.Parallel()
+
+	require.Error(t, (&TrendFilter{Interval: "month"}).Validate())
+	require.Error(t, (&TrendFilter{From: trendWeek, To: trendWeek}).Validate(), "empty ranges should be rejected")
+	require.NoError(t, (&TrendFilter{From: trendWeek}).Validate(), "open ended ranges should be allowed")
+}
+
+func TestTrendFilterBucket(t *testing.T) {
+	t.Parallel()
+
+	// Sunday evening in New York is already Monday in UTC
+	newYork := time.FixedZone("EDT", -4*60*60)
+	sunday := time.Date(2025, 6, 1, 22, 0, 0, 0, newYork)
+
+	day := &TrendFilter{Interval: TrendIntervalDay}
+	assert.Equal(t, trendWeek, day.bucket(sunday), "days should be bucketed in UTC")
+	week := &TrendFilter{Interval: TrendIntervalWeek}
+	assert.Equal(t, trendWeek, week.bucket(sunday), "weeks should be bucketed in UTC")
+	assert.Equal(t, trendWeek, week.bucket(trendWeek.AddDate(0, 0, 6)))
+}
+
+func TestWorkflowTrendCharts(t *testing.T) {
+	t.Parallel()
+
+	trends, err := BuildTrends("kalverra", "octometrics", trendRuns(), TrendFilter{})
+	require.NoError(t, err)
+
+	charts := trends.Workflows[0].Charts()
+	require.Len(t, charts, 4)
+	assert.Equal(t, "Duration (p50 and p90)", charts[0].Title)
+	assert.Contains(t, charts[0].Diagram, "xychart-beta")
+	assert.Contains(t, charts[0].Diagram, `x-axis ["Jun 2", "Jun 9"]`)
+	assert.Contains(t, charts[0].Diagram, "line [10.00, 30.00]")
+	assert.Contains(t, charts[0].Diagram, "line [20.00, 30.00]")
+	assert.Contains(t, charts[3].Diagram, "line [50.00, 0.00]")
+
+	assert.Nil(t, trends.Workflows[1].Charts(), "a single point has no trend to chart")
+}
+
+func TestTrendsRenderString(t *testing.T) {
+	t.Parallel()
+
+	trends, err := BuildTrends("kalverra", "octometrics", trendRuns(), TrendFilter{})
+	require.NoError(t, err)
+
+	md, err := trends.RenderString("md")
+	require.NoError(t, err)
+	assert.Contains(t, md, "CI")
+	assert.Contains(t, md, "| Jun 2 | 2 |")
+	assert.Contains(t, md, "50%")
+
+	out, err := trends.RenderString("json")
+	require.NoError(t, err)
+	var decoded Trends
+	require.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, trends.Workflows[0].Points, decoded.Workflows[0].Points)
+
+	html, err := trends.RenderString("html")
+	require.NoError(t, err)
+	assert.Contains(t, html, "xychart-beta")
+	assert.Contains(t, html, `action="/kalverra/octometrics/trends"`)
+}
+
+func TestHandler_TrendsPage(t *testing.T) {
+	t.Parallel()
+
+	log, dataDir := testhelpers.Setup(t)
+	store := gather.NewFileStore(dataDir)
+	for _, run := range trendRuns() {
+		id := strconv.FormatInt(run.GetID(), 10)
+		require.NoError(t, store.Save("kalverra", "octometrics", gather.WorkflowRunsDataDir, id, run))
+	}
+	handler := NewOnDemandHandler(log, nil, store, t.TempDir())
+
+	req := httptest.NewRequest("GET", "/kalverra/octometrics/trends?branch=main&interval=day", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "Trends")
+	assert.Contains(t, body, "CI")
+	assert.Contains(t, body, `value="main"`)
+	assert.NotContains(t, body, "Jun 10", "runs of other branches should be left out")
+
+	req = httptest.NewRequest("GET", "/kalverra/octometrics/trends?interval=month", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest("GET", "/kalverra/octometrics/trends?from=yesterday", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
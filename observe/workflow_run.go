@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -53,7 +54,7 @@ func workflowRunObservation(workflowRun *gather.WorkflowRunData) (*Observation,
 	observationData := &Observation{
 		ID:           fmt.Sprint(workflowRunID),
 		Name:         workflowRun.GetName(),
-		GitHubLink:   workflowRun.GetHTMLURL(),
+		GitHubLink:   gitHubRunLink(workflowRun),
 		Owner:        owner,
 		Repo:         repo,
 		State:        state,
@@ -72,12 +73,14 @@ func workflowRunObservation(workflowRun *gather.WorkflowRunData) (*Observation,
 	workflowRunTimelineData.CriticalPath = CalculateCriticalPath(workflowRun.GetJobs(), workflowRun.GetWorkflowDef())
 	workflowRunTimelineData.StepSummaries, _ = AggregateSteps(workflowRun.GetJobs())
 	workflowRunTimelineData.SlowestJobSteps = GetSlowestJobSteps(workflowRun.GetJobs(), 5)
+	workflowRunTimelineData.Matrices = BuildMatrixJobs(workflowRun.GetWorkflowDef(), workflowRun.GetJobs())
 
 	observationData.TimelineData = []*Timeline{workflowRunTimelineData}
 	observationData.FlowChart = buildFlowChart(workflowRun.GetWorkflowDef(), workflowRun.GetJobs())
 	observationData.CriticalPath = workflowRunTimelineData.CriticalPath
 	observationData.StepSummaries = workflowRunTimelineData.StepSummaries
 	observationData.SlowestJobSteps = workflowRunTimelineData.SlowestJobSteps
+	observationData.Matrices = workflowRunTimelineData.Matrices
 
 	return observationData, nil
 }
@@ -119,7 +122,7 @@ func buildWorkflowRunTimelineData(workflowRun *gather.WorkflowRunData) (*Timelin
 			if startedAt.After(workflowRun.CorrespondingPRCloseTime) {
 				postTimelineItems = append(postTimelineItems, PostTimelineItem{
 					Name: job.GetName(),
-					Link: job.GetHTMLURL(),
+					Link: gitHubJobLink(workflowRun, job),
 					Time: startedAt,
 				})
 				continue
@@ -145,7 +148,7 @@ func buildWorkflowRunTimelineData(workflowRun *gather.WorkflowRunData) (*Timelin
 			Duration:      duration,
 			QueueDuration: queueDuration,
 			Link:          jobRunLink(owner, repo, job.GetID()) + ".html",
-			HTMLURL:       job.GetHTMLURL(),
+			HTMLURL:       gitHubJobLink(workflowRun, job),
 			Runner:        job.GetRunner(),
 			Cost:          job.GetCost(),
 			CostEstimate:  job.GetCostEstimate(),
@@ -203,3 +206,29 @@ func jobRunLink(owner, repo string, jobRunID int64) string {
 func workflowRunLink(owner, repo string, workflowRunID int64) string {
 	return path.Join("/", owner, repo, gather.WorkflowRunsDataDir, fmt.Sprint(workflowRunID))
 }
+
+// gitHubRunLink returns the GitHub web URL for a workflow run.
+// The API's html_url is preferred; when it's missing (e.g. older cached data) the link is built from the
+// repository's html_url, which keeps GitHub Enterprise Server hosts intact instead of assuming github.com.
+func gitHubRunLink(workflowRun *gather.WorkflowRunData) string {
+	if link := workflowRun.GetHTMLURL(); link != "" {
+		return link
+	}
+	repoURL := workflowRun.GetRepository().GetHTMLURL()
+	if repoURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/actions/runs/%d", strings.TrimSuffix(repoURL, "/"), workflowRun.GetID())
+}
+
+// gitHubJobLink returns the GitHub web URL for a job, falling back to one built from the workflow run's link.
+func gitHubJobLink(workflowRun *gather.WorkflowRunData, job *gather.JobData) string {
+	if link := job.GetHTMLURL(); link != "" {
+		return link
+	}
+	runURL := gitHubRunLink(workflowRun)
+	if runURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/job/%d", runURL, job.GetID())
+}
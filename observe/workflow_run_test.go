@@ -159,3 +159,52 @@ func TestWorkflowRunTimelineData_CostPropagated(t *testing.T) {
 	assert.Equal(t, int64(40), timeline.Items[0].Cost, "TimelineItem.Cost should be propagated from job data")
 	assert.True(t, timeline.Items[0].CostGathered, "TimelineItem.CostGathered should be propagated from job data")
 }
+
+func TestWorkflowRunObservation_EnterpriseLinks(t *testing.T) {
+	t.Parallel()
+
+	wfRun := &github.WorkflowRun{
+		ID:           new(int64(42)),
+		Name:         new("wf"),
+		Event:        new("push"),
+		RunStartedAt: &github.Timestamp{Time: testStartTime},
+		Repository: &github.Repository{
+			Name:    new("repo"),
+			Owner:   &github.User{Login: new("owner")},
+			HTMLURL: new("https://ghes.example.com/owner/repo"),
+		},
+	}
+	job := &github.WorkflowJob{
+		ID:          new(int64(7)),
+		Name:        new("build"),
+		Status:      new("completed"),
+		Conclusion:  new("success"),
+		StartedAt:   &github.Timestamp{Time: testStartTime},
+		CompletedAt: &github.Timestamp{Time: testEndTime},
+	}
+	wfData := &gather.WorkflowRunData{
+		WorkflowRun: wfRun,
+		Jobs:        []*gather.JobData{{WorkflowJob: job}},
+	}
+
+	obs, err := workflowRunObservation(wfData)
+	require.NoError(t, err)
+	assert.Equal(t, "https://ghes.example.com/owner/repo/actions/runs/42", obs.GitHubLink)
+	require.Len(t, obs.TimelineData, 1)
+	require.Len(t, obs.TimelineData[0].Items, 1)
+	item := obs.TimelineData[0].Items[0]
+	assert.Equal(t, "https://ghes.example.com/owner/repo/actions/runs/42/job/7", item.HTMLURL)
+	assert.Equal(t, "/owner/repo/job_runs/7.html", item.Link, "local links should stay host independent")
+	assert.Equal(t, "/owner/repo/workflow_runs/42", workflowRunLink("owner", "repo", 42))
+
+	jobObs, err := jobRunObservations(wfData)
+	require.NoError(t, err)
+	require.Len(t, jobObs, 1)
+	assert.Equal(t, "https://ghes.example.com/owner/repo/actions/runs/42/job/7", jobObs[0].GitHubLink)
+
+	job.HTMLURL = new("https://ghes.example.com/owner/repo/actions/runs/42/job/7?pr=1")
+	jobObs, err = jobRunObservations(wfData)
+	require.NoError(t, err)
+	require.Len(t, jobObs, 1)
+	assert.Equal(t, *job.HTMLURL, jobObs[0].GitHubLink, "API-provided html_url should be preferred")
+}
@@ -4,6 +4,7 @@ package report
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -22,6 +23,7 @@ type ghaContext struct {
 	Token       string
 	StepSummary string
 	ServerURL   string
+	APIURL      string
 	SHA         string
 }
 
@@ -51,6 +53,7 @@ func detectGitHubActions() (*ghaContext, error) {
 		Token:       os.Getenv("GITHUB_TOKEN"),
 		StepSummary: os.Getenv("GITHUB_STEP_SUMMARY"),
 		ServerURL:   os.Getenv("GITHUB_SERVER_URL"),
+		APIURL:      os.Getenv("GITHUB_API_URL"),
 		SHA:         os.Getenv("GITHUB_SHA"),
 	}, nil
 }
@@ -72,8 +75,20 @@ func (g *ghaContext) pullRequestNumber() (int, bool) {
 	return num, true
 }
 
+// newGitHubClient creates a REST client for the current run. On GitHub Enterprise Server,
+// GITHUB_API_URL points at the instance's REST API and is used as the base URL.
 func (g *ghaContext) newGitHubClient() (*github.Client, error) {
-	client, err := github.NewClient(github.WithAuthToken(g.Token))
+	opts := []github.ClientOptionsFunc{github.WithAuthToken(g.Token)}
+	if g.APIURL != "" {
+		u, err := url.Parse(g.APIURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_API_URL %q: %w", g.APIURL, err)
+		}
+		if !strings.EqualFold(u.Hostname(), "api.github.com") {
+			opts = append(opts, github.WithURLs(&g.APIURL, nil))
+		}
+	}
+	client, err := github.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
 	}
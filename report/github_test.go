@@ -1,10 +1,15 @@
 package report
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/internal/testhelpers"
 )
 
 func TestPullRequestNumber(t *testing.T) {
@@ -48,3 +53,49 @@ func TestDetectGitHubActionsNotInGHA(t *testing.T) {
 	require.Error(t, err)
 	assert.Nil(t, gha)
 }
+
+func TestNewGitHubClientAPIURL(t *testing.T) {
+	t.Parallel()
+
+	gha := &ghaContext{Token: "token", APIURL: "https://api.github.com"}
+	client, err := gha.newGitHubClient()
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.github.com/", client.BaseURL())
+
+	gha = &ghaContext{Token: "token", APIURL: "https://ghes.example.com/api/v3"}
+	client, err = gha.newGitHubClient()
+	require.NoError(t, err)
+	assert.Equal(t, "https://ghes.example.com/api/v3/", client.BaseURL())
+}
+
+func TestEnterpriseServerComment(t *testing.T) {
+	t.Parallel()
+
+	log, _ := testhelpers.Setup(t)
+
+	var gotPath, gotBody string
+	ghes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		var comment struct {
+			Body string `json:"body"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&comment)
+		gotBody = comment.Body
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	t.Cleanup(ghes.Close)
+
+	gha := &ghaContext{
+		Owner:   "kalverra",
+		Repo:    "octometrics",
+		Token:   "token",
+		SHA:     "abc123",
+		JobName: "build",
+		APIURL:  ghes.URL + "/api/v3",
+	}
+	require.NoError(t, postComment(log, gha, "report"))
+	assert.Equal(t, "/api/v3/repos/kalverra/octometrics/commits/abc123/comments", gotPath)
+	assert.Contains(t, gotBody, "report")
+}
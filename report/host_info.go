@@ -7,7 +7,8 @@ import (
 	"github.com/kalverra/octometrics/monitor"
 )
 
-// machineInfoMarkdown returns a "### Host" section with CPU/memory/disk from analysis, or empty if none.
+// machineInfoMarkdown returns a "### Host" section with CPU/memory/disk and any container limits from analysis,
+// or empty if none.
 func machineInfoMarkdown(analysis *monitor.Analysis) string {
 	if analysis == nil || analysis.SystemInfo == nil {
 		return ""
@@ -34,6 +35,18 @@ func machineInfoMarkdown(analysis *monitor.Analysis) string {
 		lines = append(lines, fmt.Sprintf("- **Disk:** %.1f %s total", float64(si.Disk.Total)/div, unit))
 	}
 
+	if cg := si.Cgroup; cg != nil && (cg.CPULimit > 0 || cg.MemoryMax > 0) {
+		var limits []string
+		if cg.CPULimit > 0 {
+			limits = append(limits, fmt.Sprintf("%.1f CPUs", cg.CPULimit))
+		}
+		if cg.MemoryMax > 0 {
+			div, unit := byteScale(cg.MemoryMax)
+			limits = append(limits, fmt.Sprintf("%.1f %s RAM", float64(cg.MemoryMax)/div, unit))
+		}
+		lines = append(lines, fmt.Sprintf("- **Container limits:** %s (cgroup v%d)", strings.Join(limits, ", "), cg.Version))
+	}
+
 	if len(lines) == 0 {
 		return ""
 	}
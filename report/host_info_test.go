@@ -37,4 +37,18 @@ func TestMachineInfoMarkdown(t *testing.T) {
 		assert.Contains(t, md, "RAM")
 		assert.Contains(t, md, "Disk")
 	})
+	t.Run("container limits", func(t *testing.T) {
+		t.Parallel()
+		md := machineInfoMarkdown(&monitor.Analysis{
+			SystemInfo: &monitor.SystemInfo{
+				Cgroup: &monitor.SystemCgroupInfo{Version: 2, CPULimit: 2, MemoryMax: 4 * 1024 * 1024 * 1024},
+			},
+		})
+		assert.Contains(t, md, "- **Container limits:** 2.0 CPUs, 4.0 GB RAM (cgroup v2)")
+
+		md = machineInfoMarkdown(&monitor.Analysis{
+			SystemInfo: &monitor.SystemInfo{Cgroup: &monitor.SystemCgroupInfo{Version: 1}},
+		})
+		assert.Empty(t, md, "an unlimited cgroup has nothing worth showing")
+	})
 }
@@ -138,7 +138,11 @@ func MonitoringMermaidCharts(analysis *monitor.Analysis) []MonitoringChart {
 	if d := diskChartDiagram(analysis); d != "" {
 		charts = append(charts, MonitoringChart{Title: "Disk Usage", Diagram: d})
 	}
+	charts = append(charts, diskIOChartDiagrams(analysis, time.Time{}, time.Time{}, false)...)
 	charts = append(charts, ioChartDiagrams(analysis)...)
+	if d := topProcessesChartDiagram(analysis); d != "" {
+		charts = append(charts, MonitoringChart{Title: "Top Processes", Diagram: d})
+	}
 	if len(charts) == 0 {
 		return nil
 	}
@@ -169,7 +173,11 @@ func MonitoringMermaidChartsWithWindow(analysis *monitor.Analysis, windowStart,
 	if d := diskChartDiagramWindowed(analysis, windowStart, axisEnd); d != "" {
 		charts = append(charts, MonitoringChart{Title: "Disk Usage", Diagram: d})
 	}
+	charts = append(charts, diskIOChartDiagrams(analysis, windowStart, axisEnd, true)...)
 	charts = append(charts, ioChartDiagramsWindowed(analysis, windowStart, axisEnd)...)
+	if d := topProcessesChartDiagram(analysis); d != "" {
+		charts = append(charts, MonitoringChart{Title: "Top Processes", Diagram: d})
+	}
 	if len(charts) == 0 {
 		return nil
 	}
@@ -202,6 +210,11 @@ func latestMetricTime(a *monitor.Analysis) time.Time {
 			latest = p.Time
 		}
 	}
+	for _, p := range a.DiskIOMeasurements {
+		if p != nil && p.Time.After(latest) {
+			latest = p.Time
+		}
+	}
 	for _, p := range a.IOMeasurements {
 		if p != nil && p.Time.After(latest) {
 			latest = p.Time
@@ -704,6 +717,123 @@ func ioChartDiagramsWindowed(analysis *monitor.Analysis, windowStart, axisEnd ti
 	return charts
 }
 
+// diskIOChart builds Mermaid xychart-beta line charts for block device throughput and IOPS.
+func diskIOChart(analysis *monitor.Analysis) string {
+	var b strings.Builder
+	for _, c := range diskIOChartDiagrams(analysis, time.Time{}, time.Time{}, false) {
+		b.WriteString(markdownMermaidBlock(c.Diagram))
+	}
+	return b.String()
+}
+
+// diskIOChartDiagrams returns read and write throughput charts, each in its best unit per second,
+// and a combined read+write IOPS chart. Series that never moved are left out.
+func diskIOChartDiagrams(
+	analysis *monitor.Analysis,
+	windowStart, axisEnd time.Time,
+	windowed bool,
+) []MonitoringChart {
+	if len(analysis.DiskIOMeasurements) == 0 {
+		return nil
+	}
+	read, write, iops := diskIORatesOverTime(analysis.DiskIOMeasurements)
+
+	var charts []MonitoringChart
+	for _, series := range []struct {
+		title  string
+		points []timeValue
+		bytes  bool
+	}{
+		{title: "Disk Read", points: read, bytes: true},
+		{title: "Disk Write", points: write, bytes: true},
+		{title: "Disk IOPS", points: iops},
+	} {
+		var peak float64
+		for _, p := range series.points {
+			peak = max(peak, p.Value)
+		}
+		if peak == 0 {
+			continue
+		}
+
+		div, unit := 1.0, "ops/s"
+		if series.bytes {
+			div, unit = byteScale(uint64(peak))
+			unit += "/s"
+		}
+		scaled := make([]timeValue, len(series.points))
+		for i, p := range series.points {
+			scaled[i] = timeValue{Time: p.Time, Value: p.Value / div}
+		}
+		d := buildXYChartDiagram(
+			fmt.Sprintf("%s (%s)", series.title, unit),
+			unit,
+			0,
+			peak/div*1.1,
+			downsample(scaled, defaultTargetPoints, maxAggregator),
+			windowStart,
+			axisEnd,
+			windowed,
+		)
+		if d != "" {
+			charts = append(charts, MonitoringChart{Title: series.title, Diagram: d})
+		}
+	}
+	return charts
+}
+
+// diskIORatesOverTime sums every device's I/O at each observation and turns the per-interval deltas
+// into per-second rates. A lone observation is assumed to cover the monitor's default 1s interval.
+func diskIORatesOverTime(measurements []*monitor.DiskIOMeasurement) (readBytes, writeBytes, iops []timeValue) {
+	type diskIOTotal struct {
+		read, write, ops uint64
+	}
+	byTime := make(map[time.Time]*diskIOTotal)
+	for _, m := range measurements {
+		if m == nil {
+			continue
+		}
+		total, ok := byTime[m.Time]
+		if !ok {
+			total = &diskIOTotal{}
+			byTime[m.Time] = total
+		}
+		total.read += m.ReadBytes
+		total.write += m.WriteBytes
+		total.ops += m.ReadCount + m.WriteCount
+	}
+	if len(byTime) == 0 {
+		return nil, nil, nil
+	}
+
+	times := make([]time.Time, 0, len(byTime))
+	for t := range byTime {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	readBytes = make([]timeValue, len(times))
+	writeBytes = make([]timeValue, len(times))
+	iops = make([]timeValue, len(times))
+	for i, t := range times {
+		elapsed := time.Second
+		switch {
+		case i > 0:
+			elapsed = t.Sub(times[i-1])
+		case len(times) > 1:
+			elapsed = times[1].Sub(t)
+		}
+		seconds := elapsed.Seconds()
+		total := byTime[t]
+		readBytes[i] = timeValue{Time: t, Value: float64(total.read) / seconds}
+		writeBytes[i] = timeValue{Time: t, Value: float64(total.write) / seconds}
+		iops[i] = timeValue{Time: t, Value: float64(total.ops) / seconds}
+	}
+	return readBytes, writeBytes, iops
+}
+
 // cpuAverageOverTime computes the average CPU usage across all cores at each timestamp.
 func cpuAverageOverTime(cpuMeasurements map[int][]*monitor.CPUMeasurement) []timeValue {
 	type cpuReading struct {
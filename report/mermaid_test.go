@@ -374,6 +374,46 @@ func TestIOChart(t *testing.T) {
 	assert.Contains(t, result, "Network Received (MB)", "MB-range data should select MB unit")
 }
 
+func TestDiskIOChart(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	analysis := &monitor.Analysis{
+		DiskIOMeasurements: []*monitor.DiskIOMeasurement{
+			{Time: base, Device: "sda", ReadBytes: 2 * 1024 * 1024, WriteCount: 10},
+			{Time: base, Device: "sdb", ReadBytes: 2 * 1024 * 1024, WriteCount: 10},
+			{Time: base.Add(2 * time.Second), Device: "sda", ReadBytes: 8 * 1024 * 1024, ReadCount: 100},
+			{Time: base.Add(2 * time.Second), Device: "sdb", ReadCount: 100},
+		},
+	}
+
+	read, write, iops := diskIORatesOverTime(analysis.DiskIOMeasurements)
+	require.Len(t, read, 2)
+	assert.InDelta(t, 2*1024*1024, read[0].Value, 0.01, "devices should be summed and divided by the 2s interval")
+	assert.InDelta(t, 4*1024*1024, read[1].Value, 0.01)
+	assert.Zero(t, write[1].Value)
+	assert.InDelta(t, 10, iops[0].Value, 0.01)
+	assert.InDelta(t, 100, iops[1].Value, 0.01)
+
+	charts := diskIOChartDiagrams(analysis, time.Time{}, time.Time{}, false)
+	titles := make([]string, len(charts))
+	for i, c := range charts {
+		titles[i] = c.Title
+	}
+	assert.Equal(t, []string{"Disk Read", "Disk IOPS"}, titles, "idle series should be skipped")
+	assert.Contains(t, charts[0].Diagram, "Disk Read (MB/s)")
+	assert.Contains(t, charts[1].Diagram, "Disk IOPS (ops/s)")
+
+	result := buildReport(analysis, nil)
+	assert.Contains(t, result, "### Disk I/O")
+	assert.Contains(t, result, "| Disk Read | 4.0 MB/s peak / 12.0 MB total | — |")
+	assert.Contains(t, result, "| Disk IOPS | 100 | 55 |")
+
+	windowed := MonitoringMermaidChartsWithWindow(analysis, base, base.Add(10*time.Second))
+	require.Len(t, windowed, 2)
+	assert.Contains(t, windowed[0].Diagram, `x-axis "Seconds" 0 --> 10`)
+}
+
 func TestDownsample(t *testing.T) {
 	t.Parallel()
 
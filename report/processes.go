@@ -0,0 +1,150 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/kalverra/octometrics/monitor"
+)
+
+// DefaultTopProcesses is how many processes top processes tables and charts show.
+const DefaultTopProcesses = 10
+
+// ProcessSummary aggregates every sample of one process recorded by the monitor's process sampler.
+type ProcessSummary struct {
+	PID     int32
+	PPID    int32
+	Name    string
+	Cmdline string
+	// PeakCPUPercent and AvgCPUPercent are relative to one core, so busy multi-threaded processes can exceed 100%.
+	PeakCPUPercent float64
+	AvgCPUPercent  float64
+	PeakRSS        uint64
+	// Samples is how many observations the process made the top processes list in.
+	Samples int
+}
+
+// DisplayName is the process name and PID, e.g. "go (1234)".
+func (p *ProcessSummary) DisplayName() string {
+	name := p.Name
+	if name == "" {
+		name = "unknown"
+	}
+	return fmt.Sprintf("%s (%d)", name, p.PID)
+}
+
+// PeakRSSString is PeakRSS in human-readable units.
+func (p *ProcessSummary) PeakRSSString() string {
+	return formatBytes(p.PeakRSS)
+}
+
+// MarkdownCmdline is Cmdline as a code span that is safe inside a markdown table.
+func (p *ProcessSummary) MarkdownCmdline() string {
+	return markdownCode(p.Cmdline)
+}
+
+// TopProcesses returns up to n processes from analysis, heaviest peak CPU first, with ties broken by peak memory.
+// Averages only cover the observations where the process made the top processes list.
+func TopProcesses(analysis *monitor.Analysis, n int) []*ProcessSummary {
+	if analysis == nil || len(analysis.ProcessMeasurements) == 0 || n <= 0 {
+		return nil
+	}
+
+	var (
+		byPID  = map[int32]*ProcessSummary{}
+		cpuSum = map[int32]float64{}
+	)
+	for _, m := range analysis.ProcessMeasurements {
+		if m == nil {
+			continue
+		}
+		summary, ok := byPID[m.PID]
+		if !ok {
+			summary = &ProcessSummary{PID: m.PID, PPID: m.PPID, Name: m.Name, Cmdline: m.Cmdline}
+			byPID[m.PID] = summary
+		}
+		summary.Samples++
+		summary.PeakCPUPercent = max(summary.PeakCPUPercent, m.CPUPercent)
+		summary.PeakRSS = max(summary.PeakRSS, m.RSS)
+		cpuSum[m.PID] += m.CPUPercent
+	}
+
+	summaries := make([]*ProcessSummary, 0, len(byPID))
+	for pid, summary := range byPID {
+		summary.AvgCPUPercent = cpuSum[pid] / float64(summary.Samples)
+		summaries = append(summaries, summary)
+	}
+	slices.SortFunc(summaries, func(a, b *ProcessSummary) int {
+		return cmp.Or(
+			cmp.Compare(b.PeakCPUPercent, a.PeakCPUPercent),
+			cmp.Compare(b.PeakRSS, a.PeakRSS),
+			cmp.Compare(a.PID, b.PID),
+		)
+	})
+	return summaries[:min(n, len(summaries))]
+}
+
+// topProcessesTable produces a markdown table of the heaviest processes.
+func topProcessesTable(analysis *monitor.Analysis) string {
+	processes := TopProcesses(analysis, DefaultTopProcesses)
+	if len(processes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("| Process | PPID | Peak CPU | Avg CPU | Peak Memory | Command |\n")
+	b.WriteString("|---------|------|----------|---------|-------------|---------|\n")
+	for _, p := range processes {
+		fmt.Fprintf(&b, "| %s | %d | %.1f%% | %.1f%% | %s | %s |\n",
+			escapeMarkdownTableCell(p.DisplayName()),
+			p.PPID,
+			p.PeakCPUPercent,
+			p.AvgCPUPercent,
+			p.PeakRSSString(),
+			p.MarkdownCmdline(),
+		)
+	}
+	return b.String()
+}
+
+// topProcessesChartDiagram builds a Mermaid xychart-beta bar chart of the heaviest processes' peak CPU.
+func topProcessesChartDiagram(analysis *monitor.Analysis) string {
+	processes := TopProcesses(analysis, DefaultTopProcesses)
+	if len(processes) == 0 {
+		return ""
+	}
+
+	var (
+		labels = make([]string, len(processes))
+		values = make([]string, len(processes))
+		yMax   = 100.0
+	)
+	for i, p := range processes {
+		labels[i] = fmt.Sprintf("%q", sanitizeMermaidName(p.DisplayName()))
+		values[i] = fmt.Sprintf("%.1f", p.PeakCPUPercent)
+		yMax = max(yMax, p.PeakCPUPercent)
+	}
+
+	var b strings.Builder
+	b.WriteString("xychart-beta\n")
+	fmt.Fprintf(&b, "    title %q\n", "Top Processes by Peak CPU (%)")
+	fmt.Fprintf(&b, "    x-axis [%s]\n", strings.Join(labels, ", "))
+	fmt.Fprintf(&b, "    y-axis %q 0 --> %.0f\n", "CPU % of one core", math.Ceil(yMax))
+	fmt.Fprintf(&b, "    bar [%s]\n", strings.Join(values, ", "))
+	return b.String()
+}
+
+func escapeMarkdownTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// markdownCode wraps s in a code span, or returns an em dash when empty.
+func markdownCode(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return "`" + strings.ReplaceAll(escapeMarkdownTableCell(s), "`", "'") + "`"
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/monitor"
+)
+
+func processAnalysis() *monitor.Analysis {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	return &monitor.Analysis{
+		ProcessMeasurements: []*monitor.ProcessMeasurement{
+			{
+				Time:       base,
+				PID:        10,
+				PPID:       1,
+				Name:       "go",
+				Cmdline:    "go build ./...",
+				CPUPercent: 150,
+				RSS:        512 * 1024 * 1024,
+			},
+			{
+				Time:       base,
+				PID:        20,
+				PPID:       1,
+				Name:       "node",
+				Cmdline:    "node a|b.js",
+				CPUPercent: 10,
+				RSS:        2 * 1024 * 1024 * 1024,
+			},
+			{
+				Time:       base.Add(time.Second),
+				PID:        10,
+				PPID:       1,
+				Name:       "go",
+				Cmdline:    "go build ./...",
+				CPUPercent: 250,
+				RSS:        256 * 1024 * 1024,
+			},
+			{Time: base.Add(time.Second), PID: 30, PPID: 10, Name: "compile", CPUPercent: 10, RSS: 1024},
+		},
+	}
+}
+
+func TestTopProcesses(t *testing.T) {
+	t.Parallel()
+
+	processes := TopProcesses(processAnalysis(), 10)
+	require.Len(t, processes, 3)
+
+	goProc := processes[0]
+	assert.Equal(t, "go (10)", goProc.DisplayName())
+	assert.InDelta(t, 250.0, goProc.PeakCPUPercent, 0.001)
+	assert.InDelta(t, 200.0, goProc.AvgCPUPercent, 0.001)
+	assert.Equal(t, uint64(512*1024*1024), goProc.PeakRSS)
+	assert.Equal(t, 2, goProc.Samples)
+
+	assert.Equal(t, int32(20), processes[1].PID, "CPU ties should be broken by peak memory")
+	assert.Equal(t, int32(30), processes[2].PID)
+
+	assert.Len(t, TopProcesses(processAnalysis(), 1), 1)
+	assert.Nil(t, TopProcesses(&monitor.Analysis{}, 10))
+	assert.Nil(t, TopProcesses(nil, 10))
+}
+
+func TestTopProcessesTable(t *testing.T) {
+	t.Parallel()
+
+	table := topProcessesTable(processAnalysis())
+	assert.Contains(t, table, "| Process | PPID | Peak CPU | Avg CPU | Peak Memory | Command |")
+	assert.Contains(t, table, "| go (10) | 1 | 250.0% | 200.0% | 512.0 MB | `go build ./...` |")
+	assert.Contains(t, table, "`node a\\|b.js`", "pipes in command lines should not break the table")
+	assert.Contains(t, table, "| compile (30) | 10 | 10.0% | 10.0% | 1.0 KB | — |")
+
+	assert.Empty(t, topProcessesTable(&monitor.Analysis{}))
+}
+
+func TestTopProcessesChart(t *testing.T) {
+	t.Parallel()
+
+	d := topProcessesChartDiagram(processAnalysis())
+	assert.Contains(t, d, "xychart-beta")
+	assert.Contains(t, d, `x-axis ["go (10)", "node (20)", "compile (30)"]`)
+	assert.Contains(t, d, "y-axis \"CPU % of one core\" 0 --> 250")
+	assert.Contains(t, d, "bar [250.0, 10.0, 10.0]")
+
+	result := buildReport(processAnalysis(), nil)
+	assert.Contains(t, result, "### Top Processes")
+	assert.Equal(t, 1, strings.Count(result, "```mermaid"), "only the process chart has data")
+
+	charts := MonitoringMermaidCharts(processAnalysis())
+	require.Len(t, charts, 1)
+	assert.Equal(t, "Top Processes", charts[0].Title)
+}
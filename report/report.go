@@ -45,7 +45,7 @@ func Run(log zerolog.Logger, opts Options) error {
 	fmt.Print(markdown)
 
 	if !opts.SkipSummary {
-		if err := writeSummary(gha.StepSummary, markdown); err != nil {
+		if err := WriteSummary(gha.StepSummary, markdown); err != nil {
 			return fmt.Errorf("failed to write step summary: %w", err)
 		}
 		log.Info().Msg("Wrote step summary")
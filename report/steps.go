@@ -0,0 +1,107 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+
+	"github.com/kalverra/octometrics/monitor"
+)
+
+// StepResourceUsage is the resource usage of one job step, ready for display.
+type StepResourceUsage struct {
+	*monitor.StepUsage
+}
+
+// DurationString is the step's duration rounded to the second.
+func (s *StepResourceUsage) DurationString() string {
+	return s.Duration().Round(time.Second).String()
+}
+
+// PeakMemoryString is PeakMemoryUsed in human-readable units, or an em dash when memory wasn't observed.
+func (s *StepResourceUsage) PeakMemoryString() string {
+	if s.PeakMemoryUsed == 0 {
+		return "—"
+	}
+	return formatBytes(s.PeakMemoryUsed)
+}
+
+// BytesSentString is BytesSent in human-readable units.
+func (s *StepResourceUsage) BytesSentString() string {
+	return formatBytes(s.BytesSent)
+}
+
+// BytesRecvString is BytesRecv in human-readable units.
+func (s *StepResourceUsage) BytesRecvString() string {
+	return formatBytes(s.BytesRecv)
+}
+
+// StepResources attributes monitoring data to job steps. Steps marked with 'octometrics monitor mark' win,
+// otherwise the runner's own step timing is used. Steps without any observations are left out.
+func StepResources(analysis *monitor.Analysis, steps []*github.TaskStep) []*StepResourceUsage {
+	if analysis == nil {
+		return nil
+	}
+
+	usages := analysis.Steps
+	if len(usages) == 0 {
+		usages = analysis.AttributeSteps(taskStepWindows(steps))
+	}
+
+	resources := make([]*StepResourceUsage, 0, len(usages))
+	for _, usage := range usages {
+		if usage.Observations == 0 {
+			continue
+		}
+		resources = append(resources, &StepResourceUsage{StepUsage: usage})
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	return resources
+}
+
+// taskStepWindows converts completed, non-skipped GitHub job steps to step windows.
+func taskStepWindows(steps []*github.TaskStep) []*monitor.StepWindow {
+	windows := make([]*monitor.StepWindow, 0, len(steps))
+	for _, s := range steps {
+		if s.StartedAt == nil || s.CompletedAt == nil || s.GetConclusion() == "skipped" {
+			continue
+		}
+		if !s.CompletedAt.After(s.StartedAt.Time) {
+			continue
+		}
+		windows = append(windows, &monitor.StepWindow{
+			Name:  s.GetName(),
+			Start: s.StartedAt.Time,
+			End:   s.CompletedAt.Time,
+		})
+	}
+	return windows
+}
+
+// stepResourcesTable produces a markdown table of each step's peak CPU, memory and network usage.
+func stepResourcesTable(analysis *monitor.Analysis, steps []*github.TaskStep) string {
+	resources := StepResources(analysis, steps)
+	if len(resources) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("| Step | Duration | Peak CPU | Avg CPU | Peak Memory | Network Sent | Network Received |\n")
+	b.WriteString("|------|----------|----------|---------|-------------|--------------|------------------|\n")
+	for _, s := range resources {
+		fmt.Fprintf(&b, "| %s | %s | %.1f%% | %.1f%% | %s | %s | %s |\n",
+			escapeMarkdownTableCell(s.Name),
+			s.DurationString(),
+			s.PeakCPUPercent,
+			s.AvgCPUPercent,
+			s.PeakMemoryString(),
+			s.BytesSentString(),
+			s.BytesRecvString(),
+		)
+	}
+	return b.String()
+}
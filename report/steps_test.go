@@ -0,0 +1,110 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v89/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kalverra/octometrics/monitor"
+)
+
+func stepAnalysis(base time.Time) *monitor.Analysis {
+	const mb = 1024 * 1024
+	return &monitor.Analysis{
+		CPUMeasurements: map[int][]*monitor.CPUMeasurement{
+			0: {
+				{Time: base, UsedPercent: 20},
+				{Time: base.Add(5 * time.Second), UsedPercent: 90},
+				{Time: base.Add(6 * time.Second), UsedPercent: 70},
+			},
+		},
+		MemoryMeasurements: []*monitor.MemoryMeasurement{
+			{Time: base, Used: 100 * mb},
+			{Time: base.Add(5 * time.Second), Used: 512 * mb},
+		},
+		IOMeasurements: []*monitor.IOMeasurement{
+			{Time: base, BytesSent: 2048, BytesRecv: 4 * mb},
+			{Time: base.Add(5 * time.Second), BytesSent: 1024, BytesRecv: 1024},
+		},
+	}
+}
+
+func TestStepResources(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	steps := []*github.TaskStep{
+		{
+			Name:        new("Set up job"),
+			Conclusion:  new("success"),
+			StartedAt:   &github.Timestamp{Time: base},
+			CompletedAt: &github.Timestamp{Time: base.Add(5 * time.Second)},
+		},
+		{
+			Name:        new("Run a|b tests"),
+			Conclusion:  new("success"),
+			StartedAt:   &github.Timestamp{Time: base.Add(5 * time.Second)},
+			CompletedAt: &github.Timestamp{Time: base.Add(10 * time.Second)},
+		},
+		{
+			Name:        new("Skipped"),
+			Conclusion:  new("skipped"),
+			StartedAt:   &github.Timestamp{Time: base.Add(10 * time.Second)},
+			CompletedAt: &github.Timestamp{Time: base.Add(10 * time.Second)},
+		},
+		{
+			Name:        new("Post job cleanup"),
+			Conclusion:  new("success"),
+			StartedAt:   &github.Timestamp{Time: base.Add(time.Minute)},
+			CompletedAt: &github.Timestamp{Time: base.Add(2 * time.Minute)},
+		},
+	}
+
+	t.Run("runner step timing", func(t *testing.T) {
+		t.Parallel()
+
+		resources := StepResources(stepAnalysis(base), steps)
+		require.Len(t, resources, 2, "skipped steps and steps without observations should be left out")
+		assert.Equal(t, "Set up job", resources[0].Name)
+		assert.Equal(t, "5s", resources[0].DurationString())
+		assert.InDelta(t, 90.0, resources[1].PeakCPUPercent, 0.001)
+
+		table := stepResourcesTable(stepAnalysis(base), steps)
+		assert.Contains(t, table,
+			"| Step | Duration | Peak CPU | Avg CPU | Peak Memory | Network Sent | Network Received |")
+		assert.Contains(t, table, "| Set up job | 5s | 20.0% | 20.0% | 100.0 MB | 2.0 KB | 4.0 MB |")
+		assert.Contains(t, table, "| Run a\\|b tests | 5s | 90.0% | 80.0% | 512.0 MB | 1.0 KB | 1.0 KB |")
+	})
+
+	t.Run("markers win over runner step timing", func(t *testing.T) {
+		t.Parallel()
+
+		analysis := stepAnalysis(base)
+		analysis.StepMarkers = []*monitor.StepMarker{{Time: base, Name: "Everything"}}
+		analysis.Steps = analysis.AttributeSteps(analysis.MarkerStepWindows())
+
+		resources := StepResources(analysis, steps)
+		require.Len(t, resources, 1)
+		assert.Equal(t, "Everything", resources[0].Name)
+		assert.InDelta(t, 90.0, resources[0].PeakCPUPercent, 0.001)
+		assert.Equal(t, "512.0 MB", resources[0].PeakMemoryString())
+	})
+
+	t.Run("no steps", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, StepResources(stepAnalysis(base), nil))
+		assert.Nil(t, StepResources(nil, steps))
+		assert.Empty(t, stepResourcesTable(&monitor.Analysis{}, steps))
+	})
+
+	t.Run("report section", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Contains(t, buildReport(stepAnalysis(base), steps), "### Step Resources")
+		assert.NotContains(t, buildReport(stepAnalysis(base), nil), "### Step Resources")
+	})
+}
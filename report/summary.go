@@ -11,6 +11,20 @@ import (
 	"github.com/kalverra/octometrics/monitor"
 )
 
+const (
+	// nearOOMPercent is how close to its memory limit a cgroup gets before the summary flags it.
+	nearOOMPercent = 90.0
+	// cpuStallWarnPercent is the share of time tasks wait on CPU before the summary suggests a bigger runner.
+	cpuStallWarnPercent = 10.0
+)
+
+// pressureResourceNames are the summary labels of the resources PSI reports, in display order.
+var pressureResourceNames = []struct{ resource, name string }{
+	{resource: "cpu", name: "CPU"},
+	{resource: "memory", name: "Memory"},
+	{resource: "io", name: "I/O"},
+}
+
 // buildReport assembles the full markdown report from analysis data and optional job steps.
 func buildReport(analysis *monitor.Analysis, steps []*github.TaskStep) string {
 	var b strings.Builder
@@ -27,6 +41,12 @@ func buildReport(analysis *monitor.Analysis, steps []*github.TaskStep) string {
 		b.WriteString("\n")
 	}
 
+	if table := stepResourcesTable(analysis, steps); table != "" {
+		b.WriteString("### Step Resources\n\n")
+		b.WriteString(table)
+		b.WriteString("\n")
+	}
+
 	if host := machineInfoMarkdown(analysis); host != "" {
 		b.WriteString(host)
 	}
@@ -55,12 +75,28 @@ func buildReport(analysis *monitor.Analysis, steps []*github.TaskStep) string {
 		b.WriteString("\n")
 	}
 
+	if chart := diskIOChart(analysis); chart != "" {
+		b.WriteString("### Disk I/O\n\n")
+		b.WriteString(chart)
+		b.WriteString("\n")
+	}
+
 	if chart := ioChart(analysis); chart != "" {
 		b.WriteString("### Network I/O\n\n")
 		b.WriteString(chart)
 		b.WriteString("\n")
 	}
 
+	if table := topProcessesTable(analysis); table != "" {
+		b.WriteString("### Top Processes\n\n")
+		if d := topProcessesChartDiagram(analysis); d != "" {
+			b.WriteString(markdownMermaidBlock(d))
+			b.WriteString("\n")
+		}
+		b.WriteString(table)
+		b.WriteString("\n")
+	}
+
 	if table := metricSummaryTable(analysis); table != "" {
 		b.WriteString("### Resource Summary\n\n")
 		b.WriteString(table)
@@ -88,6 +124,9 @@ func metricSummaryTable(analysis *monitor.Analysis) string {
 	if diskRow := diskSummaryRow(analysis); diskRow != "" {
 		rows = append(rows, diskRow)
 	}
+	rows = append(rows, cgroupSummaryRows(analysis)...)
+	rows = append(rows, pressureSummaryRows(analysis)...)
+	rows = append(rows, diskIOSummaryRows(analysis)...)
 	sentRow, recvRow := ioSummaryRows(analysis)
 	if sentRow != "" {
 		rows = append(rows, sentRow)
@@ -190,6 +229,174 @@ func diskSummaryRow(analysis *monitor.Analysis) string {
 	return fmt.Sprintf("| Disk | %.1f GB | %.1f GB |", peakGB, avgGB)
 }
 
+// cgroupSummaryRows reports the job's cgroup against its own limits, flagging CPU throttling and memory close to OOM.
+func cgroupSummaryRows(analysis *monitor.Analysis) []string {
+	if len(analysis.CgroupMeasurements) == 0 {
+		return nil
+	}
+
+	var (
+		peakCPU, sumCPU       float64
+		peakMem               uint64
+		sumMem                float64
+		periods, throttled    uint64
+		throttledSeconds      float64
+		peakThrottledPct      float64
+		oomEvents, oomKills   uint64
+		cpuLimit              float64
+		memMax                uint64
+		peakMemPct, sumMemPct float64
+		measurements          = float64(len(analysis.CgroupMeasurements))
+	)
+	for _, m := range analysis.CgroupMeasurements {
+		peakCPU = max(peakCPU, m.CPUPercent)
+		sumCPU += m.CPUPercent
+		peakMem = max(peakMem, m.MemoryCurrent)
+		sumMem += float64(m.MemoryCurrent)
+		periods += m.Periods
+		throttled += m.ThrottledPeriods
+		throttledSeconds += m.ThrottledSeconds
+		if m.Periods > 0 {
+			peakThrottledPct = max(peakThrottledPct, float64(m.ThrottledPeriods)/float64(m.Periods)*100)
+		}
+		oomEvents += m.OOMEvents
+		oomKills += m.OOMKills
+		cpuLimit = max(cpuLimit, m.CPULimit)
+		memMax = max(memMax, m.MemoryMax)
+		if m.MemoryMax > 0 {
+			pct := float64(m.MemoryCurrent) / float64(m.MemoryMax) * 100
+			peakMemPct = max(peakMemPct, pct)
+			sumMemPct += pct
+		}
+	}
+
+	var rows []string
+	if cpuLimit > 0 {
+		rows = append(rows, fmt.Sprintf("| Container CPU | %.1f%% (limit %.1f cores) | %.1f%% |",
+			peakCPU, cpuLimit, sumCPU/measurements))
+	} else {
+		rows = append(rows, fmt.Sprintf("| Container CPU | %.1f%% | %.1f%% |", peakCPU, sumCPU/measurements))
+	}
+	if memMax > 0 {
+		rows = append(rows, fmt.Sprintf("| Container Memory | %s / %s (%.1f%%) | %s (%.1f%%) |",
+			formatBytes(peakMem), formatBytes(memMax), peakMemPct,
+			formatBytes(uint64(sumMem/measurements)), sumMemPct/measurements))
+	} else {
+		rows = append(rows, fmt.Sprintf("| Container Memory | %s | %s |",
+			formatBytes(peakMem), formatBytes(uint64(sumMem/measurements))))
+	}
+
+	if throttled > 0 {
+		rows = append(rows, fmt.Sprintf("| ⚠️ CPU Throttled | %.1f%% of periods | %.1f%% of periods, %.1fs total |",
+			peakThrottledPct, float64(throttled)/float64(periods)*100, throttledSeconds))
+	}
+	if oomEvents > 0 || oomKills > 0 || peakMemPct >= nearOOMPercent {
+		rows = append(rows, fmt.Sprintf("| ⚠️ Near OOM | %.1f%% of memory limit | %d OOM events, %d processes killed |",
+			peakMemPct, oomEvents, oomKills))
+	}
+	return rows
+}
+
+// pressureSummaryRows reports how long tasks spent stalled waiting on each resource, and the load average against
+// the core count, flagging runners where work regularly waits for a CPU.
+func pressureSummaryRows(analysis *monitor.Analysis) []string {
+	var rows []string
+
+	var avgCPUStall float64
+	for _, r := range pressureResourceNames {
+		var (
+			peak, sum, stalledSeconds float64
+			measurements              int
+		)
+		for _, m := range analysis.PressureMeasurements {
+			if m.Resource != r.resource {
+				continue
+			}
+			peak = max(peak, m.SomePercent)
+			sum += m.SomePercent
+			stalledSeconds += m.SomeStalledSeconds
+			measurements++
+		}
+		if measurements == 0 {
+			continue
+		}
+		avg := sum / float64(measurements)
+		if r.resource == "cpu" {
+			avgCPUStall = avg
+		}
+		rows = append(rows, fmt.Sprintf("| Stalled on %s | %.1f%% of time | %.1f%% of time, %.1fs total |",
+			r.name, peak, avg, stalledSeconds))
+	}
+
+	var cores int
+	if analysis.SystemInfo != nil {
+		cores = len(analysis.SystemInfo.CPU)
+	}
+	var peakLoad, peakLoadPerCore float64
+	if len(analysis.LoadMeasurements) > 0 {
+		var sum float64
+		for _, m := range analysis.LoadMeasurements {
+			peakLoad = max(peakLoad, m.Load1)
+			sum += m.Load1
+		}
+		avg := sum / float64(len(analysis.LoadMeasurements))
+		if cores > 0 {
+			peakLoadPerCore = peakLoad / float64(cores)
+			rows = append(rows, fmt.Sprintf("| Load Average (1m) | %.2f (%.2f per core) | %.2f (%.2f per core) |",
+				peakLoad, peakLoadPerCore, avg, avg/float64(cores)))
+		} else {
+			rows = append(rows, fmt.Sprintf("| Load Average (1m) | %.2f | %.2f |", peakLoad, avg))
+		}
+	}
+
+	if avgCPUStall >= cpuStallWarnPercent {
+		peakCell := "—"
+		if peakLoadPerCore > 0 {
+			peakCell = fmt.Sprintf("%.2f load per core", peakLoadPerCore)
+		}
+		rows = append(rows, fmt.Sprintf(
+			"| ⚠️ CPU Oversubscribed | %s | %.1f%% of time waiting on CPU, consider a runner with more cores |",
+			peakCell, avgCPUStall))
+	}
+	return rows
+}
+
+func diskIOSummaryRows(analysis *monitor.Analysis) []string {
+	if len(analysis.DiskIOMeasurements) == 0 {
+		return nil
+	}
+
+	var totalRead, totalWrite uint64
+	for _, m := range analysis.DiskIOMeasurements {
+		totalRead += m.ReadBytes
+		totalWrite += m.WriteBytes
+	}
+	read, write, iops := diskIORatesOverTime(analysis.DiskIOMeasurements)
+	peakRead, _ := peakAndAverage(read)
+	peakWrite, _ := peakAndAverage(write)
+	peakIOPS, avgIOPS := peakAndAverage(iops)
+
+	return []string{
+		fmt.Sprintf("| Disk Read | %s/s peak / %s total | — |",
+			formatBytes(uint64(peakRead)), formatBytes(totalRead)),
+		fmt.Sprintf("| Disk Write | %s/s peak / %s total | — |",
+			formatBytes(uint64(peakWrite)), formatBytes(totalWrite)),
+		fmt.Sprintf("| Disk IOPS | %.0f | %.0f |", peakIOPS, avgIOPS),
+	}
+}
+
+func peakAndAverage(points []timeValue) (peak, avg float64) {
+	if len(points) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, p := range points {
+		peak = max(peak, p.Value)
+		sum += p.Value
+	}
+	return peak, sum / float64(len(points))
+}
+
 func ioSummaryRows(analysis *monitor.Analysis) (sentRow, recvRow string) {
 	if len(analysis.IOMeasurements) == 0 {
 		return "", ""
@@ -231,8 +438,8 @@ func formatBytes(b uint64) string {
 	return fmt.Sprintf("%d B", b)
 }
 
-// writeSummary appends the markdown report to the GITHUB_STEP_SUMMARY file.
-func writeSummary(summaryPath, markdown string) (err error) {
+// WriteSummary appends markdown to the GITHUB_STEP_SUMMARY file.
+func WriteSummary(summaryPath, markdown string) (err error) {
 	if summaryPath == "" {
 		return fmt.Errorf("github_step_summary path is empty")
 	}
@@ -146,6 +146,99 @@ func TestMetricSummaryTable(t *testing.T) {
 	})
 }
 
+func TestCgroupSummaryRows(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	const gb = 1024 * 1024 * 1024
+
+	t.Run("throttled and near OOM", func(t *testing.T) {
+		t.Parallel()
+		analysis := &monitor.Analysis{
+			CgroupMeasurements: []*monitor.CgroupMeasurement{
+				{Time: base, CPUPercent: 100, CPULimit: 2, Periods: 10, MemoryCurrent: gb, MemoryMax: 2 * gb},
+				{
+					Time:             base.Add(time.Second),
+					CPUPercent:       200,
+					CPULimit:         2,
+					Periods:          10,
+					ThrottledPeriods: 5,
+					ThrottledSeconds: 0.25,
+					MemoryCurrent:    19 * gb / 10,
+					MemoryMax:        2 * gb,
+					OOMKills:         1,
+					OOMEvents:        1,
+				},
+			},
+		}
+		table := MetricSummary(analysis)
+		assert.Contains(t, table, "| Container CPU | 200.0% (limit 2.0 cores) | 150.0% |")
+		assert.Contains(t, table, "| Container Memory | 1.9 GB / 2.0 GB (95.0%) | 1.4 GB (72.5%) |")
+		assert.Contains(t, table, "| ⚠️ CPU Throttled | 50.0% of periods | 25.0% of periods, 0.2s total |")
+		assert.Contains(t, table, "| ⚠️ Near OOM | 95.0% of memory limit | 1 OOM events, 1 processes killed |")
+	})
+
+	t.Run("healthy and unlimited", func(t *testing.T) {
+		t.Parallel()
+		analysis := &monitor.Analysis{
+			CgroupMeasurements: []*monitor.CgroupMeasurement{
+				{Time: base, CPUPercent: 50, Periods: 10, MemoryCurrent: gb},
+			},
+		}
+		table := MetricSummary(analysis)
+		assert.Contains(t, table, "| Container CPU | 50.0% | 50.0% |")
+		assert.Contains(t, table, "| Container Memory | 1.0 GB | 1.0 GB |")
+		assert.NotContains(t, table, "⚠️")
+	})
+}
+
+func TestPressureSummaryRows(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("oversubscribed", func(t *testing.T) {
+		t.Parallel()
+		analysis := &monitor.Analysis{
+			SystemInfo: &monitor.SystemInfo{
+				CPU: []*monitor.SystemCPUInfo{{Num: 0}, {Num: 1}},
+			},
+			PressureMeasurements: []*monitor.PressureMeasurement{
+				{Time: base, Resource: "cpu", SomePercent: 10, SomeStalledSeconds: 0.1},
+				{Time: base, Resource: "io", SomePercent: 1, SomeStalledSeconds: 0.01},
+				{Time: base.Add(time.Second), Resource: "cpu", SomePercent: 30, SomeStalledSeconds: 0.3},
+				{Time: base.Add(time.Second), Resource: "io", SomePercent: 3, SomeStalledSeconds: 0.03},
+			},
+			LoadMeasurements: []*monitor.LoadMeasurement{
+				{Time: base, Load1: 3},
+				{Time: base.Add(time.Second), Load1: 5},
+			},
+		}
+		table := MetricSummary(analysis)
+		assert.Contains(t, table, "| Stalled on CPU | 30.0% of time | 20.0% of time, 0.4s total |")
+		assert.Contains(t, table, "| Stalled on I/O | 3.0% of time | 2.0% of time, 0.0s total |")
+		assert.NotContains(t, table, "Stalled on Memory", "resources without samples should be skipped")
+		assert.Contains(t, table, "| Load Average (1m) | 5.00 (2.50 per core) | 4.00 (2.00 per core) |")
+		assert.Contains(t, table,
+			"| ⚠️ CPU Oversubscribed | 2.50 load per core | "+
+				"20.0% of time waiting on CPU, consider a runner with more cores |")
+	})
+
+	t.Run("healthy without system info", func(t *testing.T) {
+		t.Parallel()
+		analysis := &monitor.Analysis{
+			PressureMeasurements: []*monitor.PressureMeasurement{
+				{Time: base, Resource: "cpu", SomePercent: 1, SomeStalledSeconds: 0.01},
+			},
+			LoadMeasurements: []*monitor.LoadMeasurement{{Time: base, Load1: 0.5}},
+		}
+		table := MetricSummary(analysis)
+		assert.Contains(t, table, "| Stalled on CPU | 1.0% of time | 1.0% of time, 0.0s total |")
+		assert.Contains(t, table, "| Load Average (1m) | 0.50 | 0.50 |")
+		assert.NotContains(t, table, "⚠️")
+	})
+}
+
 func TestWriteSummary(t *testing.T) {
 	t.Parallel()
 
@@ -154,7 +247,7 @@ func TestWriteSummary(t *testing.T) {
 		dir := t.TempDir()
 		path := filepath.Join(dir, "summary.md")
 
-		err := writeSummary(path, "# Hello\nWorld\n")
+		err := WriteSummary(path, "# Hello\nWorld\n")
 		require.NoError(t, err)
 
 		data, err := os.ReadFile(filepath.Clean(path))
@@ -169,7 +262,7 @@ func TestWriteSummary(t *testing.T) {
 
 		require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o600))
 
-		err := writeSummary(path, "appended\n")
+		err := WriteSummary(path, "appended\n")
 		require.NoError(t, err)
 
 		data, err := os.ReadFile(filepath.Clean(path))
@@ -179,7 +272,7 @@ func TestWriteSummary(t *testing.T) {
 
 	t.Run("empty path returns error", func(t *testing.T) {
 		t.Parallel()
-		err := writeSummary("", "content")
+		err := WriteSummary("", "content")
 		assert.Error(t, err)
 	})
 }